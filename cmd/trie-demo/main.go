@@ -0,0 +1,303 @@
+// Command trie-demo fetches the value of a storage key of a live chain
+// from the RPC endpoint of a Substrate node, together with its read
+// proof and the block header, verifies the value against the state
+// root of the header with this module, and prints the value.
+//
+// Usage:
+//
+//	trie-demo -rpc http://127.0.0.1:9933 -item System.Number -decode u32
+//	trie-demo -key 0x26aa394eea5630e07c48ae0c9558cef702a5c1b19ab7a04f536c519aca4983ac
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/octopus-network/trie-go/scale"
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie/proof"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var (
+	errKeyFlag            = errors.New("exactly one of the -key and -item flags must be set")
+	errStorageItemUnknown = errors.New("storage item unknown")
+	errBlockHashMismatch  = errors.New("block hash does not match header hash")
+	errValueNotInProof    = errors.New("value not in proof")
+	errDecodeTypeUnknown  = errors.New("decode type unknown")
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout)
+	stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) (err error) {
+	flagSet := flag.NewFlagSet("trie-demo", flag.ContinueOnError)
+	rpcURL := flagSet.String("rpc", "http://127.0.0.1:9933", "HTTP RPC endpoint of the node")
+	profileName := flagSet.String("profile", proof.ProfilePolkadot,
+		"chain profile, one of "+strings.Join(proof.ProfileNames(), ", "))
+	blockHex := flagSet.String("block", "", "block hash, defaulting to the finalized head")
+	keyHex := flagSet.String("key", "", "0x prefixed hex trie key of the storage value")
+	item := flagSet.String("item", "", "well known storage item of the profile, such as System.Number")
+	decodeType := flagSet.String("decode", "hex", "value type, one of hex, u32, u64, u128, string")
+	legacyDigests := flagSet.Bool("legacy-digests", false, "accept digest items of older runtimes")
+	timeout := flagSet.Duration("timeout", 30*time.Second, "timeout of the whole verification")
+	err = flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	profile, err := proof.LookupProfile(*profileName)
+	if err != nil {
+		return err
+	}
+
+	key, err := storageKey(*keyHex, *item, profile)
+	if err != nil {
+		return err
+	}
+
+	verifyOptions, err := profile.Policy.Options()
+	if err != nil {
+		return fmt.Errorf("profile policy: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	client := newRPCClient(*rpcURL, http.DefaultClient)
+
+	blockHash, err := resolveBlockHash(ctx, client, *blockHex)
+	if err != nil {
+		return err
+	}
+
+	header, err := fetchHeader(ctx, client, blockHash,
+		sub.HeaderDecodeOptions{AllowLegacyDigests: *legacyDigests})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "block %s #%d\n", blockHash, header.Number)
+	fmt.Fprintf(stdout, "state root %s\n", header.StateRoot)
+
+	encodedProofNodes, err := fetchReadProof(ctx, client, blockHash, key)
+	if err != nil {
+		return err
+	}
+
+	stateRoot := header.StateRoot.ToBytes()
+	proofTrie, err := proof.BuildTrieWithOptions(encodedProofNodes, stateRoot, verifyOptions)
+	if err != nil {
+		return fmt.Errorf("verifying read proof: %w", err)
+	}
+	value := proofTrie.Get(key)
+	if value == nil {
+		return fmt.Errorf("%w: for key 0x%x", errValueNotInProof, key)
+	}
+	fmt.Fprintf(stdout, "proof verified: %d nodes, %d bytes\n",
+		len(encodedProofNodes), proofSize(encodedProofNodes))
+
+	decoded, err := decodeValue(value, *decodeType)
+	if err != nil {
+		return fmt.Errorf("decoding value 0x%x: %w", value, err)
+	}
+	fmt.Fprintf(stdout, "key 0x%x\n", key)
+	fmt.Fprintf(stdout, "value %s\n", decoded)
+	return nil
+}
+
+// storageKey returns the trie key from the hex key or the
+// storage item name of the profile given, only one of which
+// must be set.
+func storageKey(keyHex, item string, profile proof.Profile) (key []byte, err error) {
+	switch {
+	case (keyHex == "") == (item == ""):
+		return nil, errKeyFlag
+	case keyHex != "":
+		key, err = util.HexToBytes(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key: %w", err)
+		}
+		return key, nil
+	default:
+		key, ok := profile.Prefixes[item]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s for profile %s",
+				errStorageItemUnknown, item, profile.Name)
+		}
+		return key, nil
+	}
+}
+
+// resolveBlockHash returns the block hash given as hex,
+// or the finalized head block hash if it is empty.
+func resolveBlockHash(ctx context.Context, client *rpcClient,
+	blockHex string) (blockHash util.Hash, err error) {
+	if blockHex != "" {
+		blockHash, err = util.HexToHash(blockHex)
+		if err != nil {
+			return blockHash, fmt.Errorf("parsing block hash: %w", err)
+		}
+		return blockHash, nil
+	}
+
+	err = client.call(ctx, &blockHash, "chain_getFinalizedHead")
+	if err != nil {
+		return blockHash, err
+	}
+	return blockHash, nil
+}
+
+// rpcHeader is the JSON representation of a block header
+// returned by the chain_getHeader RPC method.
+type rpcHeader struct {
+	ParentHash     util.Hash `json:"parentHash"`
+	Number         string    `json:"number"`
+	StateRoot      util.Hash `json:"stateRoot"`
+	ExtrinsicsRoot util.Hash `json:"extrinsicsRoot"`
+	Digest         struct {
+		Logs []string `json:"logs"`
+	} `json:"digest"`
+}
+
+// fetchHeader fetches the header of the block hash given, and checks
+// the hash of its SCALE encoding matches the block hash, so its state
+// root can be trusted as much as the block hash.
+func fetchHeader(ctx context.Context, client *rpcClient, blockHash util.Hash,
+	options sub.HeaderDecodeOptions) (header *sub.Header, err error) {
+	var jsonHeader rpcHeader
+	err = client.call(ctx, &jsonHeader, "chain_getHeader", blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodeRPCHeader(jsonHeader)
+	if err != nil {
+		return nil, fmt.Errorf("encoding header: %w", err)
+	}
+
+	header, err = sub.DecodeHeaderWithOptions(encoded, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Hash() != blockHash {
+		return nil, fmt.Errorf("%w: header hash is %s instead of %s",
+			errBlockHashMismatch, header.Hash(), blockHash)
+	}
+	return header, nil
+}
+
+// encodeRPCHeader returns the SCALE encoding of the JSON
+// header given, whose digest logs are already SCALE encoded.
+func encodeRPCHeader(jsonHeader rpcHeader) (encoded []byte, err error) {
+	number, err := strconv.ParseUint(strings.TrimPrefix(jsonHeader.Number, "0x"), 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing block number: %w", err)
+	}
+
+	encoded, err = scale.Marshal(struct {
+		ParentHash     util.Hash
+		Number         uint
+		StateRoot      util.Hash
+		ExtrinsicsRoot util.Hash
+		LogsCount      uint
+	}{
+		ParentHash:     jsonHeader.ParentHash,
+		Number:         uint(number),
+		StateRoot:      jsonHeader.StateRoot,
+		ExtrinsicsRoot: jsonHeader.ExtrinsicsRoot,
+		LogsCount:      uint(len(jsonHeader.Digest.Logs)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, logHex := range jsonHeader.Digest.Logs {
+		log, err := util.HexToBytes(logHex)
+		if err != nil {
+			return nil, fmt.Errorf("parsing digest log %d: %w", i, err)
+		}
+		encoded = append(encoded, log...)
+	}
+	return encoded, nil
+}
+
+// fetchReadProof fetches the encoded proof nodes proving
+// the key given at the block hash given.
+func fetchReadProof(ctx context.Context, client *rpcClient,
+	blockHash util.Hash, key []byte) (encodedProofNodes [][]byte, err error) {
+	var readProof struct {
+		At    util.Hash `json:"at"`
+		Proof []string  `json:"proof"`
+	}
+	err = client.call(ctx, &readProof, "state_getReadProof",
+		[]string{util.BytesToHex(key)}, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedProofNodes = make([][]byte, len(readProof.Proof))
+	for i, nodeHex := range readProof.Proof {
+		encodedProofNodes[i], err = util.HexToBytes(nodeHex)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proof node %d: %w", i, err)
+		}
+	}
+	return encodedProofNodes, nil
+}
+
+func proofSize(encodedProofNodes [][]byte) (size int) {
+	for _, encodedProofNode := range encodedProofNodes {
+		size += len(encodedProofNode)
+	}
+	return size
+}
+
+// decodeValue returns the SCALE encoded value given
+// decoded as the type given and formatted as a string.
+func decodeValue(value []byte, typeName string) (decoded string, err error) {
+	switch typeName {
+	case "hex":
+		return util.BytesToHex(value), nil
+	case "u32":
+		var n uint32
+		err = scale.Unmarshal(value, &n)
+		return strconv.FormatUint(uint64(n), 10), err
+	case "u64":
+		var n uint64
+		err = scale.Unmarshal(value, &n)
+		return strconv.FormatUint(n, 10), err
+	case "u128":
+		const u128Size = 16
+		if len(value) != u128Size {
+			return "", fmt.Errorf("%d bytes is not the size of a u128", len(value))
+		}
+		n, err := scale.NewUint128(value, binary.LittleEndian)
+		if err != nil {
+			return "", err
+		}
+		return n.String(), nil
+	case "string":
+		var b []byte
+		err = scale.Unmarshal(value, &b)
+		return string(b), err
+	default:
+		return "", fmt.Errorf("%w: %s", errDecodeTypeUnknown, typeName)
+	}
+}
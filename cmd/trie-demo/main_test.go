@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/octopus-network/trie-go/scale"
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/trie/proof"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNode returns an RPC test server serving the finalized head
+// block header given, and the proof of the keys given of the trie
+// given as read proof of any key.
+func newTestNode(t *testing.T, header *sub.Header, tr *trie.Trie,
+	provenKeys [][]byte) *httptest.Server {
+	t.Helper()
+
+	encodedHeader, err := scale.Marshal(*header)
+	require.NoError(t, err)
+	decodedHeader, err := sub.DecodeHeader(encodedHeader)
+	require.NoError(t, err)
+	blockHash := decodedHeader.Hash()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		require.NoError(t, err)
+
+		var result interface{}
+		switch request.Method {
+		case "chain_getFinalizedHead":
+			result = blockHash
+		case "chain_getHeader":
+			result = map[string]interface{}{
+				"parentHash":     header.ParentHash,
+				"number":         util.UintToHex(header.Number),
+				"stateRoot":      header.StateRoot,
+				"extrinsicsRoot": header.ExtrinsicsRoot,
+				"digest":         map[string][]string{"logs": {}},
+			}
+		case "state_getReadProof":
+			encodedProofNodes, err := proof.GenerateFromTrie(tr, provenKeys)
+			require.NoError(t, err)
+			proofHex := make([]string, len(encodedProofNodes))
+			for i, encodedProofNode := range encodedProofNodes {
+				proofHex[i] = util.BytesToHex(encodedProofNode)
+			}
+			result = map[string]interface{}{
+				"at":    blockHash,
+				"proof": proofHex,
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result":  result,
+		})
+		require.NoError(t, err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_run(t *testing.T) {
+	t.Parallel()
+
+	profile, err := proof.LookupProfile(proof.ProfilePolkadot)
+	require.NoError(t, err)
+	numberKey := profile.Prefixes["System.Number"]
+
+	tr := trie.NewEmptyTrie()
+	tr.Put(numberKey, []byte{0x39, 0x30, 0, 0}) // 12345
+	tr.Put([]byte(":code"), []byte{1, 2, 3})
+	header := sub.NewHeader(util.Hash{1}, tr.MustHash(), util.Hash{2},
+		12345, sub.NewDigest())
+	server := newTestNode(t, header, tr, [][]byte{numberKey})
+
+	testCases := map[string]struct {
+		args       []string
+		output     string
+		errWrapped error
+		errMessage string
+	}{
+		"storage item": {
+			args: []string{"-rpc", server.URL, "-item", "System.Number", "-decode", "u32"},
+			output: "block " + header.Hash().String() + " #12345\n" +
+				"state root " + header.StateRoot.String() + "\n" +
+				"proof verified: 2 nodes, 86 bytes\n" +
+				"key " + util.BytesToHex(numberKey) + "\n" +
+				"value 12345\n",
+		},
+		"key absent": {
+			args:       []string{"-rpc", server.URL, "-key", "0x01"},
+			errWrapped: errValueNotInProof,
+			errMessage: "value not in proof: for key 0x01",
+		},
+		"block hash mismatch": {
+			args:       []string{"-rpc", server.URL, "-key", "0x01", "-block", util.Hash{3}.String()},
+			errWrapped: errBlockHashMismatch,
+			errMessage: "block hash does not match header hash: header hash is " +
+				header.Hash().String() + " instead of " + util.Hash{3}.String(),
+		},
+		"no key": {
+			args:       []string{"-rpc", server.URL},
+			errWrapped: errKeyFlag,
+			errMessage: "exactly one of the -key and -item flags must be set",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := bytes.NewBuffer(nil)
+
+			err := run(context.Background(), testCase.args, stdout)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.output, stdout.String())
+		})
+	}
+}
+
+func Test_decodeValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		value      []byte
+		typeName   string
+		decoded    string
+		errWrapped error
+	}{
+		"hex": {
+			value:    []byte{1, 2},
+			typeName: "hex",
+			decoded:  "0x0102",
+		},
+		"u64": {
+			value:    []byte{1, 0, 0, 0, 0, 0, 0, 1},
+			typeName: "u64",
+			decoded:  "72057594037927937",
+		},
+		"u128": {
+			value:    []byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			typeName: "u128",
+			decoded:  "2",
+		},
+		"string": {
+			value:    []byte{3 << 2, 'd', 'o', 't'},
+			typeName: "string",
+			decoded:  "dot",
+		},
+		"unknown type": {
+			typeName:   "u8",
+			errWrapped: errDecodeTypeUnknown,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decoded, err := decodeValue(testCase.value, testCase.typeName)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.decoded, decoded)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+var errHTTPStatus = errors.New("unexpected HTTP status")
+
+// rpcClient is a minimal JSON-RPC 2.0 client over HTTP
+// for the RPC endpoint of a Substrate node.
+type rpcClient struct {
+	url        string
+	httpClient *http.Client
+	lastID     uint64
+}
+
+func newRPCClient(url string, httpClient *http.Client) *rpcClient {
+	return &rpcClient{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// call calls the RPC method given with the parameters given,
+// and JSON decodes its result into the result pointer given.
+func (c *rpcClient) call(ctx context.Context, result interface{},
+	method string, params ...interface{}) (err error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	request := rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&c.lastID, 1),
+		Method:  method,
+		Params:  params,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encoding %s request: %w", method, err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating %s request: %w", method, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", method, err)
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("calling %s: %w: %s", method, errHTTPStatus, httpResponse.Status)
+	}
+
+	var response rpcResponse
+	err = json.NewDecoder(httpResponse.Body).Decode(&response)
+	if err != nil {
+		return fmt.Errorf("decoding %s response: %w", method, err)
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("calling %s: %w", method, response.Error)
+	}
+
+	err = json.Unmarshal(response.Result, result)
+	if err != nil {
+		return fmt.Errorf("decoding %s result: %w", method, err)
+	}
+	return nil
+}
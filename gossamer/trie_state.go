@@ -0,0 +1,284 @@
+// Package gossamer adapts this module tries to the storage interfaces
+// of ChainSafe gossamer, so code written against gossamer's trie and
+// runtime storage can switch to this module with minimal changes.
+package gossamer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// CodeKey is the storage key of the runtime code.
+var CodeKey = []byte(":code")
+
+var ErrRootMismatch = errors.New("root hash mismatch")
+
+// Storage mirrors gossamer's lib/runtime Storage interface, where
+// gossamer's common.Hash and *trie.Trie types are replaced by their
+// equivalent util.Hash and *trie.Trie types from this module.
+// Since both hash types are [32]byte arrays, values can be converted
+// between them with a simple type conversion.
+type Storage interface {
+	Put(key []byte, value []byte) (err error)
+	Get(key []byte) []byte
+	Root() (util.Hash, error)
+	SetChild(keyToChild []byte, child *trie.Trie) error
+	SetChildStorage(keyToChild, key, value []byte) error
+	GetChildStorage(keyToChild, key []byte) ([]byte, error)
+	Delete(key []byte) (err error)
+	DeleteChild(keyToChild []byte) (err error)
+	ClearChildStorage(keyToChild, key []byte) error
+	NextKey([]byte) []byte
+	GetChild(keyToChild []byte) (*trie.Trie, error)
+	ClearPrefix(prefix []byte) (err error)
+	ClearPrefixLimit(prefix []byte, limit uint32) (deleted uint32, allDeleted bool, err error)
+	BeginStorageTransaction()
+	CommitStorageTransaction()
+	RollbackStorageTransaction()
+	LoadCode() []byte
+}
+
+var _ Storage = (*TrieState)(nil)
+
+// TrieState is the equivalent of gossamer's lib/runtime/storage
+// TrieState, implemented on top of this module trie.
+// It is safe for concurrent use.
+type TrieState struct {
+	mutex sync.RWMutex
+	t     *trie.Trie
+	// transactions contains the tries as they were before each
+	// storage transaction started, the last one being the most
+	// recent one. Transactions can be nested.
+	transactions []*trie.Trie
+}
+
+// NewTrieState creates a trie state on top of the trie given.
+// If the trie is nil, an empty trie is used.
+func NewTrieState(t *trie.Trie) *TrieState {
+	if t == nil {
+		t = trie.NewEmptyTrie()
+	}
+	return &TrieState{t: t}
+}
+
+// Trie returns the current trie of the trie state.
+func (s *TrieState) Trie() *trie.Trie {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t
+}
+
+// Snapshot creates a new trie state from a snapshot of the current trie.
+// Note it takes the write lock since snapshotting the trie modifies it.
+func (s *TrieState) Snapshot() *TrieState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return NewTrieState(s.t.Snapshot())
+}
+
+// BeginStorageTransaction starts a new nested storage transaction,
+// which is either committed or rolled back.
+func (s *TrieState) BeginStorageTransaction() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.transactions = append(s.transactions, s.t)
+	s.t = s.t.Snapshot()
+}
+
+// CommitStorageTransaction commits the changes made since the
+// start of the most recent storage transaction.
+func (s *TrieState) CommitStorageTransaction() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.transactions) == 0 {
+		return
+	}
+	s.transactions = s.transactions[:len(s.transactions)-1]
+}
+
+// RollbackStorageTransaction discards the changes made since the
+// start of the most recent storage transaction.
+func (s *TrieState) RollbackStorageTransaction() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.transactions) == 0 {
+		return
+	}
+	last := len(s.transactions) - 1
+	s.t = s.transactions[last]
+	s.transactions = s.transactions[:last]
+}
+
+// Put puts a key-value pair in the trie.
+func (s *TrieState) Put(key, value []byte) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.t.Put(key, value)
+	return nil
+}
+
+// Get returns the value for the key given, or nil if not found.
+func (s *TrieState) Get(key []byte) []byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t.Get(key)
+}
+
+// Has returns true if the key given exists in the trie.
+func (s *TrieState) Has(key []byte) bool {
+	return s.Get(key) != nil
+}
+
+// Root returns the trie root hash.
+// Note it takes the write lock since hashing the trie caches
+// the Merkle values of its nodes.
+func (s *TrieState) Root() (util.Hash, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.t.Hash()
+}
+
+// MustRoot returns the trie root hash and panics on error.
+func (s *TrieState) MustRoot() util.Hash {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.t.MustHash()
+}
+
+// CompareRoot computes the trie root hash and returns an error
+// wrapping ErrRootMismatch if it differs from the expected root hash
+// given, which can be for example a root computed by gossamer's trie
+// for the same entries.
+func (s *TrieState) CompareRoot(expected [32]byte) (err error) {
+	root, err := s.Root()
+	if err != nil {
+		return fmt.Errorf("computing root hash: %w", err)
+	}
+
+	if !bytes.Equal(root[:], expected[:]) {
+		return fmt.Errorf("%w: expected 0x%x but got 0x%x",
+			ErrRootMismatch, expected, root)
+	}
+	return nil
+}
+
+// Delete deletes the key given from the trie.
+func (s *TrieState) Delete(key []byte) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.t.Delete(key)
+	return nil
+}
+
+// NextKey returns the next key in the trie in lexicographic order,
+// or nil if there is no next key.
+func (s *TrieState) NextKey(key []byte) []byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t.NextKey(key)
+}
+
+// ClearPrefix deletes all the keys having the prefix given.
+func (s *TrieState) ClearPrefix(prefix []byte) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.t.ClearPrefix(prefix)
+	return nil
+}
+
+// ClearPrefixLimit deletes up to limit keys having the prefix given.
+func (s *TrieState) ClearPrefixLimit(prefix []byte, limit uint32) (
+	deleted uint32, allDeleted bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	deleted, allDeleted = s.t.ClearPrefixLimit(prefix, limit)
+	return deleted, allDeleted, nil
+}
+
+// TrieEntries returns all the key-value pairs of the trie.
+func (s *TrieState) TrieEntries() map[string][]byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t.Entries()
+}
+
+// SetChild sets the child trie at the key given.
+func (s *TrieState) SetChild(keyToChild []byte, child *trie.Trie) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.t.SetChild(keyToChild, child)
+}
+
+// SetChildStorage puts a key-value pair in the child trie at the key
+// given, creating the child trie if it does not exist.
+func (s *TrieState) SetChildStorage(keyToChild, key, value []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	child, err := s.t.GetChild(keyToChild)
+	if errors.Is(err, trie.ErrChildTrieDoesNotExist) || (err == nil && child == nil) {
+		err = s.t.SetChild(keyToChild, trie.NewEmptyTrie())
+		if err != nil {
+			return fmt.Errorf("creating child trie: %w", err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return s.t.PutIntoChild(keyToChild, key, value)
+}
+
+// GetChild returns the child trie at the key given.
+func (s *TrieState) GetChild(keyToChild []byte) (*trie.Trie, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t.GetChild(keyToChild)
+}
+
+// GetChildStorage returns the value for the key in the child trie
+// at the key given.
+func (s *TrieState) GetChildStorage(keyToChild, key []byte) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.t.GetFromChild(keyToChild, key)
+}
+
+// DeleteChild deletes the child trie at the key given.
+func (s *TrieState) DeleteChild(keyToChild []byte) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.t.DeleteChild(keyToChild)
+	return nil
+}
+
+// ClearChildStorage deletes the key from the child trie at the key given.
+func (s *TrieState) ClearChildStorage(keyToChild, key []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.t.ClearFromChild(keyToChild, key)
+}
+
+// LoadCode returns the runtime code stored at the :code key.
+func (s *TrieState) LoadCode() []byte {
+	return s.Get(CodeKey)
+}
+
+// LoadCodeHash returns the blake2b hash of the runtime code.
+func (s *TrieState) LoadCodeHash() (util.Hash, error) {
+	return util.Blake2bHash(s.LoadCode())
+}
+
+// GetChangedNodeHashes returns the node hashes inserted and deleted
+// since the last trie snapshot.
+// Note it takes the write lock since it caches the Merkle values
+// of the trie nodes.
+func (s *TrieState) GetChangedNodeHashes() (inserted, deleted map[string]struct{}, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.t.GetChangedNodeHashes()
+}
@@ -0,0 +1,140 @@
+package gossamer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TrieState_transactions(t *testing.T) {
+	t.Parallel()
+
+	state := NewTrieState(nil)
+	err := state.Put([]byte("a"), []byte("1"))
+	require.NoError(t, err)
+
+	state.BeginStorageTransaction()
+	err = state.Put([]byte("b"), []byte("2"))
+	require.NoError(t, err)
+
+	state.BeginStorageTransaction()
+	err = state.Delete([]byte("a"))
+	require.NoError(t, err)
+	assert.False(t, state.Has([]byte("a")))
+	state.RollbackStorageTransaction()
+
+	assert.Equal(t, []byte("1"), state.Get([]byte("a")))
+	assert.Equal(t, []byte("2"), state.Get([]byte("b")))
+	state.CommitStorageTransaction()
+
+	state.BeginStorageTransaction()
+	err = state.ClearPrefix([]byte("b"))
+	require.NoError(t, err)
+	state.RollbackStorageTransaction()
+
+	expectedEntries := map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+	}
+	assert.Equal(t, expectedEntries, state.TrieEntries())
+
+	// Unbalanced commit and rollback are no-ops.
+	state.CommitStorageTransaction()
+	state.RollbackStorageTransaction()
+	assert.Equal(t, expectedEntries, state.TrieEntries())
+}
+
+func Test_TrieState_childStorage(t *testing.T) {
+	t.Parallel()
+
+	state := NewTrieState(nil)
+	keyToChild := []byte("child")
+
+	_, err := state.GetChildStorage(keyToChild, []byte("key"))
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+
+	err = state.SetChildStorage(keyToChild, []byte("key"), []byte("value"))
+	require.NoError(t, err)
+
+	value, err := state.GetChildStorage(keyToChild, []byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	err = state.SetChildStorage(keyToChild, []byte("other"), []byte("value"))
+	require.NoError(t, err)
+	err = state.ClearChildStorage(keyToChild, []byte("key"))
+	require.NoError(t, err)
+	value, err = state.GetChildStorage(keyToChild, []byte("key"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	// Clearing the last key of the child trie removes the child trie.
+	err = state.ClearChildStorage(keyToChild, []byte("other"))
+	require.NoError(t, err)
+	_, err = state.GetChildStorage(keyToChild, []byte("other"))
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+
+	err = state.SetChildStorage(keyToChild, []byte("key"), []byte("value"))
+	require.NoError(t, err)
+	err = state.DeleteChild(keyToChild)
+	require.NoError(t, err)
+	_, err = state.GetChild(keyToChild)
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+}
+
+func Test_TrieState_CompareRoot(t *testing.T) {
+	t.Parallel()
+
+	reference := trie.NewEmptyTrie()
+	reference.Put(CodeKey, []byte{1, 2, 3})
+	expectedRoot := reference.MustHash()
+
+	state := NewTrieState(nil)
+	err := state.Put(CodeKey, []byte{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, state.LoadCode())
+
+	err = state.CompareRoot(expectedRoot)
+	assert.NoError(t, err)
+
+	err = state.Put([]byte("other"), []byte{4})
+	require.NoError(t, err)
+	err = state.CompareRoot(expectedRoot)
+	assert.ErrorIs(t, err, ErrRootMismatch)
+}
+
+func Test_TrieState_concurrentRootAndSnapshot(t *testing.T) {
+	t.Parallel()
+
+	state := NewTrieState(nil)
+	for i := 0; i < 100; i++ {
+		err := state.Put([]byte{byte(i)}, []byte{byte(i), 1})
+		require.NoError(t, err)
+	}
+	reference := trie.NewEmptyTrie()
+	for i := 0; i < 100; i++ {
+		reference.Put([]byte{byte(i)}, []byte{byte(i), 1})
+	}
+	expectedRoot := reference.MustHash()
+
+	// Root and Snapshot both modify the trie, so this
+	// test fails when run with -race if they only read lock.
+	const goroutines = 4
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				root, err := state.Root()
+				assert.NoError(t, err)
+				assert.Equal(t, expectedRoot, root)
+				_ = state.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+}
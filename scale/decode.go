@@ -75,6 +75,45 @@ func Unmarshal(data []byte, dst interface{}) (err error) {
 	return
 }
 
+// IsCanonical decodes data into the destination pointer dst and returns
+// true if data is the canonical encoding of the decoded value, that is if
+// no bytes are left over and encoding the value gives back data exactly.
+// It returns false and no error if data contains an over-long compact
+// encoding, and an error if data cannot be decoded otherwise.
+func IsCanonical(data []byte, dst interface{}) (canonical bool, err error) {
+	dstv := reflect.ValueOf(dst)
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return false, fmt.Errorf("%w: %T", ErrUnsupportedDestination, dst)
+	}
+
+	buffer := bytes.NewBuffer(data)
+	ds := decodeState{Reader: buffer}
+	err = ds.unmarshal(indirect(dstv))
+	if err != nil {
+		if isCompactNotCanonical(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if buffer.Len() > 0 {
+		return false, nil
+	}
+
+	encoded, err := Marshal(dstv.Elem().Interface())
+	if err != nil {
+		return false, fmt.Errorf("encoding decoded value: %w", err)
+	}
+	return bytes.Equal(encoded, data), nil
+}
+
+func isCompactNotCanonical(err error) bool {
+	return errors.Is(err, ErrCompactNotCanonical) ||
+		errors.Is(err, ErrU16OutOfRange) ||
+		errors.Is(err, ErrU32OutOfRange) ||
+		errors.Is(err, ErrU64OutOfRange)
+}
+
 // Decoder is used to decode from an io.Reader
 type Decoder struct {
 	decodeState
@@ -524,7 +563,7 @@ func (ds *decodeState) decodeUint(dstv reflect.Value) (err error) {
 		}
 	case 2:
 		buf := make([]byte, 3)
-		_, err = ds.Read(buf)
+		_, err = io.ReadFull(ds, buf)
 		if err != nil {
 			return fmt.Errorf("reading bytes: %w", err)
 		}
@@ -534,8 +573,11 @@ func (ds *decodeState) decodeUint(dstv reflect.Value) (err error) {
 		}
 	case 3:
 		byteLen := (prefix >> 2) + 4
+		if byteLen != 4 && byteLen != 8 {
+			return fmt.Errorf("%w: %d", ErrCompactUintPrefixUnknown, prefix)
+		}
 		buf := make([]byte, byteLen)
-		_, err = ds.Read(buf)
+		_, err = io.ReadFull(ds, buf)
 		if err != nil {
 			return fmt.Errorf("reading bytes: %w", err)
 		}
@@ -556,8 +598,6 @@ func (ds *decodeState) decodeUint(dstv reflect.Value) (err error) {
 			if value <= maxUint64>>8 {
 				return fmt.Errorf("%w: %d (%b)", ErrU64OutOfRange, value, value)
 			}
-		default:
-			return fmt.Errorf("%w: %d", ErrCompactUintPrefixUnknown, prefix)
 		}
 	}
 	temp.Elem().Set(reflect.ValueOf(value).Convert(reflect.TypeOf(in)))
@@ -571,6 +611,7 @@ var (
 	ErrU64OutOfRange            = errors.New("uint64 out of range")
 	ErrU64NotSupported          = errors.New("uint64 is not supported")
 	ErrCompactUintPrefixUnknown = errors.New("unknown prefix for compact uint")
+	ErrCompactNotCanonical      = errors.New("compact encoding is not canonical")
 )
 
 // decodeLength is helper method which calls decodeUint and casts to int
@@ -621,7 +662,7 @@ func (ds *decodeState) decodeSmallInt(firstByte, mode byte) (out int64, err erro
 		out = int64(binary.LittleEndian.Uint16([]byte{firstByte, buf}) >> 2)
 	case 2:
 		buf := make([]byte, 3)
-		_, err = ds.Read(buf)
+		_, err = io.ReadFull(ds, buf)
 		if err != nil {
 			break
 		}
@@ -632,6 +673,8 @@ func (ds *decodeState) decodeSmallInt(firstByte, mode byte) (out int64, err erro
 
 // decodeBigInt decodes a SCALE encoded byte array into a *big.Int
 // Works for all integers, including ints > 2**64
+// It returns an error wrapping ErrCompactNotCanonical if the value
+// is not encoded with the shortest possible mode and byte length.
 func (ds *decodeState) decodeBigInt(dstv reflect.Value) (err error) {
 	b, err := ds.ReadByte()
 	if err != nil {
@@ -648,6 +691,12 @@ func (ds *decodeState) decodeBigInt(dstv reflect.Value) (err error) {
 		if err != nil {
 			break
 		}
+		// the smallest value each mode must be used for
+		minimums := [...]int64{0, 1 << 6, 1 << 14}
+		if tmp < minimums[mode] {
+			return fmt.Errorf("%w: value %d encoded with mode %d",
+				ErrCompactNotCanonical, tmp, mode)
+		}
 		output = big.NewInt(tmp)
 
 	default:
@@ -656,13 +705,21 @@ func (ds *decodeState) decodeBigInt(dstv reflect.Value) (err error) {
 		byteLen := uint(topSixBits) + 4
 
 		buf := make([]byte, byteLen)
-		_, err = ds.Read(buf)
+		_, err = io.ReadFull(ds, buf)
 		if err != nil {
 			err = fmt.Errorf("reading bytes: %w", err)
 			break
 		}
+		if buf[byteLen-1] == 0 {
+			return fmt.Errorf("%w: most significant byte of %d bytes is zero",
+				ErrCompactNotCanonical, byteLen)
+		}
 		o := reverseBytes(buf)
 		output = big.NewInt(0).SetBytes(o)
+		if output.BitLen() <= 30 {
+			return fmt.Errorf("%w: value %s encoded with mode 3",
+				ErrCompactNotCanonical, output)
+		}
 	}
 	dstv.Set(reflect.ValueOf(output))
 	return
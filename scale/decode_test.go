@@ -523,3 +523,89 @@ func Test_decodeState_decodeUint(t *testing.T) {
 		})
 	}
 }
+
+func Test_decodeState_decodeBigInt_notCanonical(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string][]byte{
+		"mode 1 for 6 bit value":   {0x01, 0x00},
+		"mode 2 for 14 bit value":  {0xfe, 0xff, 0x00, 0x00},
+		"mode 3 for 30 bit value":  {0x03, 0xff, 0xff, 0xff, 0x3f},
+		"most significant zero":    {0x07, 0x00, 0x00, 0x00, 0x40, 0x00},
+		"mode 3 zero with padding": {0x0b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	}
+
+	for name, data := range testCases {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var dst *big.Int
+			err := Unmarshal(data, &dst)
+			assert.ErrorIs(t, err, ErrCompactNotCanonical)
+		})
+	}
+}
+
+func Test_IsCanonical(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		data       []byte
+		dst        interface{}
+		canonical  bool
+		errWrapped error
+	}{
+		"nil destination": {
+			data:       []byte{0x04},
+			errWrapped: ErrUnsupportedDestination,
+		},
+		"canonical uint": {
+			data:      []byte{0xfd, 0xff},
+			dst:       new(uint),
+			canonical: true,
+		},
+		"over-long uint": {
+			data: []byte{0xfd, 0x00},
+			dst:  new(uint),
+		},
+		"trailing bytes": {
+			data: []byte{0x04, 0x00},
+			dst:  new(uint),
+		},
+		"canonical bytes": {
+			data:      []byte{0x08, 0x01, 0x02},
+			dst:       new([]byte),
+			canonical: true,
+		},
+		"over-long bytes length": {
+			data: []byte{0x09, 0x00, 0x01, 0x02},
+			dst:  new([]byte),
+		},
+		"canonical big int": {
+			data:      []byte{0x03, 0x00, 0x00, 0x00, 0x40},
+			dst:       new(*big.Int),
+			canonical: true,
+		},
+		"over-long big int": {
+			data: []byte{0x07, 0x00, 0x00, 0x00, 0x40, 0x00},
+			dst:  new(*big.Int),
+		},
+		"decoding error": {
+			data:       []byte{0x02},
+			dst:        new(bool),
+			errWrapped: errDecodeBool,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			canonical, err := IsCanonical(testCase.data, testCase.dst)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.canonical, canonical)
+		})
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2022 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package scale
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrDispatchErrorUnknown = errors.New("unknown dispatch error")
+
+// DispatchError variants, matching the sp_runtime DispatchError enum.
+// Use NewDispatchError to create a VaryingDataType decoding any of them.
+type (
+	// DispatchErrorOther is some error occurred. Its message
+	// is not encoded, so only the variant index is decoded.
+	DispatchErrorOther struct{}
+	// DispatchErrorCannotLookup is a failure to lookup some data.
+	DispatchErrorCannotLookup struct{}
+	// DispatchErrorBadOrigin is a bad origin.
+	DispatchErrorBadOrigin struct{}
+	// DispatchErrorConsumerRemaining is at least one consumer remaining
+	// so the account cannot be destroyed.
+	DispatchErrorConsumerRemaining struct{}
+	// DispatchErrorNoProviders is when there are no providers
+	// so the account cannot be created.
+	DispatchErrorNoProviders struct{}
+	// DispatchErrorTooManyConsumers is when there are too many
+	// consumers so the account cannot be created.
+	DispatchErrorTooManyConsumers struct{}
+	// DispatchErrorExhausted is when resources are exhausted,
+	// for example on a block weight overflow.
+	DispatchErrorExhausted struct{}
+	// DispatchErrorCorruption is when the state is corrupt.
+	DispatchErrorCorruption struct{}
+	// DispatchErrorUnavailable is when a resource is temporarily unavailable.
+	DispatchErrorUnavailable struct{}
+	// DispatchErrorRootNotAllowed is when the root origin is not allowed.
+	DispatchErrorRootNotAllowed struct{}
+)
+
+// Index returns the VDT index
+func (DispatchErrorOther) Index() uint { return 0 }
+
+// Index returns the VDT index
+func (DispatchErrorCannotLookup) Index() uint { return 1 }
+
+// Index returns the VDT index
+func (DispatchErrorBadOrigin) Index() uint { return 2 }
+
+// Index returns the VDT index
+func (ModuleError) Index() uint { return 3 }
+
+// Index returns the VDT index
+func (DispatchErrorConsumerRemaining) Index() uint { return 4 }
+
+// Index returns the VDT index
+func (DispatchErrorNoProviders) Index() uint { return 5 }
+
+// Index returns the VDT index
+func (DispatchErrorTooManyConsumers) Index() uint { return 6 }
+
+// Index returns the VDT index
+func (TokenError) Index() uint { return 7 }
+
+// Index returns the VDT index
+func (ArithmeticError) Index() uint { return 8 }
+
+// Index returns the VDT index
+func (TransactionalError) Index() uint { return 9 }
+
+// Index returns the VDT index
+func (DispatchErrorExhausted) Index() uint { return 10 }
+
+// Index returns the VDT index
+func (DispatchErrorCorruption) Index() uint { return 11 }
+
+// Index returns the VDT index
+func (DispatchErrorUnavailable) Index() uint { return 12 }
+
+// Index returns the VDT index
+func (DispatchErrorRootNotAllowed) Index() uint { return 13 }
+
+// Index returns the VDT index
+func (TrieError) Index() uint { return 14 }
+
+func (DispatchErrorOther) String() string             { return "other" }
+func (DispatchErrorCannotLookup) String() string      { return "cannot lookup" }
+func (DispatchErrorBadOrigin) String() string         { return "bad origin" }
+func (DispatchErrorConsumerRemaining) String() string { return "consumer remaining" }
+func (DispatchErrorNoProviders) String() string       { return "no providers" }
+func (DispatchErrorTooManyConsumers) String() string  { return "too many consumers" }
+func (DispatchErrorExhausted) String() string         { return "exhausted" }
+func (DispatchErrorCorruption) String() string        { return "corruption" }
+func (DispatchErrorUnavailable) String() string       { return "unavailable" }
+func (DispatchErrorRootNotAllowed) String() string    { return "root not allowed" }
+
+// ModuleError is a custom error emitted by a pallet.
+type ModuleError struct {
+	// PalletIndex is the index of the pallet in the runtime.
+	PalletIndex uint8
+	// Error is the encoded pallet error, whose first
+	// byte is the index of the error variant in the pallet.
+	Error [4]byte
+}
+
+// ModuleErrorLookup resolves a pallet index and a pallet error
+// to human readable names, usually using the runtime metadata.
+// It returns ok as false if the error cannot be resolved.
+type ModuleErrorLookup func(palletIndex uint8, palletError [4]byte) (
+	palletName, errorName string, ok bool)
+
+// String returns the module error as a string, without resolving names.
+func (m ModuleError) String() string {
+	return fmt.Sprintf("module error: pallet index %d, error index %d",
+		m.PalletIndex, m.Error[0])
+}
+
+// Describe returns the module error as a string, resolving the
+// pallet and error names using the lookup given if it is not nil.
+func (m ModuleError) Describe(lookup ModuleErrorLookup) string {
+	if lookup != nil {
+		palletName, errorName, ok := lookup(m.PalletIndex, m.Error)
+		if ok {
+			return fmt.Sprintf("module error: %s.%s", palletName, errorName)
+		}
+	}
+	return m.String()
+}
+
+// TokenError is an error related to a fungible asset.
+type TokenError uint8
+
+// TokenError values
+const (
+	TokenErrorFundsUnavailable TokenError = iota
+	TokenErrorOnlyProvider
+	TokenErrorBelowMinimum
+	TokenErrorCannotCreate
+	TokenErrorUnknownAsset
+	TokenErrorFrozen
+	TokenErrorUnsupported
+	TokenErrorCannotCreateHold
+	TokenErrorNotExpendable
+	TokenErrorBlocked
+)
+
+func (t TokenError) String() string {
+	switch t {
+	case TokenErrorFundsUnavailable:
+		return "token error: funds unavailable"
+	case TokenErrorOnlyProvider:
+		return "token error: only provider"
+	case TokenErrorBelowMinimum:
+		return "token error: below minimum"
+	case TokenErrorCannotCreate:
+		return "token error: cannot create"
+	case TokenErrorUnknownAsset:
+		return "token error: unknown asset"
+	case TokenErrorFrozen:
+		return "token error: frozen"
+	case TokenErrorUnsupported:
+		return "token error: unsupported"
+	case TokenErrorCannotCreateHold:
+		return "token error: cannot create hold"
+	case TokenErrorNotExpendable:
+		return "token error: not expendable"
+	case TokenErrorBlocked:
+		return "token error: blocked"
+	default:
+		return fmt.Sprintf("token error: unknown %d", uint8(t))
+	}
+}
+
+// ArithmeticError is an arithmetic error.
+type ArithmeticError uint8
+
+// ArithmeticError values
+const (
+	ArithmeticErrorUnderflow ArithmeticError = iota
+	ArithmeticErrorOverflow
+	ArithmeticErrorDivisionByZero
+)
+
+func (a ArithmeticError) String() string {
+	switch a {
+	case ArithmeticErrorUnderflow:
+		return "arithmetic error: underflow"
+	case ArithmeticErrorOverflow:
+		return "arithmetic error: overflow"
+	case ArithmeticErrorDivisionByZero:
+		return "arithmetic error: division by zero"
+	default:
+		return fmt.Sprintf("arithmetic error: unknown %d", uint8(a))
+	}
+}
+
+// TransactionalError is an error related to storage transactions.
+type TransactionalError uint8
+
+// TransactionalError values
+const (
+	TransactionalErrorLimitReached TransactionalError = iota
+	TransactionalErrorNoLayer
+)
+
+func (t TransactionalError) String() string {
+	switch t {
+	case TransactionalErrorLimitReached:
+		return "transactional error: limit reached"
+	case TransactionalErrorNoLayer:
+		return "transactional error: no layer"
+	default:
+		return fmt.Sprintf("transactional error: unknown %d", uint8(t))
+	}
+}
+
+// TrieError is an error related to a trie proof verified by the runtime.
+type TrieError uint8
+
+// TrieError values
+const (
+	TrieErrorInvalidStateRoot TrieError = iota
+	TrieErrorIncompleteDatabase
+	TrieErrorValueAtIncompleteKey
+	TrieErrorDecoderError
+	TrieErrorInvalidHash
+	TrieErrorDuplicateKey
+	TrieErrorExtraneousNode
+	TrieErrorExtraneousValue
+	TrieErrorExtraneousHashReference
+	TrieErrorInvalidChildReference
+	TrieErrorValueMismatch
+	TrieErrorIncompleteProof
+	TrieErrorRootMismatch
+	TrieErrorDecodeError
+)
+
+func (t TrieError) String() string {
+	switch t {
+	case TrieErrorInvalidStateRoot:
+		return "trie error: invalid state root"
+	case TrieErrorIncompleteDatabase:
+		return "trie error: incomplete database"
+	case TrieErrorValueAtIncompleteKey:
+		return "trie error: value at incomplete key"
+	case TrieErrorDecoderError:
+		return "trie error: decoder error"
+	case TrieErrorInvalidHash:
+		return "trie error: invalid hash"
+	case TrieErrorDuplicateKey:
+		return "trie error: duplicate key"
+	case TrieErrorExtraneousNode:
+		return "trie error: extraneous node"
+	case TrieErrorExtraneousValue:
+		return "trie error: extraneous value"
+	case TrieErrorExtraneousHashReference:
+		return "trie error: extraneous hash reference"
+	case TrieErrorInvalidChildReference:
+		return "trie error: invalid child reference"
+	case TrieErrorValueMismatch:
+		return "trie error: value mismatch"
+	case TrieErrorIncompleteProof:
+		return "trie error: incomplete proof"
+	case TrieErrorRootMismatch:
+		return "trie error: root mismatch"
+	case TrieErrorDecodeError:
+		return "trie error: decode error"
+	default:
+		return fmt.Sprintf("trie error: unknown %d", uint8(t))
+	}
+}
+
+// NewDispatchError returns a VaryingDataType able to decode
+// all the variants of a sp_runtime DispatchError.
+func NewDispatchError() (vdt VaryingDataType) {
+	return MustNewVaryingDataType(
+		DispatchErrorOther{},
+		DispatchErrorCannotLookup{},
+		DispatchErrorBadOrigin{},
+		ModuleError{},
+		DispatchErrorConsumerRemaining{},
+		DispatchErrorNoProviders{},
+		DispatchErrorTooManyConsumers{},
+		TokenError(0),
+		ArithmeticError(0),
+		TransactionalError(0),
+		DispatchErrorExhausted{},
+		DispatchErrorCorruption{},
+		DispatchErrorUnavailable{},
+		DispatchErrorRootNotAllowed{},
+		TrieError(0),
+	)
+}
+
+// NewDispatchResult returns a Result able to decode a
+// DispatchResult, which is a Result<(), DispatchError>.
+func NewDispatchResult() (res Result) {
+	return NewResult(nil, NewDispatchError())
+}
+
+// DispatchErr is a decoded DispatchError implementing the error interface.
+type DispatchErr struct {
+	// Value is the DispatchError variant decoded.
+	Value VaryingDataTypeValue
+	// Description is the human readable description of the variant,
+	// with the module error names resolved if possible.
+	Description string
+}
+
+func (e *DispatchErr) Error() string {
+	return "dispatch error: " + e.Description
+}
+
+// DecodeDispatchError decodes a SCALE encoded DispatchError, resolving
+// module errors names with the lookup given, which can be nil.
+func DecodeDispatchError(data []byte, lookup ModuleErrorLookup) (
+	dispatchErr *DispatchErr, err error) {
+	vdt := NewDispatchError()
+	err = Unmarshal(data, &vdt)
+	if err != nil {
+		return nil, fmt.Errorf("decoding dispatch error: %w", err)
+	}
+
+	value, err := vdt.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchErr = &DispatchErr{Value: value}
+	switch value := value.(type) {
+	case ModuleError:
+		dispatchErr.Description = value.Describe(lookup)
+	case fmt.Stringer:
+		dispatchErr.Description = value.String()
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrDispatchErrorUnknown, value)
+	}
+	return dispatchErr, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 ChainSafe Systems (ON)
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package scale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeDispatchError(t *testing.T) {
+	t.Parallel()
+
+	lookup := func(palletIndex uint8, palletError [4]byte) (
+		palletName, errorName string, ok bool) {
+		if palletIndex == 5 && palletError[0] == 2 {
+			return "Balances", "InsufficientBalance", true
+		}
+		return "", "", false
+	}
+
+	testCases := map[string]struct {
+		data        []byte
+		lookup      ModuleErrorLookup
+		value       VaryingDataTypeValue
+		description string
+		errMessage  string
+	}{
+		"empty_data": {
+			errMessage: "decoding dispatch error: EOF",
+		},
+		"unknown_variant": {
+			data:       []byte{99},
+			errMessage: "decoding dispatch error: unable to find VaryingDataTypeValue with index: for key 99",
+		},
+		"bad_origin": {
+			data:        []byte{2},
+			value:       DispatchErrorBadOrigin{},
+			description: "bad origin",
+		},
+		"module_error_without_lookup": {
+			data:        []byte{3, 5, 2, 0, 0, 0},
+			value:       ModuleError{PalletIndex: 5, Error: [4]byte{2}},
+			description: "module error: pallet index 5, error index 2",
+		},
+		"module_error_with_lookup": {
+			data:        []byte{3, 5, 2, 0, 0, 0},
+			lookup:      lookup,
+			value:       ModuleError{PalletIndex: 5, Error: [4]byte{2}},
+			description: "module error: Balances.InsufficientBalance",
+		},
+		"module_error_lookup_miss": {
+			data:        []byte{3, 6, 1, 0, 0, 0},
+			lookup:      lookup,
+			value:       ModuleError{PalletIndex: 6, Error: [4]byte{1}},
+			description: "module error: pallet index 6, error index 1",
+		},
+		"token_error": {
+			data:        []byte{7, 5},
+			value:       TokenErrorFrozen,
+			description: "token error: frozen",
+		},
+		"arithmetic_error": {
+			data:        []byte{8, 2},
+			value:       ArithmeticErrorDivisionByZero,
+			description: "arithmetic error: division by zero",
+		},
+		"transactional_error": {
+			data:        []byte{9, 1},
+			value:       TransactionalErrorNoLayer,
+			description: "transactional error: no layer",
+		},
+		"root_not_allowed": {
+			data:        []byte{13},
+			value:       DispatchErrorRootNotAllowed{},
+			description: "root not allowed",
+		},
+		"trie_error": {
+			data:        []byte{14, 12},
+			value:       TrieErrorRootMismatch,
+			description: "trie error: root mismatch",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dispatchErr, err := DecodeDispatchError(testCase.data, testCase.lookup)
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, dispatchErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.value, dispatchErr.Value)
+			assert.Equal(t, testCase.description, dispatchErr.Description)
+			assert.EqualError(t, dispatchErr, "dispatch error: "+testCase.description)
+		})
+	}
+}
+
+func Test_NewDispatchResult(t *testing.T) {
+	t.Parallel()
+
+	// Err(Module(ModuleError { index: 5, error: [2, 0, 0, 0] }))
+	data := []byte{1, 3, 5, 2, 0, 0, 0}
+
+	result := NewDispatchResult()
+	err := Unmarshal(data, &result)
+	require.NoError(t, err)
+
+	_, err = result.Unwrap()
+	require.Error(t, err)
+	wrappedErr, ok := err.(WrappedErr)
+	require.True(t, ok)
+	vdt, ok := wrappedErr.Err.(VaryingDataType)
+	require.True(t, ok)
+	value, err := vdt.Value()
+	require.NoError(t, err)
+	assert.Equal(t, ModuleError{PalletIndex: 5, Error: [4]byte{2}}, value)
+
+	encoded, err := Marshal(result)
+	require.NoError(t, err)
+	assert.Equal(t, data, encoded)
+}
@@ -65,7 +65,9 @@ func (bh *Header) DeepCopy() (*Header, error) {
 	cp.Number = bh.Number
 
 	if len(bh.Digest.Types) > 0 {
-		cp.Digest = NewDigest()
+		// Re-use the digest item type of the header so legacy
+		// digest items are also accepted if the header has them.
+		cp.Digest = scale.NewVaryingDataTypeSlice(bh.Digest.VaryingDataType)
 		for _, d := range bh.Digest.Types {
 			digestValue, err := d.Value()
 			if err != nil {
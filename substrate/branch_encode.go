@@ -15,9 +15,11 @@ type encodingAsyncResult struct {
 	err    error
 }
 
+// runEncodeChild encodes the child given to a buffer from the
+// SmallNodeBuffers pool, which the results receiver must put back.
 func runEncodeChild(child *Node, index int,
 	results chan<- encodingAsyncResult, rateLimit <-chan struct{}) {
-	buffer := bytes.NewBuffer(nil)
+	buffer := SmallNodeBuffers.Get()
 	err := encodeChild(child, buffer)
 
 	results <- encodingAsyncResult{
@@ -89,6 +91,7 @@ func encodeChildrenOpportunisticParallel(children []*Node, buffer io.Writer) (er
 				}
 			}
 
+			SmallNodeBuffers.Put(resultBuffers[currentIndex])
 			resultBuffers[currentIndex] = nil
 
 			currentIndex++
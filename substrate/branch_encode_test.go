@@ -178,6 +178,25 @@ func Test_encodeChildrenOpportunisticParallel(t *testing.T) {
 	})
 }
 
+func Test_encodeChildrenOpportunisticParallel_pooledBuffers(t *testing.T) {
+	t.Parallel()
+
+	children := make([]*Node, ChildrenCapacity)
+	for i := range children {
+		children[i] = &Node{PartialKey: []byte{1}, StorageValue: []byte{byte(i)}}
+	}
+	statsBefore := SmallNodeBuffers.Stats()
+
+	buffer := bytes.NewBuffer(nil)
+	err := encodeChildrenOpportunisticParallel(children, buffer)
+	require.NoError(t, err)
+
+	// Note the counters may be incremented by other tests running in parallel.
+	statsAfter := SmallNodeBuffers.Stats()
+	assert.GreaterOrEqual(t, statsAfter.Gets-statsBefore.Gets, uint64(ChildrenCapacity))
+	assert.GreaterOrEqual(t, statsAfter.Puts-statsBefore.Puts, uint64(ChildrenCapacity))
+}
+
 func Test_encodeChildrenSequentially(t *testing.T) {
 	t.Parallel()
 
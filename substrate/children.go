@@ -1,6 +1,8 @@
 
 package substrate
 
+import "fmt"
+
 const (
 	// ChildrenCapacity is the maximum number of children in a branch node.
 	ChildrenCapacity = 16
@@ -38,3 +40,41 @@ func (n *Node) HasChild() (has bool) {
 	}
 	return false
 }
+
+// ChildMerkleValue is a child node of a branch together
+// with its index in the branch and its Merkle value.
+type ChildMerkleValue struct {
+	Index       byte
+	Child       *Node
+	MerkleValue []byte
+}
+
+// ChildrenMerkleValues returns the children of the branch node in
+// ascending nibble order, each with its Merkle value. The Merkle value
+// of an inlined child is its encoding, computed on the fly if needed,
+// and the Merkle value of any other child is its encoding hash digest.
+// It returns nil for a leaf node.
+func (n *Node) ChildrenMerkleValues() (children []ChildMerkleValue, err error) {
+	if n.Kind() == Leaf {
+		return nil, nil
+	}
+
+	children = make([]ChildMerkleValue, 0, n.NumChildren())
+	for i, child := range n.Children {
+		if child == nil {
+			continue
+		}
+
+		merkleValue, err := child.CalculateMerkleValue()
+		if err != nil {
+			return nil, fmt.Errorf("computing Merkle value of child at index %d: %w", i, err)
+		}
+
+		children = append(children, ChildMerkleValue{
+			Index:       byte(i),
+			Child:       child,
+			MerkleValue: merkleValue,
+		})
+	}
+	return children, nil
+}
@@ -4,9 +4,12 @@
 package substrate
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/octopus-network/trie-go/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Node_ChildrenBitmap(t *testing.T) {
@@ -157,3 +160,44 @@ func Test_Node_HasChild(t *testing.T) {
 		})
 	}
 }
+
+func Test_Node_ChildrenMerkleValues(t *testing.T) {
+	t.Parallel()
+
+	leaf, err := (&Node{PartialKey: []byte{1}, StorageValue: []byte{2}}).ChildrenMerkleValues()
+	require.NoError(t, err)
+	assert.Nil(t, leaf)
+
+	inlinedChild := &Node{
+		PartialKey:   []byte{1},
+		StorageValue: []byte{2},
+	}
+	hashedChild := &Node{
+		PartialKey:   []byte{3},
+		StorageValue: bytes.Repeat([]byte{4}, 40),
+	}
+	decodedHashedChild := &Node{
+		NodeValue: bytes.Repeat([]byte{5}, 32),
+	}
+	branch := &Node{
+		Children: padRightChildren([]*Node{
+			nil, nil, decodedHashedChild, inlinedChild,
+			nil, hashedChild,
+		}),
+	}
+
+	inlinedEncoding := bytes.NewBuffer(nil)
+	err = inlinedChild.Encode(inlinedEncoding)
+	require.NoError(t, err)
+	hashedEncoding := bytes.NewBuffer(nil)
+	err = hashedChild.Encode(hashedEncoding)
+	require.NoError(t, err)
+
+	children, err := branch.ChildrenMerkleValues()
+	require.NoError(t, err)
+	assert.Equal(t, []ChildMerkleValue{
+		{Index: 2, Child: decodedHashedChild, MerkleValue: bytes.Repeat([]byte{5}, 32)},
+		{Index: 3, Child: inlinedChild, MerkleValue: inlinedEncoding.Bytes()},
+		{Index: 5, Child: hashedChild, MerkleValue: util.MustBlake2bHash(hashedEncoding.Bytes()).ToBytes()},
+	}, children)
+}
@@ -53,11 +53,13 @@ type CopySettings struct {
 // Setting copyChildren to true will deep copy
 // children as well.
 func (n *Node) Copy(settings CopySettings) *Node {
-	cpy := &Node{
-		Dirty:       n.Dirty,
-		Generation:  n.Generation,
-		Descendants: n.Descendants,
-	}
+	cpy := Nodes.Get()
+	cpy.Dirty = n.Dirty
+	cpy.IsHashedValue = n.IsHashedValue
+	cpy.MustBeHashed = n.MustBeHashed
+	cpy.ValueHasher = n.ValueHasher
+	cpy.Generation = n.Generation
+	cpy.Descendants = n.Descendants
 
 	if n.Kind() == Branch {
 		if settings.CopyChildren {
@@ -42,13 +42,14 @@ func Decode(reader io.Reader) (n *Node, err error) {
 	}
 
 	switch variant {
-	case leafVariant.bits:
-		n, err = decodeLeaf(reader, partialKeyLength)
+	case leafVariant.bits, leafContainingHashesVariant.bits:
+		n, err = decodeLeaf(reader, variant, partialKeyLength)
 		if err != nil {
 			return nil, fmt.Errorf("cannot decode leaf: %w", err)
 		}
 		return n, nil
-	case branchVariant.bits, branchWithValueVariant.bits:
+	case branchVariant.bits, branchWithValueVariant.bits,
+		branchContainingHashesVariant.bits:
 		n, err = decodeBranch(reader, variant, partialKeyLength)
 		if err != nil {
 			return nil, fmt.Errorf("cannot decode branch: %w", err)
@@ -85,11 +86,18 @@ func decodeBranch(reader io.Reader, variant byte, partialKeyLength uint16) (
 
 	sd := scale.NewDecoder(reader)
 
-	if variant == branchWithValueVariant.bits {
+	switch variant {
+	case branchWithValueVariant.bits:
 		err := sd.Decode(&node.StorageValue)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s", ErrDecodeStorageValue, err)
 		}
+	case branchContainingHashesVariant.bits:
+		node.StorageValue, err = decodeHashedValue(reader)
+		if err != nil {
+			return nil, err
+		}
+		node.IsHashedValue = true
 	}
 
 	for i := 0; i < ChildrenCapacity; i++ {
@@ -126,7 +134,8 @@ func decodeBranch(reader io.Reader, variant byte, partialKeyLength uint16) (
 }
 
 // decodeLeaf reads from a reader and decodes to a leaf node.
-func decodeLeaf(reader io.Reader, partialKeyLength uint16) (node *Node, err error) {
+func decodeLeaf(reader io.Reader, variant byte, partialKeyLength uint16) (
+	node *Node, err error) {
 	node = &Node{}
 
 	node.PartialKey, err = decodeKey(reader, partialKeyLength)
@@ -134,6 +143,15 @@ func decodeLeaf(reader io.Reader, partialKeyLength uint16) (node *Node, err erro
 		return nil, fmt.Errorf("cannot decode key: %w", err)
 	}
 
+	if variant == leafContainingHashesVariant.bits {
+		node.StorageValue, err = decodeHashedValue(reader)
+		if err != nil {
+			return nil, err
+		}
+		node.IsHashedValue = true
+		return node, nil
+	}
+
 	sd := scale.NewDecoder(reader)
 	err = sd.Decode(&node.StorageValue)
 	if err != nil {
@@ -142,3 +160,14 @@ func decodeLeaf(reader io.Reader, partialKeyLength uint16) (node *Node, err erro
 
 	return node, nil
 }
+
+// decodeHashedValue reads the 32 bytes blake2b hash of a storage
+// value from the reader, as encoded in state version 1 nodes.
+func decodeHashedValue(reader io.Reader) (hashedValue []byte, err error) {
+	hashedValue = make([]byte, 32)
+	_, err = io.ReadFull(reader, hashedValue)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading hashed value: %s", ErrDecodeStorageValue, err)
+	}
+	return hashedValue, nil
+}
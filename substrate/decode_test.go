@@ -360,6 +360,31 @@ func Test_decodeLeaf(t *testing.T) {
 				StorageValue: []byte{1, 2, 3, 4, 5},
 			},
 		},
+		"hashed value too short": {
+			reader: bytes.NewBuffer([]byte{
+				9,    // key data
+				1, 2, // truncated hashed value
+			}),
+			variant:          leafContainingHashesVariant.bits,
+			partialKeyLength: 1,
+			errWrapped:       ErrDecodeStorageValue,
+			errMessage:       "cannot decode storage value: reading hashed value: unexpected EOF",
+		},
+		"hashed value success": {
+			reader: bytes.NewBuffer(
+				concatByteSlices([][]byte{
+					{9},                         // key data
+					bytes.Repeat([]byte{7}, 32), // hashed value
+				}),
+			),
+			variant:          leafContainingHashesVariant.bits,
+			partialKeyLength: 1,
+			leaf: &Node{
+				PartialKey:    []byte{9},
+				StorageValue:  bytes.Repeat([]byte{7}, 32),
+				IsHashedValue: true,
+			},
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -367,7 +392,7 @@ func Test_decodeLeaf(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			leaf, err := decodeLeaf(testCase.reader,
+			leaf, err := decodeLeaf(testCase.reader, testCase.variant,
 				testCase.partialKeyLength)
 
 			assert.ErrorIs(t, err, testCase.errWrapped)
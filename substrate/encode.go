@@ -36,7 +36,21 @@ func (n *Node) Encode(buffer Buffer) (err error) {
 	// Only encode node storage value if the node has a storage value,
 	// even if it is empty. Do not encode if the branch is without value.
 	// Note leaves and branches with value cannot have a `nil` storage value.
-	if n.StorageValue != nil {
+	// Hashed storage values are written as their raw 32 bytes hash,
+	// without any length prefix.
+	switch {
+	case n.StorageValue == nil:
+	case n.IsHashedValue:
+		_, err = buffer.Write(n.StorageValue)
+		if err != nil {
+			return fmt.Errorf("writing hashed storage value: %w", err)
+		}
+	case n.MustBeHashed:
+		err = HashValue(n.StorageValue, n.ValueHasher, buffer)
+		if err != nil {
+			return fmt.Errorf("hashing storage value: %w", err)
+		}
+	default:
 		encoder := scale.NewEncoder(buffer)
 		err = encoder.Encode(n.StorageValue)
 		if err != nil {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/octopus-network/trie-go/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -106,6 +107,34 @@ func Test_Branch_Encode_Decode(t *testing.T) {
 				Descendants: 1,
 			},
 		},
+		"branch with hashed value": {
+			branchToEncode: &Node{
+				PartialKey:    []byte{5},
+				StorageValue:  bytes.Repeat([]byte{1}, 32),
+				IsHashedValue: true,
+				Children:      make([]*Node, ChildrenCapacity),
+			},
+			branchDecoded: &Node{
+				PartialKey:    []byte{5},
+				StorageValue:  bytes.Repeat([]byte{1}, 32),
+				IsHashedValue: true,
+				Children:      make([]*Node, ChildrenCapacity),
+			},
+		},
+		"branch with value to hash": {
+			branchToEncode: &Node{
+				PartialKey:   []byte{5},
+				StorageValue: bytes.Repeat([]byte{1}, 40),
+				MustBeHashed: true,
+				Children:     make([]*Node, ChildrenCapacity),
+			},
+			branchDecoded: &Node{
+				PartialKey:    []byte{5},
+				StorageValue:  util.MustBlake2bHash(bytes.Repeat([]byte{1}, 40)).ToBytes(),
+				IsHashedValue: true,
+				Children:      make([]*Node, ChildrenCapacity),
+			},
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -0,0 +1,93 @@
+package substrate
+
+// EncodedLengths returns the length in bytes of the encoding of the
+// node, and the total length in bytes of the encodings of the node and
+// all its descendants, without encoding nor hashing any node.
+// Note the length of a hashed storage value is its 32 bytes hash length.
+func (n *Node) EncodedLengths() (length, subtreeLength int) {
+	length = headerLength(len(n.PartialKey), n.variant())
+	length += (len(n.PartialKey) + 1) / 2
+
+	switch {
+	case n.StorageValue == nil:
+	case n.IsHashedValue:
+		length += len(n.StorageValue)
+	case n.MustBeHashed:
+		const hashLength = 32
+		length += hashLength
+	default:
+		length += compactLength(len(n.StorageValue)) + len(n.StorageValue)
+	}
+
+	if n.Kind() == Branch {
+		const childrenBitmapLength = 2
+		length += childrenBitmapLength
+	}
+
+	for _, child := range n.Children {
+		if child == nil {
+			continue
+		}
+
+		childLength, childSubtreeLength := child.EncodedLengths()
+		subtreeLength += childSubtreeLength
+
+		// See MerkleValue for the Merkle value of a child.
+		merkleValueLength := childLength
+		const maxMerkleValueLength = 32
+		if childLength >= maxMerkleValueLength {
+			merkleValueLength = maxMerkleValueLength
+		}
+		length += compactLength(merkleValueLength) + merkleValueLength
+	}
+
+	subtreeLength += length
+	return length, subtreeLength
+}
+
+// variant returns the header variant of the node.
+func (n *Node) variant() variant {
+	hashedValue := n.IsHashedValue || n.MustBeHashed
+	switch {
+	case n.Kind() == Leaf && hashedValue:
+		return leafContainingHashesVariant
+	case n.Kind() == Leaf:
+		return leafVariant
+	case n.StorageValue == nil:
+		return branchVariant
+	case hashedValue:
+		return branchContainingHashesVariant
+	default:
+		return branchWithValueVariant
+	}
+}
+
+// headerLength returns the length in bytes of the encoded header of
+// a node with the partial key length and variant given, see encodeHeader.
+func headerLength(partialKeyLength int, variant variant) (length int) {
+	partialKeyLengthMask := int(variant.partialKeyLengthHeaderMask())
+	if partialKeyLength < partialKeyLengthMask {
+		return 1
+	}
+	const maxByteValue = 255
+	return 1 + (partialKeyLength-partialKeyLengthMask)/maxByteValue + 1
+}
+
+// compactLength returns the length in bytes of the
+// SCALE compact encoding of the integer given.
+func compactLength(n int) (length int) {
+	switch {
+	case n < 1<<6:
+		return 1
+	case n < 1<<14:
+		return 2
+	case n < 1<<30:
+		return 4
+	}
+
+	length = 1
+	for ; n > 0; n >>= 8 {
+		length++
+	}
+	return length
+}
@@ -0,0 +1,138 @@
+package substrate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Node_EncodedLengths(t *testing.T) {
+	t.Parallel()
+
+	largeChild := &Node{
+		PartialKey:   []byte{1},
+		StorageValue: bytes.Repeat([]byte{1}, 40),
+	}
+	inlinedChild := &Node{
+		PartialKey:   []byte{2},
+		StorageValue: []byte{2},
+	}
+
+	testCases := map[string]struct {
+		node *Node
+	}{
+		"leaf": {
+			node: &Node{PartialKey: []byte{1, 2, 3}, StorageValue: []byte{4}},
+		},
+		"leaf with empty value": {
+			node: &Node{StorageValue: []byte{}},
+		},
+		"leaf with long partial key": {
+			node: &Node{
+				PartialKey:   bytes.Repeat([]byte{1}, 63+255+10),
+				StorageValue: []byte{1},
+			},
+		},
+		"leaf with long partial key multiple of 255": {
+			node: &Node{
+				PartialKey:   bytes.Repeat([]byte{1}, 63+255),
+				StorageValue: []byte{1},
+			},
+		},
+		"leaf with large value": {
+			node: &Node{StorageValue: bytes.Repeat([]byte{1}, 1<<14)},
+		},
+		"leaf with value to hash": {
+			node: &Node{
+				PartialKey:   []byte{1},
+				StorageValue: bytes.Repeat([]byte{1}, 40),
+				MustBeHashed: true,
+			},
+		},
+		"leaf with hashed value": {
+			node: &Node{
+				PartialKey:    []byte{1},
+				StorageValue:  bytes.Repeat([]byte{1}, 32),
+				IsHashedValue: true,
+			},
+		},
+		"branch without value": {
+			node: &Node{
+				PartialKey: []byte{1, 2},
+				Children:   padRightChildren([]*Node{nil, largeChild, inlinedChild}),
+			},
+		},
+		"branch with value and nested branch": {
+			node: &Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{1, 2},
+				Children: padRightChildren([]*Node{
+					{
+						StorageValue: bytes.Repeat([]byte{3}, 33),
+						MustBeHashed: true,
+						Children:     padRightChildren([]*Node{inlinedChild, largeChild}),
+					},
+					nil, nil, inlinedChild,
+				}),
+			},
+		},
+	}
+
+	var subtreeEncodedLength func(node *Node) int
+	subtreeEncodedLength = func(node *Node) (length int) {
+		if node == nil {
+			return 0
+		}
+		buffer := bytes.NewBuffer(nil)
+		err := node.Encode(buffer)
+		require.NoError(t, err)
+		length = buffer.Len()
+		for _, child := range node.Children {
+			length += subtreeEncodedLength(child)
+		}
+		return length
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			err := testCase.node.Encode(buffer)
+			require.NoError(t, err)
+
+			length, subtreeLength := testCase.node.EncodedLengths()
+
+			assert.Equal(t, buffer.Len(), length)
+			assert.Equal(t, subtreeEncodedLength(testCase.node), subtreeLength)
+		})
+	}
+}
+
+func Test_compactLength(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		n      int
+		length int
+	}{
+		"zero":               {n: 0, length: 1},
+		"max single byte":    {n: 1<<6 - 1, length: 1},
+		"min two bytes":      {n: 1 << 6, length: 2},
+		"min four bytes":     {n: 1 << 14, length: 4},
+		"min big integer":    {n: 1 << 30, length: 5},
+		"five bytes integer": {n: 1 << 32, length: 6},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.length, compactLength(testCase.n))
+		})
+	}
+}
@@ -2,12 +2,43 @@ package substrate
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	// "github.com/octopus-network/trie-go/substrate/pools"
 )
 
+var ErrValueHasherDigestSize = errors.New("value hasher digest size is not 32 bytes")
+
+// HashValue writes the hash digest of the state version 1 storage value
+// given to the writer given, using the value hasher given, or blake2b-256
+// if it is nil. An error wrapping ErrValueHasherDigestSize is returned if
+// the value hasher digest is not 32 bytes, since a hashed value is decoded
+// as 32 bytes.
+func HashValue(value []byte, valueHasher func() hash.Hash, writer io.Writer) (err error) {
+	if valueHasher == nil {
+		return hashEncoding(value, writer)
+	}
+
+	const digestSize = 32
+	hasher := valueHasher()
+	if hasher.Size() != digestSize {
+		return fmt.Errorf("%w: %d bytes", ErrValueHasherDigestSize, hasher.Size())
+	}
+
+	_, err = hasher.Write(value)
+	if err != nil {
+		return fmt.Errorf("hashing value: %w", err)
+	}
+
+	_, err = writer.Write(hasher.Sum(nil))
+	if err != nil {
+		return fmt.Errorf("writing digest: %w", err)
+	}
+	return nil
+}
+
 // MerkleValue writes the Merkle value from the encoding of a non-root
 // node to the writer given.
 // If the encoding is less or equal to 32 bytes, the Merkle value is the encoding.
@@ -52,11 +83,14 @@ func hashEncoding(encoding []byte, writer io.Writer) (err error) {
 
 // CalculateMerkleValue returns the Merkle value of the non-root node.
 func (n *Node) CalculateMerkleValue() (merkleValue []byte, err error) {
-	if !n.Dirty && n.NodeValue != nil {
+	// The cached Merkle value is cleared by SetDirty as soon as the node
+	// is modified, so it is valid even if the node is not yet written to
+	// the database, and unchanged subtrees are not encoded again.
+	if n.NodeValue != nil {
 		return n.NodeValue, nil
 	}
 
-	_, merkleValue, err = n.EncodeAndHash()
+	merkleValue, err = n.encodeToMerkleValue(MerkleValue)
 	if err != nil {
 		return nil, fmt.Errorf("encoding and hashing node: %w", err)
 	}
@@ -67,11 +101,11 @@ func (n *Node) CalculateMerkleValue() (merkleValue []byte, err error) {
 // CalculateRootMerkleValue returns the Merkle value of the root node.
 func (n *Node) CalculateRootMerkleValue() (merkleValue []byte, err error) {
 	const rootMerkleValueLength = 32
-	if !n.Dirty && len(n.NodeValue) == rootMerkleValueLength {
+	if len(n.NodeValue) == rootMerkleValueLength {
 		return n.NodeValue, nil
 	}
 
-	_, merkleValue, err = n.EncodeAndHashRoot()
+	merkleValue, err = n.encodeToMerkleValue(MerkleValueRoot)
 	if err != nil {
 		return nil, fmt.Errorf("encoding and hashing root node: %w", err)
 	}
@@ -79,6 +113,48 @@ func (n *Node) CalculateRootMerkleValue() (merkleValue []byte, err error) {
 	return merkleValue, nil
 }
 
+// encodeToMerkleValue encodes the node to a buffer from the size
+// classed pool fitting the node, and returns the Merkle value
+// written by the function given from the encoding, which is
+// also cached in the node.
+func (n *Node) encodeToMerkleValue(merkleValueFn func(encoding []byte, writer io.Writer) error) (
+	merkleValue []byte, err error) {
+	encodingBuffer := GetBuffer(n.encodingSizeHint())
+	defer PutBuffer(encodingBuffer)
+
+	err = n.Encode(encodingBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("encoding node: %w", err)
+	}
+
+	// Note the Merkle value is written to its own buffer since
+	// the encoding buffer is reused once it is put back.
+	const maxMerkleValueSize = 32
+	merkleValueBuffer := bytes.NewBuffer(make([]byte, 0, maxMerkleValueSize))
+	err = merkleValueFn(encodingBuffer.Bytes(), merkleValueBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("merkle value: %w", err)
+	}
+	merkleValue = merkleValueBuffer.Bytes()
+	n.NodeValue = merkleValue
+
+	return merkleValue, nil
+}
+
+// encodingSizeHint returns an estimate of the encoding size
+// of the node in bytes, used to pick a buffer pool.
+func (n *Node) encodingSizeHint() (sizeHint int) {
+	// Header, children bitmap and storage value length prefix.
+	const maxOverheadSize = 16
+	// Compact length prefix and Merkle value of a child.
+	const maxChildEncodingSize = 1 + 32
+	sizeHint = maxOverheadSize + (len(n.PartialKey)+1)/2 + len(n.StorageValue)
+	if n.Kind() == Branch {
+		sizeHint += n.NumChildren() * maxChildEncodingSize
+	}
+	return sizeHint
+}
+
 // EncodeAndHash returns the encoding of the node and the
 // Merkle value of the node. See the `MerkleValue` method for
 // more details on the value of the Merkle value.
@@ -1,13 +1,64 @@
 package substrate
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
 	"io"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
 )
 
+func Test_HashValue(t *testing.T) {
+	t.Parallel()
+
+	value := []byte{1, 2, 3}
+	blake2bDigest := blake2b.Sum256(value)
+	sha256Digest := sha256.Sum256(value)
+
+	testCases := map[string]struct {
+		valueHasher func() hash.Hash
+		digest      []byte
+		errWrapped  error
+		errMessage  string
+	}{
+		"default blake2b": {
+			digest: blake2bDigest[:],
+		},
+		"sha256": {
+			valueHasher: sha256.New,
+			digest:      sha256Digest[:],
+		},
+		"digest size not 32 bytes": {
+			valueHasher: sha512.New,
+			errWrapped:  ErrValueHasherDigestSize,
+			errMessage:  "value hasher digest size is not 32 bytes: 64 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			err := HashValue(value, testCase.valueHasher, buffer)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.digest, buffer.Bytes())
+		})
+	}
+}
+
 func Test_MerkleValue(t *testing.T) {
 	t.Parallel()
 
@@ -180,6 +231,15 @@ func Test_Node_CalculateMerkleValue(t *testing.T) {
 			},
 			merkleValue: []byte{1},
 		},
+		"dirty node cached merkle value": {
+			node: Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{1},
+				Dirty:        true,
+				NodeValue:    []byte{1},
+			},
+			merkleValue: []byte{1},
+		},
 		"small encoding": {
 			node: Node{
 				PartialKey:   []byte{1},
@@ -205,6 +265,30 @@ func Test_Node_CalculateMerkleValue(t *testing.T) {
 	}
 }
 
+func Test_Node_CalculateMerkleValue_largeNodeBuffers(t *testing.T) {
+	t.Parallel()
+
+	node := Node{
+		PartialKey:   []byte{1},
+		StorageValue: bytes.Repeat([]byte{1}, 2048),
+	}
+	_, expectedMerkleValue, err := (&Node{
+		PartialKey:   node.PartialKey,
+		StorageValue: node.StorageValue,
+	}).EncodeAndHash()
+	require.NoError(t, err)
+	statsBefore := LargeNodeBuffers.Stats()
+
+	merkleValue, err := node.CalculateMerkleValue()
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedMerkleValue, merkleValue)
+	// Note the counters may be incremented by other tests running in parallel.
+	statsAfter := LargeNodeBuffers.Stats()
+	assert.GreaterOrEqual(t, statsAfter.Gets-statsBefore.Gets, uint64(1))
+	assert.GreaterOrEqual(t, statsAfter.Puts-statsBefore.Puts, uint64(1))
+}
+
 func Test_Node_CalculateRootMerkleValue(t *testing.T) {
 	t.Parallel()
 
@@ -226,6 +310,15 @@ func Test_Node_CalculateRootMerkleValue(t *testing.T) {
 			},
 			merkleValue: some32BHashDigest,
 		},
+		"dirty node cached merkle value 32 bytes": {
+			node: Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{2},
+				Dirty:        true,
+				NodeValue:    some32BHashDigest,
+			},
+			merkleValue: some32BHashDigest,
+		},
 		"cached merkle value not 32 bytes": {
 			node: Node{
 				PartialKey:   []byte{1},
@@ -0,0 +1,105 @@
+package substrate
+
+import (
+	"fmt"
+
+	"github.com/octopus-network/trie-go/scale"
+)
+
+// OtherDigest is a digest item of arbitrary data, as found in headers
+// of older runtimes.
+type OtherDigest struct {
+	Data []byte
+}
+
+// Index returns VDT index
+func (OtherDigest) Index() uint { return 0 }
+
+// String returns the digest as a string
+func (d OtherDigest) String() string {
+	return fmt.Sprintf("OtherDigest Data=0x%x", d.Data)
+}
+
+// ChangesTrieConfiguration is the configuration of the changes trie,
+// which was removed from Substrate.
+type ChangesTrieConfiguration struct {
+	DigestInterval uint32
+	DigestLevels   uint32
+}
+
+// ChangesTrieSignalDigest is a changes trie signal digest item, as found
+// in headers of runtimes supporting the changes trie. Signal is always 0
+// for the only new configuration signal, and Configuration is nil when
+// the changes trie is disabled.
+type ChangesTrieSignalDigest struct {
+	Signal        uint8
+	Configuration *ChangesTrieConfiguration
+}
+
+// Index returns VDT index
+func (ChangesTrieSignalDigest) Index() uint { return 7 }
+
+// String returns the digest as a string
+func (d ChangesTrieSignalDigest) String() string {
+	if d.Configuration == nil {
+		return fmt.Sprintf("ChangesTrieSignalDigest Signal=%d Configuration=nil", d.Signal)
+	}
+	return fmt.Sprintf("ChangesTrieSignalDigest Signal=%d DigestInterval=%d DigestLevels=%d",
+		d.Signal, d.Configuration.DigestInterval, d.Configuration.DigestLevels)
+}
+
+// RuntimeEnvironmentUpdatedDigest signals the runtime code or heap pages
+// were updated in the block.
+type RuntimeEnvironmentUpdatedDigest struct{}
+
+// Index returns VDT index
+func (RuntimeEnvironmentUpdatedDigest) Index() uint { return 8 }
+
+// String returns the digest as a string
+func (RuntimeEnvironmentUpdatedDigest) String() string {
+	return "RuntimeEnvironmentUpdatedDigest"
+}
+
+// NewLegacyDigestItem returns a new VaryingDataType to represent a DigestItem,
+// also accepting the digest item formats of older runtimes.
+func NewLegacyDigestItem() scale.VaryingDataType {
+	return scale.MustNewVaryingDataType(OtherDigest{}, ChangesTrieRootDigest{},
+		ConsensusDigest{}, SealDigest{}, PreRuntimeDigest{},
+		ChangesTrieSignalDigest{}, RuntimeEnvironmentUpdatedDigest{})
+}
+
+// NewLegacyDigest returns a new Digest accepting the digest item
+// formats of older runtimes.
+func NewLegacyDigest() scale.VaryingDataTypeSlice {
+	return scale.NewVaryingDataTypeSlice(NewLegacyDigestItem())
+}
+
+// HeaderDecodeOptions contains options to decode a block header.
+type HeaderDecodeOptions struct {
+	// AllowLegacyDigests can be set to true to tolerate digest items
+	// of older runtimes, such as changes trie signals, so that headers
+	// of long-running chains can still be decoded and hashed.
+	AllowLegacyDigests bool
+}
+
+// DecodeHeader decodes a SCALE encoded block header and sets its hash.
+func DecodeHeader(encoded []byte) (header *Header, err error) {
+	return DecodeHeaderWithOptions(encoded, HeaderDecodeOptions{})
+}
+
+// DecodeHeaderWithOptions is like DecodeHeader but uses the options given.
+func DecodeHeaderWithOptions(encoded []byte, options HeaderDecodeOptions) (
+	header *Header, err error) {
+	header = NewEmptyHeader()
+	if options.AllowLegacyDigests {
+		header.Digest = NewLegacyDigest()
+	}
+
+	err = scale.Unmarshal(encoded, header)
+	if err != nil {
+		return nil, fmt.Errorf("decoding header: %w", err)
+	}
+
+	header.Hash()
+	return header, nil
+}
@@ -0,0 +1,51 @@
+package substrate
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeHeaderWithOptions(t *testing.T) {
+	t.Parallel()
+
+	digest := NewLegacyDigest()
+	err := digest.Add(
+		PreRuntimeDigest{
+			ConsensusEngineID: BabeEngineID,
+			Data:              []byte{1, 2},
+		},
+		ChangesTrieSignalDigest{
+			Configuration: &ChangesTrieConfiguration{
+				DigestInterval: 4,
+				DigestLevels:   2,
+			},
+		},
+		OtherDigest{Data: []byte{3}},
+		RuntimeEnvironmentUpdatedDigest{},
+	)
+	require.NoError(t, err)
+
+	stateRoot := util.Hash{1}
+	legacyHeader := NewHeader(util.Hash{2}, stateRoot, util.Hash{3}, 5, digest)
+	encoded, err := scale.Marshal(*legacyHeader)
+	require.NoError(t, err)
+
+	_, err = DecodeHeader(encoded)
+	assert.Error(t, err)
+
+	header, err := DecodeHeaderWithOptions(encoded, HeaderDecodeOptions{
+		AllowLegacyDigests: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, legacyHeader, header)
+	assert.Equal(t, stateRoot, header.StateRoot)
+	assert.Equal(t, legacyHeader.Hash(), header.Hash())
+
+	headerCopy, err := header.DeepCopy()
+	require.NoError(t, err)
+	assert.Equal(t, header.Digest, headerCopy.Digest)
+}
@@ -4,6 +4,7 @@ package substrate
 
 import (
 	"fmt"
+	"hash"
 
 	"github.com/qdm12/gotree"
 	"golang.org/x/text/cases"
@@ -29,11 +30,26 @@ type Node struct {
 	Dirty bool
 
 	// Node value is a cached Merkle value(hash value) or inlined value
+	// It is cleared by SetDirty when the node is modified.
 	NodeValue []byte
 
 	// Descendants is the number of descendant nodes for
 	// this particular node.
 	Descendants uint32
+
+	// IsHashedValue is true if the storage value is the blake2b
+	// hash of the actual value, as found in state version 1 nodes
+	// decoded without the value preimage.
+	IsHashedValue bool
+	// MustBeHashed is true if the storage value is the actual value
+	// but must be encoded as its blake2b hash, as for state version 1
+	// nodes whose hashed value got resolved from its preimage.
+	MustBeHashed bool
+	// ValueHasher, if not nil, returns the 32 bytes digest hasher used
+	// instead of blake2b-256 to hash the storage value when MustBeHashed
+	// is true, for chains hashing state version 1 values with another
+	// hasher than their trie nodes.
+	ValueHasher func() hash.Hash
 }
 
 // Kind returns Leaf or Branch depending on what kind
@@ -51,28 +67,98 @@ func (n *Node) String() string {
 
 // StringNode returns a gotree compatible node for String methods.
 func (n Node) StringNode() (stringNode *gotree.Node) {
+	return n.StringNodeWithOptions(StringOptions{})
+}
+
+// StringOptions contains options to render a node and its descendants
+// as a string. A zero value field means there is no limit.
+type StringOptions struct {
+	// MaxDepth is the maximum depth of the descendants rendered,
+	// relative to the node rendered.
+	MaxDepth int
+	// MaxNodes is the maximum number of nodes rendered, in pre-order.
+	MaxNodes int
+	// MaxValueLength is the maximum number of bytes of storage values
+	// and Merkle values rendered. Longer values are truncated, and
+	// their length is rendered. If it is zero, values longer than 20
+	// bytes are rendered with their first and last 8 bytes only.
+	MaxValueLength int
+	// FullKeys can be set to true to render the full key of each node,
+	// as hexadecimal nibbles, in addition to its partial key.
+	FullKeys bool
+}
+
+// StringNodeWithOptions returns a gotree compatible node for String
+// methods, rendered using the options given. Children not rendered
+// because of the options limits are rendered as not shown.
+func (n Node) StringNodeWithOptions(options StringOptions) (stringNode *gotree.Node) {
+	nodesLeft := options.MaxNodes
+	if nodesLeft == 0 {
+		nodesLeft = -1
+	}
+	return n.stringNode(options, n.PartialKey, 0, &nodesLeft)
+}
+
+func (n Node) stringNode(options StringOptions, fullKey []byte,
+	depth int, nodesLeft *int) (stringNode *gotree.Node) {
+	*nodesLeft--
+
 	caser := cases.Title(language.BritishEnglish)
 	stringNode = gotree.New(caser.String(n.Kind().String()))
 	stringNode.Appendf("Generation: %d", n.Generation)
 	stringNode.Appendf("Dirty: %t", n.Dirty)
 	stringNode.Appendf("Key: " + bytesToString(n.PartialKey))
-	stringNode.Appendf("Storage value: " + bytesToString(n.StorageValue))
+	if options.FullKeys {
+		stringNode.Appendf("Full key: " + nibblesToString(fullKey))
+	}
+	stringNode.Appendf("Storage value: " + valueToString(n.StorageValue, options.MaxValueLength))
 	if n.Descendants > 0 { // must be a branch
 		stringNode.Appendf("Descendants: %d", n.Descendants)
 	}
-	stringNode.Appendf("Merkle value: " + bytesToString(n.NodeValue))
+	stringNode.Appendf("Merkle value: " + valueToString(n.NodeValue, options.MaxValueLength))
 
 	for i, child := range n.Children {
 		if child == nil {
 			continue
 		}
+
+		depthExceeded := options.MaxDepth > 0 && depth+1 > options.MaxDepth
+		if depthExceeded || *nodesLeft == 0 {
+			stringNode.Appendf("Child %d (not shown)", i)
+			continue
+		}
+
 		childNode := stringNode.Appendf("Child %d", i)
-		childNode.AppendNode(child.StringNode())
+		childFullKey := make([]byte, 0, len(fullKey)+1+len(child.PartialKey))
+		childFullKey = append(childFullKey, fullKey...)
+		childFullKey = append(childFullKey, byte(i))
+		childFullKey = append(childFullKey, child.PartialKey...)
+		childNode.AppendNode(child.stringNode(options, childFullKey, depth+1, nodesLeft))
 	}
 
 	return stringNode
 }
 
+func valueToString(b []byte, maxLength int) (s string) {
+	switch {
+	case maxLength == 0 || b == nil:
+		return bytesToString(b)
+	case len(b) <= maxLength:
+		return fmt.Sprintf("0x%x", b)
+	default:
+		return fmt.Sprintf("0x%x... (%d bytes)", b[:maxLength], len(b))
+	}
+}
+
+func nibblesToString(nibbles []byte) (s string) {
+	const hexDigits = "0123456789abcdef"
+	digits := make([]byte, len(nibbles))
+	for i, nibble := range nibbles {
+		digits[i] = hexDigits[nibble&0xf]
+	}
+	return "0x" + string(digits)
+}
+
 func bytesToString(b []byte) (s string) {
 	switch {
 	case b == nil:
@@ -14,14 +14,7 @@ func encodeHeader(node *Node, writer io.Writer) (err error) {
 	}
 
 	// Merge variant byte and partial key length together
-	var variant variant
-	if node.Kind() == Leaf {
-		variant = leafVariant
-	} else if node.StorageValue == nil {
-		variant = branchVariant
-	} else {
-		variant = branchWithValueVariant
-	}
+	variant := node.variant()
 
 	buffer := make([]byte, 1)
 	buffer[0] = variant.bits
@@ -132,8 +125,8 @@ var variantsOrderedByBitMask = [...]variant{
 	leafVariant,                   // mask 1100_0000
 	branchVariant,                 // mask 1100_0000
 	branchWithValueVariant,        // mask 1100_0000
-	// leafContainingHashesVariant,   // mask 1110_0000
-	// branchContainingHashesVariant, // mask 1111_0000
+	leafContainingHashesVariant,   // mask 1110_0000
+	branchContainingHashesVariant, // mask 1111_0000
 	// emptyVariant,                  // mask 1111_1111
 	// compactEncodingVariant,        // mask 1111_1111
 }
@@ -292,10 +292,10 @@ func Test_decodeHeader(t *testing.T) {
 		},
 		"header byte decoding error": {
 			reads: []readCall{
-				{buffArgCap: 1, read: []byte{0b0011_1110}},
+				{buffArgCap: 1, read: []byte{0b0000_1110}},
 			},
 			errWrapped: ErrVariantUnknown,
-			errMessage: "decoding header byte: node variant is unknown: for header byte 00111110",
+			errMessage: "decoding header byte: node variant is unknown: for header byte 00001110",
 		},
 		"partial key length contained in first byte": {
 			reads: []readCall{
@@ -429,8 +429,8 @@ func Test_variantsOrderedByBitMask(t *testing.T) {
 	copy(slice, variantsOrderedByBitMask[:])
 	copy(sortedSlice, variantsOrderedByBitMask[:])
 
-	sort.Slice(slice, func(i, j int) bool {
-		return slice[i].mask > slice[j].mask
+	sort.SliceStable(slice, func(i, j int) bool {
+		return slice[i].mask < slice[j].mask
 	})
 
 	assert.Equal(t, sortedSlice, slice)
@@ -164,3 +164,96 @@ func Test_Node_String(t *testing.T) {
 		})
 	}
 }
+
+func Test_Node_StringNodeWithOptions(t *testing.T) {
+	t.Parallel()
+
+	node := &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: []byte{1, 2, 3, 4},
+		Descendants:  3,
+		Children: padRightChildren([]*Node{
+			nil,
+			{
+				PartialKey:  []byte{3},
+				Descendants: 1,
+				Children: padRightChildren([]*Node{
+					{PartialKey: []byte{4}, StorageValue: []byte{5}},
+				}),
+			},
+			{StorageValue: []byte{6}},
+		}),
+	}
+
+	testCases := map[string]struct {
+		options StringOptions
+		s       string
+	}{
+		"max depth": {
+			options: StringOptions{MaxDepth: 1, MaxValueLength: 2},
+			s: `Branch
+├── Generation: 0
+├── Dirty: false
+├── Key: 0x0102
+├── Storage value: 0x0102... (4 bytes)
+├── Descendants: 3
+├── Merkle value: nil
+├── Child 1
+|   └── Branch
+|       ├── Generation: 0
+|       ├── Dirty: false
+|       ├── Key: 0x03
+|       ├── Storage value: nil
+|       ├── Descendants: 1
+|       ├── Merkle value: nil
+|       └── Child 0 (not shown)
+└── Child 2
+    └── Leaf
+        ├── Generation: 0
+        ├── Dirty: false
+        ├── Key: nil
+        ├── Storage value: 0x06
+        └── Merkle value: nil`,
+		},
+		"max nodes and full keys": {
+			options: StringOptions{MaxNodes: 3, FullKeys: true},
+			s: `Branch
+├── Generation: 0
+├── Dirty: false
+├── Key: 0x0102
+├── Full key: 0x12
+├── Storage value: 0x01020304
+├── Descendants: 3
+├── Merkle value: nil
+├── Child 1
+|   └── Branch
+|       ├── Generation: 0
+|       ├── Dirty: false
+|       ├── Key: 0x03
+|       ├── Full key: 0x1213
+|       ├── Storage value: nil
+|       ├── Descendants: 1
+|       ├── Merkle value: nil
+|       └── Child 0
+|           └── Leaf
+|               ├── Generation: 0
+|               ├── Dirty: false
+|               ├── Key: 0x04
+|               ├── Full key: 0x121304
+|               ├── Storage value: 0x05
+|               └── Merkle value: nil
+└── Child 2 (not shown)`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := node.StringNodeWithOptions(testCase.options).String()
+
+			assert.Equal(t, testCase.s, s)
+		})
+	}
+}
@@ -6,17 +6,174 @@ package substrate
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/crypto/blake2b"
 )
 
-// DigestBuffers is a sync pool of buffers of capacity 32.
-var DigestBuffers = &sync.Pool{
-	New: func() interface{} {
-		const bufferCapacity = 32
-		b := make([]byte, 0, bufferCapacity)
+// Buffer pools are size classed, such that a buffer grown while
+// handling a large proof is not handed out again for a 32 bytes
+// digest, and buffers grown beyond the maximum capacity of their
+// class are dropped instead of being retained by the pool forever.
+var (
+	// DigestBuffers is a pool of buffers of capacity 32,
+	// used to hold Merkle values and hash digests.
+	DigestBuffers = NewBufferPool(32, 32)
+	// SmallNodeBuffers is a pool of buffers used to hold
+	// encodings of leaves and small branches.
+	SmallNodeBuffers = NewBufferPool(128, 1024)
+	// LargeNodeBuffers is a pool of buffers used to hold
+	// encodings of large branches or leaves with large values
+	// while computing their Merkle values.
+	LargeNodeBuffers = NewBufferPool(1024, 64*1024)
+	// ProofBuffers is a pool of buffers used to hold encodings
+	// of proof nodes with very large values, such as the
+	// runtime code.
+	ProofBuffers = NewBufferPool(64*1024, 4*1024*1024)
+)
+
+// sizeClassedPools is the list of node and proof buffer pools
+// ordered by ascending maximum capacity.
+var sizeClassedPools = [...]*BufferPool{
+	SmallNodeBuffers,
+	LargeNodeBuffers,
+	ProofBuffers,
+}
+
+// GetBuffer returns an empty buffer from the smallest size
+// classed pool able to hold the size hint given in bytes.
+// The buffer should be given back with PutBuffer once it
+// is no longer used.
+func GetBuffer(sizeHint int) *bytes.Buffer {
+	for _, pool := range sizeClassedPools {
+		if sizeHint <= pool.maxCapacity {
+			return pool.Get()
+		}
+	}
+	// The size hint is larger than all the size classes,
+	// so allocate a buffer which will not be pooled.
+	return bytes.NewBuffer(make([]byte, 0, sizeHint))
+}
+
+// PutBuffer puts the buffer back in the size classed pool
+// corresponding to its capacity. Buffers larger than the
+// largest size class maximum capacity are dropped.
+func PutBuffer(buffer *bytes.Buffer) {
+	for _, pool := range sizeClassedPools {
+		if buffer.Cap() <= pool.maxCapacity {
+			pool.Put(buffer)
+			return
+		}
+	}
+	ProofBuffers.Put(buffer) // discarded and counted by the pool
+}
+
+// BufferPool is a pool of byte buffers for a size class.
+type BufferPool struct {
+	pool            sync.Pool
+	initialCapacity int
+	maxCapacity     int
+
+	// counters accessed atomically
+	gets        uint64
+	allocations uint64
+	puts        uint64
+	discards    uint64
+}
+
+// NewBufferPool creates a pool of buffers of the initial capacity
+// given. Buffers grown beyond the maximum capacity given are
+// discarded when they are put back in the pool.
+func NewBufferPool(initialCapacity, maxCapacity int) *BufferPool {
+	p := &BufferPool{
+		initialCapacity: initialCapacity,
+		maxCapacity:     maxCapacity,
+	}
+	p.pool.New = func() interface{} {
+		atomic.AddUint64(&p.allocations, 1)
+		b := make([]byte, 0, p.initialCapacity)
 		return bytes.NewBuffer(b)
-	},
+	}
+	return p
+}
+
+// Get returns an empty buffer from the pool.
+func (p *BufferPool) Get() *bytes.Buffer {
+	atomic.AddUint64(&p.gets, 1)
+	buffer := p.pool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+// Put puts the buffer back in the pool, unless its capacity
+// exceeds the maximum capacity of the pool.
+func (p *BufferPool) Put(buffer *bytes.Buffer) {
+	if buffer.Cap() > p.maxCapacity {
+		atomic.AddUint64(&p.discards, 1)
+		return
+	}
+	atomic.AddUint64(&p.puts, 1)
+	p.pool.Put(buffer)
+}
+
+// BufferPoolStats contains counters reflecting
+// the efficiency of a buffer pool.
+type BufferPoolStats struct {
+	// Gets is the number of buffers requested from the pool.
+	Gets uint64
+	// Allocations is the number of buffers allocated
+	// because no buffer was available in the pool.
+	Allocations uint64
+	// Puts is the number of buffers put back in the pool.
+	Puts uint64
+	// Discards is the number of buffers dropped because
+	// they grew beyond the pool maximum capacity.
+	Discards uint64
+}
+
+// Stats returns a snapshot of the pool counters.
+func (p *BufferPool) Stats() (stats BufferPoolStats) {
+	return BufferPoolStats{
+		Gets:        atomic.LoadUint64(&p.gets),
+		Allocations: atomic.LoadUint64(&p.allocations),
+		Puts:        atomic.LoadUint64(&p.puts),
+		Discards:    atomic.LoadUint64(&p.discards),
+	}
+}
+
+// ReuseRatio returns the ratio of buffers obtained from the
+// pool without allocation, between 0 and 1.
+func (s BufferPoolStats) ReuseRatio() (ratio float64) {
+	if s.Gets == 0 || s.Allocations >= s.Gets {
+		return 0
+	}
+	return float64(s.Gets-s.Allocations) / float64(s.Gets)
+}
+
+// Nodes is a pool of nodes recycled from discarded trie snapshots,
+// from which nodes are taken when copied or created by a trie.
+var Nodes = &NodePool{}
+
+// NodePool is a pool of nodes.
+type NodePool struct {
+	pool sync.Pool
+}
+
+// Get returns a zero node from the pool, or a newly
+// allocated node if the pool is empty.
+func (p *NodePool) Get() *Node {
+	node, ok := p.pool.Get().(*Node)
+	if !ok {
+		return new(Node)
+	}
+	return node
+}
+
+// Put resets the node given and puts it back in the pool.
+// The node must no longer be referenced by any trie or caller.
+func (p *NodePool) Put(node *Node) {
+	*node = Node{}
+	p.pool.Put(node)
 }
 
 // Hashers is a sync pool of blake2b 256 hashers.
@@ -0,0 +1,111 @@
+package substrate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BufferPool(t *testing.T) {
+	t.Parallel()
+
+	pool := NewBufferPool(4, 8)
+
+	buffer := pool.Get()
+	assert.Equal(t, 0, buffer.Len())
+	assert.Equal(t, 4, buffer.Cap())
+	buffer.WriteString("abc")
+	pool.Put(buffer)
+
+	oversized := bytes.NewBuffer(make([]byte, 0, 9))
+	pool.Put(oversized)
+
+	stats := pool.Stats()
+	assert.Equal(t, uint64(1), stats.Gets)
+	assert.Equal(t, uint64(1), stats.Allocations)
+	assert.Equal(t, uint64(1), stats.Puts)
+	assert.Equal(t, uint64(1), stats.Discards)
+}
+
+func Test_BufferPoolStats_ReuseRatio(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stats BufferPoolStats
+		ratio float64
+	}{
+		"no get": {},
+		"all allocated": {
+			stats: BufferPoolStats{Gets: 2, Allocations: 2},
+		},
+		"half reused": {
+			stats: BufferPoolStats{Gets: 4, Allocations: 2},
+			ratio: 0.5,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ratio := testCase.stats.ReuseRatio()
+
+			assert.Equal(t, testCase.ratio, ratio)
+		})
+	}
+}
+
+func Test_GetBuffer(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		sizeHint    int
+		minCapacity int
+	}{
+		"small node": {
+			sizeHint:    10,
+			minCapacity: SmallNodeBuffers.initialCapacity,
+		},
+		"large node": {
+			sizeHint:    2048,
+			minCapacity: LargeNodeBuffers.initialCapacity,
+		},
+		"proof": {
+			sizeHint:    100 * 1024,
+			minCapacity: ProofBuffers.initialCapacity,
+		},
+		"beyond size classes": {
+			sizeHint:    5 * 1024 * 1024,
+			minCapacity: 5 * 1024 * 1024,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := GetBuffer(testCase.sizeHint)
+			defer PutBuffer(buffer)
+
+			assert.Equal(t, 0, buffer.Len())
+			assert.GreaterOrEqual(t, buffer.Cap(), testCase.minCapacity)
+		})
+	}
+}
+
+func Test_NodePool(t *testing.T) {
+	t.Parallel()
+
+	pool := &NodePool{}
+
+	node := pool.Get()
+	assert.Equal(t, &Node{}, node)
+
+	node.PartialKey = []byte{1}
+	node.Children = make([]*Node, ChildrenCapacity)
+	pool.Put(node)
+	assert.Equal(t, &Node{}, node)
+}
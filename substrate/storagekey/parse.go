@@ -0,0 +1,114 @@
+package storagekey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPrefixMismatch    = errors.New("storage item prefix mismatch")
+	ErrKeyTooShort       = errors.New("trie key too short")
+	ErrKeyLengthUnknown  = errors.New("key length unknown")
+	ErrKeyTrailingBytes  = errors.New("trie key has trailing bytes")
+	ErrKeyHashMismatched = errors.New("key does not match its hash")
+)
+
+// Component describes how a key of a storage map is laid out in a
+// trie key, to parse it back with Parse.
+type Component struct {
+	Hasher Hasher
+	// KeyLength is the length in bytes of the SCALE encoded key. It is
+	// required for transparent hashers to find where the key ends,
+	// except for the last component where 0 means the key spans until
+	// the end of the trie key. It is ignored for opaque hashers.
+	KeyLength int
+}
+
+// ParsedKey is a key of a storage map parsed from a trie key.
+type ParsedKey struct {
+	// Hash is the hash part of the hashed key,
+	// and is empty for the Identity hasher.
+	Hash []byte
+	// Key is the original key, and is nil if
+	// the hasher does not permit to recover it.
+	Key []byte
+}
+
+// Parse parses the trie key given of the storage map item of the pallet
+// given, with one component per map key, and returns the parsed keys.
+// The original key of a transparent hasher is checked to match its
+// hash. It returns an error wrapping ErrPrefixMismatch if the trie key
+// does not belong to the storage item given.
+func Parse(trieKey []byte, pallet, item string, components ...Component) (
+	keys []ParsedKey, err error) {
+	prefix, err := Value(pallet, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(trieKey, prefix) {
+		return nil, fmt.Errorf("%w: trie key 0x%x is not prefixed by %s.%s prefix 0x%x",
+			ErrPrefixMismatch, trieKey, pallet, item, prefix)
+	}
+	rest := trieKey[len(prefix):]
+
+	keys = make([]ParsedKey, len(components))
+	for i, component := range components {
+		last := i == len(components)-1
+		keys[i], rest, err = parseComponent(rest, component, last)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key %d: %w", i, err)
+		}
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: %d bytes left", ErrKeyTrailingBytes, len(rest))
+	}
+
+	return keys, nil
+}
+
+func parseComponent(trieKey []byte, component Component, last bool) (
+	key ParsedKey, rest []byte, err error) {
+	hashSize, err := component.Hasher.hashSize()
+	if err != nil {
+		return key, nil, err
+	}
+
+	if len(trieKey) < hashSize {
+		return key, nil, fmt.Errorf("%w: %d bytes left for %s hash of %d bytes",
+			ErrKeyTooShort, len(trieKey), component.Hasher, hashSize)
+	}
+	key.Hash = trieKey[:hashSize]
+	rest = trieKey[hashSize:]
+
+	if !component.Hasher.Transparent() {
+		return key, rest, nil
+	}
+
+	keyLength := component.KeyLength
+	switch {
+	case keyLength == 0 && last:
+		keyLength = len(rest)
+	case keyLength == 0:
+		return key, nil, fmt.Errorf("%w: for %s hasher",
+			ErrKeyLengthUnknown, component.Hasher)
+	case len(rest) < keyLength:
+		return key, nil, fmt.Errorf("%w: %d bytes left for key of %d bytes",
+			ErrKeyTooShort, len(rest), keyLength)
+	}
+	key.Key = rest[:keyLength]
+	rest = rest[keyLength:]
+
+	hashed, err := component.Hasher.Hash(key.Key)
+	if err != nil {
+		return key, nil, fmt.Errorf("hashing key: %w", err)
+	}
+	if !bytes.Equal(hashed[:hashSize], key.Hash) {
+		return key, nil, fmt.Errorf("%w: key 0x%x has %s hash 0x%x instead of 0x%x",
+			ErrKeyHashMismatched, key.Key, component.Hasher, hashed[:hashSize], key.Hash)
+	}
+
+	return key, rest, nil
+}
@@ -0,0 +1,177 @@
+// Package storagekey builds and parses the trie keys of Substrate
+// pallet storage items, which are made of the twox128 hashes of the
+// pallet and item names followed by the hashed keys of the item.
+package storagekey
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/util"
+)
+
+// Hasher is a storage hasher used to hash the keys of storage maps.
+type Hasher uint8
+
+const (
+	// Identity does not hash the key.
+	Identity Hasher = iota
+	// Blake2128 is the 16 bytes blake2b hash of the key.
+	Blake2128
+	// Blake2256 is the 32 bytes blake2b hash of the key.
+	Blake2256
+	// Blake2128Concat is the 16 bytes blake2b hash of the key
+	// followed by the key.
+	Blake2128Concat
+	// Twox128 is the 16 bytes xxhash of the key.
+	Twox128
+	// Twox256 is the 32 bytes xxhash of the key.
+	Twox256
+	// Twox64Concat is the 8 bytes xxhash of the key followed by the key.
+	Twox64Concat
+)
+
+var ErrHasherUnknown = errors.New("hasher unknown")
+
+func (h Hasher) String() string {
+	switch h {
+	case Identity:
+		return "Identity"
+	case Blake2128:
+		return "Blake2_128"
+	case Blake2256:
+		return "Blake2_256"
+	case Blake2128Concat:
+		return "Blake2_128Concat"
+	case Twox128:
+		return "Twox128"
+	case Twox256:
+		return "Twox256"
+	case Twox64Concat:
+		return "Twox64Concat"
+	default:
+		panic(fmt.Sprintf("unknown hasher %d", h))
+	}
+}
+
+// Transparent returns true if the key can be recovered
+// from its hashed form, which is the case for the
+// Identity, Blake2128Concat and Twox64Concat hashers.
+func (h Hasher) Transparent() bool {
+	switch h {
+	case Identity, Blake2128Concat, Twox64Concat:
+		return true
+	default:
+		return false
+	}
+}
+
+// hashSize returns the size in bytes of the hash written
+// by the hasher, excluding the concatenated key if any.
+func (h Hasher) hashSize() (size int, err error) {
+	switch h {
+	case Identity:
+		return 0, nil
+	case Twox64Concat:
+		return 8, nil
+	case Blake2128, Blake2128Concat, Twox128:
+		return 16, nil
+	case Blake2256, Twox256:
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("%w: %d", ErrHasherUnknown, h)
+	}
+}
+
+// Hash returns the hashed form of the key given.
+func (h Hasher) Hash(key []byte) (hashed []byte, err error) {
+	switch h {
+	case Identity:
+		return append([]byte{}, key...), nil
+	case Blake2128:
+		return util.Blake2b128(key)
+	case Blake2256:
+		digest, err := util.Blake2bHash(key)
+		return digest[:], err
+	case Blake2128Concat:
+		digest, err := util.Blake2b128(key)
+		if err != nil {
+			return nil, err
+		}
+		return append(digest, key...), nil
+	case Twox128:
+		return util.Twox128Hash(key)
+	case Twox256:
+		digest, err := util.Twox256(key)
+		return digest[:], err
+	case Twox64Concat:
+		digest, err := util.Twox64(key)
+		if err != nil {
+			return nil, err
+		}
+		return append(digest, key...), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrHasherUnknown, h)
+	}
+}
+
+// Key is a key of a storage map together with its hasher.
+type Key struct {
+	Hasher Hasher
+	Key    []byte
+}
+
+// Value returns the trie key of the storage value item of the pallet
+// given, which is twox128(pallet) ++ twox128(item). It is also the
+// prefix of all the trie keys of a storage map item.
+func Value(pallet, item string) (key []byte, err error) {
+	palletHash, err := util.Twox128Hash([]byte(pallet))
+	if err != nil {
+		return nil, fmt.Errorf("hashing pallet name: %w", err)
+	}
+
+	itemHash, err := util.Twox128Hash([]byte(item))
+	if err != nil {
+		return nil, fmt.Errorf("hashing storage item name: %w", err)
+	}
+
+	key = make([]byte, 0, len(palletHash)+len(itemHash))
+	key = append(key, palletHash...)
+	return append(key, itemHash...), nil
+}
+
+// Map returns the trie key of the key given in
+// the StorageMap item of the pallet given.
+func Map(pallet, item string, hasher Hasher, key []byte) (trieKey []byte, err error) {
+	return NMap(pallet, item, Key{Hasher: hasher, Key: key})
+}
+
+// DoubleMap returns the trie key of the two keys given
+// in the StorageDoubleMap item of the pallet given.
+func DoubleMap(pallet, item string, hasher1 Hasher, key1 []byte,
+	hasher2 Hasher, key2 []byte) (trieKey []byte, err error) {
+	return NMap(pallet, item,
+		Key{Hasher: hasher1, Key: key1},
+		Key{Hasher: hasher2, Key: key2})
+}
+
+// NMap returns the trie key of the keys given in the StorageNMap item
+// of the pallet given, which is the storage item prefix followed by
+// the concatenation of the hashed keys. Each key is expected to be
+// SCALE encoded already.
+func NMap(pallet, item string, keys ...Key) (trieKey []byte, err error) {
+	trieKey, err = Value(pallet, item)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		hashed, err := key.Hasher.Hash(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("hashing key %d: %w", i, err)
+		}
+		trieKey = append(trieKey, hashed...)
+	}
+
+	return trieKey, nil
+}
@@ -0,0 +1,281 @@
+package storagekey
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func concat(slices ...[]byte) (result []byte) {
+	for _, slice := range slices {
+		result = append(result, slice...)
+	}
+	return result
+}
+
+func mustHash(t *testing.T, hasher Hasher, key []byte) []byte {
+	t.Helper()
+	hashed, err := hasher.Hash(key)
+	require.NoError(t, err)
+	return hashed
+}
+
+func Test_Hasher_Hash(t *testing.T) {
+	t.Parallel()
+
+	key := []byte{1, 2, 3}
+	blake2b128, err := util.Blake2b128(key)
+	require.NoError(t, err)
+	twox64, err := util.Twox64(key)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		hasher     Hasher
+		hashed     []byte
+		hashLength int
+		errWrapped error
+	}{
+		"identity": {
+			hasher:     Identity,
+			hashed:     key,
+			hashLength: 3,
+		},
+		"blake2_128": {
+			hasher:     Blake2128,
+			hashed:     blake2b128,
+			hashLength: 16,
+		},
+		"blake2_128_concat": {
+			hasher:     Blake2128Concat,
+			hashed:     concat(blake2b128, key),
+			hashLength: 19,
+		},
+		"blake2_256": {
+			hasher:     Blake2256,
+			hashLength: 32,
+		},
+		"twox128": {
+			hasher:     Twox128,
+			hashLength: 16,
+		},
+		"twox256": {
+			hasher:     Twox256,
+			hashLength: 32,
+		},
+		"twox64_concat": {
+			hasher:     Twox64Concat,
+			hashed:     concat(twox64, key),
+			hashLength: 11,
+		},
+		"unknown hasher": {
+			hasher:     Hasher(100),
+			errWrapped: ErrHasherUnknown,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hashed, err := testCase.hasher.Hash(key)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				return
+			}
+			assert.Len(t, hashed, testCase.hashLength)
+			if testCase.hashed != nil {
+				assert.Equal(t, testCase.hashed, hashed)
+			}
+		})
+	}
+}
+
+func Test_Value(t *testing.T) {
+	t.Parallel()
+
+	key, err := Value("System", "Number")
+
+	require.NoError(t, err)
+	// Well known storage key of System.Number
+	expected := []byte{
+		0x26, 0xaa, 0x39, 0x4e, 0xea, 0x56, 0x30, 0xe0,
+		0x7c, 0x48, 0xae, 0x0c, 0x95, 0x58, 0xce, 0xf7,
+		0x02, 0xa5, 0xc1, 0xb1, 0x9a, 0xb7, 0xa0, 0x4f,
+		0x53, 0x6c, 0x51, 0x9a, 0xca, 0x49, 0x83, 0xac,
+	}
+	assert.Equal(t, expected, key)
+}
+
+func Test_Map_DoubleMap_NMap(t *testing.T) {
+	t.Parallel()
+
+	prefix, err := Value("Pallet", "Item")
+	require.NoError(t, err)
+
+	key1, key2, key3 := []byte{1}, []byte{2, 2}, []byte{3, 3, 3}
+
+	mapKey, err := Map("Pallet", "Item", Twox64Concat, key1)
+	require.NoError(t, err)
+	assert.Equal(t, concat(prefix, mustHash(t, Twox64Concat, key1)), mapKey)
+
+	doubleMapKey, err := DoubleMap("Pallet", "Item",
+		Blake2128Concat, key1, Identity, key2)
+	require.NoError(t, err)
+	assert.Equal(t, concat(prefix,
+		mustHash(t, Blake2128Concat, key1), key2), doubleMapKey)
+
+	nMapKey, err := NMap("Pallet", "Item",
+		Key{Hasher: Blake2128, Key: key1},
+		Key{Hasher: Twox64Concat, Key: key2},
+		Key{Hasher: Blake2128Concat, Key: key3})
+	require.NoError(t, err)
+	assert.Equal(t, concat(prefix,
+		mustHash(t, Blake2128, key1),
+		mustHash(t, Twox64Concat, key2),
+		mustHash(t, Blake2128Concat, key3)), nMapKey)
+
+	_, err = NMap("Pallet", "Item", Key{Hasher: Hasher(100)})
+	assert.ErrorIs(t, err, ErrHasherUnknown)
+	assert.EqualError(t, err, "hashing key 0: hasher unknown: 100")
+}
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	key1, key2, key3 := []byte{1}, []byte{2, 2}, []byte{3, 3, 3}
+
+	nMapKey, err := NMap("Pallet", "Item",
+		Key{Hasher: Blake2128, Key: key1},
+		Key{Hasher: Twox64Concat, Key: key2},
+		Key{Hasher: Blake2128Concat, Key: key3})
+	require.NoError(t, err)
+
+	doubleMapKey, err := DoubleMap("Pallet", "Item",
+		Twox64Concat, key2, Identity, key3)
+	require.NoError(t, err)
+
+	tamperedKey := append([]byte{}, doubleMapKey...)
+	tamperedKey[len(tamperedKey)-1]++
+
+	testCases := map[string]struct {
+		trieKey    []byte
+		pallet     string
+		item       string
+		components []Component
+		keys       []ParsedKey
+		errWrapped error
+		errMessage string
+	}{
+		"n-map": {
+			trieKey: nMapKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Blake2128},
+				{Hasher: Twox64Concat, KeyLength: 2},
+				{Hasher: Blake2128Concat},
+			},
+			keys: []ParsedKey{
+				{Hash: mustHash(t, Blake2128, key1)},
+				{Hash: mustHash(t, Twox64Concat, key2)[:8], Key: key2},
+				{Hash: mustHash(t, Blake2128Concat, key3)[:16], Key: key3},
+			},
+		},
+		"double map with identity last key": {
+			trieKey: doubleMapKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Twox64Concat, KeyLength: 2},
+				{Hasher: Identity},
+			},
+			keys: []ParsedKey{
+				{Hash: mustHash(t, Twox64Concat, key2)[:8], Key: key2},
+				{Hash: []byte{}, Key: key3},
+			},
+		},
+		"prefix mismatch": {
+			trieKey:    nMapKey,
+			pallet:     "Pallet",
+			item:       "Other",
+			errWrapped: ErrPrefixMismatch,
+		},
+		"key length unknown": {
+			trieKey: doubleMapKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Twox64Concat},
+				{Hasher: Identity},
+			},
+			errWrapped: ErrKeyLengthUnknown,
+			errMessage: "parsing key 0: key length unknown: for Twox64Concat hasher",
+		},
+		"key too short": {
+			trieKey: doubleMapKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Twox64Concat, KeyLength: 2},
+				{Hasher: Blake2256},
+			},
+			errWrapped: ErrKeyTooShort,
+			errMessage: "parsing key 1: trie key too short: " +
+				"3 bytes left for Blake2_256 hash of 32 bytes",
+		},
+		"trailing bytes": {
+			trieKey: doubleMapKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Twox64Concat, KeyLength: 2},
+			},
+			errWrapped: ErrKeyTrailingBytes,
+			errMessage: "trie key has trailing bytes: 3 bytes left",
+		},
+		"identity key is not checked": {
+			trieKey: tamperedKey,
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Twox64Concat, KeyLength: 2},
+				{Hasher: Identity},
+			},
+			keys: []ParsedKey{
+				{Hash: mustHash(t, Twox64Concat, key2)[:8], Key: key2},
+				{Hash: []byte{}, Key: []byte{3, 3, 4}},
+			},
+		},
+		"concat key hash mismatch": {
+			trieKey: append(append([]byte{}, nMapKey...), 0),
+			pallet:  "Pallet",
+			item:    "Item",
+			components: []Component{
+				{Hasher: Blake2128},
+				{Hasher: Twox64Concat, KeyLength: 2},
+				{Hasher: Blake2128Concat},
+			},
+			errWrapped: ErrKeyHashMismatched,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := Parse(testCase.trieKey, testCase.pallet,
+				testCase.item, testCase.components...)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.keys, keys)
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package substrate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var (
+	ErrWarpSyncProofEmpty           = errors.New("warp sync proof has no fragment")
+	ErrJustificationTargetMismatch  = errors.New("justification target does not match header")
+	ErrJustificationInvalidVote     = errors.New("justification contains an invalid vote")
+	ErrJustificationThreshold       = errors.New("justification does not reach the voting threshold")
+	ErrJustificationAncestry        = errors.New("justification votes ancestry is invalid")
+	ErrAuthoritySetChangeMissing    = errors.New("header is missing authority set change digest")
+	ErrWarpSyncFragmentNotIncreased = errors.New("warp sync fragment block number does not increase")
+)
+
+// GrandpaAuthority is a GRANDPA voter with its ed25519 public key and weight.
+type GrandpaAuthority struct {
+	Key    [32]byte
+	Weight uint64
+}
+
+// Precommit is a GRANDPA precommit vote for a target block.
+type Precommit struct {
+	TargetHash   util.Hash
+	TargetNumber uint32
+}
+
+// SignedPrecommit is a precommit signed by a GRANDPA authority.
+type SignedPrecommit struct {
+	Precommit   Precommit
+	Signature   [64]byte
+	AuthorityID [32]byte
+}
+
+// Commit is a GRANDPA commit message for a target block.
+type Commit struct {
+	TargetHash   util.Hash
+	TargetNumber uint32
+	Precommits   []SignedPrecommit
+}
+
+// GrandpaJustification is a GRANDPA finality justification for a block.
+// VotesAncestries contains the headers linking the precommit targets
+// to the commit target.
+type GrandpaJustification struct {
+	Round           uint64
+	Commit          Commit
+	VotesAncestries []Header
+}
+
+// WarpSyncFragment is a header finalized by a GRANDPA justification,
+// which enacts an authority set change, except for the last fragment
+// of a finished proof.
+type WarpSyncFragment struct {
+	Header        Header
+	Justification GrandpaJustification
+}
+
+// WarpSyncProof is a Substrate warp sync proof, made of fragments
+// for successive authority sets.
+type WarpSyncProof struct {
+	Fragments  []WarpSyncFragment
+	IsFinished bool
+}
+
+// WarpSyncResult is the outcome of a warp sync proof verification.
+type WarpSyncResult struct {
+	// Header is the header of the last fragment, which is finalized.
+	Header *Header
+	// StateRoot is the authenticated state root of the last fragment
+	// header, which can be used to verify storage proofs.
+	StateRoot util.Hash
+	// SetID is the authority set id after the last fragment.
+	SetID uint64
+	// Authorities are the authorities after the last fragment.
+	Authorities []GrandpaAuthority
+}
+
+// DecodeWarpSyncProof decodes a SCALE encoded warp sync proof.
+func DecodeWarpSyncProof(encoded []byte) (proof WarpSyncProof, err error) {
+	decoder := scale.NewDecoder(bytes.NewReader(encoded))
+
+	var fragmentsCount uint
+	err = decoder.Decode(&fragmentsCount)
+	if err != nil {
+		return proof, fmt.Errorf("decoding fragments count: %w", err)
+	}
+
+	// Note the fragments are appended instead of allocated upfront,
+	// since the fragments count is not trusted.
+	for i := uint(0); i < fragmentsCount; i++ {
+		fragment, err := decodeWarpSyncFragment(decoder)
+		if err != nil {
+			return proof, fmt.Errorf("decoding fragment %d: %w", i, err)
+		}
+		proof.Fragments = append(proof.Fragments, fragment)
+	}
+
+	err = decoder.Decode(&proof.IsFinished)
+	if err != nil {
+		return proof, fmt.Errorf("decoding is finished: %w", err)
+	}
+
+	return proof, nil
+}
+
+func decodeWarpSyncFragment(decoder *scale.Decoder) (fragment WarpSyncFragment, err error) {
+	header, err := decodeBlockHeader(decoder)
+	if err != nil {
+		return fragment, fmt.Errorf("decoding header: %w", err)
+	}
+	fragment.Header = *header
+
+	justification := &fragment.Justification
+	err = decoder.Decode(&justification.Round)
+	if err != nil {
+		return fragment, fmt.Errorf("decoding justification round: %w", err)
+	}
+
+	err = decoder.Decode(&justification.Commit)
+	if err != nil {
+		return fragment, fmt.Errorf("decoding justification commit: %w", err)
+	}
+
+	var ancestriesCount uint
+	err = decoder.Decode(&ancestriesCount)
+	if err != nil {
+		return fragment, fmt.Errorf("decoding votes ancestries count: %w", err)
+	}
+
+	for i := uint(0); i < ancestriesCount; i++ {
+		header, err := decodeBlockHeader(decoder)
+		if err != nil {
+			return fragment, fmt.Errorf("decoding votes ancestry %d: %w", i, err)
+		}
+		justification.VotesAncestries = append(justification.VotesAncestries, *header)
+	}
+
+	return fragment, nil
+}
+
+// decodeBlockHeader decodes a block header from the decoder given,
+// since its digest must be initialised before decoding.
+func decodeBlockHeader(decoder *scale.Decoder) (header *Header, err error) {
+	header = NewEmptyHeader()
+	err = decoder.Decode(header)
+	if err != nil {
+		return nil, err
+	}
+	header.Hash()
+	return header, nil
+}
+
+// Encode SCALE encodes the warp sync proof.
+func (p WarpSyncProof) Encode() (encoded []byte, err error) {
+	return scale.Marshal(p)
+}
+
+// Verify verifies the warp sync proof starting from the authority set
+// id and authorities given, which must be trusted. Each fragment
+// justification must be signed by a supermajority of the current
+// authorities and target the fragment header, and each fragment header
+// must schedule the next authority set, except the last one if the
+// proof is finished. It returns the last finalized header together
+// with its state root and the authority set following it.
+func (p WarpSyncProof) Verify(setID uint64, authorities []GrandpaAuthority) (
+	result WarpSyncResult, err error) {
+	if len(p.Fragments) == 0 {
+		return result, ErrWarpSyncProofEmpty
+	}
+
+	var previousNumber uint
+	for i := range p.Fragments {
+		fragment := &p.Fragments[i]
+		header := &fragment.Header
+
+		if i > 0 && header.Number <= previousNumber {
+			return result, fmt.Errorf("%w: fragment %d has block number %d after block number %d",
+				ErrWarpSyncFragmentNotIncreased, i, header.Number, previousNumber)
+		}
+		previousNumber = header.Number
+
+		err = fragment.Justification.Verify(setID, authorities)
+		if err != nil {
+			return result, fmt.Errorf("verifying justification of fragment %d: %w", i, err)
+		}
+
+		headerHash := header.Hash()
+		commit := fragment.Justification.Commit
+		if commit.TargetHash != headerHash || uint(commit.TargetNumber) != header.Number {
+			return result, fmt.Errorf("%w: for fragment %d: target %s at block %d "+
+				"but header %s at block %d", ErrJustificationTargetMismatch, i,
+				commit.TargetHash, commit.TargetNumber, headerHash, header.Number)
+		}
+
+		nextAuthorities, ok, err := FindGrandpaScheduledChange(header)
+		if err != nil {
+			return result, fmt.Errorf("finding authority set change in fragment %d: %w", i, err)
+		}
+
+		isLast := i == len(p.Fragments)-1
+		if ok {
+			authorities = nextAuthorities
+			setID++
+		} else if !isLast || !p.IsFinished {
+			return result, fmt.Errorf("%w: for fragment %d at block %d",
+				ErrAuthoritySetChangeMissing, i, header.Number)
+		}
+	}
+
+	lastHeader := &p.Fragments[len(p.Fragments)-1].Header
+	return WarpSyncResult{
+		Header:      lastHeader,
+		StateRoot:   lastHeader.StateRoot,
+		SetID:       setID,
+		Authorities: authorities,
+	}, nil
+}
+
+// Verify verifies the justification is signed by a supermajority of
+// the authorities given for the authority set id given, and that each
+// precommit target descends from the commit target through the votes
+// ancestries, which must all be used.
+func (j GrandpaJustification) Verify(setID uint64, authorities []GrandpaAuthority) (err error) {
+	weights := make(map[[32]byte]uint64, len(authorities))
+	var totalWeight uint64
+	for _, authority := range authorities {
+		weights[authority.Key] = authority.Weight
+		totalWeight += authority.Weight
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("%w: authority set has no weight", ErrJustificationThreshold)
+	}
+	threshold := totalWeight - (totalWeight-1)/3
+
+	ancestries := make(map[util.Hash]*Header, len(j.VotesAncestries))
+	for i := range j.VotesAncestries {
+		header := &j.VotesAncestries[i]
+		ancestries[header.Hash()] = header
+	}
+	usedAncestries := make(map[util.Hash]struct{}, len(j.VotesAncestries))
+
+	voted := make(map[[32]byte]struct{}, len(j.Commit.Precommits))
+	var votedWeight uint64
+	for i, signed := range j.Commit.Precommits {
+		weight, ok := weights[signed.AuthorityID]
+		if !ok {
+			return fmt.Errorf("%w: precommit %d from unknown authority 0x%x",
+				ErrJustificationInvalidVote, i, signed.AuthorityID)
+		}
+
+		message := precommitSigningPayload(signed.Precommit, j.Round, setID)
+		if !ed25519.Verify(signed.AuthorityID[:], message, signed.Signature[:]) {
+			return fmt.Errorf("%w: precommit %d has an invalid signature",
+				ErrJustificationInvalidVote, i)
+		}
+
+		err = j.checkAncestry(signed.Precommit, ancestries, usedAncestries)
+		if err != nil {
+			return fmt.Errorf("precommit %d: %w", i, err)
+		}
+
+		// Equivocations are only counted once.
+		if _, ok := voted[signed.AuthorityID]; ok {
+			continue
+		}
+		voted[signed.AuthorityID] = struct{}{}
+		votedWeight += weight
+	}
+
+	if votedWeight < threshold {
+		return fmt.Errorf("%w: voted weight %d is below threshold %d",
+			ErrJustificationThreshold, votedWeight, threshold)
+	}
+
+	if len(usedAncestries) != len(ancestries) {
+		return fmt.Errorf("%w: %d unused votes ancestries",
+			ErrJustificationAncestry, len(ancestries)-len(usedAncestries))
+	}
+
+	return nil
+}
+
+// checkAncestry checks the precommit target is the commit target or
+// descends from it through the ancestries given, and marks the
+// ancestries traversed as used.
+func (j GrandpaJustification) checkAncestry(precommit Precommit,
+	ancestries map[util.Hash]*Header, used map[util.Hash]struct{}) (err error) {
+	hash := precommit.TargetHash
+	for hash != j.Commit.TargetHash {
+		header, ok := ancestries[hash]
+		if !ok || header.Number <= uint(j.Commit.TargetNumber) {
+			return fmt.Errorf("%w: target %s does not descend from commit target %s",
+				ErrJustificationAncestry, precommit.TargetHash, j.Commit.TargetHash)
+		}
+		used[hash] = struct{}{}
+		hash = header.ParentHash
+	}
+	return nil
+}
+
+// precommitSigningPayload returns the message signed by a GRANDPA
+// authority for a precommit, which is the SCALE encoding of
+// (Message::Precommit(precommit), round, set id).
+func precommitSigningPayload(precommit Precommit, round, setID uint64) (payload []byte) {
+	const precommitMessageIndex = 1
+	payload = make([]byte, 1+32+4+8+8)
+	payload[0] = precommitMessageIndex
+	copy(payload[1:], precommit.TargetHash[:])
+	binary.LittleEndian.PutUint32(payload[33:], precommit.TargetNumber)
+	binary.LittleEndian.PutUint64(payload[37:], round)
+	binary.LittleEndian.PutUint64(payload[45:], setID)
+	return payload
+}
+
+// FindGrandpaScheduledChange returns the next authorities of the GRANDPA
+// scheduled change consensus digest in the header given, if any.
+func FindGrandpaScheduledChange(header *Header) (
+	authorities []GrandpaAuthority, ok bool, err error) {
+	for _, digestItem := range header.Digest.Types {
+		value, err := digestItem.Value()
+		if err != nil {
+			return nil, false, fmt.Errorf("getting digest item value: %w", err)
+		}
+
+		consensusDigest, isConsensus := value.(ConsensusDigest)
+		if !isConsensus || consensusDigest.ConsensusEngineID != GrandpaEngineID {
+			continue
+		}
+
+		const scheduledChangeIndex = 0
+		data := consensusDigest.Data
+		if len(data) == 0 || data[0] != scheduledChangeIndex {
+			continue
+		}
+
+		var scheduledChange struct {
+			NextAuthorities []GrandpaAuthority
+			Delay           uint32
+		}
+		err = scale.Unmarshal(data[1:], &scheduledChange)
+		if err != nil {
+			return nil, false, fmt.Errorf("decoding scheduled change: %w", err)
+		}
+		return scheduledChange.NextAuthorities, true, nil
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,219 @@
+package substrate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"io"
+	"testing"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGrandpaKeys(seeds ...byte) (keys []ed25519.PrivateKey, authorities []GrandpaAuthority) {
+	for _, seed := range seeds {
+		key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+		keys = append(keys, key)
+		var authority GrandpaAuthority
+		copy(authority.Key[:], key.Public().(ed25519.PublicKey))
+		authority.Weight = 1
+		authorities = append(authorities, authority)
+	}
+	return keys, authorities
+}
+
+func newScheduledChangeHeader(t *testing.T, number uint,
+	nextAuthorities []GrandpaAuthority) *Header {
+	t.Helper()
+
+	scheduledChange := struct {
+		NextAuthorities []GrandpaAuthority
+		Delay           uint32
+	}{NextAuthorities: nextAuthorities}
+	encoded, err := scale.Marshal(scheduledChange)
+	require.NoError(t, err)
+
+	digest := NewDigest()
+	err = digest.Add(ConsensusDigest{
+		ConsensusEngineID: GrandpaEngineID,
+		Data:              append([]byte{0}, encoded...),
+	})
+	require.NoError(t, err)
+
+	return NewHeader(util.Hash{byte(number)}, util.Hash{}, util.Hash{}, number, digest)
+}
+
+// justify returns a justification for the target header, where each key
+// precommits for the corresponding vote target header.
+func justify(target *Header, setID uint64, keys []ed25519.PrivateKey,
+	voteTargets []*Header, ancestries []Header) GrandpaJustification {
+	const round = 1
+	justification := GrandpaJustification{
+		Round: round,
+		Commit: Commit{
+			TargetHash:   target.Hash(),
+			TargetNumber: uint32(target.Number),
+		},
+		VotesAncestries: ancestries,
+	}
+	for i, key := range keys {
+		precommit := Precommit{
+			TargetHash:   voteTargets[i].Hash(),
+			TargetNumber: uint32(voteTargets[i].Number),
+		}
+		signed := SignedPrecommit{Precommit: precommit}
+		copy(signed.Signature[:], ed25519.Sign(key, precommitSigningPayload(precommit, round, setID)))
+		copy(signed.AuthorityID[:], key.Public().(ed25519.PublicKey))
+		justification.Commit.Precommits = append(justification.Commit.Precommits, signed)
+	}
+	return justification
+}
+
+func Test_WarpSyncProof(t *testing.T) {
+	t.Parallel()
+
+	firstKeys, firstAuthorities := newGrandpaKeys(1, 2, 3)
+	secondKeys, secondAuthorities := newGrandpaKeys(4, 5)
+
+	changeHeader := newScheduledChangeHeader(t, 10, secondAuthorities)
+	finalHeader := NewHeader(util.Hash{1}, util.Hash{9}, util.Hash{}, 20, NewDigest())
+	childHeader := NewHeader(finalHeader.Hash(), util.Hash{}, util.Hash{}, 21, NewDigest())
+
+	proof := WarpSyncProof{
+		Fragments: []WarpSyncFragment{{
+			Header: *changeHeader,
+			Justification: justify(changeHeader, 0, firstKeys,
+				[]*Header{changeHeader, changeHeader, changeHeader}, nil),
+		}, {
+			Header: *finalHeader,
+			Justification: justify(finalHeader, 1, secondKeys,
+				[]*Header{childHeader, finalHeader}, []Header{*childHeader}),
+		}},
+		IsFinished: true,
+	}
+
+	encoded, err := proof.Encode()
+	require.NoError(t, err)
+	decoded, err := DecodeWarpSyncProof(encoded)
+	require.NoError(t, err)
+
+	result, err := decoded.Verify(0, firstAuthorities)
+	require.NoError(t, err)
+	assert.Equal(t, finalHeader.Hash(), result.Header.Hash())
+	assert.Equal(t, util.Hash{9}, result.StateRoot)
+	assert.Equal(t, uint64(1), result.SetID)
+	assert.Equal(t, secondAuthorities, result.Authorities)
+
+	t.Run("not_finished", func(t *testing.T) {
+		t.Parallel()
+
+		unfinished := proof
+		unfinished.IsFinished = false
+		_, err := unfinished.Verify(0, firstAuthorities)
+		assert.ErrorIs(t, err, ErrAuthoritySetChangeMissing)
+	})
+
+	t.Run("wrong_authorities", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := proof.Verify(0, secondAuthorities)
+		assert.ErrorIs(t, err, ErrJustificationInvalidVote)
+	})
+
+	t.Run("wrong_set_id", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := proof.Verify(1, firstAuthorities)
+		assert.ErrorIs(t, err, ErrJustificationInvalidVote)
+	})
+
+	t.Run("below_threshold", func(t *testing.T) {
+		t.Parallel()
+
+		justification := justify(changeHeader, 0, firstKeys[:2],
+			[]*Header{changeHeader, changeHeader}, nil)
+		err := justification.Verify(0, firstAuthorities)
+		assert.ErrorIs(t, err, ErrJustificationThreshold)
+	})
+
+	t.Run("unused_ancestry", func(t *testing.T) {
+		t.Parallel()
+
+		justification := justify(finalHeader, 1, secondKeys,
+			[]*Header{finalHeader, finalHeader}, []Header{*childHeader})
+		err := justification.Verify(1, secondAuthorities)
+		assert.ErrorIs(t, err, ErrJustificationAncestry)
+	})
+
+	t.Run("target_mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		mismatched := WarpSyncProof{
+			Fragments: []WarpSyncFragment{{
+				Header: *finalHeader,
+				Justification: justify(changeHeader, 0, firstKeys,
+					[]*Header{changeHeader, changeHeader, changeHeader}, nil),
+			}},
+			IsFinished: true,
+		}
+		_, err := mismatched.Verify(0, firstAuthorities)
+		assert.ErrorIs(t, err, ErrJustificationTargetMismatch)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := WarpSyncProof{}.Verify(0, firstAuthorities)
+		assert.ErrorIs(t, err, ErrWarpSyncProofEmpty)
+	})
+}
+
+func Test_DecodeWarpSyncProof(t *testing.T) {
+	t.Parallel()
+
+	hugeCount := []byte{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f}
+
+	header := NewHeader(util.Hash{1}, util.Hash{9}, util.Hash{}, 20, NewDigest())
+	proof := WarpSyncProof{
+		Fragments:  []WarpSyncFragment{{Header: *header}},
+		IsFinished: true,
+	}
+	encoded, err := proof.Encode()
+	require.NoError(t, err)
+	// Replace the empty votes ancestries count and is finished flag.
+	hugeAncestriesCount := append(encoded[:len(encoded)-2:len(encoded)-2], hugeCount...)
+
+	testCases := map[string]struct {
+		encoded    []byte
+		errWrapped error
+		errMessage string
+	}{
+		"huge_fragments_count": {
+			encoded:    hugeCount,
+			errWrapped: io.EOF,
+			errMessage: "decoding fragment 0: decoding header: decoding struct: " +
+				"unmarshalling field at index 0: EOF",
+		},
+		"huge_votes_ancestries_count": {
+			encoded:    hugeAncestriesCount,
+			errWrapped: io.EOF,
+			errMessage: "decoding fragment 0: decoding votes ancestry 0: " +
+				"decoding struct: unmarshalling field at index 0: EOF",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proof, err := DecodeWarpSyncProof(testCase.encoded)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.EqualError(t, err, testCase.errMessage)
+			assert.Empty(t, proof.Fragments)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/octopus-network/trie-go/util"
+)
+
+var ErrAsyncWriterClosed = errors.New("async writer is closed")
+
+// WriteCallback is called by an AsyncWriter once the dirty nodes
+// of the trie with the root hash given are persisted to the database,
+// with a nil error, or failed to be persisted, with a non nil error.
+type WriteCallback func(rootHash util.Hash, err error)
+
+// AsyncWriter writes dirty trie nodes to a database from a background
+// goroutine, so the caller, typically importing blocks, does not wait
+// for the database to flush to disk. Batches are written in the order
+// they are enqueued. At most queueSize batches wait to be written, and
+// enqueuing another batch blocks until one of them is written, which
+// bounds the memory used if the database cannot keep up.
+type AsyncWriter struct {
+	db      KVStore
+	options WriteOptions
+
+	batches chan asyncBatch
+	errors  chan error
+	stopped chan struct{}
+
+	mutex    sync.RWMutex
+	closed   bool
+	pending  sync.WaitGroup
+	firstErr error
+}
+
+// asyncBatch is a batch of node encodings to write
+// to the database for the trie with the root hash given.
+type asyncBatch struct {
+	rootHash util.Hash
+	batch    *memoryBatch
+	callback WriteCallback
+}
+
+// NewAsyncWriter creates an async writer writing to the database
+// given with the options given, and starts its background goroutine.
+// The queue size is the maximum number of batches waiting to be
+// written, and is set to 1 if it is lower than 1.
+// Close must be called once the writer is no longer needed.
+func NewAsyncWriter(db KVStore, queueSize int,
+	options WriteOptions) *AsyncWriter {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	w := &AsyncWriter{
+		db:      db,
+		options: options,
+		batches: make(chan asyncBatch, queueSize),
+		errors:  make(chan error, queueSize),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// WriteDirty encodes all dirty nodes of the trie given, sets them to
+// clean and enqueues their encodings to be written to the database by
+// the background goroutine. It blocks while the queue is full.
+// The callback, if not nil, is called from the background goroutine
+// once the batch is written or failed to be written. Since the nodes
+// are set to clean when enqueued, a failed write is only reported
+// through the callback, the Errors channel and Close.
+func (w *AsyncWriter) WriteDirty(t *Trie, callback WriteCallback) (err error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return ErrAsyncWriterClosed
+	}
+
+	rootHash, err := t.Hash()
+	if err != nil {
+		return fmt.Errorf("hashing trie: %w", err)
+	}
+
+	batch := &memoryBatch{}
+	err = t.writeDirtyNode(batch, t.root, nil)
+	if err != nil {
+		return err
+	}
+
+	w.pending.Add(1)
+	w.batches <- asyncBatch{
+		rootHash: rootHash,
+		batch:    batch,
+		callback: callback,
+	}
+	return nil
+}
+
+// Errors returns a channel receiving the errors of failed batch writes.
+// Errors are dropped if the channel is full, but the first error is
+// always returned by Close.
+func (w *AsyncWriter) Errors() <-chan error {
+	return w.errors
+}
+
+// Drain blocks until all the batches enqueued are written
+// to the database and their callbacks have returned.
+func (w *AsyncWriter) Drain() {
+	w.pending.Wait()
+}
+
+// Close stops accepting new batches, waits for the enqueued batches
+// to be written and stops the background goroutine. It returns the
+// first error that occurred writing a batch, if any. The errors
+// channel is closed once Close returns. Calling Close more than
+// once is safe and returns the same error.
+func (w *AsyncWriter) Close() (err error) {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.batches)
+	}
+	w.mutex.Unlock()
+
+	<-w.stopped
+	return w.firstErr
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+	defer close(w.errors)
+
+	for asyncBatch := range w.batches {
+		err := w.write(asyncBatch.batch)
+		if err != nil {
+			err = fmt.Errorf("writing trie with root hash %s: %w",
+				asyncBatch.rootHash, err)
+			if w.firstErr == nil {
+				w.firstErr = err
+			}
+			select {
+			case w.errors <- err:
+			default:
+			}
+		}
+
+		if asyncBatch.callback != nil {
+			asyncBatch.callback(asyncBatch.rootHash, err)
+		}
+		w.pending.Done()
+	}
+}
+
+func (w *AsyncWriter) write(memory *memoryBatch) (err error) {
+	batch := &storeBatch{store: w.db}
+	for _, keyValue := range memory.keyValues {
+		if keyValue.Value == nil {
+			err = batch.Del(keyValue.Key)
+			if err != nil {
+				batch.Reset()
+				return fmt.Errorf("deleting key 0x%x from database: %w", keyValue.Key, err)
+			}
+			continue
+		}
+
+		if w.options.SkipExisting {
+			has, err := w.db.Has(keyValue.Key)
+			if err != nil {
+				batch.Reset()
+				return fmt.Errorf(
+					"checking node with Merkle value 0x%x in database: %w",
+					keyValue.Key, err)
+			} else if has {
+				continue
+			}
+		}
+
+		err = batch.Put(keyValue.Key, keyValue.Value)
+		if err != nil {
+			batch.Reset()
+			return fmt.Errorf(
+				"putting encoding of node with Merkle value 0x%x in database: %w",
+				keyValue.Key, err)
+		}
+	}
+
+	return batch.Flush()
+}
+
+// memoryBatch is a batch only recording the key values put and
+// the keys deleted with a nil value, to write them to a database
+// batch later.
+type memoryBatch struct {
+	keyValues []KeyValue
+	size      int
+}
+
+func (b *memoryBatch) Put(key, value []byte) error {
+	b.keyValues = append(b.keyValues, KeyValue{Key: key, Value: value})
+	b.size += len(value)
+	return nil
+}
+
+func (b *memoryBatch) Del(key []byte) error {
+	b.keyValues = append(b.keyValues, KeyValue{Key: key})
+	return nil
+}
+
+func (b *memoryBatch) Flush() error { return nil }
+
+func (b *memoryBatch) ValueSize() int { return b.size }
+
+func (b *memoryBatch) Reset() {
+	b.keyValues = nil
+	b.size = 0
+}
@@ -0,0 +1,103 @@
+package trie
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AsyncWriter(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+
+	db := newTestDB(t)
+	writer := NewAsyncWriter(db, 2, WriteOptions{})
+
+	var mutex sync.Mutex
+	var writtenRoots []util.Hash
+	callback := func(rootHash util.Hash, err error) {
+		assert.NoError(t, err)
+		mutex.Lock()
+		writtenRoots = append(writtenRoots, rootHash)
+		mutex.Unlock()
+	}
+
+	var expectedRoots []util.Hash
+	for i := 0; i < 5; i++ {
+		trie.Put([]byte{byte(i)}, []byte{byte(i), 1})
+		err := writer.WriteDirty(trie, callback)
+		require.NoError(t, err)
+		expectedRoots = append(expectedRoots, trie.MustHash())
+	}
+
+	writer.Drain()
+	mutex.Lock()
+	assert.Equal(t, expectedRoots, writtenRoots)
+	mutex.Unlock()
+
+	err := writer.Close()
+	require.NoError(t, err)
+
+	err = writer.WriteDirty(trie, nil)
+	assert.ErrorIs(t, err, ErrAsyncWriterClosed)
+	assert.NoError(t, writer.Close())
+
+	for _, rootHash := range expectedRoots {
+		trieFromDB := NewEmptyTrie()
+		err = trieFromDB.Load(db, rootHash)
+		require.NoError(t, err)
+	}
+
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	for keyString, expectedValue := range keyValues {
+		assert.Equal(t, expectedValue, trieFromDB.Get([]byte(keyString)))
+	}
+}
+
+// failingDatabase wraps a database so that flushing it fails.
+type failingDatabase struct {
+	chaindb.Database
+}
+
+var errTest = errors.New("test error")
+
+func (d *failingDatabase) Flush() error { return errTest }
+
+func Test_AsyncWriter_error(t *testing.T) {
+	t.Parallel()
+
+	trie, _ := makeSeededTrie(t, 10)
+	rootHash := trie.MustHash()
+
+	writer := NewAsyncWriter(&failingDatabase{Database: newTestDB(t)}, 1, WriteOptions{})
+
+	callbackErr := make(chan error, 1)
+	err := writer.WriteDirty(trie, func(_ util.Hash, err error) {
+		callbackErr <- err
+	})
+	require.NoError(t, err)
+
+	expectedMessage := "writing trie with root hash " + rootHash.String() + ": test error"
+
+	err = <-callbackErr
+	assert.ErrorIs(t, err, errTest)
+	assert.EqualError(t, err, expectedMessage)
+
+	err = <-writer.Errors()
+	assert.EqualError(t, err, expectedMessage)
+
+	err = writer.Close()
+	assert.EqualError(t, err, expectedMessage)
+
+	_, ok := <-writer.Errors()
+	assert.False(t, ok)
+}
@@ -0,0 +1,89 @@
+package trie
+
+import (
+	"sort"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Batch stages Put and Delete operations on a trie, which are only
+// applied to the trie when Apply is called. A batch is not safe for
+// concurrent use.
+type Batch struct {
+	trie *Trie
+	// operations maps the staged keys to their last staged operation.
+	operations map[string]batchOperation
+}
+
+type batchOperation struct {
+	value  []byte
+	delete bool
+}
+
+// Batch returns a new empty batch for the trie.
+func (t *Trie) Batch() *Batch {
+	return &Batch{
+		trie:       t,
+		operations: make(map[string]batchOperation),
+	}
+}
+
+// Put stages putting the value at the (Little Endian) key given.
+// It overrides any operation previously staged for the key.
+func (b *Batch) Put(keyLE, value []byte) {
+	if value == nil {
+		// Force nil value to be inserted to []byte{} since `nil` means there
+		// is no value.
+		value = []byte{}
+	}
+	b.operations[string(keyLE)] = batchOperation{value: value}
+}
+
+// Delete stages deleting the (Little Endian) key given.
+// It overrides any operation previously staged for the key.
+func (b *Batch) Delete(keyLE []byte) {
+	b.operations[string(keyLE)] = batchOperation{delete: true}
+}
+
+// Len returns the number of keys staged in the batch.
+func (b *Batch) Len() int {
+	return len(b.operations)
+}
+
+// Reset discards all the operations staged in the batch.
+func (b *Batch) Reset() {
+	b.operations = make(map[string]batchOperation)
+}
+
+// Apply applies all the operations staged to the trie at once, and
+// resets the batch. The trie is not modified until Apply is called.
+// Operations are applied in ascending key order, so the nodes shared
+// by consecutive keys are only copied once for the trie generation,
+// and the node Merkle values deleted are tracked once for the batch.
+// Node hashes are only recomputed once, when the trie is next hashed.
+func (b *Batch) Apply() {
+	keys := make([]string, 0, len(b.operations))
+	for key := range b.operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	t := b.trie
+	pendingDeletedMerkleValues := make(map[string]struct{})
+	defer func() {
+		const success = true
+		t.handleTrackedDeltas(success, pendingDeletedMerkleValues)
+	}()
+
+	for _, key := range keys {
+		operation := b.operations[key]
+		nibblesKey := sub.KeyLEToNibbles([]byte(key))
+		if operation.delete {
+			t.root, _, _ = t.deleteAtNode(t.root, nibblesKey, pendingDeletedMerkleValues)
+			continue
+		}
+		t.root, _, _ = t.insert(t.root, nibblesKey, operation.value, pendingDeletedMerkleValues)
+	}
+
+	b.Reset()
+}
@@ -0,0 +1,67 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Batch_Apply(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	trie, keyValues := makeSeededTrie(t, size)
+	expected := trie.DeepCopy()
+	originalHash, err := trie.Hash()
+	require.NoError(t, err)
+
+	batch := trie.Batch()
+	i := 0
+	for key := range keyValues {
+		switch i % 3 {
+		case 0:
+			batch.Delete([]byte(key))
+			expected.Delete([]byte(key))
+		case 1:
+			batch.Put([]byte(key), []byte{byte(i)})
+			expected.Put([]byte(key), []byte{byte(i)})
+		}
+		i++
+	}
+	batch.Put([]byte("new"), nil)
+	expected.Put([]byte("new"), nil)
+	// Later staged operations override earlier ones for the same key.
+	batch.Put([]byte("deleted"), []byte{1})
+	batch.Delete([]byte("deleted"))
+
+	hash, err := trie.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, originalHash, hash, "trie modified before apply")
+
+	batch.Apply()
+	assert.Equal(t, 0, batch.Len())
+
+	expectedHash, err := expected.Hash()
+	require.NoError(t, err)
+	hash, err = trie.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash, hash)
+	assert.Equal(t, []byte{}, trie.Get([]byte("new")))
+	assert.Nil(t, trie.Get([]byte("deleted")))
+}
+
+func Test_Batch_Reset(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	batch := trie.Batch()
+	batch.Put([]byte{1}, []byte{2})
+	batch.Delete([]byte{3})
+	assert.Equal(t, 2, batch.Len())
+
+	batch.Reset()
+	batch.Apply()
+
+	assert.Nil(t, trie.RootNode())
+}
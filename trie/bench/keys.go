@@ -0,0 +1,94 @@
+package bench
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/octopus-network/trie-go/util"
+)
+
+// KeyDistribution returns a key drawn from the random source given.
+type KeyDistribution func(random *rand.Rand) (key []byte)
+
+// UniformKeys returns a key distribution of keySpace distinct
+// keys of 4 bytes, drawn uniformly.
+func UniformKeys(keySpace int) KeyDistribution {
+	return func(random *rand.Rand) (key []byte) {
+		return appendIndex(nil, random.Intn(keySpace))
+	}
+}
+
+// UniformPrefixes returns a key distribution of prefixCount distinct
+// 2 bytes prefixes, drawn uniformly.
+func UniformPrefixes(prefixCount int) KeyDistribution {
+	return func(random *rand.Rand) (prefix []byte) {
+		prefix = make([]byte, 2)
+		binary.BigEndian.PutUint16(prefix, uint16(random.Intn(prefixCount)))
+		return prefix
+	}
+}
+
+// StorageMap is a Substrate storage map of a pallet.
+type StorageMap struct {
+	Pallet string
+	Item   string
+}
+
+// DefaultStorageMaps are common Substrate storage maps keyed by account.
+var DefaultStorageMaps = []StorageMap{
+	{Pallet: "System", Item: "Account"},
+	{Pallet: "Balances", Item: "Locks"},
+	{Pallet: "Staking", Item: "Ledger"},
+	{Pallet: "Session", Item: "NextKeys"},
+}
+
+// SubstrateKeys returns a key distribution mimicking the keys of
+// Substrate storage maps keyed by account with a blake2_128_concat
+// hasher, that is twox128(pallet) ++ twox128(item) ++
+// blake2b_128(account) ++ account, for accountCount distinct 32 bytes
+// accounts drawn uniformly. Storage maps are drawn uniformly from
+// the storage maps given, or from DefaultStorageMaps if none is given.
+func SubstrateKeys(accountCount int, storageMaps ...StorageMap) KeyDistribution {
+	if len(storageMaps) == 0 {
+		storageMaps = DefaultStorageMaps
+	}
+
+	prefixes := make([][]byte, len(storageMaps))
+	for i, storageMap := range storageMaps {
+		prefixes[i] = storageMapPrefix(storageMap)
+	}
+
+	return func(random *rand.Rand) (key []byte) {
+		prefix := prefixes[random.Intn(len(prefixes))]
+		account := make([]byte, 32)
+		binary.BigEndian.PutUint64(account, uint64(random.Intn(accountCount)))
+		accountHash, err := util.Blake2b128(account)
+		if err != nil {
+			panic(err)
+		}
+
+		key = make([]byte, 0, len(prefix)+len(accountHash)+len(account))
+		key = append(key, prefix...)
+		key = append(key, accountHash...)
+		return append(key, account...)
+	}
+}
+
+// storageMapPrefix returns twox128(pallet) ++ twox128(item).
+func storageMapPrefix(storageMap StorageMap) (prefix []byte) {
+	palletHash, err := util.Twox128Hash([]byte(storageMap.Pallet))
+	if err != nil {
+		panic(err)
+	}
+	itemHash, err := util.Twox128Hash([]byte(storageMap.Item))
+	if err != nil {
+		panic(err)
+	}
+	return append(palletHash, itemHash...)
+}
+
+func appendIndex(key []byte, index int) []byte {
+	var encoded [4]byte
+	binary.BigEndian.PutUint32(encoded[:], uint32(index))
+	return append(key, encoded[:]...)
+}
@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/octopus-network/trie-go/trie"
+)
+
+// Backend is a key value store a workload can run against.
+// Other projects implement it to benchmark their own database backends.
+type Backend interface {
+	Put(key, value []byte) (err error)
+	Get(key []byte) (value []byte, err error)
+	Delete(key []byte) (err error)
+	ClearPrefix(prefix []byte) (err error)
+}
+
+// Result contains the outcome of running a workload.
+type Result struct {
+	// Counts is the number of operations run for each operation kind.
+	Counts map[OperationKind]int
+	// Duration is the total time taken to run all the operations.
+	Duration time.Duration
+}
+
+// Run runs the operations given in order against the backend given,
+// and stops at the first operation failing.
+func Run(backend Backend, operations []Operation) (result Result, err error) {
+	result.Counts = make(map[OperationKind]int)
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	for i, operation := range operations {
+		switch operation.Kind {
+		case OperationPut:
+			err = backend.Put(operation.Key, operation.Value)
+		case OperationGet:
+			_, err = backend.Get(operation.Key)
+		case OperationDelete:
+			err = backend.Delete(operation.Key)
+		case OperationClearPrefix:
+			err = backend.ClearPrefix(operation.Key)
+		default:
+			panic(fmt.Sprintf("unknown operation kind %d", operation.Kind))
+		}
+
+		if err != nil {
+			return result, fmt.Errorf("running %s operation %d for key 0x%x: %w",
+				operation.Kind, i, operation.Key, err)
+		}
+		result.Counts[operation.Kind]++
+	}
+
+	return result, nil
+}
+
+// TrieBackend is a Backend using an in-memory trie.
+type TrieBackend struct {
+	Trie *trie.Trie
+}
+
+// NewTrieBackend returns a backend using the trie given.
+func NewTrieBackend(t *trie.Trie) *TrieBackend {
+	return &TrieBackend{Trie: t}
+}
+
+// Put puts the value at the key given in the trie.
+func (b *TrieBackend) Put(key, value []byte) (err error) {
+	b.Trie.Put(key, value)
+	return nil
+}
+
+// Get returns the value at the key given in the trie.
+func (b *TrieBackend) Get(key []byte) (value []byte, err error) {
+	return b.Trie.Get(key), nil
+}
+
+// Delete deletes the value at the key given in the trie.
+func (b *TrieBackend) Delete(key []byte) (err error) {
+	b.Trie.Delete(key)
+	return nil
+}
+
+// ClearPrefix deletes the values with the key prefix given in the trie.
+func (b *TrieBackend) ClearPrefix(prefix []byte) (err error) {
+	b.Trie.ClearPrefix(prefix)
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package bench contains composable workload generators to benchmark
+// tries and the databases backing them with reproducible operations.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// OperationKind is the kind of a workload operation.
+type OperationKind uint8
+
+const (
+	// OperationPut puts a value at a key.
+	OperationPut OperationKind = iota
+	// OperationGet gets the value at a key.
+	OperationGet
+	// OperationDelete deletes the value at a key.
+	OperationDelete
+	// OperationClearPrefix deletes all the values with a key prefix.
+	OperationClearPrefix
+)
+
+func (k OperationKind) String() string {
+	switch k {
+	case OperationPut:
+		return "put"
+	case OperationGet:
+		return "get"
+	case OperationDelete:
+		return "delete"
+	case OperationClearPrefix:
+		return "clear prefix"
+	default:
+		panic(fmt.Sprintf("unknown operation kind %d", k))
+	}
+}
+
+// Operation is a single workload operation. Key is the prefix
+// for OperationClearPrefix, and Value is only set for OperationPut.
+type Operation struct {
+	Kind  OperationKind
+	Key   []byte
+	Value []byte
+}
+
+// Generator generates the operations of a workload.
+type Generator interface {
+	// Next returns the next operation using the random source given.
+	Next(random *rand.Rand) Operation
+}
+
+// GeneratorFunc is a function implementing the Generator interface.
+type GeneratorFunc func(random *rand.Rand) Operation
+
+// Next calls the generator function.
+func (f GeneratorFunc) Next(random *rand.Rand) Operation {
+	return f(random)
+}
+
+// Generate returns count operations from the generator given,
+// using a random source seeded with the seed given, so the
+// same seed always generates the same operations.
+func Generate(generator Generator, seed int64, count int) (operations []Operation) {
+	random := rand.New(rand.NewSource(seed)) //nolint:gosec
+	operations = make([]Operation, count)
+	for i := range operations {
+		operations[i] = generator.Next(random)
+	}
+	return operations
+}
+
+// Weighted is a generator with its relative weight in a mix.
+type Weighted struct {
+	Generator Generator
+	Weight    int
+}
+
+// Mix returns a generator picking each next operation from one of
+// the generators given, chosen randomly according to their weights.
+// It panics if no weight is positive.
+func Mix(weighted ...Weighted) Generator {
+	totalWeight := 0
+	for _, w := range weighted {
+		if w.Weight < 0 {
+			panic(fmt.Sprintf("negative weight %d", w.Weight))
+		}
+		totalWeight += w.Weight
+	}
+	if totalWeight == 0 {
+		panic("no positive weight")
+	}
+
+	return GeneratorFunc(func(random *rand.Rand) Operation {
+		pick := random.Intn(totalWeight)
+		for _, w := range weighted {
+			if pick < w.Weight {
+				return w.Generator.Next(random)
+			}
+			pick -= w.Weight
+		}
+		panic("unreachable")
+	})
+}
+
+// Puts returns a generator of put operations, with keys from the key
+// distribution given and random values of valueSize bytes.
+func Puts(keys KeyDistribution, valueSize int) Generator {
+	return GeneratorFunc(func(random *rand.Rand) Operation {
+		value := make([]byte, valueSize)
+		_, _ = random.Read(value)
+		return Operation{Kind: OperationPut, Key: keys(random), Value: value}
+	})
+}
+
+// Gets returns a generator of get operations,
+// with keys from the key distribution given.
+func Gets(keys KeyDistribution) Generator {
+	return GeneratorFunc(func(random *rand.Rand) Operation {
+		return Operation{Kind: OperationGet, Key: keys(random)}
+	})
+}
+
+// Deletes returns a generator of delete operations,
+// with keys from the key distribution given.
+func Deletes(keys KeyDistribution) Generator {
+	return GeneratorFunc(func(random *rand.Rand) Operation {
+		return Operation{Kind: OperationDelete, Key: keys(random)}
+	})
+}
+
+// ClearPrefixes returns a generator of clear prefix operations,
+// with prefixes from the key distribution given.
+func ClearPrefixes(prefixes KeyDistribution) Generator {
+	return GeneratorFunc(func(random *rand.Rand) Operation {
+		return Operation{Kind: OperationClearPrefix, Key: prefixes(random)}
+	})
+}
+
+// InsertHeavy returns a workload of 90% puts and 10% gets.
+func InsertHeavy(keys KeyDistribution, valueSize int) Generator {
+	return Mix(
+		Weighted{Generator: Puts(keys, valueSize), Weight: 9},
+		Weighted{Generator: Gets(keys), Weight: 1},
+	)
+}
+
+// ReadHeavy returns a workload of 90% gets and 10% puts.
+func ReadHeavy(keys KeyDistribution, valueSize int) Generator {
+	return Mix(
+		Weighted{Generator: Gets(keys), Weight: 9},
+		Weighted{Generator: Puts(keys, valueSize), Weight: 1},
+	)
+}
+
+// PrefixChurn returns a workload putting keys under a small set of
+// prefixes and regularly clearing one of these prefixes, such as
+// storage maps being filled and removed by a runtime.
+func PrefixChurn(prefixCount, keysPerPrefix, valueSize int) Generator {
+	prefixes := UniformPrefixes(prefixCount)
+	keys := func(random *rand.Rand) []byte {
+		prefix := prefixes(random)
+		key := make([]byte, len(prefix), len(prefix)+4)
+		copy(key, prefix)
+		return appendIndex(key, random.Intn(keysPerPrefix))
+	}
+	return Mix(
+		Weighted{Generator: Puts(keys, valueSize), Weight: keysPerPrefix},
+		Weighted{Generator: ClearPrefixes(prefixes), Weight: 1},
+	)
+}
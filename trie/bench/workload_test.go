@@ -0,0 +1,87 @@
+package bench
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Generate(t *testing.T) {
+	t.Parallel()
+
+	generator := InsertHeavy(UniformKeys(100), 8)
+	operations := Generate(generator, 1, 1000)
+	require.Len(t, operations, 1000)
+	assert.Equal(t, operations, Generate(generator, 1, 1000))
+	assert.NotEqual(t, operations, Generate(generator, 2, 1000))
+
+	counts := make(map[OperationKind]int)
+	for _, operation := range operations {
+		counts[operation.Kind]++
+		assert.Len(t, operation.Key, 4)
+	}
+	assert.Greater(t, counts[OperationPut], 800)
+	assert.Equal(t, 1000, counts[OperationPut]+counts[OperationGet])
+}
+
+func Test_Mix(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "no positive weight", func() {
+		Mix(Weighted{Generator: Gets(UniformKeys(1))})
+	})
+
+	generator := Mix(
+		Weighted{Generator: Deletes(UniformKeys(1)), Weight: 1},
+		Weighted{Generator: Gets(UniformKeys(1)), Weight: 0},
+	)
+	for _, operation := range Generate(generator, 1, 10) {
+		assert.Equal(t, OperationDelete, operation.Kind)
+	}
+}
+
+func Test_SubstrateKeys(t *testing.T) {
+	t.Parallel()
+
+	storageMap := StorageMap{Pallet: "System", Item: "Account"}
+	prefix := storageMapPrefix(storageMap)
+	keys := SubstrateKeys(10, storageMap)
+
+	for _, operation := range Generate(Gets(keys), 1, 20) {
+		require.Len(t, operation.Key, 32+16+32)
+		assert.True(t, bytes.HasPrefix(operation.Key, prefix))
+	}
+}
+
+func Test_Run(t *testing.T) {
+	t.Parallel()
+
+	operations := Generate(PrefixChurn(4, 10, 40), 1, 500)
+	tr := trie.NewEmptyTrie()
+	result, err := Run(NewTrieBackend(tr), operations)
+	require.NoError(t, err)
+	assert.Equal(t, 500, result.Counts[OperationPut]+result.Counts[OperationClearPrefix])
+	assert.Positive(t, result.Counts[OperationClearPrefix])
+
+	// Replaying the operations on another trie gives the same root.
+	other := trie.NewEmptyTrie()
+	_, err = Run(NewTrieBackend(other), operations)
+	require.NoError(t, err)
+	assert.Equal(t, tr.MustHash(), other.MustHash())
+
+	_, err = Run(failingBackend{}, operations)
+	assert.ErrorIs(t, err, errTest)
+}
+
+var errTest = errors.New("test error")
+
+type failingBackend struct{}
+
+func (failingBackend) Put([]byte, []byte) error   { return errTest }
+func (failingBackend) Get([]byte) ([]byte, error) { return nil, errTest }
+func (failingBackend) Delete([]byte) error        { return errTest }
+func (failingBackend) ClearPrefix([]byte) error   { return errTest }
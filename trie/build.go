@@ -0,0 +1,149 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// ErrKeysNotSorted is returned when building a trie from keys
+// which are not in strictly ascending order.
+var ErrKeysNotSorted = errors.New("keys are not sorted")
+
+// SortedIterator iterates over key value pairs in strictly ascending
+// Little Endian key order.
+type SortedIterator interface {
+	// Next returns the next key value pair, or io.EOF once
+	// all the pairs are returned. The key and value returned
+	// may be reused by the iterator after the next call.
+	Next() (keyLE, value []byte, err error)
+}
+
+// buildFrame is a node under construction with its full key in nibbles.
+type buildFrame struct {
+	fullKey []byte
+	node    *Node
+}
+
+// BuildFromSorted builds a trie from the key value pairs of the
+// iterator given, which must be in strictly ascending key order.
+// The trie is built bottom-up in a single pass, only keeping the
+// nodes of the path to the last key under construction, which is
+// much faster than inserting the pairs one by one with Put.
+// It returns an error wrapping ErrKeysNotSorted if a key is not
+// strictly greater than the previous key.
+// Values are inserted as state trie version V0 values, and child
+// trie entries are inserted in the main trie only, without loading
+// the child tries.
+func BuildFromSorted(iterator SortedIterator) (trie *Trie, err error) {
+	return buildFromSorted(iterator, V0)
+}
+
+func buildFromSorted(iterator SortedIterator, version Version) (trie *Trie, err error) {
+	trie = NewEmptyTrie()
+	trie.version = version
+
+	var stack []buildFrame
+	var previousKeyLE []byte
+	for i := 0; ; i++ {
+		keyLE, value, err := iterator.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("iterating key value pair %d: %w", i, err)
+		}
+
+		if i > 0 && bytes.Compare(keyLE, previousKeyLE) <= 0 {
+			return nil, fmt.Errorf("%w: key 0x%x is not greater than previous key 0x%x",
+				ErrKeysNotSorted, keyLE, previousKeyLE)
+		}
+		previousKeyLE = append(previousKeyLE[:0], keyLE...)
+
+		if value == nil {
+			// nil means there is no value, see insertKeyLE.
+			value = []byte{}
+		}
+
+		fullKey := sub.KeyLEToNibbles(keyLE)
+		stack = trie.pushSorted(stack, fullKey, copyBytes(value))
+	}
+
+	if len(stack) == 0 {
+		return trie, nil
+	}
+
+	for len(stack) > 1 {
+		stack = attachTop(stack)
+	}
+	root := stack[0]
+	root.node.PartialKey = root.fullKey
+	trie.root = root.node
+	return trie, nil
+}
+
+// pushSorted pushes the new leaf node at the full key given on the
+// stack, after completing the nodes of the stack which cannot have
+// further descendants since keys are sorted. The stack contains the
+// nodes from the root to the last leaf pushed, each full key being a
+// prefix of the full keys of the nodes above it.
+func (t *Trie) pushSorted(stack []buildFrame, fullKey, value []byte) (newStack []buildFrame) {
+	leaf := pooledNode(Node{
+		Generation: t.generation,
+		Dirty:      true,
+	})
+	t.setStorageValue(leaf, value)
+	newFrame := buildFrame{fullKey: fullKey, node: leaf}
+
+	if len(stack) == 0 {
+		return append(stack, newFrame)
+	}
+
+	// All the full keys of the stack are prefixes of the last key,
+	// so their common prefix length with the new key is the minimum
+	// of their length and of this common prefix length.
+	commonPrefixLength := lenCommonPrefix(stack[len(stack)-1].fullKey, fullKey)
+	for len(stack) > 1 &&
+		len(stack[len(stack)-1].fullKey) > commonPrefixLength &&
+		len(stack[len(stack)-2].fullKey) >= commonPrefixLength {
+		stack = attachTop(stack)
+	}
+
+	if len(stack[len(stack)-1].fullKey) > commonPrefixLength {
+		// The new key diverges from the top node, so a branch
+		// without value is created at their common prefix.
+		top := stack[len(stack)-1]
+		stack[len(stack)-1] = buildFrame{
+			fullKey: fullKey[:commonPrefixLength],
+			node: pooledNode(Node{
+				Generation: t.generation,
+				Dirty:      true,
+			}),
+		}
+		stack = append(stack, top)
+		stack = attachTop(stack)
+	}
+
+	return append(stack, newFrame)
+}
+
+// attachTop pops the top node of the stack and sets it as a child
+// of the node below it, converting this one to a branch if needed.
+func attachTop(stack []buildFrame) (newStack []buildFrame) {
+	child := stack[len(stack)-1]
+	stack = stack[:len(stack)-1]
+	parent := stack[len(stack)-1]
+
+	parentKeyLength := len(parent.fullKey)
+	childIndex := child.fullKey[parentKeyLength]
+	child.node.PartialKey = child.fullKey[parentKeyLength+1:]
+
+	if parent.node.Children == nil {
+		parent.node.Children = make([]*Node, sub.ChildrenCapacity)
+	}
+	parent.node.Children[childIndex] = child.node
+	parent.node.Descendants += 1 + child.node.Descendants
+	return stack
+}
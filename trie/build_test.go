@@ -0,0 +1,134 @@
+package trie
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceIterator struct {
+	keys   []string
+	values [][]byte
+	err    error
+}
+
+func (s *sliceIterator) Next() (keyLE, value []byte, err error) {
+	if len(s.keys) == 0 {
+		if s.err != nil {
+			return nil, nil, s.err
+		}
+		return nil, nil, io.EOF
+	}
+	keyLE, value = []byte(s.keys[0]), s.values[0]
+	s.keys, s.values = s.keys[1:], s.values[1:]
+	return keyLE, value, nil
+}
+
+func newSliceIterator(keyValues map[string][]byte) *sliceIterator {
+	iterator := &sliceIterator{
+		keys:   make([]string, 0, len(keyValues)),
+		values: make([][]byte, 0, len(keyValues)),
+	}
+	for key := range keyValues {
+		iterator.keys = append(iterator.keys, key)
+	}
+	sort.Strings(iterator.keys)
+	for _, key := range iterator.keys {
+		iterator.values = append(iterator.values, keyValues[key])
+	}
+	return iterator
+}
+
+func Test_BuildFromSorted(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	testCases := map[string]struct {
+		iterator   *sliceIterator
+		errWrapped error
+		errMessage string
+	}{
+		"empty": {
+			iterator: &sliceIterator{},
+		},
+		"single key": {
+			iterator: newSliceIterator(map[string][]byte{"a": {1}}),
+		},
+		"empty key and nil value": {
+			iterator: newSliceIterator(map[string][]byte{"": {1}, "a": nil}),
+		},
+		"branches with and without values": {
+			iterator: newSliceIterator(map[string][]byte{
+				"a": {1}, "ab": {2}, "abc": {3}, "abd": {4},
+				"b": {5}, "ba": {6}, "\x10": {7}, "\x11": {8},
+			}),
+		},
+		"keys not sorted": {
+			iterator: &sliceIterator{
+				keys:   []string{"b", "a"},
+				values: [][]byte{{1}, {2}},
+			},
+			errWrapped: ErrKeysNotSorted,
+			errMessage: "keys are not sorted: key 0x61 is not greater than previous key 0x62",
+		},
+		"duplicate key": {
+			iterator: &sliceIterator{
+				keys:   []string{"a", "a"},
+				values: [][]byte{{1}, {2}},
+			},
+			errWrapped: ErrKeysNotSorted,
+			errMessage: "keys are not sorted: key 0x61 is not greater than previous key 0x61",
+		},
+		"iterator error": {
+			iterator: &sliceIterator{
+				keys:   []string{"a"},
+				values: [][]byte{{1}},
+				err:    errTest,
+			},
+			errWrapped: errTest,
+			errMessage: "iterating key value pair 1: test error",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expected := NewEmptyTrie()
+			expected.SetVersion(V0)
+			for i, key := range testCase.iterator.keys {
+				expected.Put([]byte(key), testCase.iterator.values[i])
+			}
+
+			trie, err := BuildFromSorted(testCase.iterator)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, trie)
+				return
+			}
+			assert.Equal(t, expected, trie)
+		})
+	}
+}
+
+func Test_BuildFromSorted_matchesPut(t *testing.T) {
+	t.Parallel()
+
+	const size = 1000
+	expected, keyValues := makeSeededTrie(t, size)
+
+	trie, err := BuildFromSorted(newSliceIterator(keyValues))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected.root, trie.root)
+	assert.Equal(t, expected.MustHash(), trie.MustHash())
+	assert.Equal(t, keyValues, trie.Entries())
+}
@@ -1,4 +1,3 @@
-
 package trie
 
 import (
@@ -22,50 +21,45 @@ func (t *Trie) SetChild(keyToChild []byte, child *Trie) error {
 		return err
 	}
 
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
-
-	t.Put(key, childHash.ToBytes())
-	t.childTries[childHash] = child
+	t.Put(childStorageKey(keyToChild), childHash.ToBytes())
+	t.childTries[string(keyToChild)] = child
 	return nil
 }
 
 // GetChild returns the child trie at key :child_storage:[keyToChild]
 func (t *Trie) GetChild(keyToChild []byte) (*Trie, error) {
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
-
-	childHash := t.Get(key)
+	childHash := t.Get(childStorageKey(keyToChild))
 	if childHash == nil {
 		return nil, fmt.Errorf("%w at key 0x%x%x", ErrChildTrieDoesNotExist, ChildStorageKeyPrefix, keyToChild)
 	}
 
-	return t.childTries[util.BytesToHash(childHash)], nil
+	return t.childTries[string(keyToChild)], nil
+}
+
+// ChildRoot returns the root hash of the child trie located in the
+// main trie at key :child_storage:[keyToChild], as stored in the main trie.
+func (t *Trie) ChildRoot(keyToChild []byte) (root util.Hash, err error) {
+	childHash := t.Get(childStorageKey(keyToChild))
+	if childHash == nil {
+		return root, fmt.Errorf("%w at key 0x%x%x", ErrChildTrieDoesNotExist, ChildStorageKeyPrefix, keyToChild)
+	}
+	return util.BytesToHash(childHash), nil
 }
 
 // PutIntoChild puts a key-value pair into the child trie located in the main trie at key :child_storage:[keyToChild]
+// The child trie is created if it does not exist, and its root hash entry
+// in the main trie is updated, so the main trie hash commits to it.
 func (t *Trie) PutIntoChild(keyToChild, key, value []byte) error {
 	child, err := t.GetChild(keyToChild)
-	if err != nil {
-		return err
-	}
-
-	origChildHash, err := child.Hash()
-	if err != nil {
+	if errors.Is(err, ErrChildTrieDoesNotExist) {
+		child = NewEmptyTrie()
+		child.version = t.version
+		child.metrics = t.metrics
+	} else if err != nil {
 		return err
 	}
 
 	child.Put(key, value)
-	childHash, err := child.Hash()
-	if err != nil {
-		return err
-	}
-
-	delete(t.childTries, origChildHash)
-	t.childTries[childHash] = child
-
 	return t.SetChild(keyToChild, child)
 }
 
@@ -85,16 +79,21 @@ func (t *Trie) GetFromChild(keyToChild, key []byte) ([]byte, error) {
 	return val, nil
 }
 
-// DeleteChild deletes the child storage trie
+// DeleteChild deletes the child storage trie and its root hash entry in the main trie.
 func (t *Trie) DeleteChild(keyToChild []byte) {
-	key := make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
-	copy(key, ChildStorageKeyPrefix)
-	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
+	key := childStorageKey(keyToChild)
+	if t.Get(key) == nil {
+		return
+	}
 
+	delete(t.childTries, string(keyToChild))
 	t.Delete(key)
 }
 
-// ClearFromChild removes the child storage entry
+// ClearFromChild removes the child storage entry, and updates the root hash
+// entry of the child trie in the main trie. If the child trie becomes empty,
+// it is removed together with its root hash entry in the main trie, as
+// Substrate does.
 func (t *Trie) ClearFromChild(keyToChild, key []byte) error {
 	child, err := t.GetChild(keyToChild)
 	if err != nil {
@@ -103,6 +102,18 @@ func (t *Trie) ClearFromChild(keyToChild, key []byte) error {
 	if child == nil {
 		return fmt.Errorf("%w at key 0x%x%x", ErrChildTrieDoesNotExist, ChildStorageKeyPrefix, keyToChild)
 	}
+
 	child.Delete(key)
-	return nil
+	if child.root == nil {
+		t.DeleteChild(keyToChild)
+		return nil
+	}
+	return t.SetChild(keyToChild, child)
+}
+
+func childStorageKey(keyToChild []byte) (key []byte) {
+	key = make([]byte, len(ChildStorageKeyPrefix)+len(keyToChild))
+	copy(key, ChildStorageKeyPrefix)
+	copy(key[len(ChildStorageKeyPrefix):], keyToChild)
+	return key
 }
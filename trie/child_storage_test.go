@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPutAndGetChild(t *testing.T) {
@@ -68,3 +71,106 @@ func TestPutAndGetFromChild(t *testing.T) {
 		t.Fatalf("Fail: got %x expected %x", valueRes, testValue)
 	}
 }
+
+func Test_Trie_childTrieLifecycle(t *testing.T) {
+	t.Parallel()
+
+	keyToChild := []byte("child")
+	trie := NewEmptyTrie()
+	emptyHash := trie.MustHash()
+
+	_, err := trie.ChildRoot(keyToChild)
+	assert.ErrorIs(t, err, ErrChildTrieDoesNotExist)
+
+	// Putting into an absent child trie creates it.
+	err = trie.PutIntoChild(keyToChild, []byte("a"), []byte{1})
+	require.NoError(t, err)
+	err = trie.PutIntoChild(keyToChild, []byte("b"), []byte{2})
+	require.NoError(t, err)
+
+	expectedChild := NewEmptyTrie()
+	expectedChild.Put([]byte("a"), []byte{1})
+	expectedChild.Put([]byte("b"), []byte{2})
+	expectedChildRoot := expectedChild.MustHash()
+
+	childRoot, err := trie.ChildRoot(keyToChild)
+	require.NoError(t, err)
+	assert.Equal(t, expectedChildRoot, childRoot)
+
+	expectedTrie := NewEmptyTrie()
+	expectedTrie.Put(childStorageKey(keyToChild), expectedChildRoot.ToBytes())
+	assert.Equal(t, expectedTrie.MustHash(), trie.MustHash())
+
+	// Clearing a child entry updates the child root entry.
+	err = trie.ClearFromChild(keyToChild, []byte("a"))
+	require.NoError(t, err)
+	expectedChild.Delete([]byte("a"))
+	childRoot, err = trie.ChildRoot(keyToChild)
+	require.NoError(t, err)
+	assert.Equal(t, expectedChild.MustHash(), childRoot)
+	assert.Len(t, trie.childTries, 1)
+
+	trie.DeleteChild(keyToChild)
+	assert.Empty(t, trie.childTries)
+	assert.Equal(t, emptyHash, trie.MustHash())
+}
+
+func Test_Trie_identicalChildTries(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	for _, keyToChild := range []string{"a", "b"} {
+		err := trie.PutIntoChild([]byte(keyToChild), []byte("k"), []byte("v"))
+		require.NoError(t, err)
+	}
+
+	err := trie.PutIntoChild([]byte("a"), []byte("k2"), []byte("v2"))
+	require.NoError(t, err)
+
+	value, err := trie.GetFromChild([]byte("b"), []byte("k"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), value)
+	value, err = trie.GetFromChild([]byte("b"), []byte("k2"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = trie.GetFromChild([]byte("a"), []byte("k2"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), value)
+
+	err = trie.ClearFromChild([]byte("b"), []byte("k"))
+	require.NoError(t, err)
+	value, err = trie.GetFromChild([]byte("a"), []byte("k"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), value)
+}
+
+func Test_Trie_ClearFromChild_lastKey(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	err := trie.PutIntoChild([]byte("child"), []byte("k"), []byte("v"))
+	require.NoError(t, err)
+
+	err = trie.ClearFromChild([]byte("child"), []byte("k"))
+	require.NoError(t, err)
+
+	_, err = trie.GetChild([]byte("child"))
+	assert.ErrorIs(t, err, ErrChildTrieDoesNotExist)
+	assert.Nil(t, trie.Get(childStorageKey([]byte("child"))))
+	assert.Empty(t, trie.childTries)
+	assert.Equal(t, EmptyHash, trie.MustHash())
+}
+
+func Test_Trie_Snapshot_emptyChildTrie(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	err := trie.SetChild([]byte("child"), NewEmptyTrie())
+	require.NoError(t, err)
+
+	snapshot := trie.Snapshot()
+
+	child, err := snapshot.GetChild([]byte("child"))
+	require.NoError(t, err)
+	assert.Nil(t, child.root)
+}
@@ -6,19 +6,12 @@ import (
 
 	"github.com/octopus-network/trie-go/util"
 	sub "github.com/octopus-network/trie-go/substrate"
-
-	"github.com/ChainSafe/chaindb"
 )
 
-// Database is an interface to get values from a
-// key value database.
-type Database interface {
-	Get(key []byte) (value []byte, err error)
-}
-
 // Load reconstructs the trie from the database from the given root hash.
 // It is used when restarting the node to load the current state trie.
 func (t *Trie) Load(db Database, rootHash util.Hash) error {
+	db = t.observedDatabase(db)
 	if rootHash == EmptyHash {
 		t.root = nil
 		return nil
@@ -39,6 +32,11 @@ func (t *Trie) Load(db Database, rootHash util.Hash) error {
 	t.root = root
 	t.root.NodeValue = rootHashBytes
 
+	err = loadValue(db, t.root)
+	if err != nil {
+		return fmt.Errorf("loading root node value: %w", err)
+	}
+
 	return t.loadNode(db, t.root)
 }
 
@@ -79,6 +77,12 @@ func (t *Trie) loadNode(db Database, n *Node) error {
 		decodedNode.NodeValue = merkleValue
 		branch.Children[i] = decodedNode
 
+		err = loadValue(db, decodedNode)
+		if err != nil {
+			return fmt.Errorf("loading value of child at index %d with Merkle value 0x%x: %w",
+				i, merkleValue, err)
+		}
+
 		err = t.loadNode(db, decodedNode)
 		if err != nil {
 			return fmt.Errorf("loading child at index %d with Merkle value 0x%x: %w", i, merkleValue, err)
@@ -98,6 +102,8 @@ func (t *Trie) loadNode(db Database, n *Node) error {
 
 	for _, key := range t.GetKeysWithPrefix(ChildStorageKeyPrefix) {
 		childTrie := NewEmptyTrie()
+		childTrie.version = t.version
+		childTrie.metrics = t.metrics
 		value := t.Get(key)
 		rootHash := util.BytesToHash(value)
 		err := childTrie.Load(db, rootHash)
@@ -105,16 +111,39 @@ func (t *Trie) loadNode(db Database, n *Node) error {
 			return fmt.Errorf("failed to load child trie with root hash=%s: %w", rootHash, err)
 		}
 
-		hash, err := childTrie.Hash()
-		if err != nil {
-			return fmt.Errorf("cannot hash chilld trie at key 0x%x: %w", key, err)
-		}
-		t.childTries[hash] = childTrie
+		t.childTries[string(key[len(ChildStorageKeyPrefix):])] = childTrie
 	}
 
 	return nil
 }
 
+// loadValue replaces the hashed storage value of the state version 1
+// node given with its preimage from the database, if it is found there,
+// so the node value can be read and the node is still encoded with the
+// value hash. The value is left hashed if its preimage is not found.
+func loadValue(db Database, node *Node) (err error) {
+	if !node.IsHashedValue {
+		return nil
+	}
+
+	has, err := hasKey(db, node.StorageValue)
+	if err != nil {
+		return fmt.Errorf("checking value preimage for hash 0x%x: %w", node.StorageValue, err)
+	} else if !has {
+		return nil
+	}
+
+	preimage, err := db.Get(node.StorageValue)
+	if err != nil {
+		return fmt.Errorf("getting value preimage for hash 0x%x: %w", node.StorageValue, err)
+	}
+
+	node.StorageValue = preimage
+	node.IsHashedValue = false
+	node.MustBeHashed = true
+	return nil
+}
+
 // PopulateNodeHashes writes the node hash values of the node given and of
 // all its descendant nodes as keys to the nodeHashes map.
 // It is assumed the node and its descendant nodes have their Merkle value already
@@ -151,7 +180,7 @@ func PopulateNodeHashes(n *Node, nodeHashes map[string]struct{}) {
 // It recursively descends into the trie using the database starting
 // from the root node until it reaches the node with the given key.
 // It then reads the value from the database.
-func GetFromDB(db chaindb.Database, rootHash util.Hash, key []byte) (
+func GetFromDB(db Database, rootHash util.Hash, key []byte) (
 	value []byte, err error) {
 	if rootHash == EmptyHash {
 		return nil, nil
@@ -177,31 +206,29 @@ func GetFromDB(db chaindb.Database, rootHash util.Hash, key []byte) (
 // for the value corresponding to a key.
 // Note it does not copy the value so modifying the value bytes
 // slice will modify the value of the node in the trie.
-func getFromDBAtNode(db chaindb.Database, n *Node, key []byte) (
+func getFromDBAtNode(db Database, n *Node, key []byte) (
 	value []byte, err error) {
 	if n.Kind() == sub.Leaf {
 		if bytes.Equal(n.PartialKey, key) {
-			return n.StorageValue, nil
+			return getValueFromDB(db, n)
 		}
 		return nil, nil
 	}
 
 	branch := n
-	// Key is equal to the key of this branch or is empty
-	if len(key) == 0 || bytes.Equal(branch.PartialKey, key) {
-		return branch.StorageValue, nil
+	if bytes.Equal(branch.PartialKey, key) {
+		return getValueFromDB(db, branch)
 	}
 
-	commonPrefixLength := lenCommonPrefix(branch.PartialKey, key)
-	if len(key) < len(branch.PartialKey) && bytes.Equal(branch.PartialKey[:commonPrefixLength], key) {
-		// The key to search is a prefix of the node key and is smaller than the node key.
-		// Example: key to search: 0xabcd
-		//          branch key:    0xabcdef
+	if !bytes.HasPrefix(key, branch.PartialKey) {
+		// The key is shorter than the branch partial key
+		// or diverges from it, so it is not in the trie.
 		return nil, nil
 	}
 
-	// childIndex is the nibble after the common prefix length in the key being searched.
-	childIndex := key[commonPrefixLength]
+	// childIndex is the nibble after the branch partial key in the key being searched.
+	childIndex := key[len(branch.PartialKey)]
+	childKey := key[len(branch.PartialKey)+1:]
 	child := branch.Children[childIndex]
 	if child == nil {
 		return nil, nil
@@ -210,7 +237,7 @@ func getFromDBAtNode(db chaindb.Database, n *Node, key []byte) (
 	// Child can be either inlined or a hash pointer.
 	childMerkleValue := child.NodeValue
 	if len(childMerkleValue) == 0 && child.Kind() == sub.Leaf {
-		return getFromDBAtNode(db, child, key[commonPrefixLength+1:])
+		return getFromDBAtNode(db, child, childKey)
 	}
 
 	encodedChild, err := db.Get(childMerkleValue)
@@ -228,14 +255,95 @@ func getFromDBAtNode(db chaindb.Database, n *Node, key []byte) (
 			childMerkleValue, err)
 	}
 
-	return getFromDBAtNode(db, decodedChild, key[commonPrefixLength+1:])
+	return getFromDBAtNode(db, decodedChild, childKey)
 	// Note: do not wrap error since it's called recursively.
 }
 
-// WriteDirty writes all dirty nodes to the database and sets them to clean
-func (t *Trie) WriteDirty(db chaindb.Database) error {
-	batch := db.NewBatch()
-	err := t.writeDirtyNode(batch, t.root)
+// getValueFromDB returns the storage value of the node given, getting
+// it from the database if the node only holds the hash of its value,
+// as for values larger than 32 bytes in state version 1.
+func getValueFromDB(db Database, n *Node) (value []byte, err error) {
+	if !n.IsHashedValue {
+		return n.StorageValue, nil
+	}
+
+	value, err = db.Get(n.StorageValue)
+	if err != nil {
+		return nil, fmt.Errorf("getting value preimage for hash 0x%x: %w", n.StorageValue, err)
+	}
+	return value, nil
+}
+
+// WriteOptions contains options to write dirty nodes to a database.
+type WriteOptions struct {
+	// SkipExisting can be set to true to check the database for
+	// each dirty node Merkle value, and skip writing its encoding
+	// if it is already present. Since nodes are content addressed,
+	// this reduces write amplification when many blocks share most
+	// of their state, at the cost of one database read per dirty node.
+	SkipExisting bool
+	// BatchSize is the size in bytes of the node encodings written to
+	// a batch before flushing it to the database and starting a new
+	// batch, to bound the memory used when writing many dirty nodes.
+	// Note the write is then no longer atomic, unless WAL is set.
+	// If it is zero, all the dirty nodes are written in a single batch.
+	BatchSize int
+	// WAL is an optional write-ahead log the dirty nodes are written
+	// to before being written to the database, so a write interrupted
+	// by a crash can be replayed or discarded with its Recover method.
+	// It is not used by the async writer.
+	WAL *WAL
+}
+
+// flushingBatch flushes and resets the batch it wraps once
+// its value size reaches maxSize bytes.
+type flushingBatch struct {
+	*storeBatch
+	maxSize int
+}
+
+func (b *flushingBatch) Put(key, value []byte) (err error) {
+	err = b.storeBatch.Put(key, value)
+	if err != nil {
+		return err
+	}
+
+	if b.storeBatch.ValueSize() < b.maxSize {
+		return nil
+	}
+
+	err = b.storeBatch.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing batch: %w", err)
+	}
+	return nil
+}
+
+// WriteDirty writes all dirty nodes to the store and sets them to clean.
+func (t *Trie) WriteDirty(db KVStore) error {
+	return t.WriteDirtyWithOptions(db, WriteOptions{})
+}
+
+// WriteDirtyWithOptions writes all dirty nodes to the store
+// using the options given, and sets them to clean.
+// The nodes are buffered in memory and written to the store once
+// they are all encoded, or every BatchSize bytes if it is set.
+func (t *Trie) WriteDirtyWithOptions(db KVStore, options WriteOptions) error {
+	var existing keyChecker
+	if options.SkipExisting {
+		existing = db
+	}
+
+	if options.WAL != nil {
+		return t.writeDirtyWithWAL(db, options, existing)
+	}
+
+	batch := &storeBatch{store: db}
+	var writer NodeWriter = batch
+	if options.BatchSize > 0 {
+		writer = &flushingBatch{storeBatch: batch, maxSize: options.BatchSize}
+	}
+	err := t.writeDirtyNode(t.observedBatch(writer), t.root, existing)
 	if err != nil {
 		batch.Reset()
 		return err
@@ -244,7 +352,18 @@ func (t *Trie) WriteDirty(db chaindb.Database) error {
 	return batch.Flush()
 }
 
-func (t *Trie) writeDirtyNode(db chaindb.Batch, n *Node) (err error) {
+// WriteDirtyToBatch writes all dirty nodes to the batch given, without
+// flushing it, and sets them to clean. It allows to write the nodes
+// atomically with a batch of the store, such as a chaindb batch, or
+// to commit them together with other writes of the caller.
+func (t *Trie) WriteDirtyToBatch(batch NodeWriter) error {
+	return t.writeDirtyNode(t.observedBatch(batch), t.root, nil)
+}
+
+// writeDirtyNode writes the dirty node given and its dirty descendants
+// to the batch. If existing is not nil, node encodings with a Merkle value
+// already present in existing are not written to the batch.
+func (t *Trie) writeDirtyNode(db NodeWriter, n *Node, existing keyChecker) (err error) {
 	if n == nil || !n.Dirty {
 		return nil
 	}
@@ -261,11 +380,30 @@ func (t *Trie) writeDirtyNode(db chaindb.Batch, n *Node) (err error) {
 			n.NodeValue, err)
 	}
 
-	err = db.Put(merkleValue, encoding)
-	if err != nil {
-		return fmt.Errorf(
-			"putting encoding of node with Merkle value 0x%x in database: %w",
-			merkleValue, err)
+	alreadyStored := false
+	if existing != nil {
+		alreadyStored, err = existing.Has(merkleValue)
+		if err != nil {
+			return fmt.Errorf(
+				"checking node with Merkle value 0x%x in database: %w",
+				merkleValue, err)
+		}
+	}
+
+	if !alreadyStored {
+		err = db.Put(merkleValue, encoding)
+		if err != nil {
+			return fmt.Errorf(
+				"putting encoding of node with Merkle value 0x%x in database: %w",
+				merkleValue, err)
+		}
+
+		err = writeValuePreimage(db, n)
+		if err != nil {
+			return fmt.Errorf(
+				"writing value of node with Merkle value 0x%x: %w",
+				merkleValue, err)
+		}
 	}
 
 	if n.Kind() != sub.Branch {
@@ -278,7 +416,7 @@ func (t *Trie) writeDirtyNode(db chaindb.Batch, n *Node) (err error) {
 			continue
 		}
 
-		err = t.writeDirtyNode(db, child)
+		err = t.writeDirtyNode(db, child, existing)
 		if err != nil {
 			// Note: do not wrap error since it's returned recursively.
 			return err
@@ -286,7 +424,7 @@ func (t *Trie) writeDirtyNode(db chaindb.Batch, n *Node) (err error) {
 	}
 
 	for _, childTrie := range t.childTries {
-		if err := childTrie.writeDirtyNode(db, childTrie.root); err != nil {
+		if err := childTrie.writeDirtyNode(db, childTrie.root, existing); err != nil {
 			return fmt.Errorf("writing dirty node to database: %w", err)
 		}
 	}
@@ -296,6 +434,26 @@ func (t *Trie) writeDirtyNode(db chaindb.Batch, n *Node) (err error) {
 	return nil
 }
 
+// writeValuePreimage writes the storage value of the node given to the
+// batch, keyed by its hash, if the value is encoded as its hash in the
+// node encoding, as for values larger than 32 bytes in state version 1.
+func writeValuePreimage(db NodeWriter, n *Node) (err error) {
+	if !n.MustBeHashed {
+		return nil
+	}
+
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+	err = sub.HashValue(n.StorageValue, n.ValueHasher, buffer)
+	if err != nil {
+		return fmt.Errorf("hashing value: %w", err)
+	}
+
+	valueHash := make([]byte, buffer.Len())
+	copy(valueHash, buffer.Bytes())
+	return db.Put(valueHash, n.StorageValue)
+}
+
 // GetChangedNodeHashes returns the two sets of hashes for all nodes
 // inserted and deleted in the state trie since the last snapshot.
 // Returned maps are safe for mutation.
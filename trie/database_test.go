@@ -271,6 +271,94 @@ func Test_GetFromDB(t *testing.T) {
 	}
 }
 
+func Test_GetFromDB_V1(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	trie.SetVersion(V1)
+	largeValue := make([]byte, 40)
+	largeValue[0] = 1
+	trie.Put([]byte("large"), largeValue)
+	trie.Put([]byte("larger"), largeValue[:33])
+	trie.Put([]byte("small"), []byte{1})
+
+	db := newMapStore()
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	root := trie.MustHash()
+
+	value, err := GetFromDB(db, root, []byte("large"))
+	require.NoError(t, err)
+	assert.Equal(t, largeValue, value)
+
+	value, err = GetFromDB(db, root, []byte("larger"))
+	require.NoError(t, err)
+	assert.Equal(t, largeValue[:33], value)
+
+	value, err = GetFromDB(db, root, []byte("small"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	valueHash, err := nodeValueHash(&Node{StorageValue: largeValue, MustBeHashed: true})
+	require.NoError(t, err)
+	delete(db.keyValues, string(valueHash))
+	_, err = GetFromDB(db, root, []byte("large"))
+	assert.ErrorIs(t, err, errMapStoreKeyNotFound)
+}
+
+func Test_getFromDBAtNode(t *testing.T) {
+	t.Parallel()
+
+	branch := &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: []byte{1},
+		Descendants:  1,
+		Children: padRightChildren([]*Node{
+			nil, nil, nil,
+			{
+				PartialKey:   []byte{3, 4},
+				StorageValue: []byte{2},
+			},
+		}),
+	}
+
+	testCases := map[string]struct {
+		key   []byte // nibbles
+		value []byte
+	}{
+		"branch key": {
+			key:   []byte{1, 2},
+			value: []byte{1},
+		},
+		"empty key": {},
+		"key shorter than branch key": {
+			key: []byte{1},
+		},
+		"key diverging from branch key": {
+			key: []byte{1, 3, 3, 4},
+		},
+		"child key": {
+			key:   []byte{1, 2, 3, 3, 4},
+			value: []byte{2},
+		},
+		"missing child": {
+			key: []byte{1, 2, 4, 3, 4},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := getFromDBAtNode(newMapStore(), branch, testCase.key)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.value, value)
+		})
+	}
+}
+
 func Test_Trie_PutChild_Store_Load(t *testing.T) {
 	t.Parallel()
 
@@ -306,3 +394,133 @@ func Test_Trie_PutChild_Store_Load(t *testing.T) {
 		assert.Equal(t, trie.String(), trieFromDB.String())
 	}
 }
+
+// putCountingDatabase wraps a database to count the
+// number of keys put and of flushes.
+type putCountingDatabase struct {
+	chaindb.Database
+	puts    int
+	flushes int
+}
+
+func (d *putCountingDatabase) Put(key, value []byte) error {
+	d.puts++
+	return d.Database.Put(key, value)
+}
+
+func (d *putCountingDatabase) Flush() error {
+	d.flushes++
+	return d.Database.Flush()
+}
+
+func Test_Trie_WriteDirtyWithOptions_SkipExisting(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	trie, keyValues := makeSeededTrie(t, size)
+	trieCopy := trie.DeepCopy()
+
+	db := &putCountingDatabase{Database: newTestDB(t)}
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	putsFirstWrite := db.puts
+
+	// Write the same state again through a different trie
+	// which has all its nodes dirty.
+	db.puts = 0
+	options := WriteOptions{SkipExisting: true}
+	err = trieCopy.WriteDirtyWithOptions(db, options)
+	require.NoError(t, err)
+	assert.Zero(t, db.puts)
+
+	// Modify a single key, only the nodes on its path are written.
+	db.puts = 0
+	trieCopy.Put([]byte{1, 2, 3}, []byte{4})
+	err = trieCopy.WriteDirtyWithOptions(db, options)
+	require.NoError(t, err)
+	assert.Greater(t, db.puts, 0)
+	assert.Less(t, db.puts, putsFirstWrite)
+
+	rootHash := trieCopy.MustHash()
+	for keyString, expectedValue := range keyValues {
+		value, err := GetFromDB(db, rootHash, []byte(keyString))
+		require.NoError(t, err)
+		assert.Equal(t, expectedValue, value)
+	}
+}
+
+func Test_Trie_WriteDirtyWithOptions_BatchSize(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	trie, keyValues := makeSeededTrie(t, size)
+	db := &putCountingDatabase{Database: newTestDB(t)}
+
+	err := trie.WriteDirtyWithOptions(db, WriteOptions{BatchSize: 1000})
+	require.NoError(t, err)
+	assert.Greater(t, db.flushes, 1)
+
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	assert.Equal(t, keyValues, trieFromDB.Entries())
+}
+
+func databaseHashKeys(t *testing.T, db chaindb.Database) (keys map[string]struct{}) {
+	t.Helper()
+	keys = make(map[string]struct{})
+	iterator := db.NewIterator()
+	defer iterator.Release()
+	for iterator.Next() {
+		const merkleValueLength = 32
+		if len(iterator.Key()) != merkleValueLength {
+			// skip inlined nodes written by WriteDirty
+			continue
+		}
+		keys[string(iterator.Key())] = struct{}{}
+	}
+	return keys
+}
+
+func Test_Trie_PurgeRemoved(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	trie, keyValues := makeSeededTrie(t, size)
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	previousRoot := trie.MustHash()
+
+	purged, err := trie.PurgeRemoved(db, previousRoot)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	i := 0
+	for key := range keyValues {
+		switch i % 4 {
+		case 0:
+			trie.Delete([]byte(key))
+		case 1:
+			trie.Put([]byte(key), []byte{byte(i)})
+		}
+		i++
+	}
+
+	purged, err = trie.PurgeRemoved(db, previousRoot)
+	require.NoError(t, err)
+	assert.Positive(t, purged)
+	err = trie.WriteDirty(db)
+	require.NoError(t, err)
+
+	// Only the nodes of the current trie are left in the database.
+	expectedKeys := make(map[string]struct{})
+	PopulateNodeHashes(trie.root, expectedKeys)
+	expectedKeys[string(trie.MustHash().ToBytes())] = struct{}{}
+	assert.Equal(t, expectedKeys, databaseHashKeys(t, db))
+
+	trieFromDB := NewEmptyTrie()
+	err = trieFromDB.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	assert.Equal(t, trie.Entries(), trieFromDB.Entries())
+}
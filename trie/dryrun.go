@@ -0,0 +1,37 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/octopus-network/trie-go/util"
+)
+
+// KeyValue is a change of the value at a (Little Endian) key
+// of a trie, where a nil value deletes the key from the trie.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// DryRun returns the root hash the trie would have after applying
+// the changes given in order, without modifying the trie. The changes
+// are applied to a copy on write snapshot of the trie used as overlay,
+// so only the nodes on the paths of the changed keys are copied, and
+// these copies are recycled once the root hash is computed.
+func (t *Trie) DryRun(changes []KeyValue) (root util.Hash, err error) {
+	overlay := t.Snapshot()
+	defer overlay.Discard()
+	for _, change := range changes {
+		if change.Value == nil {
+			overlay.Delete(change.Key)
+			continue
+		}
+		overlay.Put(change.Key, change.Value)
+	}
+
+	root, err = overlay.Hash()
+	if err != nil {
+		return root, fmt.Errorf("hashing overlay trie: %w", err)
+	}
+	return root, nil
+}
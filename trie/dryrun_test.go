@@ -0,0 +1,54 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_DryRun(t *testing.T) {
+	t.Parallel()
+
+	generator := newGenerator()
+	keyValues := generateKeyValues(t, generator, 100)
+
+	trie := NewEmptyTrie()
+	expected := NewEmptyTrie()
+	for key, value := range keyValues {
+		trie.Put([]byte(key), value)
+		expected.Put([]byte(key), value)
+	}
+	rootBefore := trie.MustHash()
+
+	var changes []KeyValue
+	i := 0
+	for key := range keyValues {
+		switch i % 3 {
+		case 0:
+			changes = append(changes, KeyValue{Key: []byte(key)})
+			expected.Delete([]byte(key))
+		case 1:
+			changes = append(changes, KeyValue{Key: []byte(key), Value: []byte{}})
+			expected.Put([]byte(key), []byte{})
+		}
+		i++
+	}
+	changes = append(changes, KeyValue{Key: []byte("new"), Value: []byte{1, 2}})
+	expected.Put([]byte("new"), []byte{1, 2})
+
+	root, err := trie.DryRun(changes)
+	require.NoError(t, err)
+	assert.Equal(t, expected.MustHash(), root)
+
+	// The trie is left unchanged.
+	assert.Equal(t, rootBefore, trie.MustHash())
+	for key, value := range keyValues {
+		assert.Equal(t, value, trie.Get([]byte(key)))
+	}
+	assert.Nil(t, trie.Get([]byte("new")))
+
+	root, err = NewEmptyTrie().DryRun(nil)
+	require.NoError(t, err)
+	assert.Equal(t, EmptyHash, root)
+}
@@ -0,0 +1,16 @@
+package trie
+
+// EncodedSize returns the total size in bytes of the encodings of all
+// the nodes of the trie and of its child tries, computed without encoding
+// nor hashing the nodes. It estimates the database footprint of the trie
+// nodes, without the database keys and the preimages of the values hashed
+// with the state trie version V1.
+func (t *Trie) EncodedSize() (size int) {
+	if t.root != nil {
+		_, size = t.root.EncodedLengths()
+	}
+	for _, childTrie := range t.childTries {
+		size += childTrie.EncodedSize()
+	}
+	return size
+}
@@ -0,0 +1,40 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_EncodedSize(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, NewEmptyTrie().EncodedSize())
+
+	trie, _ := makeSeededTrie(t, 200)
+	err := trie.PutIntoChild([]byte("child"), []byte{1}, []byte{2})
+	require.NoError(t, err)
+
+	var encodedSize func(node *Node) int
+	encodedSize = func(node *Node) (size int) {
+		if node == nil {
+			return 0
+		}
+		buffer := bytes.NewBuffer(nil)
+		err := node.Encode(buffer)
+		require.NoError(t, err)
+		size = buffer.Len()
+		for _, child := range node.Children {
+			size += encodedSize(child)
+		}
+		return size
+	}
+
+	expectedSize := encodedSize(trie.root)
+	for _, childTrie := range trie.childTries {
+		expectedSize += encodedSize(childTrie.root)
+	}
+	assert.Equal(t, expectedSize, trie.EncodedSize())
+}
@@ -0,0 +1,151 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var ErrEntriesMaxBytesExceeded = errors.New("entries maximum bytes exceeded")
+
+// EntriesOptions contains the filters and limits used when
+// iterating over the key-value pairs of a trie.
+// A zero value field means there is no filter or no limit.
+type EntriesOptions struct {
+	// Prefix is the Little Endian key prefix that all keys must have.
+	Prefix []byte
+	// StartKey is the Little Endian key from which to start, inclusive.
+	// Since keys are iterated in ascending order, it can be set to the
+	// successor of the last key of a previous page to continue paging.
+	StartKey []byte
+	// MaxEntries is the maximum number of entries to return. Once it
+	// is reached, the iteration stops without error.
+	MaxEntries int
+	// MaxBytes is the maximum total size in bytes of the keys and
+	// values returned. An error wrapping ErrEntriesMaxBytesExceeded
+	// is returned if the next entry would exceed it.
+	MaxBytes int
+}
+
+// EntriesWithOptions returns the key-value pairs in the trie matching
+// the options given, as a map of Little Endian keys to values.
+// On error, the entries collected so far are not returned.
+func (t *Trie) EntriesWithOptions(options EntriesOptions) (
+	entries map[string][]byte, err error) {
+	entries = make(map[string][]byte)
+	err = t.WalkEntries(options, func(key, value []byte) (keepWalking bool) {
+		entries[string(key)] = value
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WalkEntries calls the callback given for each key-value pair in the
+// trie matching the options given, in ascending Little Endian key order.
+// The walk stops if the callback returns false. Subtrees not matching the
+// prefix or located before the start key are not traversed.
+func (t *Trie) WalkEntries(options EntriesOptions,
+	callback func(key, value []byte) (keepWalking bool)) (err error) {
+	walker := &entriesWalker{
+		prefix:   sub.KeyLEToNibbles(options.Prefix),
+		start:    sub.KeyLEToNibbles(options.StartKey),
+		options:  options,
+		callback: callback,
+	}
+	_, err = walker.walk(t.root, nil)
+	return err
+}
+
+// ForEach calls the callback given for each key-value pair in the trie,
+// in ascending Little Endian key order, without collecting the entries,
+// and stops as soon as the callback returns false. The value given to
+// the callback must not be modified.
+func (t *Trie) ForEach(callback func(key, value []byte) (keepWalking bool)) {
+	// No error can occur without maximum bytes limit.
+	_ = t.WalkEntries(EntriesOptions{}, callback)
+}
+
+type entriesWalker struct {
+	prefix   []byte // nibbles
+	start    []byte // nibbles
+	options  EntriesOptions
+	callback func(key, value []byte) (keepWalking bool)
+	entries  int
+	bytes    int
+}
+
+func (w *entriesWalker) walk(node *Node, prefix []byte) (stop bool, err error) {
+	if node == nil {
+		return false, nil
+	}
+
+	fullKey := concatenateSlices(prefix, node.PartialKey)
+	if !w.subtreeMatches(fullKey) {
+		return false, nil
+	}
+
+	if (node.Kind() == sub.Leaf || node.StorageValue != nil) && w.keyMatches(fullKey) {
+		stop, err = w.emit(fullKey, node.StorageValue)
+		if stop || err != nil {
+			return stop, err
+		}
+	}
+
+	if node.Kind() == sub.Leaf {
+		return false, nil
+	}
+
+	for i, child := range node.Children {
+		if child == nil {
+			continue
+		}
+		childPrefix := concatenateSlices(fullKey, intToByteSlice(i))
+		stop, err = w.walk(child, childPrefix)
+		if stop || err != nil {
+			return stop, err
+		}
+	}
+
+	return false, nil
+}
+
+// subtreeMatches returns true if the subtree of keys all starting
+// with the key nibbles given may contain a key matching the options.
+func (w *entriesWalker) subtreeMatches(keyNibbles []byte) bool {
+	if !bytes.HasPrefix(keyNibbles, w.prefix) && !bytes.HasPrefix(w.prefix, keyNibbles) {
+		return false
+	}
+
+	subtreeBeforeStart := bytes.Compare(keyNibbles, w.start) < 0 &&
+		!bytes.HasPrefix(w.start, keyNibbles)
+	return !subtreeBeforeStart
+}
+
+// keyMatches returns true if the key nibbles given match the options.
+func (w *entriesWalker) keyMatches(keyNibbles []byte) bool {
+	return bytes.HasPrefix(keyNibbles, w.prefix) &&
+		bytes.Compare(keyNibbles, w.start) >= 0
+}
+
+func (w *entriesWalker) emit(keyNibbles, value []byte) (stop bool, err error) {
+	if w.options.MaxEntries > 0 && w.entries >= w.options.MaxEntries {
+		return true, nil
+	}
+
+	keyLE := sub.NibblesToKeyLE(keyNibbles)
+	size := len(keyLE) + len(value)
+	if w.options.MaxBytes > 0 && w.bytes+size > w.options.MaxBytes {
+		return true, fmt.Errorf("%w: entry for key 0x%x of %d bytes "+
+			"would bring total to %d bytes, exceeding the maximum of %d bytes",
+			ErrEntriesMaxBytesExceeded, keyLE, size, w.bytes+size, w.options.MaxBytes)
+	}
+
+	w.entries++
+	w.bytes += size
+	return !w.callback(keyLE, value), nil
+}
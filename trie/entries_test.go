@@ -0,0 +1,156 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_EntriesWithOptions(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"a":     []byte("1"),
+		"ab":    []byte("2"),
+		"abc":   []byte("3"),
+		"abd":   []byte("4"),
+		"b":     []byte("5"),
+		"bcdef": []byte("6"),
+		"c":     []byte("7"),
+	}
+	trie := NewEmptyTrie()
+	for key, value := range keyValues {
+		trie.Put([]byte(key), value)
+	}
+
+	testCases := map[string]struct {
+		options    EntriesOptions
+		entries    map[string][]byte
+		errWrapped error
+		errMessage string
+	}{
+		"no_option": {
+			entries: keyValues,
+		},
+		"prefix": {
+			options: EntriesOptions{Prefix: []byte("ab")},
+			entries: map[string][]byte{
+				"ab":  []byte("2"),
+				"abc": []byte("3"),
+				"abd": []byte("4"),
+			},
+		},
+		"prefix_not_found": {
+			options: EntriesOptions{Prefix: []byte("z")},
+			entries: map[string][]byte{},
+		},
+		"start_key": {
+			options: EntriesOptions{StartKey: []byte("abd")},
+			entries: map[string][]byte{
+				"abd":   []byte("4"),
+				"b":     []byte("5"),
+				"bcdef": []byte("6"),
+				"c":     []byte("7"),
+			},
+		},
+		"start_key_not_in_trie": {
+			options: EntriesOptions{StartKey: []byte("bb")},
+			entries: map[string][]byte{
+				"bcdef": []byte("6"),
+				"c":     []byte("7"),
+			},
+		},
+		"prefix_and_start_key": {
+			options: EntriesOptions{
+				Prefix:   []byte("ab"),
+				StartKey: []byte("abc"),
+			},
+			entries: map[string][]byte{
+				"abc": []byte("3"),
+				"abd": []byte("4"),
+			},
+		},
+		"max_entries": {
+			options: EntriesOptions{MaxEntries: 3},
+			entries: map[string][]byte{
+				"a":   []byte("1"),
+				"ab":  []byte("2"),
+				"abc": []byte("3"),
+			},
+		},
+		"max_bytes_not_exceeded": {
+			options: EntriesOptions{
+				Prefix:   []byte("ab"),
+				MaxBytes: 11,
+			},
+			entries: map[string][]byte{
+				"ab":  []byte("2"),
+				"abc": []byte("3"),
+				"abd": []byte("4"),
+			},
+		},
+		"max_bytes_exceeded": {
+			options:    EntriesOptions{MaxBytes: 10},
+			errWrapped: ErrEntriesMaxBytesExceeded,
+			errMessage: "entries maximum bytes exceeded: " +
+				"entry for key 0x616264 of 4 bytes would bring total " +
+				"to 13 bytes, exceeding the maximum of 10 bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := trie.EntriesWithOptions(testCase.options)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.entries, entries)
+		})
+	}
+}
+
+func Test_Trie_WalkEntries(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	keys := []string{"a", "ab", "b", "ba", "c"}
+	for _, key := range keys {
+		trie.Put([]byte(key), []byte(key))
+	}
+
+	var walked []string
+	err := trie.WalkEntries(EntriesOptions{}, func(key, value []byte) (keepWalking bool) {
+		walked = append(walked, string(key))
+		return string(key) != "ba"
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "ab", "b", "ba"}, walked)
+}
+
+func Test_Trie_ForEach(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+
+	walked := make(map[string][]byte, len(keyValues))
+	trie.ForEach(func(key, value []byte) (keepWalking bool) {
+		walked[string(key)] = value
+		return true
+	})
+	assert.Equal(t, keyValues, walked)
+
+	count := 0
+	trie.ForEach(func(key, value []byte) (keepWalking bool) {
+		count++
+		return count < 3
+	})
+	assert.Equal(t, 3, count)
+}
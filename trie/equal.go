@@ -0,0 +1,52 @@
+package trie
+
+import "bytes"
+
+// Equal returns true if the trie has the same content as the other trie
+// given, that is the same key value pairs and the same child tries.
+// Dirty flags, generations and cached Merkle values are ignored, except
+// that subtrees with the same node or equal cached Merkle values are
+// considered equal without being compared further.
+func (t *Trie) Equal(other *Trie) (equal bool) {
+	if t == nil || other == nil {
+		return t == other
+	}
+
+	if !nodesEqual(t.root, other.root) {
+		return false
+	}
+
+	if len(t.childTries) != len(other.childTries) {
+		return false
+	}
+	for keyToChild, childTrie := range t.childTries {
+		otherChildTrie, ok := other.childTries[keyToChild]
+		if !ok || !childTrie.Equal(otherChildTrie) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodesEqual(a, b *Node) (equal bool) {
+	switch {
+	case a == b:
+		return true
+	case a == nil || b == nil:
+		return false
+	case a.NodeValue != nil && bytes.Equal(a.NodeValue, b.NodeValue):
+		return true
+	case !bytes.Equal(a.PartialKey, b.PartialKey),
+		(a.StorageValue == nil) != (b.StorageValue == nil),
+		!bytes.Equal(a.StorageValue, b.StorageValue),
+		a.Kind() != b.Kind():
+		return false
+	}
+
+	for i, child := range a.Children {
+		if !nodesEqual(child, b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_Equal(t *testing.T) {
+	t.Parallel()
+
+	newTrie := func(keyValues ...string) *Trie {
+		trie := NewEmptyTrie()
+		for i := 0; i < len(keyValues); i += 2 {
+			trie.Put([]byte(keyValues[i]), []byte(keyValues[i+1]))
+		}
+		return trie
+	}
+
+	hashed := newTrie("a", "1", "ab", "2", "b", "3")
+	hashed.MustHash()
+	snapshot := hashed.Snapshot()
+	snapshot.Put([]byte("c"), []byte("4"))
+	snapshot.Delete([]byte("c"))
+
+	withChild := newTrie("a", "1")
+	err := withChild.PutIntoChild([]byte("child"), []byte{1}, []byte{2})
+	require.NoError(t, err)
+	withOtherChildTries := withChild.DeepCopy()
+	for rootHash := range withOtherChildTries.childTries {
+		delete(withOtherChildTries.childTries, rootHash)
+	}
+
+	testCases := map[string]struct {
+		trie  *Trie
+		other *Trie
+		equal bool
+	}{
+		"nil tries": {
+			equal: true,
+		},
+		"nil and empty tries": {
+			other: NewEmptyTrie(),
+		},
+		"empty tries": {
+			trie:  NewEmptyTrie(),
+			other: NewEmptyTrie(),
+			equal: true,
+		},
+		"different insertion order and dirty flags": {
+			trie:  hashed,
+			other: newTrie("b", "3", "ab", "2", "a", "1"),
+			equal: true,
+		},
+		"modified snapshot": {
+			trie:  hashed,
+			other: snapshot,
+			equal: true,
+		},
+		"different value": {
+			trie:  hashed,
+			other: newTrie("a", "1", "ab", "9", "b", "3"),
+		},
+		"missing key": {
+			trie:  hashed,
+			other: newTrie("a", "1", "b", "3"),
+		},
+		"empty and no branch value": {
+			trie:  newTrie("a", "", "ab", "1", "ac", "2"),
+			other: newTrie("ab", "1", "ac", "2"),
+		},
+		"same child tries": {
+			trie:  withChild,
+			other: withChild.DeepCopy(),
+			equal: true,
+		},
+		"different child tries": {
+			trie:  withChild,
+			other: withOtherChildTries,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testCase.equal, testCase.trie.Equal(testCase.other))
+			assert.Equal(t, testCase.equal, testCase.other.Equal(testCase.trie))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// GC deletes from the store given the trie nodes and value preimages
+// which are not reachable from the root hashes given, nor from the
+// trie itself and its child tries, such as the nodes only reachable
+// from the roots of old blocks no longer needed. The nodes of the
+// child tries of the retained roots are retained as well. It returns
+// the number of records deleted. Records are trie nodes if their key
+// is 32 bytes long, or if their key is equal to their value for nodes
+// with an encoding shorter than 32 bytes, and other records are left
+// untouched. It returns an error if a node reachable from a retained
+// root is not in the store, in which case nothing is deleted.
+// The deleted Merkle values tracked by the trie are forgotten.
+func (t *Trie) GC(db GCStore, retainRoots [][]byte) (deleted int, err error) {
+	marker := &gcMarker{
+		db:        db,
+		reachable: make(map[string]struct{}),
+	}
+
+	for _, rootHash := range retainRoots {
+		if bytes.Equal(rootHash, EmptyHash[:]) {
+			continue
+		}
+
+		err = marker.markStored(rootHash, nil)
+		if err != nil {
+			return 0, fmt.Errorf("marking nodes of root hash 0x%x: %w", rootHash, err)
+		}
+	}
+
+	err = t.markInMemory(marker)
+	if err != nil {
+		return 0, fmt.Errorf("marking nodes of trie: %w", err)
+	}
+
+	var unreachable [][]byte
+	err = db.ForEach(func(key, value []byte) (err error) {
+		const hashSize = 32
+		isNodeRecord := len(key) == hashSize ||
+			(len(key) < hashSize && bytes.Equal(key, value))
+		if !isNodeRecord {
+			return nil
+		}
+
+		_, reachable := marker.reachable[string(key)]
+		if !reachable {
+			unreachable = append(unreachable, copyBytes(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("iterating over store: %w", err)
+	}
+
+	batch := &storeBatch{store: db}
+	for _, key := range unreachable {
+		err = batch.Del(key)
+		if err != nil {
+			return 0, fmt.Errorf("deleting key 0x%x: %w", key, err)
+		}
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return 0, fmt.Errorf("flushing batch: %w", err)
+	}
+
+	t.deletedMerkleValues = make(map[string]struct{})
+	return len(unreachable), nil
+}
+
+// markInMemory marks the nodes of the trie and of its child tries,
+// loading the nodes not loaded from the store of the marker.
+func (t *Trie) markInMemory(marker *gcMarker) (err error) {
+	if t.root != nil {
+		rootMerkleValue, err := t.root.CalculateRootMerkleValue()
+		if err != nil {
+			return fmt.Errorf("calculating root Merkle value: %w", err)
+		}
+		marker.reachable[string(rootMerkleValue)] = struct{}{}
+
+		// Child trie roots are not followed since the child
+		// tries may not be written yet, and are marked below.
+		const followChildTries = false
+		err = marker.markNode(t.root, nil, followChildTries)
+		if err != nil {
+			return err
+		}
+	}
+
+	for keyToChild, childTrie := range t.childTries {
+		err = childTrie.markInMemory(marker)
+		if err != nil {
+			return fmt.Errorf("marking nodes of child trie at key 0x%x: %w",
+				keyToChild, err)
+		}
+	}
+	return nil
+}
+
+// gcMarker marks the Merkle values and value hashes reachable.
+type gcMarker struct {
+	db        NodeGetter
+	reachable map[string]struct{}
+}
+
+// childStorageKeyPrefixNibbles is ChildStorageKeyPrefix in nibbles.
+var childStorageKeyPrefixNibbles = sub.KeyLEToNibbles(ChildStorageKeyPrefix)
+
+// markStored marks the node with the Merkle value given and its
+// descendants, loading them from the store. The full key is the
+// key in nibbles of the parent of the node, plus its child index.
+// Note a node already marked is not walked again, since nodes
+// are content addressed and shared by the tries of many roots.
+func (m *gcMarker) markStored(merkleValue, fullKey []byte) (err error) {
+	_, marked := m.reachable[string(merkleValue)]
+	if marked {
+		return nil
+	}
+
+	encoding, err := m.db.Get(merkleValue)
+	if err != nil {
+		return fmt.Errorf("getting node with Merkle value 0x%x: %w", merkleValue, err)
+	}
+
+	node, err := sub.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return fmt.Errorf("decoding node with Merkle value 0x%x: %w", merkleValue, err)
+	}
+	m.reachable[string(merkleValue)] = struct{}{}
+
+	const followChildTries = true
+	return m.markNode(node, fullKey, followChildTries)
+}
+
+// markNode marks the value hash and the descendants of the node given,
+// which is already marked, and the nodes of the child trie it is the
+// root of if followChildTries is true.
+func (m *gcMarker) markNode(node *Node, fullKey []byte, followChildTries bool) (err error) {
+	fullKey = concatenateSlices(fullKey, node.PartialKey)
+
+	valueHash, err := nodeValueHash(node)
+	if err != nil {
+		return fmt.Errorf("hashing value at key 0x%x: %w", sub.NibblesToKeyLE(fullKey), err)
+	}
+	if valueHash != nil {
+		m.reachable[string(valueHash)] = struct{}{}
+	}
+
+	if followChildTries && isChildTrieRoot(node, fullKey) {
+		err = m.markStored(node.StorageValue, nil)
+		if err != nil {
+			return fmt.Errorf("marking nodes of child trie at key 0x%x: %w",
+				sub.NibblesToKeyLE(fullKey), err)
+		}
+	}
+
+	if node.Kind() != sub.Branch {
+		return nil
+	}
+
+	for i, child := range node.Children {
+		if child == nil {
+			continue
+		}
+
+		childKey := concatenateSlices(fullKey, []byte{byte(i)})
+		if isUnloaded(child) {
+			err = m.markStored(child.NodeValue, childKey)
+		} else {
+			err = m.markChild(child, childKey, followChildTries)
+		}
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return err
+		}
+	}
+	return nil
+}
+
+// markChild marks the loaded or inlined child node given and its descendants.
+func (m *gcMarker) markChild(child *Node, childKey []byte, followChildTries bool) (err error) {
+	merkleValue, err := child.CalculateMerkleValue()
+	if err != nil {
+		return fmt.Errorf("calculating Merkle value: %w", err)
+	}
+	m.reachable[string(merkleValue)] = struct{}{}
+	return m.markNode(child, childKey, followChildTries)
+}
+
+// nodeValueHash returns the hash of the storage value of the node
+// given if it is stored separately from the node, and nil otherwise.
+func nodeValueHash(node *Node) (valueHash []byte, err error) {
+	switch {
+	case node.IsHashedValue:
+		return node.StorageValue, nil
+	case node.MustBeHashed:
+		buffer := sub.DigestBuffers.Get()
+		defer sub.DigestBuffers.Put(buffer)
+		err = sub.HashValue(node.StorageValue, node.ValueHasher, buffer)
+		if err != nil {
+			return nil, err
+		}
+		return copyBytes(buffer.Bytes()), nil
+	default:
+		return nil, nil
+	}
+}
+
+// isChildTrieRoot returns true if the node given at the full key
+// in nibbles given has a child trie root hash as storage value.
+func isChildTrieRoot(node *Node, fullKey []byte) bool {
+	return len(fullKey) > len(childStorageKeyPrefixNibbles) &&
+		bytes.HasPrefix(fullKey, childStorageKeyPrefixNibbles) &&
+		!node.IsHashedValue &&
+		len(node.StorageValue) == len(util.Hash{}) &&
+		!bytes.Equal(node.StorageValue, EmptyHash[:])
+}
@@ -0,0 +1,72 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_GC(t *testing.T) {
+	t.Parallel()
+
+	store := newMapStore()
+	const otherKey = "not a node record"
+	store.keyValues[otherKey] = []byte{1}
+
+	trie, _ := makeSeededTrie(t, 100)
+	trie.SetVersion(V1)
+	largeValue := make([]byte, 40)
+	largeValue[0] = 1
+	trie.Put([]byte("large"), largeValue)
+	childTrie, _ := makeSeededTrie(t, 10)
+	err := trie.SetChild([]byte("child"), childTrie)
+	require.NoError(t, err)
+	err = trie.WriteDirty(store)
+	require.NoError(t, err)
+	oldRoot := trie.MustHash()
+
+	trie.Put([]byte("large"), []byte{2})
+	trie.Delete([]byte("child"))
+	trie.Put([]byte("new key"), []byte{3})
+	err = trie.PutIntoChild([]byte("child"), []byte("child key"), []byte{4})
+	require.NoError(t, err)
+	err = trie.WriteDirty(store)
+	require.NoError(t, err)
+	newRoot := trie.MustHash()
+
+	// Both roots retained
+	deleted, err := NewEmptyTrie().GC(store, [][]byte{oldRoot[:], newRoot[:]})
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	// Missing retained root
+	missingRoot := make([]byte, 32)
+	deleted, err = NewEmptyTrie().GC(store, [][]byte{newRoot[:], missingRoot})
+	assert.ErrorIs(t, err, errMapStoreKeyNotFound)
+	assert.EqualError(t, err, "marking nodes of root hash "+
+		"0x0000000000000000000000000000000000000000000000000000000000000000: "+
+		"getting node with Merkle value "+
+		"0x0000000000000000000000000000000000000000000000000000000000000000: "+
+		"key not found")
+	assert.Zero(t, deleted)
+
+	// Only the new root retained, through a trie not loaded
+	deleted, err = NewEmptyTrie().GC(store, [][]byte{newRoot[:]})
+	require.NoError(t, err)
+	assert.Positive(t, deleted)
+
+	assert.Contains(t, store.keyValues, otherKey)
+	err = NewEmptyTrie().Load(store, oldRoot)
+	assert.ErrorIs(t, err, errMapStoreKeyNotFound)
+
+	trieFromStore := NewEmptyTrie()
+	err = trieFromStore.Load(store, newRoot)
+	require.NoError(t, err)
+	assert.True(t, trie.Equal(trieFromStore))
+
+	// Nothing left to collect with the trie itself retained
+	deleted, err = trie.GC(store, nil)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
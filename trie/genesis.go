@@ -1,7 +1,12 @@
 package trie
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 
 	// "github.com/ChainSafe/gossamer/dot/types"
 	sub "github.com/octopus-network/trie-go/substrate"
@@ -22,3 +27,137 @@ func (t *Trie) GenesisBlock() (genesisHeader sub.Header, err error) {
 	genesisHeader = *sub.NewHeader(parentHash, rootHash, extrinsicRoot, blockNumber, digest)
 	return genesisHeader, nil
 }
+
+// ErrChainSpecNotRaw is returned when a chain spec has no raw genesis storage.
+var ErrChainSpecNotRaw = errors.New("chain spec has no raw genesis")
+
+// chainSpec contains the raw genesis storage fields of a Substrate chain spec.
+type chainSpec struct {
+	Genesis struct {
+		Raw *struct {
+			Top map[string]string `json:"top"`
+			// ChildrenDefault maps the child storage keys, without the
+			// :child_storage:default: prefix, to the child trie storage.
+			ChildrenDefault map[string]map[string]string `json:"childrenDefault"`
+		} `json:"raw"`
+	} `json:"genesis"`
+}
+
+// NewFromChainSpec builds the genesis state trie from the raw genesis
+// storage `genesis.raw.top` and `genesis.raw.childrenDefault` of the
+// Substrate chain spec JSON read from the reader given, using the state
+// trie version given. The child tries are built as well, and their root
+// hashes are inserted in the main trie. It returns the trie together with
+// its root hash, which is the genesis state root.
+func NewFromChainSpec(reader io.Reader, version Version) (trie *Trie, stateRoot util.Hash, err error) {
+	var spec chainSpec
+	err = json.NewDecoder(reader).Decode(&spec)
+	if err != nil {
+		return nil, stateRoot, fmt.Errorf("decoding chain spec: %w", err)
+	}
+
+	raw := spec.Genesis.Raw
+	if raw == nil {
+		return nil, stateRoot, ErrChainSpecNotRaw
+	}
+
+	iterator, err := newHexMapIterator(raw.Top)
+	if err != nil {
+		return nil, stateRoot, fmt.Errorf("decoding top storage: %w", err)
+	}
+
+	trie, err = buildFromSorted(iterator, version)
+	if err != nil {
+		return nil, stateRoot, fmt.Errorf("building top trie: %w", err)
+	}
+
+	for keyToChildHex, childStorage := range raw.ChildrenDefault {
+		keyToChild, err := util.HexToBytes(keyToChildHex)
+		if err != nil {
+			return nil, stateRoot, fmt.Errorf("decoding child storage key: %w", err)
+		}
+
+		iterator, err := newHexMapIterator(childStorage)
+		if err != nil {
+			return nil, stateRoot, fmt.Errorf("decoding child storage 0x%x: %w", keyToChild, err)
+		}
+
+		child, err := buildFromSorted(iterator, version)
+		if err != nil {
+			return nil, stateRoot, fmt.Errorf("building child trie 0x%x: %w", keyToChild, err)
+		}
+
+		if child.root == nil {
+			// Empty child tries have no root entry in the main trie.
+			continue
+		}
+
+		err = trie.SetChild(keyToChild, child)
+		if err != nil {
+			return nil, stateRoot, fmt.Errorf("setting child trie 0x%x: %w", keyToChild, err)
+		}
+	}
+
+	stateRoot, err = trie.Hash()
+	if err != nil {
+		return nil, stateRoot, fmt.Errorf("hashing trie: %w", err)
+	}
+
+	return trie, stateRoot, nil
+}
+
+// hexIterator is a sorted iterator over key value
+// pairs decoded from hexadecimal keys and values.
+type hexIterator struct {
+	keys   [][]byte
+	values [][]byte
+}
+
+func newHexMapIterator(data map[string]string) (iterator *hexIterator, err error) {
+	iterator = &hexIterator{
+		keys:   make([][]byte, 0, len(data)),
+		values: make([][]byte, 0, len(data)),
+	}
+
+	for keyHex, valueHex := range data {
+		err = iterator.add(keyHex, valueHex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Sort(iterator)
+	return iterator, nil
+}
+
+func (h *hexIterator) add(keyHex, valueHex string) (err error) {
+	key, err := util.HexToBytes(keyHex)
+	if err != nil {
+		return fmt.Errorf("cannot convert key hex to bytes: %w", err)
+	}
+
+	value, err := util.HexToBytes(valueHex)
+	if err != nil {
+		return fmt.Errorf("cannot convert value hex to bytes: %w", err)
+	}
+
+	h.keys = append(h.keys, key)
+	h.values = append(h.values, value)
+	return nil
+}
+
+func (h *hexIterator) Len() int           { return len(h.keys) }
+func (h *hexIterator) Less(i, j int) bool { return bytes.Compare(h.keys[i], h.keys[j]) < 0 }
+func (h *hexIterator) Swap(i, j int) {
+	h.keys[i], h.keys[j] = h.keys[j], h.keys[i]
+	h.values[i], h.values[j] = h.values[j], h.values[i]
+}
+
+func (h *hexIterator) Next() (keyLE, value []byte, err error) {
+	if len(h.keys) == 0 {
+		return nil, nil, io.EOF
+	}
+	keyLE, value = h.keys[0], h.values[0]
+	h.keys, h.values = h.keys[1:], h.values[1:]
+	return keyLE, value, nil
+}
@@ -1,12 +1,17 @@
 package trie
 
 import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
 	"testing"
 
 	// "github.com/ChainSafe/gossamer/dot/types"
 	sub "github.com/octopus-network/trie-go/substrate"
 	"github.com/octopus-network/trie-go/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Trie_GenesisBlock(t *testing.T) {
@@ -68,3 +73,78 @@ func Test_Trie_GenesisBlock(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewFromChainSpec(t *testing.T) {
+	t.Parallel()
+
+	largeValue := bytes.Repeat([]byte{9}, 40)
+	expected := NewEmptyTrie()
+	expected.SetVersion(V1)
+	expected.Put([]byte{1, 2}, []byte{3})
+	expected.Put([]byte{1}, largeValue)
+	err := expected.PutIntoChild([]byte("child"), []byte{4}, []byte{5})
+	require.NoError(t, err)
+	expectedRoot := expected.MustHash()
+
+	validSpec := `{"name": "test", "genesis": {"raw": {
+		"top": {"0x0102": "0x03", "0x01": "0x` + strings.Repeat("09", len(largeValue)) + `"},
+		"childrenDefault": {"0x6368696c64": {"0x04": "0x05"}, "0x656d707479": {}}
+	}}}`
+
+	testCases := map[string]struct {
+		spec       string
+		stateRoot  util.Hash
+		errWrapped error
+		errMessage string
+	}{
+		"valid chain spec": {
+			spec:      validSpec,
+			stateRoot: expectedRoot,
+		},
+		"invalid JSON": {
+			spec:       `{`,
+			errWrapped: io.ErrUnexpectedEOF,
+			errMessage: "decoding chain spec: unexpected EOF",
+		},
+		"chain spec not raw": {
+			spec:       `{"genesis": {"runtime": {}}}`,
+			errWrapped: ErrChainSpecNotRaw,
+			errMessage: "chain spec has no raw genesis",
+		},
+		"bad top key": {
+			spec:       `{"genesis": {"raw": {"top": {"01": "0x01"}}}}`,
+			errWrapped: util.ErrNoPrefix,
+			errMessage: "decoding top storage: cannot convert key hex to bytes: " +
+				"could not byteify non 0x prefixed string: 01",
+		},
+		"bad child storage key": {
+			spec:       `{"genesis": {"raw": {"top": {}, "childrenDefault": {"0xzz": {}}}}}`,
+			errWrapped: hex.InvalidByteError('z'),
+			errMessage: "decoding child storage key: encoding/hex: invalid byte: U+007A 'z': 0xzz",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trie, stateRoot, err := NewFromChainSpec(strings.NewReader(testCase.spec), V1)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, trie)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.stateRoot, stateRoot)
+			assert.Equal(t, expected.Entries(), trie.Entries())
+			child, err := trie.GetChild([]byte("child"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte{5}, child.Get([]byte{4}))
+			_, err = trie.GetChild([]byte("empty"))
+			assert.ErrorIs(t, err, ErrChildTrieDoesNotExist)
+		})
+	}
+}
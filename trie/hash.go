@@ -0,0 +1,88 @@
+package trie
+
+import (
+	"fmt"
+	"sync"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// HashOptions contains the options to compute the root hash of a trie.
+type HashOptions struct {
+	// Workers is the maximum number of goroutines computing the Merkle
+	// values of independent subtrees in parallel, including the calling
+	// goroutine. A value of 0 or 1 hashes the trie like Hash.
+	Workers int
+}
+
+// HashWithOptions returns the hashed root of the trie using the options
+// given. With more than one worker, the Merkle values of the root children
+// subtrees, and recursively of their children subtrees, are computed in
+// parallel as long as a worker is available, and cached in the nodes
+// before the root node is hashed. This speeds up hashing large tries with
+// many modified nodes, such as a freshly built genesis trie.
+// Note HashWithOptions must not be called concurrently with modifications
+// of the trie, nor with the hashing of a snapshot of the trie.
+func (t *Trie) HashWithOptions(options HashOptions) (rootHash util.Hash, err error) {
+	if options.Workers > 1 && t.root != nil {
+		// The calling goroutine is one of the workers.
+		workers := make(chan struct{}, options.Workers-1)
+		err = hashChildrenParallel(t.root, workers)
+		if err != nil {
+			return rootHash, fmt.Errorf("hashing root children: %w", err)
+		}
+	}
+
+	return t.Hash()
+}
+
+// hashChildrenParallel computes and caches the Merkle values of the
+// branch children of the node given which have no cached Merkle value.
+// Each child subtree is hashed in its own goroutine if a worker slot is
+// available in the workers channel, and in the calling goroutine otherwise.
+func hashChildrenParallel(node *Node, workers chan struct{}) (err error) {
+	if node.Kind() != sub.Branch {
+		return nil
+	}
+
+	var waitGroup sync.WaitGroup
+	errs := make([]error, len(node.Children))
+	for i, child := range node.Children {
+		if child == nil || child.Kind() == sub.Leaf || child.NodeValue != nil {
+			// Leaves are hashed when encoding their parent, and
+			// cached Merkle values do not need to be computed.
+			continue
+		}
+
+		select {
+		case workers <- struct{}{}:
+			waitGroup.Add(1)
+			go func(i int, child *Node) {
+				defer waitGroup.Done()
+				errs[i] = hashSubtreeParallel(child, workers)
+				<-workers
+			}(i, child)
+		default:
+			errs[i] = hashSubtreeParallel(child, workers)
+		}
+	}
+	waitGroup.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("child at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func hashSubtreeParallel(node *Node, workers chan struct{}) (err error) {
+	err = hashChildrenParallel(node, workers)
+	if err != nil {
+		return err
+	}
+
+	_, err = node.CalculateMerkleValue()
+	return err
+}
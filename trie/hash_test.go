@@ -0,0 +1,48 @@
+package trie
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_HashWithOptions(t *testing.T) {
+	t.Parallel()
+
+	const size = 2000
+	reference, keyValues := makeSeededTrie(t, size)
+	expectedHash := reference.MustHash()
+
+	for _, workers := range []int{0, 1, 2, 4, 64} {
+		trie := NewEmptyTrie()
+		for key, value := range keyValues {
+			trie.Put([]byte(key), value)
+		}
+
+		rootHash, err := trie.HashWithOptions(HashOptions{Workers: workers})
+		require.NoError(t, err)
+		assert.Equal(t, expectedHash, rootHash, "workers %d", workers)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		trie := NewEmptyTrie()
+		trie.SetVersion(V1)
+		trie.Put([]byte{0x10}, []byte{1})
+		trie.Put([]byte{0x11}, []byte{2})
+		trie.Put([]byte{0x20}, make([]byte, 40))
+		trie.Put([]byte{0x21}, []byte{3})
+		trie.root.Children[2].Children[0].ValueHasher = sha512.New
+
+		_, err := trie.HashWithOptions(HashOptions{Workers: 4})
+		assert.ErrorIs(t, err, sub.ErrValueHasherDigestSize)
+		assert.EqualError(t, err, "hashing root children: child at index 2: "+
+			"encoding and hashing node: encoding node: cannot encode children of branch: "+
+			"computing leaf Merkle value: encoding and hashing node: encoding node: "+
+			"hashing storage value: value hasher digest size is not 32 bytes: 64 bytes")
+	})
+}
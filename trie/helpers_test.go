@@ -115,3 +115,39 @@ func padRightChildren(slice []*Node) (paddedSlice []*Node) {
 	copy(paddedSlice, slice)
 	return paddedSlice
 }
+
+// fullCopy copies the trie given together with all its nodes, so
+// the copy is left unchanged by the in place modifications made by
+// the unexported methods of the trie, unlike a DeepCopy copy.
+func fullCopy(trie *Trie) (trieCopy *Trie) {
+	if trie == nil {
+		return nil
+	}
+
+	trieCopy = &Trie{
+		generation: trie.generation,
+		version:    trie.version,
+		metrics:    trie.metrics,
+	}
+
+	if trie.deletedMerkleValues != nil {
+		trieCopy.deletedMerkleValues = make(map[string]struct{}, len(trie.deletedMerkleValues))
+		for k := range trie.deletedMerkleValues {
+			trieCopy.deletedMerkleValues[k] = struct{}{}
+		}
+	}
+
+	if trie.childTries != nil {
+		trieCopy.childTries = make(map[string]*Trie, len(trie.childTries))
+		for keyToChild, childTrie := range trie.childTries {
+			trieCopy.childTries[keyToChild] = fullCopy(childTrie)
+		}
+	}
+
+	if trie.root != nil {
+		copySettings := sub.DeepCopySettings
+		trieCopy.root = trie.root.Copy(copySettings)
+	}
+
+	return trieCopy
+}
@@ -0,0 +1,196 @@
+package trie
+
+import (
+	"bytes"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Iterator iterates over the key-value pairs of a trie in ascending,
+// or descending for a reverse iterator, Little Endian key order, without
+// knowing the keys in advance.
+// It is positioned before the first pair it returns, so Next must be
+// called before Key and Value. The trie must not be modified while the
+// iterator is in use, and an iterator is not safe for concurrent use.
+type Iterator struct {
+	root    *Node
+	reverse bool
+	// bound is the key in nibbles from which to iterate, inclusive.
+	// It is the lower bound for a forward iterator, and the upper
+	// bound for a reverse iterator if bounded is true.
+	bound   []byte
+	bounded bool
+	// stack contains the nodes left to visit, the last one
+	// being the next one visited.
+	stack []iteratorFrame
+	key   []byte
+	value []byte
+}
+
+type iteratorFrame struct {
+	node *Node
+	// fullKey is the full key of the node in nibbles.
+	fullKey []byte
+	// childrenPushed is true for a reverse iterator frame whose
+	// children were pushed, so only its value is left to visit.
+	childrenPushed bool
+}
+
+// NewIterator returns an iterator over the key-value pairs of the trie,
+// positioned before its first key.
+func (t *Trie) NewIterator() (iterator *Iterator) {
+	iterator = &Iterator{root: t.root}
+	iterator.Seek(nil)
+	return iterator
+}
+
+// NewReverseIterator returns an iterator over the key-value pairs of
+// the trie in descending key order, positioned after its last key.
+func (t *Trie) NewReverseIterator() (iterator *Iterator) {
+	iterator = &Iterator{root: t.root, reverse: true}
+	iterator.reset()
+	return iterator
+}
+
+// Seek positions the iterator before the first key greater or equal
+// to the (Little Endian) key given, so the next call to Next moves to
+// this key. For a reverse iterator, it positions the iterator after
+// the last key lower or equal to the key given instead. Subtrees
+// located before the key in the iteration order are not traversed.
+func (it *Iterator) Seek(keyLE []byte) {
+	it.reset()
+	it.bound = sub.KeyLEToNibbles(keyLE)
+	it.bounded = true
+}
+
+func (it *Iterator) reset() {
+	it.bound = nil
+	it.bounded = false
+	it.stack = it.stack[:0]
+	it.key = nil
+	it.value = nil
+	if it.root != nil {
+		it.stack = append(it.stack, iteratorFrame{
+			node:    it.root,
+			fullKey: it.root.PartialKey,
+		})
+	}
+}
+
+// Next moves the iterator to the next key-value pair, and returns
+// false if there is no next pair, in which case Key and Value return nil.
+func (it *Iterator) Next() (ok bool) {
+	if it.reverse {
+		return it.previous()
+	}
+
+	for len(it.stack) > 0 {
+		frame := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+
+		subtreeBeforeStart := bytes.Compare(frame.fullKey, it.bound) < 0 &&
+			!bytes.HasPrefix(it.bound, frame.fullKey)
+		if subtreeBeforeStart {
+			continue
+		}
+
+		node := frame.node
+		if node.Kind() == sub.Branch {
+			// Children are pushed in descending order, so they
+			// are popped in ascending order of their keys.
+			for i := len(node.Children) - 1; i >= 0; i-- {
+				child := node.Children[i]
+				if child == nil {
+					continue
+				}
+				it.stack = append(it.stack, iteratorFrame{
+					node:    child,
+					fullKey: concatenateSlices(frame.fullKey, intToByteSlice(i), child.PartialKey),
+				})
+			}
+		}
+
+		hasValue := node.Kind() == sub.Leaf || node.StorageValue != nil
+		if hasValue && bytes.Compare(frame.fullKey, it.bound) >= 0 {
+			it.key = sub.NibblesToKeyLE(frame.fullKey)
+			it.value = node.StorageValue
+			return true
+		}
+	}
+
+	it.key = nil
+	it.value = nil
+	return false
+}
+
+// previous moves a reverse iterator to the previous key-value pair.
+// Since the key of a branch is a prefix of the keys of its children,
+// its value is visited after the values of all its children.
+func (it *Iterator) previous() (ok bool) {
+	for len(it.stack) > 0 {
+		frame := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+		node := frame.node
+
+		if !frame.childrenPushed {
+			// All keys of a subtree are greater or equal to its full key.
+			subtreeAfterBound := it.bounded && bytes.Compare(frame.fullKey, it.bound) > 0
+			if subtreeAfterBound {
+				continue
+			}
+
+			if node.Kind() == sub.Branch {
+				frame.childrenPushed = true
+				it.stack = append(it.stack, frame)
+				// Children are pushed in ascending order, so they
+				// are popped in descending order of their keys.
+				for i, child := range node.Children {
+					if child == nil {
+						continue
+					}
+					it.stack = append(it.stack, iteratorFrame{
+						node:    child,
+						fullKey: concatenateSlices(frame.fullKey, intToByteSlice(i), child.PartialKey),
+					})
+				}
+				continue
+			}
+		}
+
+		hasValue := node.Kind() == sub.Leaf || node.StorageValue != nil
+		if hasValue {
+			it.key = sub.NibblesToKeyLE(frame.fullKey)
+			it.value = node.StorageValue
+			return true
+		}
+	}
+
+	it.key = nil
+	it.value = nil
+	return false
+}
+
+// Key returns the Little Endian key of the current key-value pair.
+func (it *Iterator) Key() (keyLE []byte) {
+	return it.key
+}
+
+// Value returns the value of the current key-value pair.
+// It must not be modified.
+func (it *Iterator) Value() (value []byte) {
+	return it.value
+}
+
+// PrevKey returns the previous key in the trie in lexicographic order,
+// strictly before the (Little Endian) key given, which does not need
+// to be in the trie. It returns nil if no previous key is found.
+func (t *Trie) PrevKey(keyLE []byte) (prevKeyLE []byte) {
+	iterator := t.NewReverseIterator()
+	iterator.Seek(keyLE)
+	for iterator.Next() {
+		if !bytes.Equal(iterator.Key(), keyLE) {
+			return iterator.Key()
+		}
+	}
+	return nil
+}
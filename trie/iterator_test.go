@@ -0,0 +1,172 @@
+package trie
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Iterator(t *testing.T) {
+	t.Parallel()
+
+	const size = 500
+	trie, keyValues := makeSeededTrie(t, size)
+
+	sortedKeys := make([]string, 0, len(keyValues))
+	for key := range keyValues {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	iterator := trie.NewIterator()
+	var keys []string
+	for iterator.Next() {
+		key := string(iterator.Key())
+		assert.Equal(t, keyValues[key], iterator.Value())
+		keys = append(keys, key)
+	}
+	assert.Equal(t, sortedKeys, keys)
+	assert.Nil(t, iterator.Key())
+	assert.Nil(t, iterator.Value())
+	assert.False(t, iterator.Next())
+
+	t.Run("seek", func(t *testing.T) {
+		t.Parallel()
+
+		iterator := trie.NewIterator()
+		for _, index := range []int{250, 0, len(sortedKeys) - 1, 10} {
+			iterator.Seek([]byte(sortedKeys[index]))
+			require.True(t, iterator.Next())
+			assert.Equal(t, sortedKeys[index], string(iterator.Key()))
+			require.True(t, iterator.Next() || index == len(sortedKeys)-1)
+			if index < len(sortedKeys)-1 {
+				assert.Equal(t, sortedKeys[index+1], string(iterator.Key()))
+			}
+		}
+
+		// Seeking between two keys moves to the greater key.
+		betweenKey := sortedKeys[100] + "\x00"
+		iterator.Seek([]byte(betweenKey))
+		require.True(t, iterator.Next())
+		assert.Equal(t, sortedKeys[101], string(iterator.Key()))
+
+		// Seeking past the last key leaves no key to iterate.
+		iterator.Seek([]byte(sortedKeys[len(sortedKeys)-1] + "\xff"))
+		assert.False(t, iterator.Next())
+	})
+}
+
+func Test_Iterator_branchValues(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	keys := []string{"a", "ab", "abc", "abd", "b", "ba"}
+	for i, key := range keys {
+		trie.Put([]byte(key), []byte{byte(i)})
+	}
+
+	iterator := trie.NewIterator()
+	iterator.Seek([]byte("abc"))
+	var iterated []string
+	for iterator.Next() {
+		iterated = append(iterated, string(iterator.Key()))
+	}
+	assert.Equal(t, []string{"abc", "abd", "b", "ba"}, iterated)
+}
+
+func Test_Iterator_emptyTrie(t *testing.T) {
+	t.Parallel()
+
+	iterator := NewEmptyTrie().NewIterator()
+	assert.False(t, iterator.Next())
+	iterator.Seek([]byte{1})
+	assert.False(t, iterator.Next())
+}
+
+func Test_ReverseIterator(t *testing.T) {
+	t.Parallel()
+
+	const size = 500
+	trie, keyValues := makeSeededTrie(t, size)
+
+	sortedKeys := make([]string, 0, len(keyValues))
+	for key := range keyValues {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(sortedKeys)))
+
+	iterator := trie.NewReverseIterator()
+	var keys []string
+	for iterator.Next() {
+		key := string(iterator.Key())
+		assert.Equal(t, keyValues[key], iterator.Value())
+		keys = append(keys, key)
+	}
+	assert.Equal(t, sortedKeys, keys)
+	assert.False(t, iterator.Next())
+
+	// Seeking between two keys moves to the lower key.
+	iterator.Seek([]byte(sortedKeys[100] + "\x00"))
+	require.True(t, iterator.Next())
+	assert.Equal(t, sortedKeys[100], string(iterator.Key()))
+	require.True(t, iterator.Next())
+	assert.Equal(t, sortedKeys[101], string(iterator.Key()))
+
+	// Seeking before the first key leaves no key to iterate.
+	iterator.Seek(nil)
+	assert.False(t, iterator.Next())
+}
+
+func Test_ReverseIterator_branchValues(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	keys := []string{"a", "ab", "abc", "abd", "b", "ba"}
+	for i, key := range keys {
+		trie.Put([]byte(key), []byte{byte(i)})
+	}
+
+	iterator := trie.NewReverseIterator()
+	iterator.Seek([]byte("abcd"))
+	var iterated []string
+	for iterator.Next() {
+		iterated = append(iterated, string(iterator.Key()))
+	}
+	assert.Equal(t, []string{"abc", "ab", "a"}, iterated)
+
+	assert.False(t, NewEmptyTrie().NewReverseIterator().Next())
+}
+
+func Test_Trie_PrevKey(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	for _, key := range []string{"a", "ab", "abc", "b", "ba"} {
+		trie.Put([]byte(key), []byte{1})
+	}
+
+	testCases := map[string]struct {
+		key     string
+		prevKey []byte
+	}{
+		"first key":           {key: "a"},
+		"before first key":    {key: ""},
+		"existing key":        {key: "abc", prevKey: []byte("ab")},
+		"absent key":          {key: "abz", prevKey: []byte("abc")},
+		"branch key":          {key: "b", prevKey: []byte("abc")},
+		"after last key":      {key: "c", prevKey: []byte("ba")},
+		"child of branch key": {key: "ab\x00", prevKey: []byte("ab")},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prevKey := trie.PrevKey([]byte(testCase.key))
+			assert.Equal(t, testCase.prevKey, prevKey)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package trie
+
+import "errors"
+
+var ErrNoCheckpoint = errors.New("no checkpoint")
+
+// Journal records the changes made through it to a trie since its
+// checkpoints, so the trie can be rolled back to the state it had at
+// the last checkpoint, for transactional storage semantics such as the
+// storage_start_transaction, storage_commit_transaction and
+// storage_rollback_transaction host functions. Checkpoints can be nested.
+// Changes are only recorded while there is at least one checkpoint, and
+// changes made to the trie without using the journal are not recorded.
+// A journal is not safe for concurrent use.
+type Journal struct {
+	trie        *Trie
+	checkpoints []journalCheckpoint
+	// entries contains the previous values of the keys changed since
+	// the first checkpoint, in the order the changes were made.
+	entries []journalEntry
+}
+
+type journalCheckpoint struct {
+	// entries is the number of journal entries at the checkpoint.
+	entries int
+	// deletedMerkleValues is a copy of the trie deleted Merkle values
+	// at the checkpoint, since rolling back recreates nodes which would
+	// otherwise remain tracked as deleted.
+	deletedMerkleValues map[string]struct{}
+}
+
+type journalEntry struct {
+	keyLE []byte
+	// previousValue is the value at the key before the change,
+	// and is nil if the key was not in the trie.
+	previousValue []byte
+}
+
+// NewJournal returns a journal recording the changes made to the trie.
+func (t *Trie) NewJournal() (journal *Journal) {
+	return &Journal{trie: t}
+}
+
+// Checkpoint starts a new (nested) checkpoint, to which the
+// trie can be rolled back with Rollback.
+func (j *Journal) Checkpoint() {
+	deletedMerkleValues := make(map[string]struct{}, len(j.trie.deletedMerkleValues))
+	for merkleValue := range j.trie.deletedMerkleValues {
+		deletedMerkleValues[merkleValue] = struct{}{}
+	}
+
+	j.checkpoints = append(j.checkpoints, journalCheckpoint{
+		entries:             len(j.entries),
+		deletedMerkleValues: deletedMerkleValues,
+	})
+}
+
+// Commit keeps the changes made since the last checkpoint and removes
+// this checkpoint. The changes can still be rolled back if there is an
+// outer checkpoint. It returns ErrNoCheckpoint if there is no checkpoint.
+func (j *Journal) Commit() (err error) {
+	if len(j.checkpoints) == 0 {
+		return ErrNoCheckpoint
+	}
+
+	j.checkpoints = j.checkpoints[:len(j.checkpoints)-1]
+	if len(j.checkpoints) == 0 {
+		j.entries = nil
+	}
+	return nil
+}
+
+// Rollback reverts the changes made since the last checkpoint, in
+// reverse order, and removes this checkpoint. It returns ErrNoCheckpoint
+// if there is no checkpoint.
+func (j *Journal) Rollback() (err error) {
+	if len(j.checkpoints) == 0 {
+		return ErrNoCheckpoint
+	}
+
+	checkpoint := j.checkpoints[len(j.checkpoints)-1]
+	j.checkpoints = j.checkpoints[:len(j.checkpoints)-1]
+
+	for i := len(j.entries) - 1; i >= checkpoint.entries; i-- {
+		entry := j.entries[i]
+		if entry.previousValue == nil {
+			j.trie.Delete(entry.keyLE)
+			continue
+		}
+		j.trie.Put(entry.keyLE, entry.previousValue)
+	}
+	j.entries = j.entries[:checkpoint.entries]
+	j.trie.deletedMerkleValues = checkpoint.deletedMerkleValues
+	return nil
+}
+
+// Depth returns the number of nested checkpoints.
+func (j *Journal) Depth() (depth int) {
+	return len(j.checkpoints)
+}
+
+// Put puts the value at the (Little Endian) key given in the
+// trie, recording the previous value if there is a checkpoint.
+func (j *Journal) Put(keyLE, value []byte) {
+	j.record(keyLE)
+	j.trie.Put(keyLE, value)
+}
+
+// Delete deletes the (Little Endian) key given from the trie,
+// recording the previous value if there is a checkpoint.
+func (j *Journal) Delete(keyLE []byte) {
+	j.record(keyLE)
+	j.trie.Delete(keyLE)
+}
+
+func (j *Journal) record(keyLE []byte) {
+	if len(j.checkpoints) == 0 {
+		return
+	}
+
+	j.entries = append(j.entries, journalEntry{
+		keyLE:         copyBytes(keyLE),
+		previousValue: j.trie.Get(keyLE),
+	})
+}
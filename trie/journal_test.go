@@ -0,0 +1,64 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Journal(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	trie, keyValues := makeSeededTrie(t, size)
+	// Bump the trie generation so changes track deleted Merkle values.
+	_ = trie.Snapshot()
+	expectedHash := trie.MustHash()
+	expectedDeleted := make(map[string]struct{}, len(trie.deletedMerkleValues))
+	for merkleValue := range trie.deletedMerkleValues {
+		expectedDeleted[merkleValue] = struct{}{}
+	}
+
+	journal := trie.NewJournal()
+	assert.ErrorIs(t, journal.Rollback(), ErrNoCheckpoint)
+	assert.ErrorIs(t, journal.Commit(), ErrNoCheckpoint)
+
+	journal.Checkpoint()
+	var someKey string
+	for someKey = range keyValues {
+		break
+	}
+	journal.Put([]byte(someKey), []byte("modified"))
+	journal.Put([]byte("new key"), []byte("new value"))
+
+	// Nested checkpoint committed, then rolled back with the outer one.
+	journal.Checkpoint()
+	assert.Equal(t, 2, journal.Depth())
+	journal.Delete([]byte(someKey))
+	journal.Put([]byte("new key"), []byte("newer value"))
+	err := journal.Commit()
+	require.NoError(t, err)
+	assert.Nil(t, trie.Get([]byte(someKey)))
+	assert.NotEqual(t, expectedDeleted, trie.deletedMerkleValues)
+
+	// Nested checkpoint rolled back.
+	journal.Checkpoint()
+	journal.Put([]byte("other key"), []byte{1})
+	journal.Delete([]byte("new key"))
+	err = journal.Rollback()
+	require.NoError(t, err)
+	assert.Nil(t, trie.Get([]byte("other key")))
+	assert.Equal(t, []byte("newer value"), trie.Get([]byte("new key")))
+
+	err = journal.Rollback()
+	require.NoError(t, err)
+	assert.Zero(t, journal.Depth())
+	assert.Equal(t, keyValues, trie.Entries())
+	assert.Equal(t, expectedHash, trie.MustHash())
+	assert.Equal(t, expectedDeleted, trie.deletedMerkleValues)
+
+	// Changes without checkpoint are not recorded.
+	journal.Put([]byte("new key"), []byte("new value"))
+	assert.Empty(t, journal.entries)
+}
@@ -0,0 +1,70 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+)
+
+var ErrPageLimitZero = errors.New("page limit is zero")
+
+// GetKeysWithPrefixPaged returns at most limit keys in Little Endian
+// format having the (Little Endian) prefix given, in ascending order,
+// starting strictly after the key startAfterLE if it is not nil.
+// It also returns a cursor to pass as startAfterLE to get the next
+// page, which is nil if there are no more keys with the prefix.
+// Keys are iterated from the trie without collecting all the keys with
+// the prefix, so large storage maps can be enumerated page by page.
+// Note, unlike GetKeysWithPrefix, a trailing zero nibble of the prefix
+// is not ignored, so only keys starting with all the prefix bytes match.
+func (t *Trie) GetKeysWithPrefixPaged(prefixLE, startAfterLE []byte, limit uint) (
+	keysLE [][]byte, cursor []byte, err error) {
+	if limit == 0 {
+		return nil, nil, ErrPageLimitZero
+	}
+
+	iterator := t.NewIterator()
+	seekKey := prefixLE
+	if bytes.Compare(startAfterLE, seekKey) > 0 {
+		seekKey = startAfterLE
+	}
+	iterator.Seek(seekKey)
+
+	for iterator.Next() {
+		key := iterator.Key()
+		if startAfterLE != nil && bytes.Equal(key, startAfterLE) {
+			continue
+		} else if !bytes.HasPrefix(key, prefixLE) {
+			break
+		}
+
+		if uint(len(keysLE)) == limit {
+			// There is at least one more key with the prefix.
+			return keysLE, keysLE[len(keysLE)-1], nil
+		}
+		keysLE = append(keysLE, key)
+	}
+
+	return keysLE, nil, nil
+}
+
+// GetKeys returns all the keys of the trie in Little Endian
+// format, in ascending lexicographic order.
+func (t *Trie) GetKeys() (keysLE [][]byte) {
+	return t.GetKeysWithOffset(0, 0)
+}
+
+// GetKeysWithOffset returns at most limit keys of the trie in Little
+// Endian format, in ascending lexicographic order, skipping the first
+// offset keys. A zero limit means there is no limit.
+func (t *Trie) GetKeysWithOffset(offset, limit uint) (keysLE [][]byte) {
+	var skipped uint
+	t.ForEach(func(key, _ []byte) (keepWalking bool) {
+		if skipped < offset {
+			skipped++
+			return true
+		}
+		keysLE = append(keysLE, key)
+		return limit == 0 || uint(len(keysLE)) < limit
+	})
+	return keysLE
+}
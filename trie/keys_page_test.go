@@ -0,0 +1,156 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_GetKeysWithPrefixPaged(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	for _, key := range []string{"a", "map", "map\x00", "map\x01", "map\x01\x02", "map\x10", "mb"} {
+		trie.Put([]byte(key), []byte{1})
+	}
+
+	testCases := map[string]struct {
+		prefix     []byte
+		startAfter []byte
+		limit      uint
+		keys       [][]byte
+		cursor     []byte
+		errWrapped error
+	}{
+		"zero limit": {
+			prefix:     []byte("map"),
+			errWrapped: ErrPageLimitZero,
+		},
+		"first page": {
+			prefix: []byte("map"),
+			limit:  2,
+			keys:   [][]byte{[]byte("map"), []byte("map\x00")},
+			cursor: []byte("map\x00"),
+		},
+		"middle page": {
+			prefix:     []byte("map"),
+			startAfter: []byte("map\x00"),
+			limit:      2,
+			keys:       [][]byte{[]byte("map\x01"), []byte("map\x01\x02")},
+			cursor:     []byte("map\x01\x02"),
+		},
+		"last page": {
+			prefix:     []byte("map"),
+			startAfter: []byte("map\x01\x02"),
+			limit:      2,
+			keys:       [][]byte{[]byte("map\x10")},
+		},
+		"exact last page": {
+			prefix:     []byte("map"),
+			startAfter: []byte("map\x01\x02"),
+			limit:      1,
+			keys:       [][]byte{[]byte("map\x10")},
+		},
+		"start after absent key": {
+			prefix:     []byte("map"),
+			startAfter: []byte("map\x05"),
+			limit:      10,
+			keys:       [][]byte{[]byte("map\x10")},
+		},
+		"start after before prefix": {
+			prefix:     []byte("map\x01"),
+			startAfter: []byte("a"),
+			limit:      10,
+			keys:       [][]byte{[]byte("map\x01"), []byte("map\x01\x02")},
+		},
+		"trailing zero nibble prefix": {
+			prefix: []byte("map\x00"),
+			limit:  10,
+			keys:   [][]byte{[]byte("map\x00")},
+		},
+		"no key with prefix": {
+			prefix: []byte("z"),
+			limit:  10,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, cursor, err := trie.GetKeysWithPrefixPaged(testCase.prefix,
+				testCase.startAfter, testCase.limit)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.keys, keys)
+			assert.Equal(t, testCase.cursor, cursor)
+		})
+	}
+}
+
+func Test_Trie_GetKeysWithPrefixPaged_allPages(t *testing.T) {
+	t.Parallel()
+
+	trie, _ := makeSeededTrie(t, 300)
+	prefix := []byte{trie.NextKey(nil)[0]}
+	var expected [][]byte
+	for _, key := range trie.GetKeysWithPrefix(nil) {
+		if bytes.HasPrefix(key, prefix) {
+			expected = append(expected, key)
+		}
+	}
+
+	var keys [][]byte
+	var startAfter []byte
+	for {
+		page, next, err := trie.GetKeysWithPrefixPaged(prefix, startAfter, 7)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 7)
+		keys = append(keys, page...)
+		if next == nil {
+			break
+		}
+		startAfter = next
+	}
+
+	assert.Equal(t, expected, keys)
+}
+
+func Test_Trie_GetKeysWithOffset(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	for _, key := range []string{"b", "a", "ab", "", "ba"} {
+		trie.Put([]byte(key), []byte{1})
+	}
+
+	allKeys := [][]byte{{}, []byte("a"), []byte("ab"), []byte("b"), []byte("ba")}
+	assert.Equal(t, allKeys, trie.GetKeys())
+	assert.Nil(t, NewEmptyTrie().GetKeys())
+
+	testCases := map[string]struct {
+		offset uint
+		limit  uint
+		keys   [][]byte
+	}{
+		"no offset no limit": {keys: allKeys},
+		"limit":              {limit: 2, keys: allKeys[:2]},
+		"offset":             {offset: 3, keys: allKeys[3:]},
+		"offset and limit":   {offset: 1, limit: 3, keys: allKeys[1:4]},
+		"limit above count":  {offset: 4, limit: 10, keys: allKeys[4:]},
+		"offset above count": {offset: 5},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			keys := trie.GetKeysWithOffset(testCase.offset, testCase.limit)
+			assert.Equal(t, testCase.keys, keys)
+		})
+	}
+}
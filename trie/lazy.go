@@ -0,0 +1,236 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// LazyTrie is a trie backed by a database, where only the root node is
+// loaded initially. Other nodes are loaded from the database by their
+// Merkle value the first time they are on the path of a key accessed,
+// and are then kept in memory, so a state larger than the memory
+// available can be read and modified. Memory can be reclaimed with
+// EvictClean once modifications are written with WriteDirty, and evicted
+// nodes can be cached with a NodeCache, see NewLazyTrieWithOptions.
+// Child tries are not loaded, and a lazy trie is not safe for
+// concurrent use, including concurrent reads.
+type LazyTrie struct {
+	trie  *Trie
+	db    Database
+	cache *NodeCache
+}
+
+// LazyTrieOptions contains the options of a lazy trie.
+type LazyTrieOptions struct {
+	// NodeCache is the cache of node encodings to use when loading
+	// nodes, which can be shared between lazy tries. Nodes are always
+	// loaded from the database if it is nil.
+	NodeCache *NodeCache
+}
+
+// NewLazyTrie returns a lazy trie with the root hash given,
+// loading only its root node from the database given.
+func NewLazyTrie(db Database, rootHash util.Hash) (lazyTrie *LazyTrie, err error) {
+	return NewLazyTrieWithOptions(db, rootHash, LazyTrieOptions{})
+}
+
+// NewLazyTrieWithOptions returns a lazy trie with the root hash given,
+// loading only its root node from the database given, using the options given.
+func NewLazyTrieWithOptions(db Database, rootHash util.Hash, options LazyTrieOptions) (
+	lazyTrie *LazyTrie, err error) {
+	lazyTrie = &LazyTrie{
+		trie:  NewEmptyTrie(),
+		db:    db,
+		cache: options.NodeCache,
+	}
+
+	if rootHash == EmptyHash {
+		return lazyTrie, nil
+	}
+
+	lazyTrie.trie.root, err = loadNodeFromDB(db, options.NodeCache, rootHash.ToBytes())
+	if err != nil {
+		return nil, fmt.Errorf("loading root node: %w", err)
+	}
+	return lazyTrie, nil
+}
+
+// SetVersion sets the state trie version used to encode
+// the values inserted in the trie from now on.
+func (l *LazyTrie) SetVersion(version Version) {
+	l.trie.SetVersion(version)
+}
+
+// SetMetrics sets the metrics receiving observations of the operations
+// done on the trie, including the database reads of the nodes loaded.
+func (l *LazyTrie) SetMetrics(metrics Metrics) {
+	l.trie.SetMetrics(metrics)
+}
+
+// Get returns the value at the (Little Endian) key given,
+// loading the nodes on the key path from the database if needed.
+func (l *LazyTrie) Get(keyLE []byte) (value []byte, err error) {
+	const loadSiblings = false
+	err = l.loadPath(sub.KeyLEToNibbles(keyLE), loadSiblings)
+	if err != nil {
+		return nil, err
+	}
+	return l.trie.Get(keyLE), nil
+}
+
+// Put puts the value at the (Little Endian) key given,
+// loading the nodes on the key path from the database if needed.
+func (l *LazyTrie) Put(keyLE, value []byte) (err error) {
+	const loadSiblings = false
+	err = l.loadPath(sub.KeyLEToNibbles(keyLE), loadSiblings)
+	if err != nil {
+		return err
+	}
+	l.trie.Put(keyLE, value)
+	return nil
+}
+
+// Delete deletes the (Little Endian) key given, loading the nodes
+// on the key path, and the children of branches on the path which
+// may get merged with their parent, from the database if needed.
+func (l *LazyTrie) Delete(keyLE []byte) (err error) {
+	const loadSiblings = true
+	err = l.loadPath(sub.KeyLEToNibbles(keyLE), loadSiblings)
+	if err != nil {
+		return err
+	}
+	l.trie.Delete(keyLE)
+	return nil
+}
+
+// Hash returns the root hash of the trie. Nodes not loaded are
+// not modified, so their Merkle value is used as it is.
+func (l *LazyTrie) Hash() (rootHash util.Hash, err error) {
+	return l.trie.Hash()
+}
+
+// WriteDirty writes the nodes modified since the trie was
+// loaded or last written to the store given.
+func (l *LazyTrie) WriteDirty(db KVStore) (err error) {
+	return l.trie.WriteDirty(db)
+}
+
+// EvictClean unloads the nodes not modified since they were loaded
+// or last written to the database, except the root node, so they
+// are loaded again from the database the next time they are needed.
+func (l *LazyTrie) EvictClean() {
+	evictClean(l.trie.root)
+}
+
+// loadPath loads the nodes on the path of the key nibbles given which
+// are not loaded yet. If loadSiblings is true, the children of branches
+// with at most two children on the path are loaded as well, since one of
+// them may be merged with its parent branch when the key is deleted.
+func (l *LazyTrie) loadPath(keyNibbles []byte, loadSiblings bool) (err error) {
+	node := l.trie.root
+	for node != nil && node.Kind() == sub.Branch {
+		if !bytes.HasPrefix(keyNibbles, node.PartialKey) {
+			return nil
+		}
+		keyNibbles = keyNibbles[len(node.PartialKey):]
+
+		if loadSiblings && node.NumChildren() <= 2 {
+			for i := range node.Children {
+				err = l.loadChild(node, byte(i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(keyNibbles) == 0 {
+			return nil
+		}
+
+		childIndex := keyNibbles[0]
+		err = l.loadChild(node, childIndex)
+		if err != nil {
+			return err
+		}
+		node = node.Children[childIndex]
+		keyNibbles = keyNibbles[1:]
+	}
+	return nil
+}
+
+// loadChild loads the child at the index given of the branch given
+// from the database, if it is not loaded yet.
+func (l *LazyTrie) loadChild(branch *Node, childIndex byte) (err error) {
+	child := branch.Children[childIndex]
+	if !isUnloaded(child) {
+		return nil
+	}
+
+	loaded, err := loadNodeFromDB(l.trie.observedDatabase(l.db), l.cache, child.NodeValue)
+	if err != nil {
+		return fmt.Errorf("loading child at index %d: %w", childIndex, err)
+	}
+	loaded.Generation = l.trie.generation
+	// Note the child is replaced in place since loading it does
+	// not modify the branch encoding nor its Merkle value.
+	branch.Children[childIndex] = loaded
+	return nil
+}
+
+// isUnloaded returns true if the node given is a child decoded from its
+// parent branch encoding with only its Merkle value, and is not loaded.
+func isUnloaded(node *Node) bool {
+	const merkleValueLength = 32
+	return node != nil && !node.Dirty && len(node.NodeValue) == merkleValueLength &&
+		node.PartialKey == nil && node.StorageValue == nil && node.Children == nil
+}
+
+// loadNodeFromDB loads the node with the Merkle value given from the
+// node cache given, or from the database given if it is not cached.
+func loadNodeFromDB(db Database, cache *NodeCache, merkleValue []byte) (node *Node, err error) {
+	encoding, cached := cache.get(merkleValue)
+	if !cached {
+		encoding, err = db.Get(merkleValue)
+		if err != nil {
+			return nil, fmt.Errorf("getting node with Merkle value 0x%x from database: %w",
+				merkleValue, err)
+		}
+		cache.add(merkleValue, encoding)
+	}
+
+	node, err = sub.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return nil, fmt.Errorf("decoding node with Merkle value 0x%x: %w",
+			merkleValue, err)
+	}
+	node.NodeValue = merkleValue
+
+	err = loadValue(db, node)
+	if err != nil {
+		return nil, fmt.Errorf("loading value of node with Merkle value 0x%x: %w",
+			merkleValue, err)
+	}
+	return node, nil
+}
+
+func evictClean(node *Node) {
+	if node == nil || node.Kind() != sub.Branch {
+		return
+	}
+
+	const merkleValueLength = 32
+	for i, child := range node.Children {
+		if child == nil || isUnloaded(child) {
+			continue
+		}
+
+		if !child.Dirty && len(child.NodeValue) == merkleValueLength {
+			node.Children[i] = &Node{NodeValue: child.NodeValue}
+			continue
+		}
+		evictClean(child)
+	}
+}
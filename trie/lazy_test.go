@@ -0,0 +1,118 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countLoadedNodes(node *Node) (count int) {
+	if node == nil || isUnloaded(node) {
+		return 0
+	}
+	count = 1
+	for _, child := range node.Children {
+		count += countLoadedNodes(child)
+	}
+	return count
+}
+
+func Test_LazyTrie(t *testing.T) {
+	t.Parallel()
+
+	const size = 300
+	trie, keyValues := makeSeededTrie(t, size)
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+	rootHash := trie.MustHash()
+
+	lazyTrie, err := NewLazyTrie(db, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, 1, countLoadedNodes(lazyTrie.trie.root))
+
+	hash, err := lazyTrie.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, rootHash, hash)
+
+	var someKey string
+	for key := range keyValues {
+		someKey = key
+		break
+	}
+	value, err := lazyTrie.Get([]byte(someKey))
+	require.NoError(t, err)
+	assert.Equal(t, keyValues[someKey], value)
+	loaded := countLoadedNodes(lazyTrie.trie.root)
+	assert.Less(t, loaded, 10)
+
+	for key, value := range keyValues {
+		lazyValue, err := lazyTrie.Get([]byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, value, lazyValue)
+	}
+
+	value, err = lazyTrie.Get([]byte("absent"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	// Modifications match the ones on the in-memory trie.
+	lazyTrie.EvictClean()
+	assert.Equal(t, 1, countLoadedNodes(lazyTrie.trie.root))
+	i := 0
+	for key := range keyValues {
+		switch i % 3 {
+		case 0:
+			err = lazyTrie.Delete([]byte(key))
+			trie.Delete([]byte(key))
+		case 1:
+			err = lazyTrie.Put([]byte(key), []byte{byte(i)})
+			trie.Put([]byte(key), []byte{byte(i)})
+		}
+		require.NoError(t, err)
+		i++
+	}
+	err = lazyTrie.Put([]byte("new"), []byte("value"))
+	require.NoError(t, err)
+	trie.Put([]byte("new"), []byte("value"))
+
+	hash, err = lazyTrie.Hash()
+	require.NoError(t, err)
+	expectedHash := trie.MustHash()
+	assert.Equal(t, expectedHash, hash)
+
+	// Written modifications can be evicted and loaded again.
+	err = lazyTrie.WriteDirty(db)
+	require.NoError(t, err)
+	lazyTrie.EvictClean()
+	assert.Equal(t, 1, countLoadedNodes(lazyTrie.trie.root))
+	value, err = lazyTrie.Get([]byte("new"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	reopened, err := NewLazyTrie(db, expectedHash)
+	require.NoError(t, err)
+	for key, value := range trie.Entries() {
+		lazyValue, err := reopened.Get([]byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, value, lazyValue)
+	}
+}
+
+func Test_NewLazyTrie(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t)
+
+	lazyTrie, err := NewLazyTrie(db, EmptyHash)
+	require.NoError(t, err)
+	err = lazyTrie.Put([]byte{1}, []byte{2})
+	require.NoError(t, err)
+	value, err := lazyTrie.Get([]byte{1})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, value)
+
+	_, err = NewLazyTrie(db, [32]byte{1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package trie
+
+import (
+	"unsafe"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// MemStats contains memory usage statistics of a trie
+// and of its child tries.
+type MemStats struct {
+	// Leaves is the number of leaf nodes.
+	Leaves int
+	// Branches is the number of branch nodes.
+	Branches int
+	// PartialKeyBytes is the total size in bytes of the
+	// node partial keys, where each nibble uses a byte.
+	PartialKeyBytes int
+	// StorageValueBytes is the total size in bytes of
+	// the node storage values.
+	StorageValueBytes int
+	// EstimatedHeapBytes is an estimation of the heap memory used by
+	// the nodes, including their structures, children slices and the
+	// capacity of their byte slices. Nodes shared with snapshots of
+	// the trie are counted as well.
+	EstimatedHeapBytes int
+}
+
+var (
+	nodeStructSize    = int(unsafe.Sizeof(Node{}))
+	childrenSliceSize = sub.ChildrenCapacity * int(unsafe.Sizeof((*Node)(nil)))
+)
+
+// MemStats returns memory usage statistics of the trie,
+// walking all its nodes and the nodes of its child tries.
+func (t *Trie) MemStats() (stats MemStats) {
+	addNodeMemStats(t.root, &stats)
+	for _, childTrie := range t.childTries {
+		childStats := childTrie.MemStats()
+		stats.Leaves += childStats.Leaves
+		stats.Branches += childStats.Branches
+		stats.PartialKeyBytes += childStats.PartialKeyBytes
+		stats.StorageValueBytes += childStats.StorageValueBytes
+		stats.EstimatedHeapBytes += childStats.EstimatedHeapBytes
+	}
+	return stats
+}
+
+func addNodeMemStats(node *Node, stats *MemStats) {
+	if node == nil {
+		return
+	}
+
+	stats.PartialKeyBytes += len(node.PartialKey)
+	stats.StorageValueBytes += len(node.StorageValue)
+	stats.EstimatedHeapBytes += nodeStructSize + cap(node.PartialKey) +
+		cap(node.StorageValue) + cap(node.NodeValue)
+
+	if node.Kind() == sub.Leaf {
+		stats.Leaves++
+		return
+	}
+
+	stats.Branches++
+	stats.EstimatedHeapBytes += childrenSliceSize
+	for _, child := range node.Children {
+		addNodeMemStats(child, stats)
+	}
+}
@@ -0,0 +1,36 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_MemStats(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, MemStats{}, NewEmptyTrie().MemStats())
+
+	trie := NewEmptyTrie()
+	trie.Put([]byte{0x12}, []byte{1, 2, 3})
+	trie.Put([]byte{0x13}, []byte{4})
+	trie.Put([]byte{0x13, 0x45}, []byte{5, 6})
+
+	stats := trie.MemStats()
+	// Root branch with partial key 1, leaf at index 2 with an empty
+	// partial key, branch at index 3 with an empty partial key and
+	// leaf at index 4 of this branch with partial key 5.
+	assert.Equal(t, 2, stats.Leaves)
+	assert.Equal(t, 2, stats.Branches)
+	assert.Equal(t, 2, stats.PartialKeyBytes)
+	assert.Equal(t, 6, stats.StorageValueBytes)
+	assert.Greater(t, stats.EstimatedHeapBytes, 4*nodeStructSize+2*childrenSliceSize-1)
+
+	err := trie.PutIntoChild([]byte("child"), []byte{1}, []byte{7})
+	require.NoError(t, err)
+	withChild := trie.MemStats()
+	// the child trie leaf and its root hash entry in the main trie
+	assert.Equal(t, stats.StorageValueBytes+1+32, withChild.StorageValueBytes)
+	assert.Greater(t, withChild.EstimatedHeapBytes, stats.EstimatedHeapBytes)
+}
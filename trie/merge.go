@@ -0,0 +1,175 @@
+package trie
+
+import sub "github.com/octopus-network/trie-go/substrate"
+
+// MergeFrom merges all the key-value pairs and child tries of the other
+// trie given into the trie. For keys present in both tries, the value of
+// the other trie is kept if overwrite is true, and the value of the trie
+// is kept otherwise. Subtrees of the other trie whose keys do not collide
+// with keys of the trie are grafted as they are, so they are shared by
+// both tries without being copied or walked, which makes assembling a
+// state from several sources, such as a genesis state, cheap.
+// The generations of both tries are incremented so that shared nodes
+// are copied on write by both tries, as for Snapshot.
+// Grafted nodes keep their dirty status, so nodes of the other trie
+// already written to a database are not written again by WriteDirty.
+func (t *Trie) MergeFrom(other *Trie, overwrite bool) {
+	generation := t.generation
+	if other.generation > generation {
+		generation = other.generation
+	}
+	t.generation = generation + 1
+	other.generation = generation + 1
+
+	pendingDeletedMerkleValues := make(map[string]struct{})
+	defer func() {
+		const success = true
+		t.handleTrackedDeltas(success, pendingDeletedMerkleValues)
+	}()
+
+	t.root = t.mergeNodes(t.root, other.root, overwrite, pendingDeletedMerkleValues)
+
+	for keyToChild, childTrie := range other.childTries {
+		_, exists := t.childTries[keyToChild]
+		if exists && !overwrite {
+			continue
+		}
+		t.childTries[keyToChild] = childTrie.Snapshot()
+	}
+}
+
+// mergeNodes merges the source node subtree into the destination node
+// subtree, both located at the same position in their respective tries,
+// and returns the new destination node.
+func (t *Trie) mergeNodes(destination, source *Node, overwrite bool,
+	pendingDeletedMerkleValues map[string]struct{}) (newDestination *Node) {
+	if source == nil {
+		return destination
+	} else if destination == nil {
+		return source
+	}
+
+	commonPrefixLength := lenCommonPrefix(destination.PartialKey, source.PartialKey)
+	destinationDiverges := commonPrefixLength < len(destination.PartialKey)
+	sourceDiverges := commonPrefixLength < len(source.PartialKey)
+
+	switch {
+	case destinationDiverges && sourceDiverges:
+		// Both subtrees are disjoint, so they become the children
+		// of a new branch with their common partial key prefix.
+		newBranch := pooledNode(Node{
+			PartialKey: copyBytes(destination.PartialKey[:commonPrefixLength]),
+			Generation: t.generation,
+			Children:   make([]*Node, sub.ChildrenCapacity),
+			Dirty:      true,
+		})
+
+		destinationIndex := destination.PartialKey[commonPrefixLength]
+		destination = t.prepForMutation(destination, pendingDeletedMerkleValues)
+		destination.PartialKey = destination.PartialKey[commonPrefixLength+1:]
+		newBranch.Children[destinationIndex] = destination
+
+		sourceIndex := source.PartialKey[commonPrefixLength]
+		source = t.copyForGraft(source)
+		source.PartialKey = source.PartialKey[commonPrefixLength+1:]
+		newBranch.Children[sourceIndex] = source
+
+		newBranch.Descendants = 2 + destination.Descendants + source.Descendants
+		return newBranch
+	case destination.Kind() == sub.Branch && !destinationDiverges && sourceDiverges:
+		// The source subtree is below a child of the destination branch.
+		newBranch := t.prepForMutation(destination, pendingDeletedMerkleValues)
+		childIndex := source.PartialKey[commonPrefixLength]
+		source = t.copyForGraft(source)
+		source.PartialKey = source.PartialKey[commonPrefixLength+1:]
+		newBranch.Children[childIndex] = t.mergeNodes(newBranch.Children[childIndex],
+			source, overwrite, pendingDeletedMerkleValues)
+		newBranch.Descendants = countDescendants(newBranch)
+		return newBranch
+	case destination.Kind() == sub.Branch && source.Kind() == sub.Branch &&
+		!destinationDiverges && !sourceDiverges:
+		// Both branches have the same key, so their values
+		// and children are merged.
+		newBranch := t.prepForMutation(destination, pendingDeletedMerkleValues)
+		if source.StorageValue != nil && (overwrite || newBranch.StorageValue == nil) {
+			copyValue(newBranch, source)
+		}
+		for i, sourceChild := range source.Children {
+			newBranch.Children[i] = t.mergeNodes(newBranch.Children[i],
+				sourceChild, overwrite, pendingDeletedMerkleValues)
+		}
+		newBranch.Descendants = countDescendants(newBranch)
+		return newBranch
+	}
+
+	// Remaining cases involve a leaf or a source key being a prefix of
+	// the destination key, so the source entries are inserted one by one.
+	newDestination = destination
+	walkNodeEntries(source, nil, func(keyNibbles, value []byte) {
+		if !overwrite && retrieve(newDestination, keyNibbles) != nil {
+			return
+		}
+		newDestination, _, _ = t.insert(newDestination, keyNibbles, value, pendingDeletedMerkleValues)
+	})
+	return newDestination
+}
+
+func (t *Trie) prepForMutation(node *Node,
+	pendingDeletedMerkleValues map[string]struct{}) (newNode *Node) {
+	copySettings := sub.DefaultCopySettings
+	if node.Kind() == sub.Leaf {
+		return t.prepLeafForMutation(node, copySettings, pendingDeletedMerkleValues)
+	}
+	return t.prepBranchForMutation(node, copySettings, pendingDeletedMerkleValues)
+}
+
+// copyForGraft copies a node of another trie about to be modified,
+// without tracking its Merkle value as deleted since it does not
+// belong to this trie.
+func (t *Trie) copyForGraft(node *Node) (newNode *Node) {
+	newNode = node.Copy(sub.DefaultCopySettings)
+	newNode.Generation = t.generation
+	newNode.SetDirty()
+	return newNode
+}
+
+func copyValue(destination, source *Node) {
+	destination.StorageValue = copyBytes(source.StorageValue)
+	destination.IsHashedValue = source.IsHashedValue
+	destination.MustBeHashed = source.MustBeHashed
+	destination.ValueHasher = source.ValueHasher
+}
+
+func copyBytes(b []byte) (copied []byte) {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func countDescendants(branch *Node) (descendants uint32) {
+	for _, child := range branch.Children {
+		if child != nil {
+			descendants += 1 + child.Descendants
+		}
+	}
+	return descendants
+}
+
+// walkNodeEntries calls the callback given for each key-value pair
+// of the node subtree given, with keys in nibbles relative to the
+// position of the node, prefixed with the prefix given.
+func walkNodeEntries(node *Node, prefix []byte, callback func(keyNibbles, value []byte)) {
+	if node == nil {
+		return
+	}
+
+	fullKey := concatenateSlices(prefix, node.PartialKey)
+	if node.Kind() == sub.Leaf || node.StorageValue != nil {
+		callback(copyBytes(fullKey), node.StorageValue)
+	}
+
+	for i, child := range node.Children {
+		walkNodeEntries(child, concatenateSlices(fullKey, intToByteSlice(i)), callback)
+	}
+}
@@ -0,0 +1,133 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_MergeFrom(t *testing.T) {
+	t.Parallel()
+
+	type entry struct {
+		key, value string
+	}
+
+	testCases := map[string]struct {
+		trieEntries  []entry
+		otherEntries []entry
+		overwrite    bool
+		merged       map[string][]byte
+	}{
+		"empty tries": {
+			merged: map[string][]byte{},
+		},
+		"empty other trie": {
+			trieEntries: []entry{{"a", "1"}},
+			merged:      map[string][]byte{"a": []byte("1")},
+		},
+		"empty trie": {
+			otherEntries: []entry{{"a", "1"}, {"ab", "2"}},
+			merged:       map[string][]byte{"a": []byte("1"), "ab": []byte("2")},
+		},
+		"disjoint tries": {
+			trieEntries:  []entry{{"aa", "1"}, {"ab", "2"}},
+			otherEntries: []entry{{"ba", "3"}, {"bb", "4"}},
+			merged: map[string][]byte{
+				"aa": []byte("1"), "ab": []byte("2"),
+				"ba": []byte("3"), "bb": []byte("4"),
+			},
+		},
+		"other trie below branch child": {
+			trieEntries:  []entry{{"a", "1"}, {"b", "2"}},
+			otherEntries: []entry{{"ca", "3"}, {"cb", "4"}},
+			merged: map[string][]byte{
+				"a": []byte("1"), "b": []byte("2"),
+				"ca": []byte("3"), "cb": []byte("4"),
+			},
+		},
+		"colliding keys without overwrite": {
+			trieEntries:  []entry{{"a", "1"}, {"ab", "2"}, {"b", "3"}},
+			otherEntries: []entry{{"a", "4"}, {"ab", "5"}, {"abc", "6"}, {"b", "7"}},
+			merged: map[string][]byte{
+				"a": []byte("1"), "ab": []byte("2"),
+				"abc": []byte("6"), "b": []byte("3"),
+			},
+		},
+		"colliding keys with overwrite": {
+			trieEntries:  []entry{{"a", "1"}, {"ab", "2"}, {"b", "3"}},
+			otherEntries: []entry{{"a", "4"}, {"ab", "5"}, {"abc", "6"}, {"b", "7"}},
+			overwrite:    true,
+			merged: map[string][]byte{
+				"a": []byte("4"), "ab": []byte("5"),
+				"abc": []byte("6"), "b": []byte("7"),
+			},
+		},
+		"leaf merged into branch": {
+			trieEntries:  []entry{{"ab", "1"}, {"ac", "2"}},
+			otherEntries: []entry{{"a", "3"}},
+			merged: map[string][]byte{
+				"a": []byte("3"), "ab": []byte("1"), "ac": []byte("2"),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trie := NewEmptyTrie()
+			for _, e := range testCase.trieEntries {
+				trie.Put([]byte(e.key), []byte(e.value))
+			}
+			other := NewEmptyTrie()
+			for _, e := range testCase.otherEntries {
+				other.Put([]byte(e.key), []byte(e.value))
+			}
+			otherHash := other.MustHash()
+
+			trie.MergeFrom(other, testCase.overwrite)
+
+			assert.Equal(t, testCase.merged, trie.Entries())
+
+			expected := NewEmptyTrie()
+			for key, value := range testCase.merged {
+				expected.Put([]byte(key), value)
+			}
+			assert.Equal(t, expected.MustHash(), trie.MustHash())
+			assert.Equal(t, otherHash, other.MustHash())
+		})
+	}
+}
+
+func Test_Trie_MergeFrom_sharedNodes(t *testing.T) {
+	t.Parallel()
+
+	const size = 200
+	other, otherKeyValues := makeSeededTrie(t, size)
+	trie := NewEmptyTrie()
+	trie.Put([]byte("key"), []byte("value"))
+
+	trie.MergeFrom(other, false)
+
+	expected := other.DeepCopy()
+	expected.Put([]byte("key"), []byte("value"))
+	expectedHash, err := expected.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash, trie.MustHash())
+	assert.Equal(t, expected.root.Descendants, trie.root.Descendants)
+
+	// Writes on either trie do not affect the other trie.
+	otherHash := other.MustHash()
+	trie.ClearPrefix(nil)
+	assert.Equal(t, otherHash, other.MustHash())
+	assert.Equal(t, otherKeyValues, other.Entries())
+
+	for key := range otherKeyValues {
+		other.Delete([]byte(key))
+	}
+	trie.MergeFrom(expected, false)
+	assert.Equal(t, expectedHash, trie.MustHash())
+}
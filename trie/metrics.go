@@ -0,0 +1,122 @@
+package trie
+
+import "time"
+
+// Metrics receives an observation of each operation done on a trie
+// having it set with SetMetrics, so services embedding the trie can
+// export observability data, such as counters of operations and
+// histograms of their durations. Implementations must be safe for
+// concurrent use if set on tries used concurrently.
+type Metrics interface {
+	ObserveOperation(operation Operation, duration time.Duration)
+}
+
+// Operation is an operation observed by the trie metrics.
+type Operation uint8
+
+const (
+	// OperationGet is a value read with Get.
+	OperationGet Operation = iota
+	// OperationPut is a value insertion with Put.
+	OperationPut
+	// OperationDelete is a key deletion with Delete.
+	OperationDelete
+	// OperationHash is a root hash computation with Hash.
+	OperationHash
+	// OperationDatabaseRead is a database read of a node
+	// encoding or value, when loading a trie or its nodes.
+	OperationDatabaseRead
+	// OperationDatabaseWrite is a database batch write of a node
+	// encoding or value, when writing the dirty nodes of a trie.
+	OperationDatabaseWrite
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationGet:
+		return "get"
+	case OperationPut:
+		return "put"
+	case OperationDelete:
+		return "delete"
+	case OperationHash:
+		return "hash"
+	case OperationDatabaseRead:
+		return "database read"
+	case OperationDatabaseWrite:
+		return "database write"
+	default:
+		return "unknown"
+	}
+}
+
+// SetMetrics sets the metrics receiving observations of the operations
+// done on the trie and its child tries. A nil metrics disables them.
+// Snapshots and child tries created from the trie inherit its metrics.
+func (t *Trie) SetMetrics(metrics Metrics) {
+	t.metrics = metrics
+	for _, childTrie := range t.childTries {
+		childTrie.SetMetrics(metrics)
+	}
+}
+
+func noopDone() {}
+
+// observe returns a function to call once the operation given is
+// done, which reports its duration to the trie metrics if it is set.
+func (t *Trie) observe(operation Operation) (done func()) {
+	if t.metrics == nil {
+		return noopDone
+	}
+
+	start := time.Now()
+	return func() {
+		t.metrics.ObserveOperation(operation, time.Since(start))
+	}
+}
+
+// observedDatabase returns the database given wrapped to observe
+// its reads if the trie metrics is set.
+func (t *Trie) observedDatabase(db Database) Database {
+	if _, ok := db.(*metricsDatabase); ok || t.metrics == nil {
+		return db
+	}
+	return &metricsDatabase{Database: db, metrics: t.metrics}
+}
+
+// observedBatch returns the batch given wrapped to observe
+// its writes if the trie metrics is set.
+func (t *Trie) observedBatch(batch NodeWriter) NodeWriter {
+	if _, ok := batch.(*metricsBatch); ok || t.metrics == nil {
+		return batch
+	}
+	return &metricsBatch{NodeWriter: batch, metrics: t.metrics}
+}
+
+type metricsDatabase struct {
+	Database
+	metrics Metrics
+}
+
+func (d *metricsDatabase) Get(key []byte) (value []byte, err error) {
+	start := time.Now()
+	value, err = d.Database.Get(key)
+	d.metrics.ObserveOperation(OperationDatabaseRead, time.Since(start))
+	return value, err
+}
+
+func (d *metricsDatabase) Has(key []byte) (has bool, err error) {
+	return hasKey(d.Database, key)
+}
+
+type metricsBatch struct {
+	NodeWriter
+	metrics Metrics
+}
+
+func (b *metricsBatch) Put(key, value []byte) (err error) {
+	start := time.Now()
+	err = b.NodeWriter.Put(key, value)
+	b.metrics.ObserveOperation(OperationDatabaseWrite, time.Since(start))
+	return err
+}
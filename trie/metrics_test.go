@@ -0,0 +1,79 @@
+package trie
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingMetrics struct {
+	mutex  sync.Mutex
+	counts map[Operation]int
+}
+
+func newCountingMetrics() *countingMetrics {
+	return &countingMetrics{counts: make(map[Operation]int)}
+}
+
+func (m *countingMetrics) ObserveOperation(operation Operation, _ time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.counts[operation]++
+}
+
+func Test_Trie_SetMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := newCountingMetrics()
+	trie := NewEmptyTrie()
+	trie.SetMetrics(metrics)
+
+	trie.Put([]byte{1}, []byte{1})
+	trie.Put([]byte{2}, []byte{2})
+	trie.Get([]byte{1})
+	trie.Delete([]byte{2})
+	trie.Put([]byte{0x10}, []byte{3})
+	_, err := trie.Hash()
+	require.NoError(t, err)
+
+	expectedCounts := map[Operation]int{
+		OperationPut:    3,
+		OperationGet:    1,
+		OperationDelete: 1,
+		OperationHash:   1,
+	}
+	assert.Equal(t, expectedCounts, metrics.counts)
+
+	// The root branch and the two leaves are written.
+	db := newTestDB(t)
+	err = trie.WriteDirty(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, metrics.counts[OperationDatabaseWrite])
+
+	loaded := NewEmptyTrie()
+	loadedMetrics := newCountingMetrics()
+	loaded.SetMetrics(loadedMetrics)
+	err = loaded.Load(db, trie.MustHash())
+	require.NoError(t, err)
+	// The leaves are inlined in the root branch encoding.
+	assert.Equal(t, 1, loadedMetrics.counts[OperationDatabaseRead])
+
+	// Snapshots inherit the metrics.
+	loaded.Snapshot().Get([]byte{1})
+	assert.Equal(t, 1, loadedMetrics.counts[OperationGet])
+
+	// Metrics can be disabled.
+	trie.SetMetrics(nil)
+	trie.Get([]byte{1})
+	assert.Equal(t, 1, metrics.counts[OperationGet])
+}
+
+func Test_Operation_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "database read", OperationDatabaseRead.String())
+	assert.Equal(t, "unknown", Operation(100).String())
+}
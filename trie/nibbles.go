@@ -0,0 +1,72 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var ErrNibbleOutOfRange = errors.New("nibble out of range")
+
+// KeyLEToNibbles converts a Little Endian key to its nibbles,
+// each nibble being a byte with a value between 0 and 15.
+func KeyLEToNibbles(keyLE []byte) (nibbles []byte) {
+	return sub.KeyLEToNibbles(keyLE)
+}
+
+// NibblesToKeyLE converts nibbles to their Little Endian key.
+// Note for an odd number of nibbles, the first byte of the key
+// only contains the first nibble, so the conversion is not the
+// inverse of KeyLEToNibbles.
+func NibblesToKeyLE(nibbles []byte) (keyLE []byte) {
+	return sub.NibblesToKeyLE(nibbles)
+}
+
+// GetNibbles returns the value in the trie at the key given as nibbles,
+// or nil if there is no value at this key or if a nibble is greater than 15.
+func (t *Trie) GetNibbles(keyNibbles []byte) (value []byte) {
+	if checkNibbles(keyNibbles) != nil {
+		return nil
+	}
+	return retrieve(t.root, keyNibbles)
+}
+
+// PutNibbles inserts the value given in the trie at the key given as
+// nibbles, which can have an odd number of nibbles. It returns an error
+// wrapping ErrNibbleOutOfRange if a nibble is greater than 15, in which
+// case the trie is left unchanged.
+func (t *Trie) PutNibbles(keyNibbles, value []byte) (err error) {
+	err = checkNibbles(keyNibbles)
+	if err != nil {
+		return err
+	}
+
+	pendingDeletedMerkleValues := make(map[string]struct{})
+	defer func() {
+		const success = true
+		t.handleTrackedDeltas(success, pendingDeletedMerkleValues)
+	}()
+
+	if value == nil {
+		// Force nil value to be inserted to []byte{} since `nil` means there
+		// is no value.
+		value = []byte{}
+	}
+	// The key is copied since nodes partial keys are slices of it.
+	key := copyBytes(keyNibbles)
+	if key == nil {
+		key = []byte{}
+	}
+	t.root, _, _ = t.insert(t.root, key, value, pendingDeletedMerkleValues)
+	return nil
+}
+
+func checkNibbles(nibbles []byte) (err error) {
+	for i, nibble := range nibbles {
+		if nibble > 0xf {
+			return fmt.Errorf("%w: nibble %d at index %d", ErrNibbleOutOfRange, nibble, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_PutNibbles_GetNibbles(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	expected := NewEmptyTrie()
+
+	keyNibbles := []byte{1, 2, 3, 4}
+	err := trie.PutNibbles(keyNibbles, []byte{1})
+	require.NoError(t, err)
+	expected.Put(NibblesToKeyLE(keyNibbles), []byte{1})
+	keyNibbles[0] = 9 // the trie does not reference the key given
+
+	err = trie.PutNibbles([]byte{1, 2}, nil)
+	require.NoError(t, err)
+	expected.Put([]byte{0x12}, nil)
+
+	assert.Equal(t, expected.MustHash(), trie.MustHash())
+	assert.Equal(t, []byte{1}, trie.GetNibbles(KeyLEToNibbles([]byte{0x12, 0x34})))
+	assert.Equal(t, []byte{}, trie.GetNibbles([]byte{1, 2}))
+	assert.Nil(t, trie.GetNibbles([]byte{1, 2, 3}))
+
+	// Odd length keys are supported.
+	err = trie.PutNibbles([]byte{1, 2, 3}, []byte{2})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, trie.GetNibbles([]byte{1, 2, 3}))
+
+	err = trie.PutNibbles([]byte{1, 16}, []byte{3})
+	assert.ErrorIs(t, err, ErrNibbleOutOfRange)
+	assert.EqualError(t, err, "nibble out of range: nibble 16 at index 1")
+	assert.Nil(t, trie.GetNibbles([]byte{1, 16}))
+}
@@ -4,3 +4,6 @@ import sub "github.com/octopus-network/trie-go/substrate"
 
 // Node is a node in the trie and can be a leaf or a branch.
 type Node = sub.Node
+
+// StringOptions contains options to render a trie as a string.
+type StringOptions = sub.StringOptions
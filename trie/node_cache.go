@@ -0,0 +1,125 @@
+package trie
+
+import (
+	"container/list"
+	"sync"
+)
+
+// NodeCache is a least recently used cache of node encodings keyed by
+// their Merkle value, bounded by the total size in bytes of the cached
+// encodings. It is used by lazy tries to avoid reading and decoding again
+// from the database the nodes recently evicted or loaded by other lazy
+// tries sharing the cache. Encodings are cached rather than decoded nodes
+// since loaded nodes are modified in place by their trie.
+// It is safe for concurrent use.
+type NodeCache struct {
+	maxBytes int
+
+	mutex            sync.Mutex
+	merkleValueToLRU map[string]*list.Element
+	lru              *list.List // front is the most recently used
+	bytes            int
+	hits             uint64
+	misses           uint64
+}
+
+type nodeCacheEntry struct {
+	merkleValue string
+	encoding    []byte
+}
+
+// NodeCacheStats contains the statistics of a node cache.
+type NodeCacheStats struct {
+	// Hits is the number of node encodings found in the cache.
+	Hits uint64
+	// Misses is the number of node encodings not found in the cache.
+	Misses uint64
+	// Entries is the number of node encodings cached.
+	Entries int
+	// Bytes is the total size of the node encodings cached.
+	Bytes int
+}
+
+// NewNodeCache creates a node cache holding node encodings
+// with a total size of at most maxBytes bytes.
+func NewNodeCache(maxBytes int) *NodeCache {
+	return &NodeCache{
+		maxBytes:         maxBytes,
+		merkleValueToLRU: make(map[string]*list.Element),
+		lru:              list.New(),
+	}
+}
+
+// Stats returns the statistics of the node cache.
+func (c *NodeCache) Stats() (stats NodeCacheStats) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return NodeCacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.lru.Len(),
+		Bytes:   c.bytes,
+	}
+}
+
+// Purge removes all the cached node encodings,
+// without resetting the hit and miss counters.
+func (c *NodeCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.merkleValueToLRU = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// get returns the cached encoding of the node with the Merkle value
+// given, and false if it is not cached. It returns false if the cache
+// is nil, so a nil cache can be used to disable caching.
+func (c *NodeCache) get(merkleValue []byte) (encoding []byte, ok bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.merkleValueToLRU[string(merkleValue)]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(element)
+	return element.Value.(*nodeCacheEntry).encoding, true
+}
+
+// add caches the node encoding given, evicting the least recently
+// used encodings until the cache size fits its maximum size. Encodings
+// larger than the maximum size are not cached.
+func (c *NodeCache) add(merkleValue, encoding []byte) {
+	if c == nil || len(encoding) > c.maxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.merkleValueToLRU[string(merkleValue)]; ok {
+		// The encoding of a Merkle value never changes.
+		return
+	}
+
+	for c.bytes+len(encoding) > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := c.lru.Remove(oldest).(*nodeCacheEntry)
+		delete(c.merkleValueToLRU, entry.merkleValue)
+		c.bytes -= len(entry.encoding)
+	}
+
+	entry := &nodeCacheEntry{
+		merkleValue: string(merkleValue),
+		encoding:    encoding,
+	}
+	c.merkleValueToLRU[entry.merkleValue] = c.lru.PushFront(entry)
+	c.bytes += len(encoding)
+}
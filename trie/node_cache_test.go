@@ -0,0 +1,91 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NodeCache(t *testing.T) {
+	t.Parallel()
+
+	cache := NewNodeCache(5)
+	cache.add([]byte{1}, []byte{1, 1})
+	cache.add([]byte{2}, []byte{2, 2})
+	cache.add([]byte{3}, make([]byte, 6)) // too large to be cached
+
+	encoding, ok := cache.get([]byte{1})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1, 1}, encoding)
+
+	// The least recently used encoding 2 is evicted.
+	cache.add([]byte{4}, []byte{4, 4})
+	_, ok = cache.get([]byte{2})
+	assert.False(t, ok)
+	_, ok = cache.get([]byte{3})
+	assert.False(t, ok)
+
+	expectedStats := NodeCacheStats{
+		Hits:    1,
+		Misses:  2,
+		Entries: 2,
+		Bytes:   4,
+	}
+	assert.Equal(t, expectedStats, cache.Stats())
+
+	cache.Purge()
+	expectedStats.Entries, expectedStats.Bytes = 0, 0
+	assert.Equal(t, expectedStats, cache.Stats())
+
+	var nilCache *NodeCache
+	nilCache.add([]byte{1}, []byte{1})
+	_, ok = nilCache.get([]byte{1})
+	assert.False(t, ok)
+}
+
+type getCountingDatabase struct {
+	Database
+	gets int
+}
+
+func (db *getCountingDatabase) Get(key []byte) (value []byte, err error) {
+	db.gets++
+	return db.Database.Get(key)
+}
+
+func Test_LazyTrie_nodeCache(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+
+	countingDB := &getCountingDatabase{Database: db}
+	cache := NewNodeCache(1 << 20)
+	lazyTrie, err := NewLazyTrieWithOptions(countingDB, trie.MustHash(),
+		LazyTrieOptions{NodeCache: cache})
+	require.NoError(t, err)
+
+	for key := range keyValues {
+		_, err = lazyTrie.Get([]byte(key))
+		require.NoError(t, err)
+	}
+	gets := countingDB.gets
+	stats := cache.Stats()
+	assert.Zero(t, stats.Hits)
+	assert.Equal(t, uint64(gets), stats.Misses)
+	assert.Equal(t, gets, stats.Entries)
+
+	// Evicted nodes are loaded again from the cache.
+	lazyTrie.EvictClean()
+	for key, value := range keyValues {
+		lazyValue, err := lazyTrie.Get([]byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, value, lazyValue)
+	}
+	assert.Equal(t, gets, countingDB.gets)
+	assert.Equal(t, uint64(gets-1), cache.Stats().Hits) // root node is not evicted
+}
@@ -0,0 +1,51 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/octopus-network/trie-go/util"
+)
+
+var ErrStateRootMismatch = errors.New("state root mismatch")
+
+// NewFromPairs builds a trie from the hexadecimal key value pairs given,
+// as returned by the state_getPairs RPC method, using the state trie
+// version given, and verifies its root hash matches the state root given.
+// It returns an error wrapping ErrStateRootMismatch if the root hashes
+// differ, for example if the pairs are incomplete or the version is wrong,
+// or an error wrapping ErrKeysNotSorted if a key is duplicated.
+// Note child tries are not built, since state_getPairs only returns the
+// key value pairs of the main trie, including the child trie root entries.
+func NewFromPairs(pairs [][2]string, stateRoot util.Hash, version Version) (
+	trie *Trie, err error) {
+	iterator := &hexIterator{
+		keys:   make([][]byte, 0, len(pairs)),
+		values: make([][]byte, 0, len(pairs)),
+	}
+	for i, pair := range pairs {
+		err = iterator.add(pair[0], pair[1])
+		if err != nil {
+			return nil, fmt.Errorf("decoding pair %d: %w", i, err)
+		}
+	}
+	sort.Sort(iterator)
+
+	trie, err = buildFromSorted(iterator, version)
+	if err != nil {
+		return nil, fmt.Errorf("building trie: %w", err)
+	}
+
+	rootHash, err := trie.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("hashing trie: %w", err)
+	}
+
+	if rootHash != stateRoot {
+		return nil, fmt.Errorf("%w: computed %s but expected %s",
+			ErrStateRootMismatch, rootHash, stateRoot)
+	}
+
+	return trie, nil
+}
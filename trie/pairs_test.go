@@ -0,0 +1,74 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewFromPairs(t *testing.T) {
+	t.Parallel()
+
+	expected := NewEmptyTrie()
+	expected.Put([]byte{1}, []byte{2})
+	expected.Put([]byte{1, 2}, []byte{3})
+	expected.Put([]byte{4}, []byte{})
+	stateRoot := expected.MustHash()
+
+	pairs := [][2]string{{"0x0102", "0x03"}, {"0x04", "0x"}, {"0x01", "0x02"}}
+
+	testCases := map[string]struct {
+		pairs      [][2]string
+		stateRoot  util.Hash
+		version    Version
+		errWrapped error
+		errMessage string
+	}{
+		"matching state root": {
+			pairs:     pairs,
+			stateRoot: stateRoot,
+			version:   V0,
+		},
+		"state root mismatch": {
+			pairs:      pairs[:2],
+			stateRoot:  stateRoot,
+			version:    V0,
+			errWrapped: ErrStateRootMismatch,
+			errMessage: "state root mismatch: " +
+				"computed 0x00b08447c135ef30139834686fb4fb7d3e94b186dd4e23984f38fe0fc17e471d " +
+				"but expected " + stateRoot.String(),
+		},
+		"bad value hex": {
+			pairs:      [][2]string{{"0x01", "02"}},
+			errWrapped: util.ErrNoPrefix,
+			errMessage: "decoding pair 0: cannot convert value hex to bytes: " +
+				"could not byteify non 0x prefixed string: 02",
+		},
+		"duplicate key": {
+			pairs:      [][2]string{{"0x01", "0x02"}, {"0x01", "0x03"}},
+			errWrapped: ErrKeysNotSorted,
+			errMessage: "building trie: keys are not sorted: " +
+				"key 0x01 is not greater than previous key 0x01",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trie, err := NewFromPairs(testCase.pairs, testCase.stateRoot, testCase.version)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, trie)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expected.Entries(), trie.Entries())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package proof
+
+import (
+	"fmt"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/substrate/storagekey"
+)
+
+// BabeAuthority is a BABE authority with its sr25519
+// public key and its weight in the slot lottery.
+type BabeAuthority struct {
+	AuthorityID [32]byte
+	Weight      uint64
+}
+
+// StorageValueKey returns the storage key of a storage value
+// item of a pallet, which is twox128(pallet) ++ twox128(item).
+func StorageValueKey(pallet, item string) (key []byte, err error) {
+	return storagekey.Value(pallet, item)
+}
+
+// VerifySessionValidators verifies and returns the account IDs of
+// the Session.Validators storage value proved by the encoded proof
+// nodes given against the state root given.
+func VerifySessionValidators(encodedProofNodes [][]byte, stateRoot []byte) (
+	validators [][32]byte, err error) {
+	err = readStorageValue(encodedProofNodes, stateRoot, "Session", "Validators", &validators)
+	if err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// VerifyBabeAuthorities verifies and returns the authorities of the
+// Babe.Authorities storage value proved by the encoded proof nodes
+// given against the state root given.
+func VerifyBabeAuthorities(encodedProofNodes [][]byte, stateRoot []byte) (
+	authorities []BabeAuthority, err error) {
+	err = readStorageValue(encodedProofNodes, stateRoot, "Babe", "Authorities", &authorities)
+	if err != nil {
+		return nil, err
+	}
+	return authorities, nil
+}
+
+// readStorageValue reads the value of the storage item given from
+// the proof, and SCALE decodes it into the destination given.
+func readStorageValue(encodedProofNodes [][]byte, stateRoot []byte,
+	pallet, item string, destination interface{}) (err error) {
+	key, err := StorageValueKey(pallet, item)
+	if err != nil {
+		return fmt.Errorf("computing %s.%s storage key: %w", pallet, item, err)
+	}
+
+	value, err := readStreaming(encodedProofNodes, stateRoot, key, VerifyOptions{})
+	if err != nil {
+		return fmt.Errorf("reading %s.%s from proof: %w", pallet, item, err)
+	}
+
+	err = scale.Unmarshal(value, destination)
+	if err != nil {
+		return fmt.Errorf("decoding %s.%s: %w", pallet, item, err)
+	}
+	return nil
+}
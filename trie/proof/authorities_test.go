@@ -0,0 +1,75 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StorageValueKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := StorageValueKey("Session", "Validators")
+	require.NoError(t, err)
+	assert.Equal(t, util.MustHexToBytes(
+		"0xcec5070d609dd3497f72bde07fc96ba088dcde934c658227ee1dfafcd6e16903"), key)
+
+	key, err = StorageValueKey("Babe", "Authorities")
+	require.NoError(t, err)
+	assert.Equal(t, util.MustHexToBytes(
+		"0x1cb6f36e027abb2091cfb5110ab5087f5e0621c4869aa60c02be9adcc98a0d1d"), key)
+}
+
+func Test_VerifySessionValidators_VerifyBabeAuthorities(t *testing.T) {
+	t.Parallel()
+
+	validators := [][32]byte{{1}, {2}, {3}}
+	authorities := []BabeAuthority{
+		{AuthorityID: [32]byte{4}, Weight: 1},
+		{AuthorityID: [32]byte{5}, Weight: 2},
+	}
+
+	validatorsKey, err := StorageValueKey("Session", "Validators")
+	require.NoError(t, err)
+	authoritiesKey, err := StorageValueKey("Babe", "Authorities")
+	require.NoError(t, err)
+
+	tr := trie.NewEmptyTrie()
+	encodedValidators, err := scale.Marshal(validators)
+	require.NoError(t, err)
+	tr.Put(validatorsKey, encodedValidators)
+	encodedAuthorities, err := scale.Marshal(authorities)
+	require.NoError(t, err)
+	tr.Put(authoritiesKey, encodedAuthorities)
+	tr.Put([]byte("other"), generateBytes(t, 40))
+	stateRoot := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	encodedProofNodes, err := Generate(stateRoot,
+		[][]byte{validatorsKey, authoritiesKey}, database)
+	require.NoError(t, err)
+
+	provedValidators, err := VerifySessionValidators(encodedProofNodes, stateRoot)
+	require.NoError(t, err)
+	assert.Equal(t, validators, provedValidators)
+
+	provedAuthorities, err := VerifyBabeAuthorities(encodedProofNodes, stateRoot)
+	require.NoError(t, err)
+	assert.Equal(t, authorities, provedAuthorities)
+
+	otherProofNodes, err := Generate(stateRoot, [][]byte{[]byte("other")}, database)
+	require.NoError(t, err)
+	_, err = VerifyBabeAuthorities(otherProofNodes, stateRoot)
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+}
@@ -0,0 +1,62 @@
+package proof
+
+import (
+	"runtime"
+	"sync"
+)
+
+// VerifyRequest contains the arguments to verify a single
+// key and value against a proof, as given to Verify.
+type VerifyRequest struct {
+	EncodedProofNodes [][]byte
+	RootHash          []byte
+	Key               []byte
+	// Value is only compared if it is not empty.
+	Value []byte
+	// Options are the resource limits enforced when
+	// building the proof trie for this request.
+	Options VerifyOptions
+}
+
+// VerifyBatch verifies the independent requests given concurrently
+// using a pool of workers. If workers is not strictly positive, the
+// number of CPUs is used. It returns a slice of errors of the same
+// length as the requests, where each error is the result of verifying
+// the request at the same index, and is nil on success.
+func VerifyBatch(requests []VerifyRequest, workers int) (errs []error) {
+	errs = make([]error, len(requests))
+	if len(requests) == 0 {
+		return errs
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				request := requests[index]
+				// each worker writes to a distinct index so
+				// no synchronisation is needed on the slice.
+				errs[index] = VerifyWithOptions(request.EncodedProofNodes,
+					request.RootHash, request.Key, request.Value, request.Options)
+			}
+		}()
+	}
+
+	for index := range requests {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
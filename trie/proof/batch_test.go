@@ -0,0 +1,83 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyBatch(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	keys := make([][]byte, 20)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key%02d", i))
+		tr.Put(keys[i], generateBytes(t, 40))
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	requests := make([]VerifyRequest, len(keys))
+	for i, key := range keys {
+		encodedProofNodes, err := Generate(rootHash, [][]byte{key}, database)
+		require.NoError(t, err)
+		requests[i] = VerifyRequest{
+			EncodedProofNodes: encodedProofNodes,
+			RootHash:          rootHash,
+			Key:               key,
+			Value:             tr.Get(key),
+		}
+	}
+
+	const emptyProofIndex, tooManyNodesIndex = 3, 11
+	requests[emptyProofIndex].EncodedProofNodes = nil
+	requests[tooManyNodesIndex].Options = VerifyOptions{MaxNodes: 1}
+
+	testCases := map[string]struct {
+		workers int
+	}{
+		"default_workers": {},
+		"single_worker":   {workers: 1},
+		"more_workers_than_requests": {
+			workers: 100,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := VerifyBatch(requests, testCase.workers)
+
+			require.Len(t, errs, len(requests))
+			for i, err := range errs {
+				switch i {
+				case emptyProofIndex:
+					assert.ErrorIs(t, err, ErrEmptyProof)
+				case tooManyNodesIndex:
+					assert.ErrorIs(t, err, ErrTooManyProofNodes)
+				default:
+					assert.NoError(t, err, "request %d", i)
+				}
+			}
+		})
+	}
+
+	t.Run("no_request", func(t *testing.T) {
+		t.Parallel()
+		errs := VerifyBatch(nil, 4)
+		assert.Empty(t, errs)
+	})
+}
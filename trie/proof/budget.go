@@ -0,0 +1,144 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var ErrProofBudgetExceeded = errors.New("proof size budget exceeded")
+
+// BudgetExceededError is the error returned when the proof of a single
+// key exceeds the proof size budget, so the keys cannot be split across
+// proofs within the budget. It wraps ErrProofBudgetExceeded.
+type BudgetExceededError struct {
+	// Key is the (Little Endian) full key whose proof exceeds the budget.
+	Key []byte
+	// MaxSize is the proof size budget in bytes.
+	MaxSize int
+	// MinSize is the size in bytes of the proof of the key alone,
+	// which is the minimum achievable size of a proof of the key.
+	MinSize int
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: proof of key 0x%x is at least %d bytes, "+
+		"exceeding the maximum of %d bytes",
+		ErrProofBudgetExceeded, e.Key, e.MinSize, e.MaxSize)
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrProofBudgetExceeded
+}
+
+// KeysProof is a proof of a subset of keys.
+type KeysProof struct {
+	// Keys are the (Little Endian) full keys proven.
+	Keys [][]byte
+	// EncodedProofNodes are the deduplicated encoded proof nodes.
+	EncodedProofNodes [][]byte
+}
+
+// Size returns the size in bytes of the encoded proof nodes.
+func (p KeysProof) Size() (size int) {
+	for _, encodedProofNode := range p.EncodedProofNodes {
+		size += len(encodedProofNode)
+	}
+	return size
+}
+
+// GenerateWithBudget is like Generate but splits the keys given across
+// as few proofs as possible such that the total size of the encoded proof
+// nodes of each proof does not exceed the maximum size in bytes given,
+// for on-chain verification with strict payload limits.
+// The database given is used to load the trie using the root hash given.
+func GenerateWithBudget(rootHash []byte, fullKeys [][]byte, database Database,
+	maxSize int) (proofs []KeysProof, err error) {
+	t := trie.NewEmptyTrie()
+	err = t.Load(database, util.BytesToHash(rootHash))
+	if err != nil {
+		return nil, fmt.Errorf("loading trie: %w", err)
+	}
+	return GenerateFromTrieWithBudget(t, fullKeys, maxSize)
+}
+
+// GenerateFromTrieWithBudget is like GenerateWithBudget but for the
+// in-memory trie given. The keys are sorted so keys sharing nodes are
+// proven together, and are then packed greedily into proofs. If the
+// proof of a single key exceeds the maximum size, a *BudgetExceededError
+// is returned with the minimum achievable proof size for that key.
+func GenerateFromTrieWithBudget(t *trie.Trie, fullKeys [][]byte,
+	maxSize int) (proofs []KeysProof, err error) {
+	sortedKeys := make([][]byte, len(fullKeys))
+	copy(sortedKeys, fullKeys)
+	sort.Slice(sortedKeys, func(i, j int) bool {
+		return bytes.Compare(sortedKeys[i], sortedKeys[j]) < 0
+	})
+
+	rootNode := t.RootNode()
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+
+	var current KeysProof
+	var currentSize int
+	merkleValuesSeen := make(map[string]struct{})
+	for _, fullKey := range sortedKeys {
+		keyProofNodes, err := walkRoot(rootNode, sub.KeyLEToNibbles(fullKey))
+		if err != nil {
+			return nil, fmt.Errorf("walking to node at key 0x%x: %w", fullKey, err)
+		}
+
+		var keyProofSize, newNodesSize int
+		newMerkleValues := make([]string, len(keyProofNodes))
+		for i, encodedProofNode := range keyProofNodes {
+			buffer.Reset()
+			err = sub.MerkleValue(encodedProofNode, buffer)
+			if err != nil {
+				return nil, fmt.Errorf("blake2b hash: %w", err)
+			}
+			newMerkleValues[i] = buffer.String()
+
+			keyProofSize += len(encodedProofNode)
+			_, seen := merkleValuesSeen[newMerkleValues[i]]
+			if !seen {
+				newNodesSize += len(encodedProofNode)
+			}
+		}
+
+		if keyProofSize > maxSize {
+			return nil, &BudgetExceededError{
+				Key:     fullKey,
+				MaxSize: maxSize,
+				MinSize: keyProofSize,
+			}
+		}
+
+		if currentSize+newNodesSize > maxSize {
+			proofs = append(proofs, current)
+			current = KeysProof{}
+			currentSize = 0
+			merkleValuesSeen = make(map[string]struct{})
+		}
+
+		current.Keys = append(current.Keys, fullKey)
+		for i, encodedProofNode := range keyProofNodes {
+			_, seen := merkleValuesSeen[newMerkleValues[i]]
+			if seen {
+				continue
+			}
+			merkleValuesSeen[newMerkleValues[i]] = struct{}{}
+			current.EncodedProofNodes = append(current.EncodedProofNodes, encodedProofNode)
+			currentSize += len(encodedProofNode)
+		}
+	}
+
+	if len(current.Keys) > 0 {
+		proofs = append(proofs, current)
+	}
+	return proofs, nil
+}
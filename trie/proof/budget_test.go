@@ -0,0 +1,101 @@
+package proof
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateFromTrieWithBudget(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	keys := [][]byte{[]byte("dog"), []byte("cat"), []byte("catapult"),
+		[]byte("mouse"), []byte("horse")}
+	for i, key := range keys {
+		tr.Put(key, generateBytes(t, uint(40+i)))
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	fullProof, err := GenerateFromTrie(tr, keys)
+	require.NoError(t, err)
+	fullProofSize := KeysProof{EncodedProofNodes: fullProof}.Size()
+
+	t.Run("single proof", func(t *testing.T) {
+		t.Parallel()
+
+		proofs, err := GenerateFromTrieWithBudget(tr, keys, fullProofSize)
+		require.NoError(t, err)
+		require.Len(t, proofs, 1)
+		assert.ElementsMatch(t, keys, proofs[0].Keys)
+		assert.ElementsMatch(t, fullProof, proofs[0].EncodedProofNodes)
+	})
+
+	t.Run("split proofs", func(t *testing.T) {
+		t.Parallel()
+
+		// The budget is the size of the largest proof of a single key.
+		var maxSize int
+		for _, key := range keys {
+			keyProof, err := GenerateFromTrie(tr, [][]byte{key})
+			require.NoError(t, err)
+			keyProofSize := KeysProof{EncodedProofNodes: keyProof}.Size()
+			if keyProofSize > maxSize {
+				maxSize = keyProofSize
+			}
+		}
+		require.Less(t, maxSize, fullProofSize)
+
+		proofs, err := GenerateFromTrieWithBudget(tr, keys, maxSize)
+		require.NoError(t, err)
+		require.Greater(t, len(proofs), 1)
+
+		var provenKeys [][]byte
+		for _, proof := range proofs {
+			assert.LessOrEqual(t, proof.Size(), maxSize)
+			for _, key := range proof.Keys {
+				value := generateBytes(t, uint(40+indexOfKey(keys, key)))
+				err = Verify(proof.EncodedProofNodes, rootHash, key, value)
+				assert.NoError(t, err)
+			}
+			provenKeys = append(provenKeys, proof.Keys...)
+		}
+		assert.ElementsMatch(t, keys, provenKeys)
+	})
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		catProof, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+		require.NoError(t, err)
+		catProofSize := KeysProof{EncodedProofNodes: catProof}.Size()
+
+		_, err = GenerateFromTrieWithBudget(tr, keys, catProofSize-1)
+
+		assert.ErrorIs(t, err, ErrProofBudgetExceeded)
+		var budgetErr *BudgetExceededError
+		require.True(t, errors.As(err, &budgetErr))
+		assert.Equal(t, []byte("cat"), budgetErr.Key)
+		assert.Equal(t, catProofSize, budgetErr.MinSize)
+		assert.Equal(t, catProofSize-1, budgetErr.MaxSize)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := GenerateFromTrieWithBudget(tr, [][]byte{[]byte("owl")}, fullProofSize)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+func indexOfKey(keys [][]byte, key []byte) (index int) {
+	for i := range keys {
+		if string(keys[i]) == string(key) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,189 @@
+package proof
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/octopus-network/trie-go/trie"
+)
+
+var ErrRootHashNotCached = errors.New("root hash not cached")
+
+// TrieCache is a least recently used cache of proof tries built
+// from encoded proof nodes, keyed by their root hash. It avoids
+// rebuilding the same proof trie when verifying many keys against
+// the same state root. It is safe for concurrent use.
+type TrieCache struct {
+	capacity int
+	options  VerifyOptions
+
+	mutex     sync.Mutex
+	rootToLRU map[string]*list.Element
+	lru       *list.List // front is the most recently used
+}
+
+type trieCacheEntry struct {
+	rootHash          string
+	encodedProofNodes [][]byte
+	proofTrie         *trie.Trie
+}
+
+// NewTrieCache creates a proof trie cache holding at most capacity
+// proof tries, with a minimum of one proof trie. The options given
+// are used when building proof tries.
+func NewTrieCache(capacity int, options VerifyOptions) *TrieCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &TrieCache{
+		capacity:  capacity,
+		options:   options,
+		rootToLRU: make(map[string]*list.Element, capacity),
+		lru:       list.New(),
+	}
+}
+
+// Add builds the proof trie from the encoded proof nodes and root hash
+// given, and caches it, evicting the least recently used proof trie if
+// the cache is full. If a proof trie is already cached for the root
+// hash, it is rebuilt from the encoded proof nodes of both proofs, so
+// keys proved by either proof can be verified. The cache is left
+// unchanged if an error is returned.
+func (c *TrieCache) Add(encodedProofNodes [][]byte, rootHash []byte) (err error) {
+	proofTrie, err := BuildTrieWithOptions(encodedProofNodes, rootHash, c.options)
+	if err != nil {
+		return fmt.Errorf("building trie from proof encoded nodes: %w", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.rootToLRU[string(rootHash)]
+	if ok {
+		entry := element.Value.(*trieCacheEntry)
+		merged := mergeProofNodes(entry.encodedProofNodes, encodedProofNodes)
+		proofTrie, err = BuildTrieWithOptions(merged, rootHash, c.options)
+		if err != nil {
+			return fmt.Errorf("building trie from merged proof encoded nodes: %w", err)
+		}
+		entry.encodedProofNodes = merged
+		entry.proofTrie = proofTrie
+		c.lru.MoveToFront(element)
+		return nil
+	}
+
+	if c.lru.Len() >= c.capacity {
+		oldest := c.lru.Back()
+		if oldest != nil {
+			c.lru.Remove(oldest)
+			delete(c.rootToLRU, oldest.Value.(*trieCacheEntry).rootHash)
+		}
+	}
+
+	entry := &trieCacheEntry{
+		rootHash:          string(rootHash),
+		encodedProofNodes: mergeProofNodes(nil, encodedProofNodes),
+		proofTrie:         proofTrie,
+	}
+	c.rootToLRU[entry.rootHash] = c.lru.PushFront(entry)
+	return nil
+}
+
+// Verify verifies the key and value given belong to the cached
+// proof trie for the root hash given. The value is only compared
+// if it is not empty. It returns an error wrapping ErrRootHashNotCached
+// if no proof trie is cached for the root hash.
+func (c *TrieCache) Verify(rootHash, key, value []byte) (err error) {
+	proofTrie := c.get(rootHash)
+	if proofTrie == nil {
+		return fmt.Errorf("%w: 0x%x", ErrRootHashNotCached, rootHash)
+	}
+
+	proofTrieValue, err := proofTrie.TryGet(key)
+	if err != nil {
+		return fmt.Errorf("getting key %s from proof trie for root hash 0x%x: %w",
+			bytesToString(key), rootHash, err)
+	} else if proofTrieValue == nil {
+		return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	if len(value) > 0 && !bytes.Equal(value, proofTrieValue) {
+		return fmt.Errorf("%w: expected value %s but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofTrieValue))
+	}
+
+	return nil
+}
+
+// Contains returns true if a proof trie is cached for the root hash.
+// It does not change the recency of the cached proof trie.
+func (c *TrieCache) Contains(rootHash []byte) (ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok = c.rootToLRU[string(rootHash)]
+	return ok
+}
+
+// Invalidate removes the proof trie cached for the root hash, if any.
+func (c *TrieCache) Invalidate(rootHash []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.rootToLRU[string(rootHash)]
+	if !ok {
+		return
+	}
+	c.lru.Remove(element)
+	delete(c.rootToLRU, string(rootHash))
+}
+
+// Purge removes all the cached proof tries.
+func (c *TrieCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rootToLRU = make(map[string]*list.Element, c.capacity)
+	c.lru.Init()
+}
+
+// Len returns the number of cached proof tries.
+func (c *TrieCache) Len() (length int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+// mergeProofNodes returns a new slice with the encoded proof nodes
+// given, where encoded proof nodes present in both are only kept once.
+// Unlike Merge, it does not hash the encoded proof nodes, so it does
+// not depend on the hasher of the cache options.
+func mergeProofNodes(a, b [][]byte) (merged [][]byte) {
+	merged = make([][]byte, 0, len(a)+len(b))
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for _, encodedProofNodes := range [...][][]byte{a, b} {
+		for _, encodedProofNode := range encodedProofNodes {
+			_, ok := seen[string(encodedProofNode)]
+			if ok {
+				continue
+			}
+			seen[string(encodedProofNode)] = struct{}{}
+			merged = append(merged, encodedProofNode)
+		}
+	}
+	return merged
+}
+
+func (c *TrieCache) get(rootHash []byte) (proofTrie *trie.Trie) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.rootToLRU[string(rootHash)]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*trieCacheEntry).proofTrie
+}
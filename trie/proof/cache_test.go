@@ -0,0 +1,101 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TrieCache(t *testing.T) {
+	t.Parallel()
+
+	leafA := sub.Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: generateBytes(t, 40),
+	}
+	leafB := sub.Node{
+		PartialKey:   []byte{2, 3},
+		StorageValue: []byte{3},
+	}
+	leafC := sub.Node{
+		PartialKey:   []byte{4, 5},
+		StorageValue: []byte{5},
+	}
+	rootA, rootB, rootC := blake2bNode(t, leafA), blake2bNode(t, leafB), blake2bNode(t, leafC)
+
+	cache := NewTrieCache(2, VerifyOptions{})
+
+	err := cache.Verify(rootA, []byte{0x12}, nil)
+	assert.ErrorIs(t, err, ErrRootHashNotCached)
+
+	err = cache.Add(nil, rootA)
+	assert.ErrorIs(t, err, ErrEmptyProof)
+
+	err = cache.Add([][]byte{encodeNode(t, leafA)}, rootA)
+	require.NoError(t, err)
+	err = cache.Add([][]byte{encodeNode(t, leafB)}, rootB)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cache.Len())
+
+	err = cache.Verify(rootA, []byte{0x12}, leafA.StorageValue)
+	assert.NoError(t, err)
+	err = cache.Verify(rootA, []byte{0x12}, []byte{9})
+	assert.ErrorIs(t, err, ErrValueMismatchProofTrie)
+	err = cache.Verify(rootA, []byte{0x03}, nil)
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+
+	// root A was used most recently so root B gets evicted.
+	err = cache.Add([][]byte{encodeNode(t, leafC)}, rootC)
+	require.NoError(t, err)
+	assert.True(t, cache.Contains(rootA))
+	assert.False(t, cache.Contains(rootB))
+	assert.True(t, cache.Contains(rootC))
+
+	cache.Invalidate(rootA)
+	assert.False(t, cache.Contains(rootA))
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+	err = cache.Verify(rootC, []byte{0x45}, nil)
+	assert.ErrorIs(t, err, ErrRootHashNotCached)
+}
+
+func Test_TrieCache_Add_merge(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 41))
+	rootHash := tr.MustHash().ToBytes()
+
+	catProof, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+	dogProof, err := GenerateFromTrie(tr, [][]byte{[]byte("dog")})
+	require.NoError(t, err)
+
+	cache := NewTrieCache(1, VerifyOptions{})
+
+	err = cache.Add(catProof, rootHash)
+	require.NoError(t, err)
+	err = cache.Verify(rootHash, []byte("dog"), tr.Get([]byte("dog")))
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+
+	err = cache.Add(dogProof, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+
+	err = cache.Verify(rootHash, []byte("cat"), tr.Get([]byte("cat")))
+	assert.NoError(t, err)
+	err = cache.Verify(rootHash, []byte("dog"), tr.Get([]byte("dog")))
+	assert.NoError(t, err)
+
+	// An invalid proof leaves the cached proof trie unchanged.
+	err = cache.Add([][]byte{getBadNodeEncoding()}, rootHash)
+	assert.Error(t, err)
+	err = cache.Verify(rootHash, []byte("cat"), tr.Get([]byte("cat")))
+	assert.NoError(t, err)
+}
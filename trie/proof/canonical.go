@@ -0,0 +1,109 @@
+package proof
+
+import (
+	"fmt"
+	"sort"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Canonical returns the encoded proof nodes given deduplicated and in
+// canonical order, so that two parties with proofs containing the same
+// nodes obtain byte identical proofs, suitable for hashing or signing.
+// The canonical order is the pre-order of the proof trie: the root node
+// first, then the nodes of each child subtrie in ascending child index
+// order, where the value preimage of a node with a hashed value (state
+// version 1) directly follows the node. Encoded proof nodes not reachable
+// from the root node follow, sorted by ascending Merkle value.
+// Generate, GenerateFromTrie and Merge already emit nodes in this order.
+func Canonical(encodedProofNodes [][]byte, rootHash []byte) (
+	canonical [][]byte, err error) {
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	rootEncoding, ok := digestToEncoding[string(rootHash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: for root hash 0x%x", ErrRootNodeNotFound, rootHash)
+	}
+
+	canonical = make([][]byte, 0, len(digestToEncoding))
+	emit := func(digest []byte) {
+		encoding, ok := digestToEncoding[string(digest)]
+		if ok {
+			canonical = append(canonical, encoding)
+			delete(digestToEncoding, string(digest))
+		}
+	}
+
+	root, err := decodeProofNode(rootEncoding, nodeIndex(encodedProofNodes, rootEncoding), rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding root node: %w", err)
+	}
+
+	type frame struct {
+		node *sub.Node
+		// digest is the Merkle value of the hash referenced node,
+		// and is nil for nodes inlined in their parent.
+		digest []byte
+	}
+
+	// Frames are popped from the stack in pre-order, so the children
+	// of a node are pushed in descending child index order.
+	stack := []frame{{node: root, digest: rootHash}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := current.node
+		if current.digest != nil {
+			_, ok := digestToEncoding[string(current.digest)]
+			if !ok {
+				// node shared with an already emitted subtrie
+				continue
+			}
+			emit(current.digest)
+		}
+
+		if node.IsHashedValue {
+			emit(node.StorageValue)
+		}
+
+		for i := len(node.Children) - 1; i >= 0; i-- {
+			child := node.Children[i]
+			if child == nil {
+				continue
+			}
+
+			inlinedChild := child.StorageValue != nil || child.HasChild()
+			if inlinedChild {
+				stack = append(stack, frame{node: child})
+				continue
+			}
+
+			encoding, ok := digestToEncoding[string(child.NodeValue)]
+			if !ok {
+				continue
+			}
+
+			decoded, err := decodeProofNode(encoding,
+				nodeIndex(encodedProofNodes, encoding), child.NodeValue)
+			if err != nil {
+				return nil, fmt.Errorf("decoding child node: %w", err)
+			}
+			stack = append(stack, frame{node: decoded, digest: child.NodeValue})
+		}
+	}
+
+	unreachable := make([]string, 0, len(digestToEncoding))
+	for digest := range digestToEncoding {
+		unreachable = append(unreachable, digest)
+	}
+	sort.Strings(unreachable)
+	for _, digest := range unreachable {
+		canonical = append(canonical, digestToEncoding[digest])
+	}
+
+	return canonical, nil
+}
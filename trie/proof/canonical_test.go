@@ -0,0 +1,151 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Canonical(t *testing.T) {
+	t.Parallel()
+
+	value := generateBytes(t, 40)
+	leafHashedValue := sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: value,
+		MustBeHashed: true,
+	}
+	leafLarge := sub.Node{
+		PartialKey:   []byte{3},
+		StorageValue: generateBytes(t, 41),
+	}
+	branch := sub.Node{
+		PartialKey:   []byte{4},
+		StorageValue: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			nil, &leafLarge,
+		}),
+	}
+	root := sub.Node{
+		PartialKey: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			&leafHashedValue,
+			{PartialKey: []byte{5}, StorageValue: []byte{6}},
+			&branch,
+		}),
+	}
+	rootHash := blake2bNode(t, root)
+	unreachable := generateBytes(t, 50)
+
+	canonicalProof := [][]byte{
+		encodeNode(t, root),
+		encodeNode(t, leafHashedValue),
+		value,
+		encodeNode(t, branch),
+		encodeNode(t, leafLarge),
+	}
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		canonical         [][]byte
+		errWrapped        error
+		errMessage        string
+	}{
+		"root not found": {
+			encodedProofNodes: [][]byte{encodeNode(t, leafLarge)},
+			rootHash:          rootHash,
+			errWrapped:        ErrRootNodeNotFound,
+			errMessage: fmt.Sprintf("root node not found in proof: "+
+				"for root hash 0x%x", rootHash),
+		},
+		"already canonical": {
+			encodedProofNodes: canonicalProof,
+			rootHash:          rootHash,
+			canonical:         canonicalProof,
+		},
+		"reversed and duplicated": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, leafLarge),
+				encodeNode(t, branch),
+				value,
+				encodeNode(t, leafHashedValue),
+				encodeNode(t, leafLarge),
+				encodeNode(t, root),
+			},
+			rootHash:  rootHash,
+			canonical: canonicalProof,
+		},
+		"unreachable node last": {
+			encodedProofNodes: [][]byte{
+				unreachable,
+				encodeNode(t, branch),
+				encodeNode(t, root),
+			},
+			rootHash: rootHash,
+			canonical: [][]byte{
+				encodeNode(t, root),
+				encodeNode(t, branch),
+				unreachable,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			canonical, err := Canonical(testCase.encodedProofNodes, testCase.rootHash)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.canonical, canonical)
+		})
+	}
+}
+
+func Test_GenerateFromTrie_Merge_canonical(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	keys := [][]byte{[]byte("dog"), []byte("cat"), []byte("catapult"),
+		[]byte("mouse"), []byte("doge")}
+	for i, key := range keys {
+		tr.Put(key, generateBytes(t, uint(30+i)))
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	reversedKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		reversedKeys[len(keys)-1-i] = key
+	}
+
+	proof, err := GenerateFromTrie(tr, keys)
+	require.NoError(t, err)
+	proofReversed, err := GenerateFromTrie(tr, reversedKeys)
+	require.NoError(t, err)
+	assert.Equal(t, proof, proofReversed)
+
+	canonical, err := Canonical(proof, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, proof, canonical)
+
+	proofA, err := GenerateFromTrie(tr, keys[:2])
+	require.NoError(t, err)
+	proofB, err := GenerateFromTrie(tr, keys[2:])
+	require.NoError(t, err)
+
+	mergedAB, err := Merge(proofA, proofB, rootHash)
+	require.NoError(t, err)
+	mergedBA, err := Merge(proofB, proofA, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, proof, mergedAB)
+	assert.Equal(t, proof, mergedBA)
+}
@@ -0,0 +1,62 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// StateChange is an authenticated record of the values of
+// a storage key in the tries of an old and a new root hash.
+type StateChange struct {
+	Key     []byte
+	OldRoot []byte
+	NewRoot []byte
+	// OldValue is the value of the key in the old trie,
+	// and is nil if the key is absent from the old trie.
+	OldValue []byte
+	// NewValue is the value of the key in the new trie,
+	// and is nil if the key is absent from the new trie.
+	NewValue []byte
+}
+
+// Changed returns true if the key was inserted, deleted
+// or had its value modified between the old and new roots.
+func (c StateChange) Changed() bool {
+	return (c.OldValue == nil) != (c.NewValue == nil) ||
+		!bytes.Equal(c.OldValue, c.NewValue)
+}
+
+// VerifyStateChange verifies the proofs of the same (Little Endian) key
+// against an old and a new root hash, and returns the values of the key
+// at both roots as a state change record. A proof showing the key is
+// absent from a trie gives a nil value for that trie, whereas a proof
+// lacking a node on the key path gives an error wrapping
+// ErrKeyNotFoundInProofTrie.
+func VerifyStateChange(oldProof [][]byte, oldRoot []byte,
+	newProof [][]byte, newRoot []byte, key []byte) (change StateChange, err error) {
+	return VerifyStateChangeWithOptions(oldProof, oldRoot, newProof, newRoot, key, VerifyOptions{})
+}
+
+// VerifyStateChangeWithOptions is like VerifyStateChange but enforces
+// the resource limits given on each of the two proofs.
+func VerifyStateChangeWithOptions(oldProof [][]byte, oldRoot []byte,
+	newProof [][]byte, newRoot []byte, key []byte, options VerifyOptions) (
+	change StateChange, err error) {
+	oldValue, err := lookupStreaming(oldProof, oldRoot, key, options)
+	if err != nil {
+		return change, fmt.Errorf("verifying proof for old root: %w", err)
+	}
+
+	newValue, err := lookupStreaming(newProof, newRoot, key, options)
+	if err != nil {
+		return change, fmt.Errorf("verifying proof for new root: %w", err)
+	}
+
+	return StateChange{
+		Key:      key,
+		OldRoot:  oldRoot,
+		NewRoot:  newRoot,
+		OldValue: oldValue,
+		NewValue: newValue,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyStateChange(t *testing.T) {
+	t.Parallel()
+
+	oldTrie := trie.NewEmptyTrie()
+	oldTrie.Put([]byte("cat"), generateBytes(t, 40))
+	oldTrie.Put([]byte("catapulta"), generateBytes(t, 40))
+	oldTrie.Put([]byte("dog"), generateBytes(t, 40))
+	oldRoot := oldTrie.MustHash().ToBytes()
+
+	newTrie := trie.NewEmptyTrie()
+	newTrie.Put([]byte("cat"), generateBytes(t, 40))
+	newTrie.Put([]byte("cow"), []byte{1})
+	newTrie.Put([]byte("dog"), generateBytes(t, 41))
+	newRoot := newTrie.MustHash().ToBytes()
+
+	// The nodes proving the absence of a key are on the paths of the
+	// keys present, so proofs of absence need no extra keys.
+	oldProof, err := GenerateFromTrie(oldTrie,
+		[][]byte{[]byte("cat"), []byte("catapulta"), []byte("dog")})
+	require.NoError(t, err)
+	newProof, err := GenerateFromTrie(newTrie,
+		[][]byte{[]byte("cat"), []byte("cow"), []byte("dog")})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		oldProof   [][]byte
+		newRoot    []byte
+		key        []byte
+		change     StateChange
+		changed    bool
+		errWrapped error
+	}{
+		"unchanged": {
+			oldProof: oldProof,
+			newRoot:  newRoot,
+			key:      []byte("cat"),
+			change: StateChange{
+				OldValue: generateBytes(t, 40),
+				NewValue: generateBytes(t, 40),
+			},
+		},
+		"modified": {
+			oldProof: oldProof,
+			newRoot:  newRoot,
+			key:      []byte("dog"),
+			change: StateChange{
+				OldValue: generateBytes(t, 40),
+				NewValue: generateBytes(t, 41),
+			},
+			changed: true,
+		},
+		"inserted": {
+			oldProof: oldProof,
+			newRoot:  newRoot,
+			key:      []byte("cow"),
+			change:   StateChange{NewValue: []byte{1}},
+			changed:  true,
+		},
+		"deleted": {
+			oldProof: oldProof,
+			newRoot:  newRoot,
+			key:      []byte("catapulta"),
+			change:   StateChange{OldValue: generateBytes(t, 40)},
+			changed:  true,
+		},
+		"old proof missing node": {
+			oldProof:   oldProof[:1],
+			newRoot:    newRoot,
+			key:        []byte("dog"),
+			errWrapped: ErrKeyNotFoundInProofTrie,
+		},
+		"wrong new root": {
+			oldProof:   oldProof,
+			newRoot:    oldRoot,
+			key:        []byte("dog"),
+			errWrapped: ErrRootNodeNotFound,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			change, err := VerifyStateChange(testCase.oldProof, oldRoot,
+				newProof, testCase.newRoot, testCase.key)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				return
+			}
+
+			expected := testCase.change
+			expected.Key = testCase.key
+			expected.OldRoot = oldRoot
+			expected.NewRoot = newRoot
+			assert.Equal(t, expected, change)
+			assert.Equal(t, testCase.changed, change.Changed())
+		})
+	}
+}
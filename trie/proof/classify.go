@@ -0,0 +1,119 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// FailureClass is the class of a proof verification failure,
+// which can drive retry and alerting logic without matching
+// error messages.
+type FailureClass uint8
+
+const (
+	// FailureNone is the class of a nil error.
+	FailureNone FailureClass = iota
+	// FailureUnknown is the class of errors not originating
+	// from the proof, such as an invalid hasher configuration.
+	FailureUnknown
+	// FailureMalformedProof is the class of errors for proofs
+	// which cannot be decoded or break the proof format rules.
+	FailureMalformedProof
+	// FailureWrongRoot is the class of errors for proofs
+	// not containing the root node of the root hash given.
+	FailureWrongRoot
+	// FailureAbsentKey is the class of errors for keys
+	// not disclosed by the proof.
+	FailureAbsentKey
+	// FailureValueMismatch is the class of errors for keys
+	// disclosed by the proof with a different value.
+	FailureValueMismatch
+	// FailureResourceLimit is the class of errors for proofs
+	// exceeding the resource limits of the verification.
+	FailureResourceLimit
+)
+
+func (c FailureClass) String() string {
+	switch c {
+	case FailureNone:
+		return "none"
+	case FailureUnknown:
+		return "unknown"
+	case FailureMalformedProof:
+		return "malformed proof"
+	case FailureWrongRoot:
+		return "wrong root"
+	case FailureAbsentKey:
+		return "absent key"
+	case FailureValueMismatch:
+		return "value mismatch"
+	case FailureResourceLimit:
+		return "resource limit"
+	default:
+		panic(fmt.Sprintf("unknown failure class %d", c))
+	}
+}
+
+// errorClasses maps the sentinel errors of proof
+// verification failures to their failure class.
+var errorClasses = []struct {
+	err   error
+	class FailureClass
+}{
+	{err: ErrEmptyProof, class: FailureMalformedProof},
+	{err: sub.ErrVariantUnknown, class: FailureMalformedProof},
+	{err: sub.ErrDecodeStorageValue, class: FailureMalformedProof},
+	{err: sub.ErrReadChildrenBitmap, class: FailureMalformedProof},
+	{err: sub.ErrDecodeChildHash, class: FailureMalformedProof},
+	{err: ErrEmbeddedProofMalformed, class: FailureMalformedProof},
+	{err: ErrEmbeddedProofPath, class: FailureMalformedProof},
+	{err: ErrEmbeddedProofNodes, class: FailureMalformedProof},
+	{err: ErrEnvelopeEmpty, class: FailureMalformedProof},
+	{err: ErrEnvelopeVersionUnknown, class: FailureMalformedProof},
+	{err: ErrEnvelopeMalformed, class: FailureMalformedProof},
+	{err: ErrProofNotMinimal, class: FailureMalformedProof},
+	{err: ErrProofVersionMismatch, class: FailureMalformedProof},
+	{err: ErrProofNodeNotCanonical, class: FailureMalformedProof},
+	{err: ErrPrefixProofIncomplete, class: FailureMalformedProof},
+	{err: ErrCompressedProofMalformed, class: FailureMalformedProof},
+	{err: ErrFetchedNodeMissing, class: FailureMalformedProof},
+	{err: ErrFetchedNodeHashMismatch, class: FailureMalformedProof},
+	{err: ErrProofHashMismatch, class: FailureMalformedProof},
+	{err: ErrRootNodeNotFound, class: FailureWrongRoot},
+	{err: ErrMultiProofRootMissing, class: FailureWrongRoot},
+	{err: ErrBlockHashMismatch, class: FailureWrongRoot},
+	{err: ErrKeyNotFoundInProofTrie, class: FailureAbsentKey},
+	{err: ErrKeyNotFound, class: FailureAbsentKey},
+	{err: ErrNodeNotInProof, class: FailureAbsentKey},
+	{err: ErrValueMismatchProofTrie, class: FailureValueMismatch},
+	{err: ErrTooManyProofNodes, class: FailureResourceLimit},
+	{err: ErrProofNodeTooLarge, class: FailureResourceLimit},
+	{err: ErrProofTrieTooDeep, class: FailureResourceLimit},
+	{err: ErrRateLimited, class: FailureResourceLimit},
+	{err: context.DeadlineExceeded, class: FailureResourceLimit},
+}
+
+// Classify returns the failure class of the error given, as returned
+// by the functions of this package. It returns FailureNone for a nil
+// error and FailureUnknown if the error does not match any class.
+func Classify(err error) (class FailureClass) {
+	if err == nil {
+		return FailureNone
+	}
+
+	var decodeErr *NodeDecodeError
+	if errors.As(err, &decodeErr) {
+		return FailureMalformedProof
+	}
+
+	for _, errorClass := range errorClasses {
+		if errors.Is(err, errorClass.err) {
+			return errorClass.class
+		}
+	}
+
+	return FailureUnknown
+}
@@ -0,0 +1,86 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Classify(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err   error
+		class FailureClass
+	}{
+		"nil error": {
+			class: FailureNone,
+		},
+		"unknown error": {
+			err:   errors.New("test"),
+			class: FailureUnknown,
+		},
+		"hasher unknown": {
+			err:   fmt.Errorf("%w: %q", ErrHasherUnknown, "sha1"),
+			class: FailureUnknown,
+		},
+		"node decode error": {
+			err: fmt.Errorf("decoding root node: %w", &NodeDecodeError{
+				Err: errors.New("test"),
+			}),
+			class: FailureMalformedProof,
+		},
+		"wrapped decoding error": {
+			err:   fmt.Errorf("decoding: %w", sub.ErrVariantUnknown),
+			class: FailureMalformedProof,
+		},
+		"empty proof": {
+			err:   fmt.Errorf("building trie: %w", ErrEmptyProof),
+			class: FailureMalformedProof,
+		},
+		"root node not found": {
+			err:   ErrRootNodeNotFound,
+			class: FailureWrongRoot,
+		},
+		"key not found": {
+			err:   fmt.Errorf("%w: 0x01", ErrKeyNotFoundInProofTrie),
+			class: FailureAbsentKey,
+		},
+		"value mismatch": {
+			err:   ErrValueMismatchProofTrie,
+			class: FailureValueMismatch,
+		},
+		"too many proof nodes": {
+			err:   ErrTooManyProofNodes,
+			class: FailureResourceLimit,
+		},
+		"deadline exceeded": {
+			err:   fmt.Errorf("hashing proof node at index 0: %w", context.DeadlineExceeded),
+			class: FailureResourceLimit,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			class := Classify(testCase.err)
+
+			assert.Equal(t, testCase.class, class)
+		})
+	}
+}
+
+func Test_Classify_BuildTrie(t *testing.T) {
+	t.Parallel()
+
+	_, err := BuildTrie([][]byte{getBadNodeEncoding()}, blake2b(t, getBadNodeEncoding()))
+
+	assert.Equal(t, FailureMalformedProof, Classify(err))
+	assert.Equal(t, "malformed proof", Classify(err).String())
+}
@@ -0,0 +1,530 @@
+package proof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// CompressionVersion1 is the first version of the compressed proof format.
+const CompressionVersion1 byte = 1
+
+var ErrCompressedProofMalformed = errors.New("compressed proof malformed")
+
+// Record kinds of the compressed proof format.
+const (
+	recordRaw byte = iota
+	recordLeaf
+	recordBranch
+)
+
+// Record flags of the compressed proof format.
+const (
+	recordKindMask    byte = 0b0000_0011
+	recordHasValue    byte = 0b0000_0100
+	recordHashedValue byte = 0b0000_1000
+)
+
+// packedNode is a proof node split into its fields, where hash digests
+// of other proof items can be replaced by a reference to their index.
+type packedNode struct {
+	branch      bool
+	partialKey  []byte // nibbles
+	hasValue    bool
+	hashedValue bool
+	// value is the storage value, or its hash digest for a hashed value.
+	value []byte
+	// valueRef is the index plus one of the preimage of a hashed
+	// value in the proof items, or 0 if value is set instead.
+	valueRef int
+	// children are the Merkle values of the children.
+	children [sub.ChildrenCapacity][]byte
+	// childRefs are the indexes plus one of the encoded children in
+	// the proof items, or 0 if their Merkle value is set instead.
+	childRefs [sub.ChildrenCapacity]int
+	present   uint16 // children bitmap
+}
+
+// Compress compresses the encoded proof nodes given, to reduce the size
+// of proofs sent to on-chain light clients where calldata is expensive.
+// Each node is stored with a single flags byte instead of its header,
+// its partial key nibbles packed two per byte, and the hash digests of
+// its children and hashed value replaced by the index of the matching
+// proof item if the proof contains it. Proof items which are not nodes,
+// such as hashed value preimages, or which are not canonically encoded
+// are stored as is. Decompress restores the exact encoded proof nodes.
+func Compress(encodedProofNodes [][]byte) (compressed []byte, err error) {
+	digestToIndex := make(map[string]int, len(encodedProofNodes))
+	buffer := bytes.NewBuffer(nil)
+	for i, encodedProofNode := range encodedProofNodes {
+		buffer.Reset()
+		err = sub.MerkleValueRoot(encodedProofNode, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("calculating Merkle value of node %d: %w", i, err)
+		}
+		_, exists := digestToIndex[buffer.String()]
+		if !exists {
+			digestToIndex[buffer.String()] = i
+		}
+	}
+
+	compressed = append(compressed, CompressionVersion1)
+	compressed = appendUvarint(compressed, uint64(len(encodedProofNodes)))
+	for _, encodedProofNode := range encodedProofNodes {
+		packed, ok := packNode(encodedProofNode, digestToIndex)
+		if ok {
+			encoding, err := unpackedEncoding(packed, func(index int) []byte {
+				return encodedProofNodes[index]
+			})
+			ok = err == nil && bytes.Equal(encoding, encodedProofNode)
+		}
+
+		if !ok {
+			compressed = append(compressed, recordRaw)
+			compressed = appendUvarint(compressed, uint64(len(encodedProofNode)))
+			compressed = append(compressed, encodedProofNode...)
+			continue
+		}
+
+		compressed = appendPackedNode(compressed, packed)
+	}
+
+	return compressed, nil
+}
+
+// packNode splits the encoded proof node given into its fields, and
+// returns false if it cannot be decoded as a node.
+func packNode(encoding []byte, digestToIndex map[string]int) (
+	packed packedNode, ok bool) {
+	node, err := sub.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return packed, false
+	}
+
+	packed.branch = node.Kind() == sub.Branch
+	packed.partialKey = node.PartialKey
+	packed.hasValue = node.StorageValue != nil
+	packed.hashedValue = node.IsHashedValue
+	packed.value = node.StorageValue
+	if packed.hashedValue {
+		index, found := digestToIndex[string(node.StorageValue)]
+		if found {
+			packed.valueRef = index + 1
+			packed.value = nil
+		}
+	}
+
+	children, err := node.ChildrenMerkleValues()
+	if err != nil {
+		return packed, false
+	}
+	for _, child := range children {
+		packed.present |= 1 << child.Index
+		index, found := digestToIndex[string(child.MerkleValue)]
+		if found && len(child.MerkleValue) == 32 {
+			packed.childRefs[child.Index] = index + 1
+			continue
+		}
+		packed.children[child.Index] = child.MerkleValue
+	}
+
+	return packed, true
+}
+
+// unpackedEncoding returns the node encoding of the packed node given,
+// using the function given to obtain the proof item at a referenced index.
+func unpackedEncoding(packed packedNode, item func(index int) []byte) (
+	encoding []byte, err error) {
+	node := &sub.Node{
+		PartialKey:    packed.partialKey,
+		IsHashedValue: packed.hashedValue,
+	}
+
+	digestBuffer := bytes.NewBuffer(nil)
+	digest := func(index int) (digest []byte, err error) {
+		digestBuffer.Reset()
+		err = sub.MerkleValueRoot(item(index), digestBuffer)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, digestBuffer.Bytes()...), nil
+	}
+
+	if packed.hasValue {
+		node.StorageValue = packed.value
+		if node.StorageValue == nil {
+			node.StorageValue = []byte{}
+		}
+		if packed.valueRef > 0 {
+			node.StorageValue, err = digest(packed.valueRef - 1)
+			if err != nil {
+				return nil, fmt.Errorf("calculating hashed value: %w", err)
+			}
+		}
+	}
+
+	if packed.branch {
+		node.Children = make([]*sub.Node, sub.ChildrenCapacity)
+		for i := range node.Children {
+			if packed.present&(1<<i) == 0 {
+				continue
+			}
+			merkleValue := packed.children[i]
+			if packed.childRefs[i] > 0 {
+				merkleValue, err = digest(packed.childRefs[i] - 1)
+				if err != nil {
+					return nil, fmt.Errorf("calculating Merkle value of child %d: %w", i, err)
+				}
+			}
+			node.Children[i] = &sub.Node{NodeValue: merkleValue}
+		}
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = node.Encode(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("encoding node: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func appendPackedNode(data []byte, packed packedNode) (newData []byte) {
+	flags := recordLeaf
+	if packed.branch {
+		flags = recordBranch
+	}
+	if packed.hasValue {
+		flags |= recordHasValue
+	}
+	if packed.hashedValue {
+		flags |= recordHashedValue
+	}
+	data = append(data, flags)
+
+	data = appendUvarint(data, uint64(len(packed.partialKey)))
+	data = append(data, packNibbles(packed.partialKey)...)
+
+	if packed.hasValue {
+		if packed.hashedValue {
+			data = appendUvarint(data, uint64(packed.valueRef))
+			if packed.valueRef == 0 {
+				data = append(data, packed.value...)
+			}
+		} else {
+			data = appendUvarint(data, uint64(len(packed.value)))
+			data = append(data, packed.value...)
+		}
+	}
+
+	if !packed.branch {
+		return data
+	}
+
+	data = append(data, byte(packed.present), byte(packed.present>>8))
+	for i := 0; i < sub.ChildrenCapacity; i++ {
+		if packed.present&(1<<i) == 0 {
+			continue
+		}
+		data = appendUvarint(data, uint64(packed.childRefs[i]))
+		if packed.childRefs[i] == 0 {
+			data = appendUvarint(data, uint64(len(packed.children[i])))
+			data = append(data, packed.children[i]...)
+		}
+	}
+
+	return data
+}
+
+// Decompress decompresses the proof given, as compressed by Compress,
+// and returns its encoded proof nodes. It returns an error wrapping
+// ErrCompressedProofMalformed if the compressed proof is malformed.
+func Decompress(compressed []byte) (encodedProofNodes [][]byte, err error) {
+	reader := &compressedReader{data: compressed}
+
+	version, err := reader.byte()
+	if err != nil {
+		return nil, err
+	} else if version != CompressionVersion1 {
+		return nil, fmt.Errorf("%w: version %d is unknown",
+			ErrCompressedProofMalformed, version)
+	}
+
+	count, err := reader.uvarint()
+	if err != nil {
+		return nil, err
+	} else if count > uint64(len(compressed)) {
+		// Each record is at least one byte long.
+		return nil, fmt.Errorf("%w: %d records exceed the compressed proof size",
+			ErrCompressedProofMalformed, count)
+	}
+
+	records := make([]compressedRecord, count)
+	for i := range records {
+		records[i], err = reader.record(int(count))
+		if err != nil {
+			return nil, fmt.Errorf("reading record %d: %w", i, err)
+		}
+	}
+
+	if len(reader.data) > 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes",
+			ErrCompressedProofMalformed, len(reader.data))
+	}
+
+	encodedProofNodes, err = resolveRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	return encodedProofNodes, nil
+}
+
+// compressedRecord is a proof item read from a compressed proof,
+// which is either raw or a packed node.
+type compressedRecord struct {
+	raw    []byte
+	packed *packedNode
+}
+
+// references returns the indexes of the proof items referenced.
+func (r compressedRecord) references() (indexes []int) {
+	if r.packed == nil {
+		return nil
+	}
+	if r.packed.valueRef > 0 {
+		indexes = append(indexes, r.packed.valueRef-1)
+	}
+	for _, childRef := range r.packed.childRefs {
+		if childRef > 0 {
+			indexes = append(indexes, childRef-1)
+		}
+	}
+	return indexes
+}
+
+// resolveRecords returns the encoded proof items of the records given,
+// encoding each packed node after the items it references, using an
+// explicit stack so crafted reference chains cannot exhaust the stack.
+func resolveRecords(records []compressedRecord) (encodings [][]byte, err error) {
+	const (
+		unvisited = iota
+		visiting
+		resolved
+	)
+	states := make([]byte, len(records))
+	encodings = make([][]byte, len(records))
+	item := func(index int) []byte { return encodings[index] }
+
+	for start := range records {
+		stack := []int{start}
+		for len(stack) > 0 {
+			index := stack[len(stack)-1]
+			if states[index] == resolved {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			states[index] = visiting
+
+			pushed := false
+			for _, reference := range records[index].references() {
+				switch states[reference] {
+				case visiting:
+					return nil, fmt.Errorf("%w: record %d has a reference cycle",
+						ErrCompressedProofMalformed, index)
+				case unvisited:
+					stack = append(stack, reference)
+					pushed = true
+				}
+				if pushed {
+					break
+				}
+			}
+			if pushed {
+				continue
+			}
+
+			record := records[index]
+			if record.packed == nil {
+				encodings[index] = append([]byte{}, record.raw...)
+			} else {
+				encodings[index], err = unpackedEncoding(*record.packed, item)
+				if err != nil {
+					return nil, fmt.Errorf("%w: record %d: %s",
+						ErrCompressedProofMalformed, index, err)
+				}
+			}
+			states[index] = resolved
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return encodings, nil
+}
+
+// compressedReader reads the fields of a compressed proof,
+// returning errors wrapping ErrCompressedProofMalformed.
+type compressedReader struct {
+	data []byte
+}
+
+func (r *compressedReader) byte() (b byte, err error) {
+	if len(r.data) == 0 {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrCompressedProofMalformed)
+	}
+	b = r.data[0]
+	r.data = r.data[1:]
+	return b, nil
+}
+
+func (r *compressedReader) uvarint() (value uint64, err error) {
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: invalid variable length integer", ErrCompressedProofMalformed)
+	}
+	r.data = r.data[n:]
+	return value, nil
+}
+
+func (r *compressedReader) bytes(length uint64) (b []byte, err error) {
+	if length > uint64(len(r.data)) {
+		return nil, fmt.Errorf("%w: %d bytes exceed the %d bytes left",
+			ErrCompressedProofMalformed, length, len(r.data))
+	}
+	b = r.data[:length]
+	r.data = r.data[length:]
+	return b, nil
+}
+
+func (r *compressedReader) lengthPrefixed() (b []byte, err error) {
+	length, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	return r.bytes(length)
+}
+
+// reference reads a reference to a proof item, where 0 means no
+// reference, and checks it is within the records count given.
+func (r *compressedReader) reference(count int) (reference int, err error) {
+	value, err := r.uvarint()
+	if err != nil {
+		return 0, err
+	} else if value > uint64(count) {
+		return 0, fmt.Errorf("%w: reference %d exceeds the %d records",
+			ErrCompressedProofMalformed, value, count)
+	}
+	return int(value), nil
+}
+
+func (r *compressedReader) record(count int) (record compressedRecord, err error) {
+	flags, err := r.byte()
+	if err != nil {
+		return record, err
+	}
+
+	kind := flags & recordKindMask
+	switch {
+	case kind == recordRaw && flags == recordRaw:
+		record.raw, err = r.lengthPrefixed()
+		return record, err
+	case kind == recordLeaf, kind == recordBranch:
+	default:
+		return record, fmt.Errorf("%w: record flags %08b are invalid",
+			ErrCompressedProofMalformed, flags)
+	}
+
+	packed := &packedNode{
+		branch:      kind == recordBranch,
+		hasValue:    flags&recordHasValue != 0,
+		hashedValue: flags&recordHashedValue != 0,
+	}
+
+	nibbles, err := r.uvarint()
+	if err != nil {
+		return record, err
+	}
+	// Note the nibbles count is checked before any arithmetic on it,
+	// since it can be as large as 2^64-1. A node header encodes a
+	// partial key of at most 65535 nibbles.
+	const maxPartialKeyNibbles = math.MaxUint16
+	const nibblesPerByte = 2
+	if nibbles > maxPartialKeyNibbles {
+		return record, fmt.Errorf("%w: partial key of %d nibbles exceeds the maximum of %d nibbles",
+			ErrCompressedProofMalformed, nibbles, maxPartialKeyNibbles)
+	} else if nibbles > nibblesPerByte*uint64(len(r.data)) {
+		return record, fmt.Errorf("%w: partial key of %d nibbles exceeds the %d bytes left",
+			ErrCompressedProofMalformed, nibbles, len(r.data))
+	}
+	packedKey, err := r.bytes((nibbles + 1) / 2)
+	if err != nil {
+		return record, err
+	}
+	packed.partialKey = unpackNibbles(packedKey, int(nibbles))
+
+	if packed.hasValue {
+		if packed.hashedValue {
+			packed.valueRef, err = r.reference(count)
+			if err == nil && packed.valueRef == 0 {
+				const hashSize = 32
+				packed.value, err = r.bytes(hashSize)
+			}
+		} else {
+			packed.value, err = r.lengthPrefixed()
+		}
+		if err != nil {
+			return record, err
+		}
+	}
+
+	if packed.branch {
+		bitmap, err := r.bytes(2)
+		if err != nil {
+			return record, err
+		}
+		packed.present = uint16(bitmap[0]) | uint16(bitmap[1])<<8
+		for i := 0; i < sub.ChildrenCapacity; i++ {
+			if packed.present&(1<<i) == 0 {
+				continue
+			}
+			packed.childRefs[i], err = r.reference(count)
+			if err == nil && packed.childRefs[i] == 0 {
+				packed.children[i], err = r.lengthPrefixed()
+			}
+			if err != nil {
+				return record, err
+			}
+		}
+	}
+
+	record.packed = packed
+	return record, nil
+}
+
+// packNibbles packs the nibbles given two per byte, with the first
+// nibble in the high bits, padding the last byte with a zero nibble.
+func packNibbles(nibbles []byte) (packed []byte) {
+	packed = make([]byte, (len(nibbles)+1)/2)
+	for i, nibble := range nibbles {
+		if i%2 == 0 {
+			packed[i/2] = nibble << 4
+		} else {
+			packed[i/2] |= nibble & 0x0f
+		}
+	}
+	return packed
+}
+
+func unpackNibbles(packed []byte, count int) (nibbles []byte) {
+	nibbles = make([]byte, count)
+	for i := range nibbles {
+		if i%2 == 0 {
+			nibbles[i] = packed[i/2] >> 4
+		} else {
+			nibbles[i] = packed[i/2] & 0x0f
+		}
+	}
+	return nibbles
+}
@@ -0,0 +1,176 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Compress_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	keys := [][]byte{[]byte("cat"), []byte("catapult"), []byte("dog"),
+		[]byte("doge"), []byte("mouse")}
+	for i, key := range keys {
+		tr.Put(key, generateBytes(t, uint(20+i*10)))
+	}
+	tr.Put([]byte("ant"), []byte{1})
+	rootHash := tr.MustHash().ToBytes()
+
+	encodedProofNodes, err := GenerateFromTrie(tr, keys)
+	require.NoError(t, err)
+
+	compressed, err := Compress(encodedProofNodes)
+	require.NoError(t, err)
+
+	var uncompressedSize int
+	for _, encodedProofNode := range encodedProofNodes {
+		uncompressedSize += len(encodedProofNode)
+	}
+	assert.Less(t, len(compressed), uncompressedSize)
+
+	decompressed, err := Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, encodedProofNodes, decompressed)
+
+	for i, key := range keys {
+		err = Verify(decompressed, rootHash, key, generateBytes(t, uint(20+i*10)))
+		assert.NoError(t, err)
+	}
+}
+
+func Test_Compress_hashedValue(t *testing.T) {
+	t.Parallel()
+
+	value := generateBytes(t, 40)
+	leafWithHashedValue := &sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: value,
+		MustBeHashed: true,
+	}
+	root := sub.Node{
+		PartialKey: []byte{},
+		Children: padRightChildren([]*sub.Node{
+			nil, leafWithHashedValue, nil,
+			{PartialKey: []byte{4}, StorageValue: []byte{5}},
+		}),
+	}
+	encodedProofNodes := [][]byte{
+		encodeNode(t, root),
+		encodeNode(t, *leafWithHashedValue),
+		value, // hashed value preimage
+	}
+
+	compressed, err := Compress(encodedProofNodes)
+	require.NoError(t, err)
+	// The hash digests of the leaf and of its value are referenced by index.
+	assert.Less(t, len(compressed), len(concatBytes(encodedProofNodes))-48)
+
+	decompressed, err := Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, encodedProofNodes, decompressed)
+
+	err = Verify(decompressed, blake2bNode(t, root), []byte{0x12}, value)
+	assert.NoError(t, err)
+}
+
+func Test_Compress_rawItems(t *testing.T) {
+	t.Parallel()
+
+	encodedProofNodes := [][]byte{
+		getBadNodeEncoding(),
+		{},
+		generateBytes(t, 40),
+	}
+
+	compressed, err := Compress(encodedProofNodes)
+	require.NoError(t, err)
+
+	decompressed, err := Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, encodedProofNodes, decompressed)
+}
+
+func Test_Decompress(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		compressed []byte
+		errMessage string
+	}{
+		"empty": {
+			errMessage: "compressed proof malformed: unexpected end of data",
+		},
+		"unknown version": {
+			compressed: []byte{9},
+			errMessage: "compressed proof malformed: version 9 is unknown",
+		},
+		"count exceeding size": {
+			compressed: []byte{CompressionVersion1, 100},
+			errMessage: "compressed proof malformed: 100 records exceed the compressed proof size",
+		},
+		"invalid flags": {
+			compressed: []byte{CompressionVersion1, 1, 0b0000_0011},
+			errMessage: "reading record 0: compressed proof malformed: " +
+				"record flags 00000011 are invalid",
+		},
+		"raw item length exceeding data": {
+			compressed: []byte{CompressionVersion1, 1, recordRaw, 5, 1},
+			errMessage: "reading record 0: compressed proof malformed: " +
+				"5 bytes exceed the 1 bytes left",
+		},
+		"partial key nibbles max uint64": {
+			compressed: []byte{CompressionVersion1, 1, recordLeaf,
+				0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+			errMessage: "reading record 0: compressed proof malformed: " +
+				"partial key of 18446744073709551615 nibbles exceeds the maximum of 65535 nibbles",
+		},
+		"partial key nibbles exceeding data": {
+			compressed: []byte{CompressionVersion1, 1, recordLeaf, 3, 0x12},
+			errMessage: "reading record 0: compressed proof malformed: " +
+				"partial key of 3 nibbles exceeds the 1 bytes left",
+		},
+		"reference out of range": {
+			compressed: []byte{CompressionVersion1, 1,
+				recordLeaf | recordHasValue | recordHashedValue, 0, 2},
+			errMessage: "reading record 0: compressed proof malformed: " +
+				"reference 2 exceeds the 1 records",
+		},
+		"reference cycle": {
+			compressed: []byte{CompressionVersion1, 2,
+				recordLeaf | recordHasValue | recordHashedValue, 0, 2,
+				recordLeaf | recordHasValue | recordHashedValue, 0, 1},
+			errMessage: "compressed proof malformed: record 1 has a reference cycle",
+		},
+		"trailing bytes": {
+			compressed: []byte{CompressionVersion1, 0, 1},
+			errMessage: "compressed proof malformed: 1 trailing bytes",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encodedProofNodes, err := Decompress(testCase.compressed)
+
+			assert.Nil(t, encodedProofNodes)
+			assert.ErrorIs(t, err, ErrCompressedProofMalformed)
+			assert.EqualError(t, err, testCase.errMessage)
+		})
+	}
+}
+
+func Test_packNibbles(t *testing.T) {
+	t.Parallel()
+
+	nibbles := []byte{1, 2, 3, 4, 5}
+	packed := packNibbles(nibbles)
+	assert.Equal(t, []byte{0x12, 0x34, 0x50}, packed)
+	assert.Equal(t, nibbles, unpackNibbles(packed, len(nibbles)))
+}
@@ -0,0 +1,126 @@
+package proof
+
+import (
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Coverage contains statistics on the part of a trie covered by a proof,
+// to tell at a glance whether a proof covers what it is expected to cover.
+type Coverage struct {
+	// ResolvedChildren is the number of branch child slots whose child
+	// node is inlined in its parent or is in the proof.
+	ResolvedChildren int
+	// TruncatedChildren is the number of branch child slots whose child
+	// node is not in the proof, which are pruned from the proof trie.
+	TruncatedChildren int
+	// Entries is the number of key-value pairs proven, as returned
+	// by Entries for the proof trie.
+	Entries int
+	// MissingValues is the number of keys with a hashed value (state
+	// version 1) whose value preimage is not in the proof.
+	MissingValues int
+}
+
+// Complete returns true if no branch child and no hashed value
+// is missing from the proof, so the proof covers the entire trie.
+func (c Coverage) Complete() bool {
+	return c.TruncatedChildren == 0 && c.MissingValues == 0
+}
+
+// CoverageStats returns the coverage statistics of the proof trie
+// that BuildTrie builds from the encoded proof nodes and root hash given.
+func CoverageStats(encodedProofNodes [][]byte, rootHash []byte) (
+	coverage Coverage, err error) {
+	return CoverageStatsWithOptions(encodedProofNodes, rootHash, VerifyOptions{})
+}
+
+// CoverageStatsWithOptions is like CoverageStats but enforces the
+// resource limits given on the encoded proof nodes and on the nodes
+// decoded, like BuildTrieWithOptions.
+func CoverageStatsWithOptions(encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions) (coverage Coverage, err error) {
+	err = options.checkNodesCount(len(encodedProofNodes))
+	if err != nil {
+		return coverage, err
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, options)
+	if err != nil {
+		return coverage, err
+	}
+
+	rootEncoding, ok := digestToEncoding[string(rootHash)]
+	if !ok {
+		return coverage, fmt.Errorf("%w: for root hash 0x%x", ErrRootNodeNotFound, rootHash)
+	}
+	root, err := decodeProofNode(rootEncoding, nodeIndex(encodedProofNodes, rootEncoding), rootHash)
+	if err != nil {
+		return coverage, fmt.Errorf("decoding root node: %w", err)
+	}
+
+	type frame struct {
+		node  *sub.Node
+		depth int
+	}
+	stack := []frame{{node: root}}
+	nodesDecoded := 1
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := current.node
+		if node.StorageValue != nil {
+			_, preimageFound := digestToEncoding[string(node.StorageValue)]
+			if !node.IsHashedValue || preimageFound {
+				coverage.Entries++
+			} else {
+				coverage.MissingValues++
+			}
+		}
+
+		for _, child := range node.Children {
+			if child == nil {
+				continue
+			}
+
+			// Note an inlined leaf can have an empty non-nil storage value,
+			// whereas a hash referenced child has a nil storage value.
+			inlinedChild := child.StorageValue != nil || child.HasChild()
+			if inlinedChild {
+				coverage.ResolvedChildren++
+				stack = append(stack, frame{node: child, depth: current.depth})
+				continue
+			}
+
+			encoding, ok := digestToEncoding[string(child.NodeValue)]
+			if !ok {
+				coverage.TruncatedChildren++
+				continue
+			}
+			coverage.ResolvedChildren++
+
+			childDepth := current.depth + 1
+			err = options.checkDepth(childDepth)
+			if err != nil {
+				return coverage, err
+			}
+
+			nodesDecoded++
+			err = options.checkNodesCount(nodesDecoded)
+			if err != nil {
+				return coverage, err
+			}
+
+			child, err = decodeProofNode(encoding,
+				nodeIndex(encodedProofNodes, encoding), child.NodeValue)
+			if err != nil {
+				return coverage, fmt.Errorf("decoding child node: %w", err)
+			}
+			stack = append(stack, frame{node: child, depth: childDepth})
+		}
+	}
+
+	return coverage, nil
+}
@@ -0,0 +1,105 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CoverageStats(t *testing.T) {
+	t.Parallel()
+
+	leafLarge := sub.Node{
+		PartialKey:   []byte{3},
+		StorageValue: generateBytes(t, 40),
+	}
+	assertLongEncoding(t, leafLarge)
+
+	leafHashedValue := sub.Node{
+		PartialKey:   []byte{4},
+		StorageValue: generateBytes(t, 50),
+		MustBeHashed: true,
+	}
+
+	root := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			&leafLarge,
+			{PartialKey: []byte{5}, StorageValue: []byte{2}},
+			&leafHashedValue,
+		}),
+	}
+	rootHash := blake2bNode(t, root)
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		coverage          Coverage
+		complete          bool
+		errWrapped        error
+		errMessage        string
+	}{
+		"root not found": {
+			encodedProofNodes: [][]byte{encodeNode(t, leafLarge)},
+			rootHash:          rootHash,
+			errWrapped:        ErrRootNodeNotFound,
+			errMessage:        fmt.Sprintf("root node not found in proof: for root hash 0x%x", rootHash),
+		},
+		"all children truncated": {
+			encodedProofNodes: [][]byte{encodeNode(t, root)},
+			rootHash:          rootHash,
+			coverage: Coverage{
+				ResolvedChildren:  1,
+				TruncatedChildren: 2,
+				Entries:           2,
+			},
+		},
+		"hashed value preimage missing": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, root),
+				encodeNode(t, leafLarge),
+				encodeNode(t, leafHashedValue),
+			},
+			rootHash: rootHash,
+			coverage: Coverage{
+				ResolvedChildren: 3,
+				Entries:          3,
+				MissingValues:    1,
+			},
+		},
+		"complete": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, root),
+				encodeNode(t, leafLarge),
+				encodeNode(t, leafHashedValue),
+				leafHashedValue.StorageValue,
+			},
+			rootHash: rootHash,
+			coverage: Coverage{
+				ResolvedChildren: 3,
+				Entries:          4,
+			},
+			complete: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			coverage, err := CoverageStats(testCase.encodedProofNodes, testCase.rootHash)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.coverage, coverage)
+			assert.Equal(t, testCase.complete, coverage.Complete())
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package proof
+
+import (
+	"errors"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// errNodeNotCovered is returned by the node encoding function used
+// by Covers to stop the key path walk at the first missing node.
+var errNodeNotCovered = errors.New("node not covered by proof")
+
+// Covers returns true if the encoded proof nodes given contain all the
+// nodes along the path of the key given from the root hash given,
+// including the value preimage if the key has a hashed value, such that
+// the proof proves either the value of the key or its absence. Only the
+// nodes on the key path are decoded and values are not compared, so a
+// batching layer can cheaply decide whether it must fetch more nodes.
+// An error is returned if a node on the path cannot be decoded.
+func Covers(encodedProofNodes [][]byte, rootHash, key []byte) (covers bool, err error) {
+	var options VerifyOptions
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, options)
+	if err != nil {
+		return false, err
+	}
+
+	nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+		encoding, ok := digestToEncoding[string(merkleValue)]
+		if !ok {
+			return nil, errNodeNotCovered
+		}
+		return encoding, nil
+	}
+
+	_, _, err = walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, options)
+	switch {
+	case errors.Is(err, errNodeNotCovered):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
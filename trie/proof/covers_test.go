@@ -0,0 +1,84 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Covers(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("catapult"), generateBytes(t, 41))
+	tr.Put([]byte("dog"), generateBytes(t, 42))
+	rootHash := tr.MustHash().ToBytes()
+
+	catProof, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+
+	badEncoding := getBadNodeEncoding()
+	badChildBranch := sub.Node{
+		PartialKey: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			{NodeValue: blake2b(t, badEncoding)},
+		}),
+	}
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		key               []byte
+		covers            bool
+		errWrapped        error
+	}{
+		"empty proof": {
+			rootHash: rootHash,
+			key:      []byte("cat"),
+		},
+		"key covered": {
+			encodedProofNodes: catProof,
+			rootHash:          rootHash,
+			key:               []byte("cat"),
+			covers:            true,
+		},
+		"absent key covered": {
+			encodedProofNodes: catProof,
+			rootHash:          rootHash,
+			key:               []byte("cow"),
+			covers:            true,
+		},
+		"key path not covered": {
+			encodedProofNodes: catProof,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+		},
+		"wrong root hash": {
+			encodedProofNodes: catProof,
+			rootHash:          []byte{1},
+			key:               []byte("cat"),
+		},
+		"node on path not decodable": {
+			encodedProofNodes: [][]byte{encodeNode(t, badChildBranch), badEncoding},
+			rootHash:          blake2bNode(t, badChildBranch),
+			key:               []byte{0x10},
+			errWrapped:        sub.ErrVariantUnknown,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			covers, err := Covers(testCase.encodedProofNodes, testCase.rootHash, testCase.key)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.covers, covers)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/trie"
+)
+
+var ErrNodeNotInProof = errors.New("node not found in proof")
+
+var _ trie.Database = (*ProofDB)(nil)
+
+// ProofDB is a read only node database backed by encoded proof nodes,
+// mapping each node hash digest to its encoding. It implements the
+// trie.Database interface, so the database based lookup code path of
+// the trie package can be used directly on top of a proof, for example
+// with trie.GetFromDB for a partial proof, or Trie.Load for a proof
+// containing all the nodes of the trie.
+type ProofDB struct {
+	digestToEncoding map[string][]byte
+}
+
+// NewProofDB creates a proof database from the encoded proof nodes given.
+func NewProofDB(encodedProofNodes [][]byte) (db *ProofDB, err error) {
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &ProofDB{
+		digestToEncoding: digestToEncoding,
+	}, nil
+}
+
+// Get returns the encoding of the node with the hash digest given,
+// or an error wrapping ErrNodeNotInProof if it is not in the proof.
+// The encoding returned must not be modified.
+func (db *ProofDB) Get(key []byte) (value []byte, err error) {
+	value, ok := db.digestToEncoding[string(key)]
+	if !ok {
+		return nil, fmt.Errorf("%w: for hash digest 0x%x", ErrNodeNotInProof, key)
+	}
+	return value, nil
+}
+
+// Has returns true if the node with the hash digest given is in the proof.
+func (db *ProofDB) Has(key []byte) (has bool, err error) {
+	_, has = db.digestToEncoding[string(key)]
+	return has, nil
+}
+
+// Len returns the number of distinct nodes in the proof.
+func (db *ProofDB) Len() (length int) {
+	return len(db.digestToEncoding)
+}
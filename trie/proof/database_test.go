@@ -0,0 +1,91 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ProofDB(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": generateBytes(t, 40),
+		"dog":       generateBytes(t, 33),
+		"doguinho":  []byte("doguinho"),
+	}
+
+	tr := trie.NewEmptyTrie()
+	keys := make([][]byte, 0, len(keyValues))
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+		keys = append(keys, []byte(key))
+	}
+	rootHash := tr.MustHash()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	t.Run("partial_proof", func(t *testing.T) {
+		t.Parallel()
+
+		encodedProofNodes, err := Generate(rootHash.ToBytes(),
+			[][]byte{[]byte("catapulta")}, database)
+		require.NoError(t, err)
+
+		proofDB, err := NewProofDB(encodedProofNodes)
+		require.NoError(t, err)
+		assert.Equal(t, len(encodedProofNodes), proofDB.Len())
+
+		has, err := proofDB.Has(rootHash.ToBytes())
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		value, err := trie.GetFromDB(proofDB, rootHash, []byte("catapulta"))
+		require.NoError(t, err)
+		assert.Equal(t, keyValues["catapulta"], value)
+
+		_, err = trie.GetFromDB(proofDB, rootHash, []byte("dog"))
+		assert.ErrorIs(t, err, ErrNodeNotInProof)
+
+		_, err = proofDB.Get([]byte{1})
+		assert.ErrorIs(t, err, ErrNodeNotInProof)
+		assert.EqualError(t, err, "node not found in proof: for hash digest 0x01")
+	})
+
+	t.Run("full_proof", func(t *testing.T) {
+		t.Parallel()
+
+		encodedProofNodes, err := Generate(rootHash.ToBytes(), keys, database)
+		require.NoError(t, err)
+
+		proofDB, err := NewProofDB(encodedProofNodes)
+		require.NoError(t, err)
+
+		proofTrie := trie.NewEmptyTrie()
+		err = proofTrie.Load(proofDB, rootHash)
+		require.NoError(t, err)
+
+		assert.Equal(t, keyValues, proofTrie.Entries())
+		assert.Equal(t, rootHash, proofTrie.MustHash())
+	})
+
+	t.Run("empty_root", func(t *testing.T) {
+		t.Parallel()
+
+		proofDB, err := NewProofDB(nil)
+		require.NoError(t, err)
+
+		value, err := trie.GetFromDB(proofDB, trie.EmptyHash, []byte("cat"))
+		require.NoError(t, err)
+		assert.Nil(t, value)
+	})
+}
@@ -0,0 +1,67 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// NodeDecodeError is the error returned when an encoded proof node
+// cannot be decoded. It records where the malformed node is in the
+// proof so the party which provided it can be identified, and wraps
+// the decoding error such as sub.ErrVariantUnknown.
+type NodeDecodeError struct {
+	// Index is the index of the node in the encoded proof nodes,
+	// or -1 if it is not known, for example when loading the
+	// proof from a map of hash digest to encoding.
+	Index int
+	// Digest is the hash digest of the node encoding.
+	Digest []byte
+	// Offset is the byte offset in the node encoding
+	// at which the decoding stopped.
+	Offset int
+	// Err is the decoding error.
+	Err error
+}
+
+func (e *NodeDecodeError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("proof node with hash digest 0x%x: at byte offset %d: %s",
+			e.Digest, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("proof node at index %d with hash digest 0x%x: at byte offset %d: %s",
+		e.Index, e.Digest, e.Offset, e.Err)
+}
+
+func (e *NodeDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeProofNode decodes the encoded proof node given, and returns
+// a *NodeDecodeError with the index and hash digest given on failure.
+func decodeProofNode(encoding []byte, index int, digest []byte) (
+	node *sub.Node, err error) {
+	reader := bytes.NewReader(encoding)
+	node, err = sub.Decode(reader)
+	if err != nil {
+		return nil, &NodeDecodeError{
+			Index:  index,
+			Digest: append([]byte{}, digest...),
+			Offset: len(encoding) - reader.Len(),
+			Err:    err,
+		}
+	}
+	return node, nil
+}
+
+// nodeIndex returns the index of the encoding given in the encoded
+// proof nodes given, or -1 if it is not found.
+func nodeIndex(encodedProofNodes [][]byte, encoding []byte) (index int) {
+	for i, encodedProofNode := range encodedProofNodes {
+		if bytes.Equal(encodedProofNode, encoding) {
+			return i
+		}
+	}
+	return -1
+}
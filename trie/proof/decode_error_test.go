@@ -0,0 +1,102 @@
+package proof
+
+import (
+	"errors"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDecodeTest = errors.New("test error")
+
+func Test_NodeDecodeError_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		err     *NodeDecodeError
+		message string
+	}{
+		"index known": {
+			err: &NodeDecodeError{
+				Index:  2,
+				Digest: []byte{1, 2},
+				Offset: 3,
+				Err:    errDecodeTest,
+			},
+			message: "proof node at index 2 with hash digest 0x0102: " +
+				"at byte offset 3: test error",
+		},
+		"index unknown": {
+			err: &NodeDecodeError{
+				Index:  -1,
+				Digest: []byte{1, 2},
+				Offset: 3,
+				Err:    errDecodeTest,
+			},
+			message: "proof node with hash digest 0x0102: " +
+				"at byte offset 3: test error",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.EqualError(t, testCase.err, testCase.message)
+			assert.ErrorIs(t, testCase.err, errDecodeTest)
+		})
+	}
+}
+
+func Test_decodeProofNode(t *testing.T) {
+	t.Parallel()
+
+	leaf := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: generateBytes(t, 40),
+	}
+
+	node, err := decodeProofNode(encodeNode(t, leaf), 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, leaf.StorageValue, node.StorageValue)
+
+	truncatedBranch := []byte{
+		0b1000_0000 | 0b0000_0001, // branch with key size 1
+		1,                         // key
+		0b0000_0001, 0b0000_0000,  // children bitmap
+		// missing child hash
+	}
+	_, err = decodeProofNode(truncatedBranch, 4, []byte{9})
+
+	var decodeErr *NodeDecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 4, decodeErr.Index)
+	assert.Equal(t, []byte{9}, decodeErr.Digest)
+	assert.Equal(t, len(truncatedBranch), decodeErr.Offset)
+	assert.ErrorIs(t, err, sub.ErrDecodeChildHash)
+}
+
+func Test_BuildTrie_NodeDecodeError(t *testing.T) {
+	t.Parallel()
+
+	badEncoding := getBadNodeEncoding()
+	branchEncoding := concatBytes([][]byte{
+		{0b1000_0000 | 0b0000_0001},             // branch with key size 1
+		{1},                                     // key
+		{0b0000_0001, 0b0000_0000},              // children bitmap
+		scaleEncode(t, blake2b(t, badEncoding)), // child hash
+	})
+	encodedProofNodes := [][]byte{branchEncoding, badEncoding}
+
+	_, err := BuildTrie(encodedProofNodes, blake2b(t, branchEncoding))
+
+	var decodeErr *NodeDecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 1, decodeErr.Index)
+	assert.Equal(t, blake2b(t, badEncoding), decodeErr.Digest)
+	assert.Equal(t, 1, decodeErr.Offset)
+	assert.ErrorIs(t, err, sub.ErrVariantUnknown)
+}
@@ -0,0 +1,185 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// NodeDiff describes the differences between the nodes at the same
+// depth on a key path in two proofs.
+type NodeDiff struct {
+	// Depth is the depth of the nodes on the key path,
+	// where the root node has a depth of 0.
+	Depth int
+	// MerkleValueA is the Merkle value of the node in the first proof,
+	// and is nil if the key path ends before this depth.
+	MerkleValueA []byte
+	// MerkleValueB is the Merkle value of the node in the second proof,
+	// and is nil if the key path ends before this depth.
+	MerkleValueB []byte
+	// PartialKeyChanged is true if both nodes exist
+	// and their partial keys differ.
+	PartialKeyChanged bool
+	// StorageValueChanged is true if both nodes exist
+	// and their storage values differ.
+	StorageValueChanged bool
+	// ChildrenChanged are the indexes of the children whose Merkle
+	// values differ, if both nodes exist.
+	ChildrenChanged []byte
+}
+
+// Changed returns true if the nodes differ.
+func (d NodeDiff) Changed() bool {
+	return !bytes.Equal(d.MerkleValueA, d.MerkleValueB)
+}
+
+// DiffKeyPath compares the proofs of the same (Little Endian) key for
+// two trie roots, and returns the differences between the nodes at each
+// depth of the key path, from the root node down. This helps to find
+// out why a proof verifies against one block and not against the next.
+// It returns an error wrapping ErrKeyNotFoundInProofTrie if a proof
+// lacks a node on the key path.
+func DiffKeyPath(encodedProofNodesA [][]byte, rootHashA []byte,
+	encodedProofNodesB [][]byte, rootHashB []byte, key []byte) (
+	diffs []NodeDiff, err error) {
+	pathA, err := keyPathNodes(encodedProofNodesA, rootHashA, key)
+	if err != nil {
+		return nil, fmt.Errorf("walking first proof: %w", err)
+	}
+
+	pathB, err := keyPathNodes(encodedProofNodesB, rootHashB, key)
+	if err != nil {
+		return nil, fmt.Errorf("walking second proof: %w", err)
+	}
+
+	depth := len(pathA)
+	if len(pathB) > depth {
+		depth = len(pathB)
+	}
+
+	diffs = make([]NodeDiff, depth)
+	for i := range diffs {
+		diffs[i].Depth = i
+		if i < len(pathA) {
+			diffs[i].MerkleValueA = pathA[i].merkleValue
+		}
+		if i < len(pathB) {
+			diffs[i].MerkleValueB = pathB[i].merkleValue
+		}
+		if i >= len(pathA) || i >= len(pathB) {
+			continue
+		}
+
+		nodeA, nodeB := pathA[i].node, pathB[i].node
+		diffs[i].PartialKeyChanged = !bytes.Equal(nodeA.PartialKey, nodeB.PartialKey)
+		diffs[i].StorageValueChanged = !bytes.Equal(nodeA.StorageValue, nodeB.StorageValue) ||
+			(nodeA.StorageValue == nil) != (nodeB.StorageValue == nil)
+		diffs[i].ChildrenChanged, err = childrenChanged(nodeA, nodeB)
+		if err != nil {
+			return nil, fmt.Errorf("comparing children at depth %d: %w", i, err)
+		}
+	}
+
+	return diffs, nil
+}
+
+type pathNode struct {
+	node        *sub.Node
+	merkleValue []byte
+}
+
+// keyPathNodes returns the nodes on the key path in the proof given,
+// from the root node down to the last node on the path.
+func keyPathNodes(encodedProofNodes [][]byte, rootHash, key []byte) (
+	path []pathNode, err error) {
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	rootEncoding, ok := digestToEncoding[string(rootHash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: for root hash 0x%x",
+			ErrRootNodeNotFound, rootHash)
+	}
+
+	node, err := decodeProofNode(rootEncoding,
+		nodeIndex(encodedProofNodes, rootEncoding), rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding root node: %w", err)
+	}
+	merkleValue := rootHash
+
+	keyNibbles := sub.KeyLEToNibbles(key)
+	for {
+		path = append(path, pathNode{node: node, merkleValue: merkleValue})
+
+		if !bytes.HasPrefix(keyNibbles, node.PartialKey) {
+			return path, nil
+		}
+		keyNibbles = keyNibbles[len(node.PartialKey):]
+		if len(keyNibbles) == 0 || node.Kind() == sub.Leaf {
+			return path, nil
+		}
+
+		child := node.Children[keyNibbles[0]]
+		keyNibbles = keyNibbles[1:]
+		if child == nil {
+			return path, nil
+		}
+
+		if len(child.NodeValue) < sub.INLINE_LEN {
+			// child is inlined and already decoded
+			merkleValue, err = child.CalculateMerkleValue()
+			if err != nil {
+				return nil, fmt.Errorf("computing inlined node Merkle value: %w", err)
+			}
+			node = child
+			continue
+		}
+
+		merkleValue = child.NodeValue
+		encoding, ok := digestToEncoding[string(merkleValue)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s in proof trie for root hash 0x%x: "+
+				"node for hash digest 0x%x not in proof",
+				ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash, merkleValue)
+		}
+
+		node, err = decodeProofNode(encoding,
+			nodeIndex(encodedProofNodes, encoding), merkleValue)
+		if err != nil {
+			return nil, fmt.Errorf("decoding node: %w", err)
+		}
+	}
+}
+
+// childrenChanged returns the indexes of the children
+// whose Merkle values differ between the two nodes given.
+func childrenChanged(nodeA, nodeB *sub.Node) (indexes []byte, err error) {
+	childrenA, err := nodeA.ChildrenMerkleValues()
+	if err != nil {
+		return nil, err
+	}
+	childrenB, err := nodeB.ChildrenMerkleValues()
+	if err != nil {
+		return nil, err
+	}
+
+	var merkleValuesA, merkleValuesB [sub.ChildrenCapacity][]byte
+	for _, child := range childrenA {
+		merkleValuesA[child.Index] = child.MerkleValue
+	}
+	for _, child := range childrenB {
+		merkleValuesB[child.Index] = child.MerkleValue
+	}
+
+	for i := range merkleValuesA {
+		if !bytes.Equal(merkleValuesA[i], merkleValuesB[i]) {
+			indexes = append(indexes, byte(i))
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,99 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiffKeyPath(t *testing.T) {
+	t.Parallel()
+
+	entries := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": generateBytes(t, 40),
+		"dog":       generateBytes(t, 40),
+	}
+	newTrie := func(changes map[string][]byte) *trie.Trie {
+		tr := trie.NewEmptyTrie()
+		for key, value := range entries {
+			tr.Put([]byte(key), value)
+		}
+		for key, value := range changes {
+			tr.Put([]byte(key), value)
+		}
+		return tr
+	}
+
+	trieA := newTrie(nil)
+	rootHashA := trieA.MustHash().ToBytes()
+	dogProofA, err := GenerateFromTrie(trieA, [][]byte{[]byte("dog")})
+	require.NoError(t, err)
+
+	t.Run("value_changed", func(t *testing.T) {
+		t.Parallel()
+
+		trieB := newTrie(map[string][]byte{"dog": generateBytes(t, 41)})
+		rootHashB := trieB.MustHash().ToBytes()
+		dogProofB, err := GenerateFromTrie(trieB, [][]byte{[]byte("dog")})
+		require.NoError(t, err)
+
+		diffs, err := DiffKeyPath(dogProofA, rootHashA, dogProofB, rootHashB, []byte("dog"))
+		require.NoError(t, err)
+		require.Len(t, diffs, 2)
+
+		assert.True(t, diffs[0].Changed())
+		assert.Equal(t, rootHashA, diffs[0].MerkleValueA)
+		assert.Equal(t, rootHashB, diffs[0].MerkleValueB)
+		assert.False(t, diffs[0].PartialKeyChanged)
+		assert.False(t, diffs[0].StorageValueChanged)
+		assert.Equal(t, []byte{4}, diffs[0].ChildrenChanged)
+
+		assert.True(t, diffs[1].Changed())
+		assert.Equal(t, 1, diffs[1].Depth)
+		assert.True(t, diffs[1].StorageValueChanged)
+		assert.Empty(t, diffs[1].ChildrenChanged)
+	})
+
+	t.Run("sibling_changed", func(t *testing.T) {
+		t.Parallel()
+
+		trieB := newTrie(map[string][]byte{"cat": generateBytes(t, 41)})
+		rootHashB := trieB.MustHash().ToBytes()
+		dogProofB, err := GenerateFromTrie(trieB, [][]byte{[]byte("dog")})
+		require.NoError(t, err)
+
+		diffs, err := DiffKeyPath(dogProofA, rootHashA, dogProofB, rootHashB, []byte("dog"))
+		require.NoError(t, err)
+		require.Len(t, diffs, 2)
+		assert.True(t, diffs[0].Changed())
+		assert.Equal(t, []byte{3}, diffs[0].ChildrenChanged)
+		assert.False(t, diffs[1].Changed())
+	})
+
+	t.Run("path_shortened", func(t *testing.T) {
+		t.Parallel()
+
+		trieB := trie.NewEmptyTrie()
+		trieB.Put([]byte("dog"), generateBytes(t, 40))
+		rootHashB := trieB.MustHash().ToBytes()
+		dogProofB, err := GenerateFromTrie(trieB, [][]byte{[]byte("dog")})
+		require.NoError(t, err)
+
+		diffs, err := DiffKeyPath(dogProofA, rootHashA, dogProofB, rootHashB, []byte("dog"))
+		require.NoError(t, err)
+		require.Len(t, diffs, 2)
+		assert.True(t, diffs[0].PartialKeyChanged)
+		assert.True(t, diffs[1].Changed())
+		assert.Nil(t, diffs[1].MerkleValueB)
+	})
+
+	t.Run("missing_node", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := DiffKeyPath(dogProofA[:1], rootHashA, dogProofA, rootHashA, []byte("dog"))
+		assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+	})
+}
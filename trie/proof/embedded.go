@@ -0,0 +1,224 @@
+package proof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var (
+	ErrEmbeddedProofMalformed = errors.New("embedded proof malformed")
+	ErrEmbeddedProofPath      = errors.New("embedded proof path does not match key")
+	ErrEmbeddedProofNodes     = errors.New("embedded proof nodes mismatch")
+)
+
+// EmbeddedProof is a compact proof of a single key, designed for
+// constrained verification environments such as on-chain light clients.
+// Its encoded proof nodes are concatenated in a single blob in the
+// order they are visited from the root node down to the node holding
+// the key, and its path descriptor contains the child index taken at
+// each branch visited, including inlined branches.
+type EmbeddedProof struct {
+	// Blob is the concatenation of the encoded proof nodes.
+	Blob []byte
+	// Offsets contains the end offset in Blob of each encoded proof node.
+	Offsets []uint32
+	// Path contains the child index (nibble) taken at each branch
+	// from the root node to the node holding the key.
+	Path []byte
+}
+
+// NewEmbeddedProof creates an embedded proof for the key given from
+// the encoded proof nodes given, which can be in any order and may
+// contain nodes for other keys. Only the nodes on the path from the
+// root to the key are kept.
+func NewEmbeddedProof(encodedProofNodes [][]byte, rootHash, key []byte) (
+	proof EmbeddedProof, err error) {
+	if len(encodedProofNodes) == 0 {
+		return proof, fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return proof, err
+	}
+
+	nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+		encoding, ok := digestToEncoding[string(merkleValue)]
+		if !ok {
+			if bytes.Equal(merkleValue, rootHash) {
+				return nil, fmt.Errorf("%w: for root hash 0x%x",
+					ErrRootNodeNotFound, rootHash)
+			}
+			return nil, fmt.Errorf("%w: for hash digest 0x%x",
+				ErrEmbeddedProofNodes, merkleValue)
+		}
+		proof.Blob = append(proof.Blob, encoding...)
+		proof.Offsets = append(proof.Offsets, uint32(len(proof.Blob)))
+		return encoding, nil
+	}
+
+	value, path, err := walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, VerifyOptions{})
+	if err != nil {
+		return EmbeddedProof{}, err
+	} else if value == nil {
+		return EmbeddedProof{}, fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+	proof.Path = path
+
+	return proof, nil
+}
+
+// Node returns the encoded proof node at the index given.
+func (p EmbeddedProof) Node(index int) (encoding []byte) {
+	start := uint32(0)
+	if index > 0 {
+		start = p.Offsets[index-1]
+	}
+	return p.Blob[start:p.Offsets[index]]
+}
+
+// Encode encodes the embedded proof as:
+// uvarint(nodes count) | uvarint(node length)... |
+// uvarint(path length) | path nibbles packed two per byte | blob.
+func (p EmbeddedProof) Encode() (encoded []byte) {
+	encoded = make([]byte, 0, len(p.Blob)+len(p.Offsets)*2+len(p.Path)/2+2*binary.MaxVarintLen32)
+	encoded = appendUvarint(encoded, uint64(len(p.Offsets)))
+	previousOffset := uint32(0)
+	for _, offset := range p.Offsets {
+		encoded = appendUvarint(encoded, uint64(offset-previousOffset))
+		previousOffset = offset
+	}
+
+	encoded = appendUvarint(encoded, uint64(len(p.Path)))
+	for i := 0; i < len(p.Path); i += 2 {
+		packed := p.Path[i] << 4
+		if i+1 < len(p.Path) {
+			packed |= p.Path[i+1] & 0xf
+		}
+		encoded = append(encoded, packed)
+	}
+
+	return append(encoded, p.Blob...)
+}
+
+// DecodeEmbeddedProof decodes an embedded proof encoded with Encode.
+func DecodeEmbeddedProof(encoded []byte) (proof EmbeddedProof, err error) {
+	reader := bytes.NewReader(encoded)
+
+	nodesCount, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return proof, fmt.Errorf("%w: reading nodes count: %s",
+			ErrEmbeddedProofMalformed, err)
+	} else if nodesCount > uint64(reader.Len()) {
+		// each node length takes at least one byte
+		return proof, fmt.Errorf("%w: nodes count %d exceeds remaining %d bytes",
+			ErrEmbeddedProofMalformed, nodesCount, reader.Len())
+	}
+
+	proof.Offsets = make([]uint32, nodesCount)
+	offset := uint64(0)
+	for i := range proof.Offsets {
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return proof, fmt.Errorf("%w: reading length of node %d: %s",
+				ErrEmbeddedProofMalformed, i, err)
+		}
+		offset += length
+		if offset > uint64(len(encoded)) {
+			return proof, fmt.Errorf("%w: node %d ends at offset %d beyond %d bytes",
+				ErrEmbeddedProofMalformed, i, offset, len(encoded))
+		}
+		proof.Offsets[i] = uint32(offset)
+	}
+
+	pathLength, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return proof, fmt.Errorf("%w: reading path length: %s",
+			ErrEmbeddedProofMalformed, err)
+	}
+	// Note the path length is checked against the remaining bytes
+	// before any arithmetic on it, since it can be as large as 2^64-1.
+	const nibblesPerByte = 2
+	if pathLength > nibblesPerByte*uint64(reader.Len()) {
+		return proof, fmt.Errorf("%w: path length %d exceeds remaining %d bytes",
+			ErrEmbeddedProofMalformed, pathLength, reader.Len())
+	}
+
+	proof.Path = make([]byte, pathLength)
+	for i := 0; i < int(pathLength); i += 2 {
+		packed, _ := reader.ReadByte() // length checked above
+		proof.Path[i] = packed >> 4
+		if i+1 < int(pathLength) {
+			proof.Path[i+1] = packed & 0xf
+		}
+	}
+
+	proof.Blob = encoded[len(encoded)-reader.Len():]
+	if offset != uint64(len(proof.Blob)) {
+		return proof, fmt.Errorf("%w: nodes total length %d does not match blob length %d",
+			ErrEmbeddedProofMalformed, offset, len(proof.Blob))
+	}
+
+	return proof, nil
+}
+
+// VerifyEmbedded verifies the key and value given belong to the trie
+// with the root hash given, using the embedded proof given. The value
+// is only compared if it is not empty. Only the nodes on the key path
+// are decoded, and the proof must not contain any other node.
+// A node not matching its hash digest results in an error wrapping
+// ErrProofHashMismatch.
+func VerifyEmbedded(proof EmbeddedProof, rootHash, key, value []byte) (err error) {
+	if len(proof.Offsets) == 0 {
+		return fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	nodeIndex := 0
+	nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+		if nodeIndex >= len(proof.Offsets) {
+			return nil, fmt.Errorf("%w: missing node for hash digest 0x%x",
+				ErrEmbeddedProofNodes, merkleValue)
+		}
+		encoding = proof.Node(nodeIndex)
+		nodeIndex++
+		return encoding, nil
+	}
+
+	proofValue, path, err := walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, VerifyOptions{})
+	if err != nil {
+		return err
+	}
+
+	if nodeIndex != len(proof.Offsets) {
+		return fmt.Errorf("%w: %d extraneous nodes",
+			ErrEmbeddedProofNodes, len(proof.Offsets)-nodeIndex)
+	} else if !bytes.Equal(path, proof.Path) {
+		return fmt.Errorf("%w: path descriptor %x but key path is %x",
+			ErrEmbeddedProofPath, proof.Path, path)
+	}
+
+	if proofValue == nil {
+		return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	if len(value) > 0 && !bytes.Equal(value, proofValue) {
+		return fmt.Errorf("%w: expected value %s but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofValue))
+	}
+
+	return nil
+}
+
+func appendUvarint(b []byte, x uint64) []byte {
+	var encoded [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(encoded[:], x)
+	return append(b, encoded[:n]...)
+}
@@ -0,0 +1,186 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EmbeddedProof(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": []byte("catapulta"),
+		"dog":       generateBytes(t, 33),
+		"doguinho":  []byte("doguinho"),
+	}
+
+	tr := trie.NewEmptyTrie()
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	// The proof contains nodes for both keys, in any order.
+	encodedProofNodes, err := Generate(rootHash,
+		[][]byte{[]byte("dog"), []byte("catapulta")}, database)
+	require.NoError(t, err)
+
+	key := []byte("catapulta")
+	embedded, err := NewEmbeddedProof(encodedProofNodes, rootHash, key)
+	require.NoError(t, err)
+	assert.Less(t, len(embedded.Offsets), len(encodedProofNodes))
+	assert.Equal(t, uint32(len(embedded.Blob)), embedded.Offsets[len(embedded.Offsets)-1])
+
+	encoded := embedded.Encode()
+	decoded, err := DecodeEmbeddedProof(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, embedded, decoded)
+
+	err = VerifyEmbedded(decoded, rootHash, key, keyValues["catapulta"])
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		proof      func() EmbeddedProof
+		key        []byte
+		value      []byte
+		errWrapped error
+	}{
+		"value_mismatch": {
+			proof:      func() EmbeddedProof { return embedded },
+			key:        key,
+			value:      []byte("catapultb"),
+			errWrapped: ErrValueMismatchProofTrie,
+		},
+		"other_key": {
+			proof:      func() EmbeddedProof { return embedded },
+			key:        []byte("doguinho"),
+			errWrapped: ErrProofHashMismatch,
+		},
+		"tampered_blob": {
+			proof: func() EmbeddedProof {
+				tampered := embedded
+				tampered.Blob = append([]byte{}, embedded.Blob...)
+				tampered.Blob[len(tampered.Blob)-1]++
+				return tampered
+			},
+			key:        key,
+			errWrapped: ErrProofHashMismatch,
+		},
+		"extraneous_node": {
+			proof: func() EmbeddedProof {
+				extra := embedded
+				extra.Blob = append(append([]byte{}, embedded.Blob...), 0)
+				extra.Offsets = append(append([]uint32{}, embedded.Offsets...),
+					uint32(len(extra.Blob)))
+				return extra
+			},
+			key:        key,
+			errWrapped: ErrEmbeddedProofNodes,
+		},
+		"path_mismatch": {
+			proof: func() EmbeddedProof {
+				wrongPath := embedded
+				wrongPath.Path = append([]byte{}, embedded.Path...)
+				wrongPath.Path[0] ^= 0xf
+				return wrongPath
+			},
+			key:        key,
+			errWrapped: ErrEmbeddedProofPath,
+		},
+		"empty_proof": {
+			proof:      func() EmbeddedProof { return EmbeddedProof{} },
+			key:        key,
+			errWrapped: ErrEmptyProof,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyEmbedded(testCase.proof(), rootHash, testCase.key, testCase.value)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+}
+
+func Test_DecodeEmbeddedProof(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		encoded    []byte
+		proof      EmbeddedProof
+		errWrapped error
+		errMessage string
+	}{
+		"empty_input": {
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: reading nodes count: EOF",
+		},
+		"nodes_count_too_large": {
+			encoded:    []byte{3, 1},
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: nodes count 3 exceeds remaining 1 bytes",
+		},
+		"node_length_too_large": {
+			encoded:    []byte{1, 9, 0},
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: node 0 ends at offset 9 beyond 3 bytes",
+		},
+		"path_too_long": {
+			encoded:    []byte{1, 1, 5, 0x12},
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: path length 5 exceeds remaining 1 bytes",
+		},
+		"path_length_max_uint64": {
+			encoded: []byte{1, 1,
+				0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+				0x12},
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: path length 18446744073709551615 exceeds remaining 1 bytes",
+		},
+		"blob_length_mismatch": {
+			encoded:    []byte{1, 2, 1, 0x10, 0xaa},
+			errWrapped: ErrEmbeddedProofMalformed,
+			errMessage: "embedded proof malformed: nodes total length 2 does not match blob length 1",
+		},
+		"success": {
+			encoded: []byte{2, 1, 2, 3, 0x12, 0x30, 0xaa, 0xbb, 0xcc},
+			proof: EmbeddedProof{
+				Blob:    []byte{0xaa, 0xbb, 0xcc},
+				Offsets: []uint32{1, 3},
+				Path:    []byte{1, 2, 3},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proof, err := DecodeEmbeddedProof(testCase.encoded)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.proof, proof)
+			assert.Equal(t, testCase.encoded, proof.Encode())
+		})
+	}
+}
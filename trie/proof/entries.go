@@ -0,0 +1,61 @@
+package proof
+
+import (
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+)
+
+// WalkEntries calls the callback given for each key-value pair disclosed
+// by the proof trie given, as built by BuildTrie, in ascending Little
+// Endian key order. The walk stops if the callback returns false.
+// Subtrees pruned from the proof are not part of the proof trie, and keys
+// with a hashed value (state version 1) whose preimage is not in the proof
+// are skipped, since only the value hash is known.
+func WalkEntries(proofTrie *trie.Trie, callback func(key, value []byte) (keepWalking bool)) {
+	walkEntries(proofTrie.RootNode(), nil, callback)
+}
+
+// Entries returns the key-value pairs disclosed by the proof trie
+// given, as a map of Little Endian keys to values. See WalkEntries
+// for the entries included.
+func Entries(proofTrie *trie.Trie) (entries map[string][]byte) {
+	entries = make(map[string][]byte)
+	WalkEntries(proofTrie, func(key, value []byte) (keepWalking bool) {
+		entries[string(key)] = value
+		return true
+	})
+	return entries
+}
+
+func walkEntries(node *sub.Node, prefix []byte,
+	callback func(key, value []byte) (keepWalking bool)) (stop bool) {
+	if node == nil {
+		return false
+	}
+
+	fullKey := make([]byte, 0, len(prefix)+len(node.PartialKey))
+	fullKey = append(fullKey, prefix...)
+	fullKey = append(fullKey, node.PartialKey...)
+
+	// A branch without value has a nil storage value, which is kept nil
+	// if the branch is converted to a leaf when its children are pruned.
+	if node.StorageValue != nil && !node.IsHashedValue {
+		if !callback(sub.NibblesToKeyLE(fullKey), node.StorageValue) {
+			return true
+		}
+	}
+
+	for i, child := range node.Children {
+		if child == nil {
+			continue
+		}
+		childPrefix := make([]byte, 0, len(fullKey)+1)
+		childPrefix = append(childPrefix, fullKey...)
+		childPrefix = append(childPrefix, byte(i))
+		if walkEntries(child, childPrefix, callback) {
+			return true
+		}
+	}
+
+	return false
+}
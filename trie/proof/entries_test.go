@@ -0,0 +1,103 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Entries(t *testing.T) {
+	t.Parallel()
+
+	largeTrie := trie.NewEmptyTrie()
+	largeTrie.Put([]byte("cat"), generateBytes(t, 40))
+	largeTrie.Put([]byte("dog"), generateBytes(t, 41))
+	largeTrie.Put([]byte("doge"), generateBytes(t, 42))
+	largeProof, err := GenerateFromTrie(largeTrie, [][]byte{[]byte("dog")})
+	require.NoError(t, err)
+
+	smallTrie := trie.NewEmptyTrie()
+	smallTrie.Put([]byte("cat"), []byte{1})
+	smallTrie.Put([]byte("dog"), []byte{2})
+	smallProof, err := GenerateFromTrie(smallTrie, [][]byte{[]byte("dog")})
+	require.NoError(t, err)
+
+	preimage := generateBytes(t, 40)
+	hashedValueLeaf := sub.Node{
+		PartialKey:    []byte{1, 2},
+		StorageValue:  blake2b(t, preimage),
+		IsHashedValue: true,
+	}
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		entries           map[string][]byte
+	}{
+		"pruned subtrees": {
+			encodedProofNodes: largeProof,
+			rootHash:          largeTrie.MustHash().ToBytes(),
+			entries: map[string][]byte{
+				"dog": generateBytes(t, 41),
+			},
+		},
+		"inlined nodes": {
+			encodedProofNodes: smallProof,
+			rootHash:          smallTrie.MustHash().ToBytes(),
+			entries: map[string][]byte{
+				"cat": {1},
+				"dog": {2},
+			},
+		},
+		"hashed value without preimage": {
+			encodedProofNodes: [][]byte{encodeNode(t, hashedValueLeaf)},
+			rootHash:          blake2bNode(t, hashedValueLeaf),
+			entries:           map[string][]byte{},
+		},
+		"hashed value with preimage": {
+			encodedProofNodes: [][]byte{encodeNode(t, hashedValueLeaf), preimage},
+			rootHash:          blake2bNode(t, hashedValueLeaf),
+			entries: map[string][]byte{
+				string([]byte{0x12}): preimage,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proofTrie, err := BuildTrie(testCase.encodedProofNodes, testCase.rootHash)
+			require.NoError(t, err)
+
+			entries := Entries(proofTrie)
+
+			assert.Equal(t, testCase.entries, entries)
+		})
+	}
+}
+
+func Test_WalkEntries(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), []byte{1})
+	tr.Put([]byte("dog"), []byte{2})
+	tr.Put([]byte("doge"), []byte{3})
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("doge")})
+	require.NoError(t, err)
+	proofTrie, err := BuildTrie(encodedProofNodes, tr.MustHash().ToBytes())
+	require.NoError(t, err)
+
+	var keys []string
+	WalkEntries(proofTrie, func(key, value []byte) (keepWalking bool) {
+		keys = append(keys, string(key))
+		return len(keys) < 2
+	})
+
+	assert.Equal(t, []string{"cat", "dog"}, keys)
+}
@@ -0,0 +1,142 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/trie"
+)
+
+// EnvelopeVersion1 is the first version of the envelope format.
+const EnvelopeVersion1 byte = 1
+
+var (
+	ErrEnvelopeEmpty          = errors.New("envelope is empty")
+	ErrEnvelopeVersionUnknown = errors.New("envelope version unknown")
+	ErrEnvelopeMalformed      = errors.New("envelope malformed")
+)
+
+// Envelope is a self-describing container for encoded proof nodes,
+// recording how the proof nodes must be interpreted so a stored proof
+// remains verifiable as the proof format evolves.
+//
+// Its binary format starts with the envelope version byte, followed,
+// for EnvelopeVersion1, by the SCALE encoding of the hasher name, the
+// state version number as defined by the specification (0 for v0
+// and 1 for v1) and the encoded proof nodes.
+type Envelope struct {
+	// Hasher is the name of the trie hasher, which is one of
+	// HasherBlake2b256 and HasherKeccak256. It defaults to
+	// HasherBlake2b256 if left empty.
+	Hasher string
+	// StateVersion is the state trie version of the proof nodes.
+	StateVersion trie.Version
+	// Nodes are the encoded proof nodes.
+	Nodes [][]byte
+}
+
+// envelopeV1 is the SCALE encoded body of an EnvelopeVersion1 envelope.
+type envelopeV1 struct {
+	Hasher       string
+	StateVersion uint8
+	Nodes        [][]byte
+}
+
+// Policy returns the verification policy with the
+// hasher and state version of the envelope.
+func (e Envelope) Policy() Policy {
+	return Policy{
+		Version: e.StateVersion,
+		Hasher:  e.Hasher,
+	}
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+// and encodes the envelope with the EnvelopeVersion1 format.
+func (e Envelope) MarshalBinary() (data []byte, err error) {
+	hasher := e.Hasher
+	if hasher == "" {
+		hasher = HasherBlake2b256
+	}
+
+	_, err = Policy{Hasher: hasher}.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	stateVersion, err := specStateVersion(e.StateVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := scale.Marshal(envelopeV1{
+		Hasher:       hasher,
+		StateVersion: stateVersion,
+		Nodes:        e.Nodes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scale encoding envelope: %w", err)
+	}
+
+	data = make([]byte, 0, 1+len(body))
+	data = append(data, EnvelopeVersion1)
+	return append(data, body...), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It returns an error wrapping ErrEnvelopeVersionUnknown if the envelope
+// version is not supported by this package.
+func (e *Envelope) UnmarshalBinary(data []byte) (err error) {
+	if len(data) == 0 {
+		return ErrEnvelopeEmpty
+	}
+
+	switch data[0] {
+	case EnvelopeVersion1:
+	default:
+		return fmt.Errorf("%w: %d", ErrEnvelopeVersionUnknown, data[0])
+	}
+
+	var body envelopeV1
+	err = scale.Unmarshal(data[1:], &body)
+	if err != nil {
+		return fmt.Errorf("%w: scale decoding: %s", ErrEnvelopeMalformed, err)
+	}
+
+	_, err = Policy{Hasher: body.Hasher}.Options()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrEnvelopeMalformed, err)
+	}
+
+	var stateVersion trie.Version
+	switch body.StateVersion {
+	case 0:
+		stateVersion = trie.V0
+	case 1:
+		stateVersion = trie.V1
+	default:
+		return fmt.Errorf("%w: state version %d is unknown",
+			ErrEnvelopeMalformed, body.StateVersion)
+	}
+
+	*e = Envelope{
+		Hasher:       body.Hasher,
+		StateVersion: stateVersion,
+		Nodes:        body.Nodes,
+	}
+	return nil
+}
+
+// specStateVersion returns the state version number
+// as defined by the specification.
+func specStateVersion(version trie.Version) (number uint8, err error) {
+	switch version {
+	case trie.V0:
+		return 0, nil
+	case trie.V1:
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("%w: %d", trie.ErrVersionUnknown, version)
+	}
+}
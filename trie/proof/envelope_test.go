@@ -0,0 +1,167 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Envelope_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		envelope   Envelope
+		data       []byte
+		errWrapped error
+		errMessage string
+	}{
+		"default hasher": {
+			envelope: Envelope{
+				StateVersion: trie.V1,
+				Nodes:        [][]byte{{1, 2}},
+			},
+			data: concatBytes([][]byte{
+				{EnvelopeVersion1},
+				{11 << 2}, []byte(HasherBlake2b256),
+				{1},            // state version
+				{1 << 2},       // nodes count
+				{2 << 2, 1, 2}, // node
+			}),
+		},
+		"keccak hasher and state version 0": {
+			envelope: Envelope{
+				Hasher:       HasherKeccak256,
+				StateVersion: trie.V0,
+			},
+			data: concatBytes([][]byte{
+				{EnvelopeVersion1},
+				{10 << 2}, []byte(HasherKeccak256),
+				{0}, // state version
+				{0}, // nodes count
+			}),
+		},
+		"unknown hasher": {
+			envelope:   Envelope{Hasher: "sha1", StateVersion: trie.V0},
+			errWrapped: ErrHasherUnknown,
+			errMessage: `hasher unknown: "sha1"`,
+		},
+		"state version not set": {
+			errWrapped: trie.ErrVersionUnknown,
+			errMessage: "version unknown: 0",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := testCase.envelope.MarshalBinary()
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.data, data)
+		})
+	}
+}
+
+func Test_Envelope_UnmarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		data       []byte
+		envelope   Envelope
+		errWrapped error
+		errMessage string
+	}{
+		"empty data": {
+			errWrapped: ErrEnvelopeEmpty,
+			errMessage: "envelope is empty",
+		},
+		"unknown envelope version": {
+			data:       []byte{2},
+			errWrapped: ErrEnvelopeVersionUnknown,
+			errMessage: "envelope version unknown: 2",
+		},
+		"malformed body": {
+			data:       []byte{EnvelopeVersion1},
+			errWrapped: ErrEnvelopeMalformed,
+		},
+		"unknown hasher": {
+			data: concatBytes([][]byte{
+				{EnvelopeVersion1},
+				{4 << 2}, []byte("sha1"),
+				{0}, {0},
+			}),
+			errWrapped: ErrEnvelopeMalformed,
+			errMessage: `envelope malformed: hasher unknown: "sha1"`,
+		},
+		"unknown state version": {
+			data: concatBytes([][]byte{
+				{EnvelopeVersion1},
+				{11 << 2}, []byte(HasherBlake2b256),
+				{2}, {0},
+			}),
+			errWrapped: ErrEnvelopeMalformed,
+			errMessage: "envelope malformed: state version 2 is unknown",
+		},
+		"success": {
+			data: concatBytes([][]byte{
+				{EnvelopeVersion1},
+				{11 << 2}, []byte(HasherBlake2b256),
+				{1},
+				{1 << 2}, {2 << 2, 1, 2},
+			}),
+			envelope: Envelope{
+				Hasher:       HasherBlake2b256,
+				StateVersion: trie.V1,
+				Nodes:        [][]byte{{1, 2}},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var envelope Envelope
+			err := envelope.UnmarshalBinary(testCase.data)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.envelope, envelope)
+		})
+	}
+}
+
+func Test_Envelope_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), []byte{1})
+	tr.Put([]byte("dog"), []byte{2})
+	rootHash := tr.MustHash().ToBytes()
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+
+	data, err := Envelope{
+		StateVersion: trie.V1,
+		Nodes:        encodedProofNodes,
+	}.MarshalBinary()
+	require.NoError(t, err)
+
+	var envelope Envelope
+	err = envelope.UnmarshalBinary(data)
+	require.NoError(t, err)
+
+	err = VerifyWithPolicy(envelope.Nodes, rootHash, []byte("cat"), []byte{1},
+		envelope.Policy())
+	assert.NoError(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 
 	sub "github.com/octopus-network/trie-go/substrate"
 	"github.com/octopus-network/trie-go/util"
@@ -31,13 +32,33 @@ func Generate(rootHash []byte, fullKeys [][]byte, database Database) (
 	if err := trie.Load(database, util.BytesToHash(rootHash)); err != nil {
 		return nil, fmt.Errorf("loading trie: %w", err)
 	}
-	rootNode := trie.RootNode()
+	return GenerateFromTrie(trie, fullKeys)
+}
+
+// GenerateFromTrie generates and deduplicates the encoded proof nodes
+// for the in-memory trie given and for the slice of (Little Endian)
+// full keys given. Unlike Generate, the trie does not need to be
+// written to a database first, which is useful for tries built in
+// memory, for example from genesis data.
+// The encoded proof nodes are in the canonical order described in
+// Canonical, regardless of the order of the full keys given.
+func GenerateFromTrie(t *trie.Trie, fullKeys [][]byte) (
+	encodedProofNodes [][]byte, err error) {
+	rootNode := t.RootNode()
 
-	buffer := sub.DigestBuffers.Get().(*bytes.Buffer)
+	// Walking the keys in ascending order and deduplicating the nodes
+	// on their paths yields the nodes in the trie pre-order.
+	sortedKeys := make([][]byte, len(fullKeys))
+	copy(sortedKeys, fullKeys)
+	sort.Slice(sortedKeys, func(i, j int) bool {
+		return bytes.Compare(sortedKeys[i], sortedKeys[j]) < 0
+	})
+
+	buffer := sub.DigestBuffers.Get()
 	defer sub.DigestBuffers.Put(buffer)
 
 	merkleValuesSeen := make(map[string]struct{})
-	for _, fullKey := range fullKeys {
+	for _, fullKey := range sortedKeys {
 		fullKeyNibbles := sub.KeyLEToNibbles(fullKey)
 		newEncodedProofNodes, err := walkRoot(rootNode, fullKeyNibbles)
 		if err != nil {
@@ -87,6 +108,9 @@ func walkRoot(root *sub.Node, fullKey []byte) (
 
 	nodeFound := len(fullKey) == 0 || bytes.Equal(root.PartialKey, fullKey)
 	if nodeFound {
+		if bytes.Equal(root.PartialKey, fullKey) {
+			return appendValuePreimage(encodedProofNodes, root)
+		}
 		return encodedProofNodes, nil
 	}
 
@@ -139,6 +163,9 @@ func walk(parent *sub.Node, fullKey []byte) (
 
 	nodeFound := len(fullKey) == 0 || bytes.Equal(parent.PartialKey, fullKey)
 	if nodeFound {
+		if bytes.Equal(parent.PartialKey, fullKey) {
+			return appendValuePreimage(encodedProofNodes, parent)
+		}
 		return encodedProofNodes, nil
 	}
 
@@ -164,6 +191,24 @@ func walk(parent *sub.Node, fullKey []byte) (
 	return encodedProofNodes, nil
 }
 
+// appendValuePreimage appends the storage value of the node given to
+// the encoded proof nodes if the node encoding only contains the hash of
+// its storage value (state version 1), so the value can be verified.
+// It returns an error wrapping trie.ErrValueNotLoaded if the node only
+// holds the hash of its storage value.
+func appendValuePreimage(encodedProofNodes [][]byte, node *sub.Node) (
+	newEncodedProofNodes [][]byte, err error) {
+	switch {
+	case node.MustBeHashed:
+		return append(encodedProofNodes, node.StorageValue), nil
+	case node.IsHashedValue:
+		return nil, fmt.Errorf("%w: for value hash digest 0x%x",
+			trie.ErrValueNotLoaded, node.StorageValue)
+	default:
+		return encodedProofNodes, nil
+	}
+}
+
 // lenCommonPrefix returns the length of the
 // common prefix between two byte slices.
 func lenCommonPrefix(a, b []byte) (length int) {
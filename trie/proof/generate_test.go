@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/ChainSafe/chaindb"
 	"github.com/golang/mock/gomock"
 	sub "github.com/octopus-network/trie-go/substrate"
 	"github.com/octopus-network/trie-go/trie"
@@ -283,6 +284,33 @@ func Test_walkRoot(t *testing.T) {
 		},
 		// The parent encode error cannot be triggered here
 		// since it can only be caused by a buffer.Write error.
+		"parent leaf with value to hash": {
+			parent: &sub.Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: largeValue,
+				MustBeHashed: true,
+			},
+			fullKey: []byte{1, 2},
+			encodedProofNodes: [][]byte{
+				encodeNode(t, sub.Node{
+					PartialKey:   []byte{1, 2},
+					StorageValue: largeValue,
+					MustBeHashed: true,
+				}),
+				largeValue,
+			},
+		},
+		"parent leaf with hashed value": {
+			parent: &sub.Node{
+				PartialKey:    []byte{1, 2},
+				StorageValue:  make([]byte, 32),
+				IsHashedValue: true,
+			},
+			fullKey:    []byte{1, 2},
+			errWrapped: trie.ErrValueNotLoaded,
+			errMessage: "value not loaded: for value hash digest " +
+				"0x0000000000000000000000000000000000000000000000000000000000000000",
+		},
 		"parent leaf and empty full key": {
 			parent: &sub.Node{
 				PartialKey:   []byte{1, 2},
@@ -850,3 +878,39 @@ func Benchmark_walkRoot(b *testing.B) {
 		_, _ = walkRoot(rootNode, longestKeyNibbles)
 	}
 }
+
+func Test_GenerateFromTrie(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("catapulta"), []byte{1})
+	tr.Put([]byte("dog"), generateBytes(t, 40))
+	rootHash := tr.MustHash().ToBytes()
+
+	fullKeys := [][]byte{[]byte("catapulta"), []byte("dog")}
+	encodedProofNodes, err := GenerateFromTrie(tr, fullKeys)
+	require.NoError(t, err)
+
+	for _, fullKey := range fullKeys {
+		err = VerifyStreaming(encodedProofNodes, rootHash, fullKey, tr.Get(fullKey))
+		require.NoError(t, err)
+	}
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+	fromDatabase, err := Generate(rootHash, fullKeys, database)
+	require.NoError(t, err)
+	assert.Equal(t, fromDatabase, encodedProofNodes)
+
+	_, err = GenerateFromTrie(tr, [][]byte{[]byte("cow")})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	encodedProofNodes, err = GenerateFromTrie(trie.NewEmptyTrie(), [][]byte{{}})
+	require.NoError(t, err)
+	assert.Empty(t, encodedProofNodes)
+}
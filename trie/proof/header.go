@@ -0,0 +1,71 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/scale"
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var ErrBlockHashMismatch = errors.New("block hash mismatch")
+
+// HeaderOptions contains options to verify a proof against a block header.
+type HeaderOptions struct {
+	// ExpectedBlockHash, if not the zero hash, is compared with the hash
+	// of the header given before verifying the proof, for callers which
+	// obtained the header from an untrusted source but trust the block
+	// hash, for example from finality proofs.
+	ExpectedBlockHash util.Hash
+	// VerifyOptions contains the resource limits and hasher
+	// used to verify the proof against the header state root.
+	VerifyOptions VerifyOptions
+}
+
+// VerifyAgainstHeader verifies the key and value given belong to the
+// state trie of the block header given, like Verify with the header
+// state root as root hash.
+func VerifyAgainstHeader(encodedProofNodes [][]byte, header sub.Header,
+	key, value []byte) (err error) {
+	return VerifyAgainstHeaderWithOptions(encodedProofNodes, header, key, value, HeaderOptions{})
+}
+
+// VerifyAgainstHeaderWithOptions is like VerifyAgainstHeader but
+// first checks the header hash matches the expected block hash of the
+// options given, if any, and verifies the proof with the verify options
+// given. An error wrapping ErrBlockHashMismatch is returned if the
+// header hash does not match the expected block hash.
+func VerifyAgainstHeaderWithOptions(encodedProofNodes [][]byte, header sub.Header,
+	key, value []byte, options HeaderOptions) (err error) {
+	if options.ExpectedBlockHash != (util.Hash{}) {
+		// Note the hash is computed instead of using header.Hash()
+		// since the latter is cached and may be stale if a field
+		// of the header was modified after it was first hashed.
+		blockHash, err := hashHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if blockHash != options.ExpectedBlockHash {
+			return fmt.Errorf("%w: header hash is %s instead of %s",
+				ErrBlockHashMismatch, blockHash, options.ExpectedBlockHash)
+		}
+	}
+
+	return VerifyWithOptions(encodedProofNodes, header.StateRoot.ToBytes(),
+		key, value, options.VerifyOptions)
+}
+
+func hashHeader(header sub.Header) (blockHash util.Hash, err error) {
+	encoding, err := scale.Marshal(header)
+	if err != nil {
+		return blockHash, fmt.Errorf("encoding header: %w", err)
+	}
+
+	blockHash, err = util.Blake2bHash(encoding)
+	if err != nil {
+		return blockHash, fmt.Errorf("hashing header: %w", err)
+	}
+	return blockHash, nil
+}
@@ -0,0 +1,79 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyAgainstHeaderWithOptions(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	key := []byte("account")
+	value := generateBytes(t, 40)
+	tr.Put(key, value)
+	tr.Put([]byte("other"), []byte{1})
+
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{key})
+	require.NoError(t, err)
+
+	header := *sub.NewHeader(util.Hash{1}, tr.MustHash(), util.Hash{2}, 10, sub.NewDigest())
+	blockHash := header.Hash()
+
+	otherHeader := header
+	otherHeader.Number = 11
+
+	testCases := map[string]struct {
+		header     sub.Header
+		options    HeaderOptions
+		errWrapped error
+		errMessage string
+	}{
+		"without block hash": {
+			header: header,
+		},
+		"matching block hash": {
+			header:  header,
+			options: HeaderOptions{ExpectedBlockHash: blockHash},
+		},
+		"block hash mismatch": {
+			header:     otherHeader,
+			options:    HeaderOptions{ExpectedBlockHash: blockHash},
+			errWrapped: ErrBlockHashMismatch,
+			errMessage: "block hash mismatch: header hash is " +
+				mustHashHeader(t, otherHeader).String() +
+				" instead of " + blockHash.String(),
+		},
+		"state root mismatch": {
+			header:     *sub.NewHeader(util.Hash{1}, util.Hash{3}, util.Hash{2}, 10, sub.NewDigest()),
+			errWrapped: ErrRootNodeNotFound,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyAgainstHeaderWithOptions(encodedProofNodes, testCase.header,
+				key, value, testCase.options)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func mustHashHeader(t *testing.T, header sub.Header) (blockHash util.Hash) {
+	t.Helper()
+	blockHash, err := hashHeader(header)
+	require.NoError(t, err)
+	return blockHash
+}
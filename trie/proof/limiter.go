@@ -0,0 +1,147 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// Operation is a proof operation subject to admission control.
+type Operation uint8
+
+const (
+	// OperationVerify is the verification of a proof.
+	OperationVerify Operation = iota
+	// OperationGenerate is the generation of a proof.
+	OperationGenerate
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationVerify:
+		return "verify"
+	case OperationGenerate:
+		return "generate"
+	default:
+		return fmt.Sprintf("unknown operation %d", uint8(o))
+	}
+}
+
+// Limiter decides if an operation of the given cost is admitted.
+// It should return an error wrapping ErrRateLimited if the
+// operation is rejected. Implementations must be safe for
+// concurrent use.
+type Limiter interface {
+	Allow(operation Operation, cost int) (err error)
+}
+
+// TokenBucket is a Limiter using the token bucket algorithm,
+// shared across all operations. Each operation consumes as many
+// tokens as its cost, and tokens are refilled at a constant rate
+// up to the bucket capacity.
+type TokenBucket struct {
+	ratePerSecond float64
+	capacity      float64
+	now           func() time.Time
+
+	mutex      sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewTokenBucket creates a full token bucket of the capacity given,
+// refilled at the rate given in tokens per second.
+func NewTokenBucket(ratePerSecond float64, capacity int) *TokenBucket {
+	return newTokenBucket(ratePerSecond, capacity, time.Now)
+}
+
+func newTokenBucket(ratePerSecond float64, capacity int,
+	now func() time.Time) *TokenBucket {
+	return &TokenBucket{
+		ratePerSecond: ratePerSecond,
+		capacity:      float64(capacity),
+		now:           now,
+		tokens:        float64(capacity),
+		lastRefill:    now(),
+	}
+}
+
+// Allow consumes cost tokens from the bucket, or returns an
+// error wrapping ErrRateLimited if there are not enough tokens.
+// A cost larger than the bucket capacity is always rejected.
+func (b *TokenBucket) Allow(operation Operation, cost int) (err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * b.ratePerSecond
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.lastRefill = now
+	}
+
+	if float64(cost) > b.tokens {
+		return fmt.Errorf("%w: %s operation of cost %d exceeds %.0f available tokens",
+			ErrRateLimited, operation, cost, b.tokens)
+	}
+	b.tokens -= float64(cost)
+	return nil
+}
+
+// Guard runs proof operations only if they are admitted by its
+// limiter, so a public facing service can protect itself from
+// abusive clients. The cost of a verification is its number of
+// encoded proof nodes, and the cost of a generation is its number
+// of keys.
+type Guard struct {
+	limiter Limiter
+	options VerifyOptions
+}
+
+// NewGuard creates a guard using the limiter given for admission
+// control, and the options given to verify proofs. A nil limiter
+// admits all operations.
+func NewGuard(limiter Limiter, options VerifyOptions) *Guard {
+	return &Guard{
+		limiter: limiter,
+		options: options,
+	}
+}
+
+// Verify is like VerifyWithOptions but first checks the
+// verification is admitted by the guard limiter.
+func (g *Guard) Verify(encodedProofNodes [][]byte, rootHash, key, value []byte) (err error) {
+	err = g.allow(OperationVerify, len(encodedProofNodes))
+	if err != nil {
+		return err
+	}
+	return VerifyWithOptions(encodedProofNodes, rootHash, key, value, g.options)
+}
+
+// Generate is like Generate but first checks the
+// generation is admitted by the guard limiter.
+func (g *Guard) Generate(rootHash []byte, fullKeys [][]byte, database Database) (
+	encodedProofNodes [][]byte, err error) {
+	err = g.allow(OperationGenerate, len(fullKeys))
+	if err != nil {
+		return nil, err
+	}
+	return Generate(rootHash, fullKeys, database)
+}
+
+func (g *Guard) allow(operation Operation, cost int) (err error) {
+	if g.limiter == nil {
+		return nil
+	}
+	err = g.limiter.Allow(operation, cost)
+	if err != nil {
+		return fmt.Errorf("admitting %s operation: %w", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package proof
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TokenBucket_Allow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	bucket := newTokenBucket(2, 4, func() time.Time { return now })
+
+	err := bucket.Allow(OperationVerify, 3)
+	require.NoError(t, err)
+
+	err = bucket.Allow(OperationGenerate, 2)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.EqualError(t, err, "rate limited: generate operation of cost 2 exceeds 1 available tokens")
+
+	now = now.Add(500 * time.Millisecond)
+	err = bucket.Allow(OperationGenerate, 2)
+	require.NoError(t, err)
+
+	// Refill is capped at the bucket capacity.
+	now = now.Add(time.Hour)
+	err = bucket.Allow(OperationVerify, 4)
+	require.NoError(t, err)
+	err = bucket.Allow(OperationVerify, 1)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	now = now.Add(time.Hour)
+	err = bucket.Allow(OperationVerify, 5)
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
+
+func Test_Guard(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 40))
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	const ratePerSecond = 0
+	guard := NewGuard(NewTokenBucket(ratePerSecond, 4), VerifyOptions{})
+
+	// Generating costs one token per key.
+	encodedProofNodes, err := guard.Generate(rootHash, [][]byte{[]byte("cat")}, database)
+	require.NoError(t, err)
+	require.Len(t, encodedProofNodes, 2)
+
+	// Verifying costs one token per encoded proof node.
+	err = guard.Verify(encodedProofNodes, rootHash, []byte("cat"), tr.Get([]byte("cat")))
+	require.NoError(t, err)
+
+	err = guard.Verify(encodedProofNodes, rootHash, []byte("cat"), nil)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = guard.Generate(rootHash, [][]byte{[]byte("cat")}, database)
+	require.NoError(t, err)
+	_, err = guard.Generate(rootHash, [][]byte{[]byte("dog")}, database)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.EqualError(t, err, "admitting generate operation: "+
+		"rate limited: generate operation of cost 1 exceeds 0 available tokens")
+
+	unlimited := NewGuard(nil, VerifyOptions{})
+	err = unlimited.Verify(encodedProofNodes, rootHash, []byte("cat"), nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,83 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Merge combines two proofs generated against the same root hash
+// into a single proof. Encoded proof nodes present in both proofs
+// are only kept once, and the merged proof is in the canonical order
+// described in Canonical, so merging proofs A and B gives the same
+// proof as merging proofs B and A.
+// Each non empty proof given must contain the root node matching
+// the root hash given, and every encoded node must be decodable.
+func Merge(proofA, proofB [][]byte, rootHash []byte) (
+	merged [][]byte, err error) {
+	if len(proofA) == 0 && len(proofB) == 0 {
+		return nil, fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+
+	merged = make([][]byte, 0, len(proofA)+len(proofB))
+	digestsSeen := make(map[string]struct{}, len(proofA)+len(proofB))
+	proofs := [...]struct {
+		name  string
+		nodes [][]byte
+	}{
+		{name: "first", nodes: proofA},
+		{name: "second", nodes: proofB},
+	}
+
+	for _, proof := range proofs {
+		if len(proof.nodes) == 0 {
+			continue
+		}
+
+		rootFound := false
+		for i, encodedProofNode := range proof.nodes {
+			buffer.Reset()
+			err = sub.MerkleValueRoot(encodedProofNode, buffer)
+			if err != nil {
+				return nil, fmt.Errorf("calculating Merkle value of node %d of %s proof: %w",
+					i, proof.name, err)
+			}
+			digest := buffer.Bytes()
+
+			if bytes.Equal(digest, rootHash) {
+				rootFound = true
+			}
+
+			_, seen := digestsSeen[string(digest)]
+			if seen {
+				continue
+			}
+			digestsSeen[string(digest)] = struct{}{}
+
+			_, err = decodeProofNode(encodedProofNode, i, digest)
+			if err != nil {
+				return nil, fmt.Errorf("decoding node of %s proof: %w",
+					proof.name, err)
+			}
+
+			merged = append(merged, encodedProofNode)
+		}
+
+		if !rootFound {
+			return nil, fmt.Errorf("%w: in %s proof for root hash 0x%x",
+				ErrRootNodeNotFound, proof.name, rootHash)
+		}
+	}
+
+	merged, err = Canonical(merged, rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("ordering merged proof: %w", err)
+	}
+
+	return merged, nil
+}
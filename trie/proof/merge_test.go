@@ -0,0 +1,141 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Merge(t *testing.T) {
+	t.Parallel()
+
+	leafA := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: generateBytes(t, 40),
+	}
+	assertLongEncoding(t, leafA)
+
+	leafB := sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: generateBytes(t, 50),
+	}
+	assertLongEncoding(t, leafB)
+
+	branch := sub.Node{
+		PartialKey: []byte{3},
+		Children: padRightChildren([]*sub.Node{
+			&leafA,
+			&leafB,
+		}),
+	}
+
+	otherRoot := sub.Node{
+		PartialKey:   []byte{4},
+		StorageValue: []byte{5},
+	}
+
+	testCases := map[string]struct {
+		proofA     [][]byte
+		proofB     [][]byte
+		rootHash   []byte
+		merged     [][]byte
+		errWrapped error
+		errMessage string
+	}{
+		"both proofs empty": {
+			rootHash:   []byte{1},
+			errWrapped: ErrEmptyProof,
+			errMessage: "proof slice empty: for Merkle root hash 0x01",
+		},
+		"second proof empty": {
+			proofA:   [][]byte{encodeNode(t, branch), encodeNode(t, leafA)},
+			rootHash: blake2bNode(t, branch),
+			merged:   [][]byte{encodeNode(t, branch), encodeNode(t, leafA)},
+		},
+		"deduplicated union": {
+			proofA:   [][]byte{encodeNode(t, branch), encodeNode(t, leafA)},
+			proofB:   [][]byte{encodeNode(t, leafB), encodeNode(t, branch)},
+			rootHash: blake2bNode(t, branch),
+			merged: [][]byte{
+				encodeNode(t, branch),
+				encodeNode(t, leafA),
+				encodeNode(t, leafB),
+			},
+		},
+		"second proof for another root": {
+			proofA:     [][]byte{encodeNode(t, branch), encodeNode(t, leafA)},
+			proofB:     [][]byte{encodeNode(t, otherRoot)},
+			rootHash:   blake2bNode(t, branch),
+			errWrapped: ErrRootNodeNotFound,
+			errMessage: fmt.Sprintf("root node not found in proof: "+
+				"in second proof for root hash 0x%x", blake2bNode(t, branch)),
+		},
+		"bad node encoding": {
+			proofA:     [][]byte{encodeNode(t, branch), getBadNodeEncoding()},
+			rootHash:   blake2bNode(t, branch),
+			errWrapped: sub.ErrVariantUnknown,
+			errMessage: "decoding node of first proof: proof node at index 1 with hash digest " +
+				"0xcfa21f0ec11a3658d77701b7b1f52fbcb783fe3df662977b6e860252b6c37e1e: " +
+				"at byte offset 1: decoding header: " +
+				"decoding header byte: node variant is unknown: " +
+				"for header byte 00000001",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			merged, err := Merge(testCase.proofA, testCase.proofB, testCase.rootHash)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.merged, merged)
+		})
+	}
+}
+
+func Test_Generate_Merge_Verify(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": []byte("catapulta"),
+		"dog":       generateBytes(t, 33),
+		"doguinho":  []byte("doguinho"),
+	}
+
+	tr := trie.NewEmptyTrie()
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	proofA, err := Generate(rootHash, [][]byte{[]byte("cat")}, database)
+	require.NoError(t, err)
+	proofB, err := Generate(rootHash, [][]byte{[]byte("doguinho")}, database)
+	require.NoError(t, err)
+
+	merged, err := Merge(proofA, proofB, rootHash)
+	require.NoError(t, err)
+
+	proofTrie, err := BuildTrie(merged, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, keyValues["cat"], proofTrie.Get([]byte("cat")))
+	assert.Equal(t, keyValues["doguinho"], proofTrie.Get([]byte("doguinho")))
+}
@@ -0,0 +1,49 @@
+package proof
+
+import "time"
+
+// Metrics receives an observation of each proof verification done
+// with VerifyOptions having it set, so services embedding this package
+// can export observability data, such as a counter of verifications,
+// a counter of failures by failure class and histograms of proof sizes
+// and verification durations. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	ObserveVerification(observation Observation)
+}
+
+// Observation is the observation of a single proof verification.
+type Observation struct {
+	// ProofNodes is the number of encoded proof nodes.
+	ProofNodes int
+	// ProofSize is the total size in bytes of the encoded proof nodes.
+	ProofSize int
+	// Duration is the duration of the verification.
+	Duration time.Duration
+	// Failure is the failure class of the verification error,
+	// which is FailureNone if the verification succeeded.
+	Failure FailureClass
+}
+
+// observeVerification returns a function to call with the verification
+// error once the verification of the encoded proof nodes given is done,
+// which reports the verification to the options metrics if it is set.
+func (o VerifyOptions) observeVerification(encodedProofNodes [][]byte) (
+	done func(err error)) {
+	if o.Metrics == nil {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	return func(err error) {
+		observation := Observation{
+			ProofNodes: len(encodedProofNodes),
+			Duration:   time.Since(start),
+			Failure:    Classify(err),
+		}
+		for _, encodedProofNode := range encodedProofNodes {
+			observation.ProofSize += len(encodedProofNode)
+		}
+		o.Metrics.ObserveVerification(observation)
+	}
+}
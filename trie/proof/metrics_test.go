@@ -0,0 +1,61 @@
+package proof
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsRecorder struct {
+	mutex        sync.Mutex
+	observations []Observation
+}
+
+func (m *metricsRecorder) ObserveVerification(observation Observation) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.observations = append(m.observations, observation)
+}
+
+func Test_VerifyOptions_Metrics(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 41))
+	rootHash := tr.MustHash().ToBytes()
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+	var proofSize int
+	for _, encodedProofNode := range encodedProofNodes {
+		proofSize += len(encodedProofNode)
+	}
+
+	metrics := &metricsRecorder{}
+	options := VerifyOptions{Metrics: metrics}
+
+	err = VerifyWithOptions(encodedProofNodes, rootHash, []byte("cat"),
+		generateBytes(t, 40), options)
+	require.NoError(t, err)
+
+	_, err = BuildTrieWithOptions(nil, rootHash, options)
+	require.ErrorIs(t, err, ErrEmptyProof)
+
+	err = VerifyEmptyValueWithOptions(encodedProofNodes, rootHash, []byte("cat"), options)
+	require.ErrorIs(t, err, ErrValueMismatchProofTrie)
+
+	require.Len(t, metrics.observations, 3)
+	expected := []Observation{
+		{ProofNodes: len(encodedProofNodes), ProofSize: proofSize, Failure: FailureNone},
+		{Failure: FailureMalformedProof},
+		{ProofNodes: len(encodedProofNodes), ProofSize: proofSize, Failure: FailureValueMismatch},
+	}
+	for i, observation := range metrics.observations {
+		assert.GreaterOrEqual(t, observation.Duration.Nanoseconds(), int64(0))
+		observation.Duration = 0
+		assert.Equal(t, expected[i], observation)
+	}
+}
@@ -0,0 +1,88 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var ErrProofNotMinimal = errors.New("proof is not minimal")
+
+// UnreferencedNodes builds the proof trie from the encoded proof nodes
+// and root hash given, and returns the indexes, in ascending order, of
+// the encoded proof nodes never referenced from the root node.
+// Duplicated encoded proof nodes are reported as unreferenced after
+// their first occurrence. A minimal proof has no unreferenced node.
+func UnreferencedNodes(encodedProofNodes [][]byte, rootHash []byte) (
+	indexes []int, err error) {
+	return unreferencedNodes(encodedProofNodes, rootHash, VerifyOptions{})
+}
+
+// unreferencedNodes is like UnreferencedNodes but builds
+// the proof trie using the options given.
+func unreferencedNodes(encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions) (indexes []int, err error) {
+	referenced := map[string]struct{}{
+		string(rootHash): {},
+	}
+	_, err = buildTrie(context.Background(), encodedProofNodes, rootHash,
+		options, referenced, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building trie from proof encoded nodes: %w", err)
+	}
+
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+
+	for i, encodedProofNode := range encodedProofNodes {
+		buffer.Reset()
+		err = options.hashEncoding(encodedProofNode, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("calculating Merkle value of node %d: %w", i, err)
+		}
+
+		digest := buffer.String()
+		_, ok := referenced[digest]
+		if !ok && options.ValueHasher != nil {
+			// The node may be the preimage of a hashed value,
+			// which is referenced by its value hash digest.
+			buffer.Reset()
+			err = options.hashValue(encodedProofNode, buffer)
+			if err != nil {
+				return nil, fmt.Errorf("calculating value hash of node %d: %w", i, err)
+			}
+			digest = buffer.String()
+			_, ok = referenced[digest]
+		}
+		if !ok {
+			indexes = append(indexes, i)
+			continue
+		}
+		// Delete the digest so any later duplicate is reported.
+		delete(referenced, digest)
+	}
+
+	return indexes, nil
+}
+
+// CheckMinimal returns an error wrapping ErrProofNotMinimal if any
+// of the encoded proof nodes given is not referenced from the root.
+func CheckMinimal(encodedProofNodes [][]byte, rootHash []byte) (err error) {
+	return checkMinimal(encodedProofNodes, rootHash, VerifyOptions{})
+}
+
+func checkMinimal(encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions) (err error) {
+	indexes, err := unreferencedNodes(encodedProofNodes, rootHash, options)
+	if err != nil {
+		return err
+	}
+
+	if len(indexes) > 0 {
+		return fmt.Errorf("%w: %d unreferenced nodes at indexes %v",
+			ErrProofNotMinimal, len(indexes), indexes)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UnreferencedNodes(t *testing.T) {
+	t.Parallel()
+
+	leafA := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: generateBytes(t, 40),
+	}
+	assertLongEncoding(t, leafA)
+
+	leafB := sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: generateBytes(t, 50),
+	}
+	assertLongEncoding(t, leafB)
+
+	branch := sub.Node{
+		PartialKey: []byte{3},
+		Children: padRightChildren([]*sub.Node{
+			&leafA,
+			&leafB,
+		}),
+	}
+
+	unrelated := sub.Node{
+		PartialKey:   []byte{4},
+		StorageValue: generateBytes(t, 60),
+	}
+	assertLongEncoding(t, unrelated)
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		indexes           []int
+		errWrapped        error
+	}{
+		"empty_proof": {
+			rootHash:   blake2bNode(t, branch),
+			errWrapped: ErrEmptyProof,
+		},
+		"root_not_found": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, leafA),
+			},
+			rootHash:   blake2bNode(t, branch),
+			errWrapped: ErrRootNodeNotFound,
+		},
+		"minimal_proof": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, leafB),
+				encodeNode(t, branch),
+				encodeNode(t, leafA),
+			},
+			rootHash: blake2bNode(t, branch),
+		},
+		"padded_proof": {
+			encodedProofNodes: [][]byte{
+				encodeNode(t, unrelated),
+				encodeNode(t, branch),
+				encodeNode(t, leafA),
+				encodeNode(t, leafA),
+				encodeNode(t, branch),
+			},
+			rootHash: blake2bNode(t, branch),
+			indexes:  []int{0, 3, 4},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			indexes, err := UnreferencedNodes(testCase.encodedProofNodes, testCase.rootHash)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.indexes, indexes)
+		})
+	}
+}
+
+func Test_CheckMinimal(t *testing.T) {
+	t.Parallel()
+
+	leaf := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: generateBytes(t, 40),
+	}
+	assertLongEncoding(t, leaf)
+
+	root := sub.Node{
+		PartialKey: []byte{2},
+		Children: padRightChildren([]*sub.Node{
+			&leaf,
+		}),
+	}
+	rootHash := blake2bNode(t, root)
+
+	err := CheckMinimal([][]byte{encodeNode(t, root), encodeNode(t, leaf)}, rootHash)
+	assert.NoError(t, err)
+
+	err = CheckMinimal([][]byte{encodeNode(t, root), encodeNode(t, leaf), encodeNode(t, leaf)}, rootHash)
+	assert.ErrorIs(t, err, ErrProofNotMinimal)
+	assert.EqualError(t, err, "proof is not minimal: 1 unreferenced nodes at indexes [2]")
+}
@@ -0,0 +1,141 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var ErrMultiProofRootMissing = errors.New("multi proof has no nodes for root hash")
+
+// MultiProof carries the encoded proof nodes of several tries, such
+// as a main state trie and some of its child tries, keyed by root hash.
+type MultiProof struct {
+	Roots map[util.Hash][][]byte
+}
+
+// Add adds the encoded proof nodes given for the root hash given.
+func (p *MultiProof) Add(rootHash util.Hash, encodedProofNodes [][]byte) {
+	if p.Roots == nil {
+		p.Roots = make(map[util.Hash][][]byte)
+	}
+	p.Roots[rootHash] = append(p.Roots[rootHash], encodedProofNodes...)
+}
+
+// GenerateMultiProof generates a multi proof for the main keys given
+// of the in-memory trie given, and for the child keys given, which map
+// child storage keys, without the child storage key prefix, to keys of
+// the corresponding child trie. The main trie proof also proves the
+// root hash of each child trie.
+func GenerateMultiProof(t *trie.Trie, mainKeys [][]byte,
+	childKeys map[string][][]byte) (proof MultiProof, err error) {
+	fullKeys := make([][]byte, 0, len(mainKeys)+len(childKeys))
+	fullKeys = append(fullKeys, mainKeys...)
+
+	for childKey, keys := range childKeys {
+		child, err := t.GetChild([]byte(childKey))
+		if err != nil {
+			return proof, fmt.Errorf("getting child trie: %w", err)
+		}
+
+		childRootHash, err := child.Hash()
+		if err != nil {
+			return proof, fmt.Errorf("hashing child trie at key 0x%x: %w", childKey, err)
+		}
+
+		encodedProofNodes, err := GenerateFromTrie(child, keys)
+		if err != nil {
+			return proof, fmt.Errorf("generating proof for child trie at key 0x%x: %w",
+				childKey, err)
+		}
+		proof.Add(childRootHash, encodedProofNodes)
+
+		fullKeys = append(fullKeys, childStorageKey([]byte(childKey)))
+	}
+
+	rootHash, err := t.Hash()
+	if err != nil {
+		return proof, fmt.Errorf("hashing trie: %w", err)
+	}
+
+	encodedProofNodes, err := GenerateFromTrie(t, fullKeys)
+	if err != nil {
+		return proof, fmt.Errorf("generating proof for main trie: %w", err)
+	}
+	proof.Add(rootHash, encodedProofNodes)
+
+	return proof, nil
+}
+
+// Verify verifies the key and value given belong to the trie proven
+// for the state root given if the child key given is nil, or to the
+// child trie at the child key given otherwise. The child trie root
+// hash is itself verified against the state root.
+func (p MultiProof) Verify(stateRoot, childKey, key, value []byte) (err error) {
+	return p.VerifyWithOptions(stateRoot, childKey, key, value, VerifyOptions{})
+}
+
+// VerifyWithOptions is like Verify but enforces the resource
+// limits given when building each proof trie.
+func (p MultiProof) VerifyWithOptions(stateRoot, childKey, key, value []byte,
+	options VerifyOptions) (err error) {
+	rootHash := stateRoot
+	if childKey != nil {
+		rootHash, err = p.childRootHash(stateRoot, childKey, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	encodedProofNodes, err := p.encodedProofNodes(rootHash)
+	if err != nil {
+		return err
+	}
+
+	return VerifyWithOptions(encodedProofNodes, rootHash, key, value, options)
+}
+
+// childRootHash returns the root hash of the child trie at the
+// child key given, as proven in the trie of the state root given.
+func (p MultiProof) childRootHash(stateRoot, childKey []byte,
+	options VerifyOptions) (childRootHash []byte, err error) {
+	encodedProofNodes, err := p.encodedProofNodes(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	proofTrie, err := BuildTrieWithOptions(encodedProofNodes, stateRoot, options)
+	if err != nil {
+		return nil, fmt.Errorf("building main trie from proof encoded nodes: %w", err)
+	}
+
+	childRootHash, err = proofTrie.TryGet(childStorageKey(childKey))
+	if err != nil {
+		return nil, fmt.Errorf("getting child trie root hash for child key 0x%x "+
+			"from proof trie for root hash 0x%x: %w", childKey, stateRoot, err)
+	} else if childRootHash == nil {
+		return nil, fmt.Errorf("%w: child trie root hash for child key 0x%x "+
+			"in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, childKey, stateRoot)
+	}
+	return childRootHash, nil
+}
+
+func (p MultiProof) encodedProofNodes(rootHash []byte) (
+	encodedProofNodes [][]byte, err error) {
+	encodedProofNodes, ok := p.Roots[util.BytesToHash(rootHash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: 0x%x", ErrMultiProofRootMissing, rootHash)
+	}
+	return encodedProofNodes, nil
+}
+
+// childStorageKey returns the key in the main trie of
+// the root hash of the child trie at the child key given.
+func childStorageKey(childKey []byte) (key []byte) {
+	key = make([]byte, 0, len(trie.ChildStorageKeyPrefix)+len(childKey))
+	key = append(key, trie.ChildStorageKeyPrefix...)
+	return append(key, childKey...)
+}
@@ -0,0 +1,51 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MultiProof(t *testing.T) {
+	t.Parallel()
+
+	child := trie.NewEmptyTrie()
+	child.Put([]byte("mouse"), generateBytes(t, 40))
+	child.Put([]byte("rat"), generateBytes(t, 41))
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 42))
+	tr.Put([]byte("dog"), generateBytes(t, 43))
+	err := tr.SetChild([]byte("rodents"), child)
+	require.NoError(t, err)
+	stateRoot := tr.MustHash().ToBytes()
+
+	proof, err := GenerateMultiProof(tr, [][]byte{[]byte("cat")},
+		map[string][][]byte{"rodents": {[]byte("rat")}})
+	require.NoError(t, err)
+	assert.Len(t, proof.Roots, 2)
+
+	err = proof.Verify(stateRoot, nil, []byte("cat"), generateBytes(t, 42))
+	assert.NoError(t, err)
+
+	err = proof.Verify(stateRoot, []byte("rodents"), []byte("rat"), generateBytes(t, 41))
+	assert.NoError(t, err)
+
+	err = proof.Verify(stateRoot, []byte("birds"), []byte("rat"), nil)
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+
+	err = proof.Verify([]byte{1}, []byte("rodents"), []byte("rat"), nil)
+	assert.ErrorIs(t, err, ErrMultiProofRootMissing)
+	assert.Equal(t, FailureWrongRoot, Classify(err))
+
+	var missingChild MultiProof
+	missingChild.Add(util.BytesToHash(stateRoot), proof.Roots[util.BytesToHash(stateRoot)])
+	err = missingChild.Verify(stateRoot, []byte("rodents"), []byte("rat"), nil)
+	assert.ErrorIs(t, err, ErrMultiProofRootMissing)
+
+	_, err = GenerateMultiProof(tr, nil, map[string][][]byte{"birds": {[]byte("owl")}})
+	assert.ErrorIs(t, err, trie.ErrChildTrieDoesNotExist)
+}
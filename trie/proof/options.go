@@ -0,0 +1,135 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var (
+	ErrTooManyProofNodes = errors.New("too many proof nodes")
+	ErrProofNodeTooLarge = errors.New("proof node encoding too large")
+	ErrProofTrieTooDeep  = errors.New("proof trie too deep")
+	ErrHasherDigestSize  = errors.New("hasher digest size is not 32 bytes")
+)
+
+// VerifyOptions contains resource limits enforced when building
+// a trie from encoded proof nodes, which usually come from an
+// untrusted peer. A zero value field means there is no limit.
+type VerifyOptions struct {
+	// MaxNodes is the maximum number of encoded proof nodes given,
+	// and also the maximum number of nodes decoded from the proof
+	// when loading the proof trie. The latter is required since
+	// a single encoded node can be referenced by multiple branches.
+	MaxNodes int
+	// MaxDepth is the maximum depth of the proof trie,
+	// where the root node has a depth of 0.
+	MaxDepth int
+	// MaxNodeSize is the maximum size in bytes of an encoded proof node.
+	MaxNodeSize int
+	// Hasher, if not nil, returns a new 32 bytes digest hasher used
+	// instead of blake2b-256 to hash the encoded proof nodes, for
+	// chains using another trie hasher such as keccak-256.
+	Hasher func() hash.Hash
+	// ValueHasher, if not nil, returns a new 32 bytes digest hasher used
+	// to hash the state version 1 values instead of Hasher, for chains
+	// hashing their values with another hasher than their trie nodes.
+	// If it is nil, values are hashed with Hasher, or blake2b-256 if
+	// Hasher is nil as well.
+	ValueHasher func() hash.Hash
+	// Metrics, if not nil, receives an observation of each proof
+	// verification and proof trie build done with these options.
+	Metrics Metrics
+}
+
+func (o VerifyOptions) checkNodesCount(count int) (err error) {
+	if o.MaxNodes > 0 && count > o.MaxNodes {
+		return fmt.Errorf("%w: %d exceeds the maximum of %d",
+			ErrTooManyProofNodes, count, o.MaxNodes)
+	}
+	return nil
+}
+
+func (o VerifyOptions) checkNodeSize(size int) (err error) {
+	if o.MaxNodeSize > 0 && size > o.MaxNodeSize {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes",
+			ErrProofNodeTooLarge, size, o.MaxNodeSize)
+	}
+	return nil
+}
+
+func (o VerifyOptions) checkDepth(depth int) (err error) {
+	if o.MaxDepth > 0 && depth > o.MaxDepth {
+		return fmt.Errorf("%w: depth %d exceeds the maximum of %d",
+			ErrProofTrieTooDeep, depth, o.MaxDepth)
+	}
+	return nil
+}
+
+// valueHasher returns the hasher of state version 1 values,
+// which is nil for blake2b-256.
+func (o VerifyOptions) valueHasher() func() hash.Hash {
+	if o.ValueHasher != nil {
+		return o.ValueHasher
+	}
+	return o.Hasher
+}
+
+// hashValue writes the hash digest of the state version 1 value
+// given to the writer, using the value hasher of the options.
+func (o VerifyOptions) hashValue(value []byte, writer io.Writer) (err error) {
+	return sub.HashValue(value, o.valueHasher(), writer)
+}
+
+// mapValueDigest maps the value hash digest of the encoded proof node
+// given to the encoded proof node in the map given, since it may be the
+// preimage of a state version 1 hashed value. This is only needed if the
+// value hasher differs from the node hasher, since the node hash digest
+// is otherwise already mapped. Existing map entries are not overwritten.
+func (o VerifyOptions) mapValueDigest(digestToEncoding map[string][]byte,
+	encodedProofNode []byte, buffer *bytes.Buffer) (err error) {
+	if o.ValueHasher == nil {
+		return nil
+	}
+
+	buffer.Reset()
+	err = o.hashValue(encodedProofNode, buffer)
+	if err != nil {
+		return fmt.Errorf("calculating value hash: %w", err)
+	}
+
+	_, exists := digestToEncoding[buffer.String()]
+	if !exists {
+		digestToEncoding[buffer.String()] = encodedProofNode
+	}
+	return nil
+}
+
+// hashEncoding writes the hash digest of the encoding given to the
+// writer, using the options hasher or blake2b-256 if it is nil.
+func (o VerifyOptions) hashEncoding(encoding []byte, writer io.Writer) (err error) {
+	if o.Hasher == nil {
+		return sub.MerkleValueRoot(encoding, writer)
+	}
+
+	const digestSize = 32
+	hasher := o.Hasher()
+	if hasher.Size() != digestSize {
+		return fmt.Errorf("%w: %d bytes", ErrHasherDigestSize, hasher.Size())
+	}
+
+	_, err = hasher.Write(encoding)
+	if err != nil {
+		return fmt.Errorf("hashing encoding: %w", err)
+	}
+
+	_, err = writer.Write(hasher.Sum(nil))
+	if err != nil {
+		return fmt.Errorf("writing digest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package proof
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_BuildTrieWithOptions(t *testing.T) {
+	t.Parallel()
+
+	leafLarge := sub.Node{
+		PartialKey:   []byte{3},
+		StorageValue: generateBytes(t, 40),
+	}
+	assertLongEncoding(t, leafLarge)
+
+	// branchSharing references the same large leaf from
+	// all its 16 children, so its proof contains 2 encoded
+	// nodes but its proof trie contains 17 decoded nodes.
+	branchSharing := sub.Node{
+		PartialKey: []byte{1},
+		Children:   make([]*sub.Node, sub.ChildrenCapacity),
+	}
+	for i := range branchSharing.Children {
+		branchSharing.Children[i] = &leafLarge
+	}
+
+	branchDeep := sub.Node{
+		PartialKey: []byte{2},
+		Children: padRightChildren([]*sub.Node{
+			&branchSharing,
+		}),
+	}
+
+	proofSharing := [][]byte{
+		encodeNode(t, branchSharing),
+		encodeNode(t, leafLarge),
+	}
+	proofDeep := [][]byte{
+		encodeNode(t, branchDeep),
+		encodeNode(t, branchSharing),
+		encodeNode(t, leafLarge),
+	}
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		options           VerifyOptions
+		errWrapped        error
+		errMessage        string
+	}{
+		"no limits": {
+			encodedProofNodes: proofDeep,
+			rootHash:          blake2bNode(t, branchDeep),
+		},
+		"limits not reached": {
+			encodedProofNodes: proofDeep,
+			rootHash:          blake2bNode(t, branchDeep),
+			options: VerifyOptions{
+				MaxNodes:    18,
+				MaxDepth:    2,
+				MaxNodeSize: len(encodeNode(t, branchSharing)),
+			},
+		},
+		"too many encoded proof nodes": {
+			encodedProofNodes: proofDeep,
+			rootHash:          blake2bNode(t, branchDeep),
+			options:           VerifyOptions{MaxNodes: 2},
+			errWrapped:        ErrTooManyProofNodes,
+			errMessage:        "too many proof nodes: 3 exceeds the maximum of 2",
+		},
+		"too many decoded proof nodes": {
+			encodedProofNodes: proofSharing,
+			rootHash:          blake2bNode(t, branchSharing),
+			options:           VerifyOptions{MaxNodes: 10},
+			errWrapped:        ErrTooManyProofNodes,
+			errMessage: "loading proof: " +
+				"too many proof nodes: 11 exceeds the maximum of 10",
+		},
+		"proof node too large": {
+			encodedProofNodes: proofSharing,
+			rootHash:          blake2bNode(t, branchSharing),
+			options:           VerifyOptions{MaxNodeSize: 32},
+			errWrapped:        ErrProofNodeTooLarge,
+			errMessage: "proof node at index 0: proof node encoding too large: " +
+				"532 bytes exceeds the maximum of 32 bytes",
+		},
+		"proof trie too deep": {
+			encodedProofNodes: proofDeep,
+			rootHash:          blake2bNode(t, branchDeep),
+			options:           VerifyOptions{MaxDepth: 1},
+			errWrapped:        ErrProofTrieTooDeep,
+			errMessage: "loading proof: " +
+				"proof trie too deep: depth 2 exceeds the maximum of 1",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proofTrie, err := BuildTrieWithOptions(testCase.encodedProofNodes,
+				testCase.rootHash, testCase.options)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				assert.Nil(t, proofTrie)
+			} else {
+				assert.NotNil(t, proofTrie)
+			}
+		})
+	}
+}
+
+func Test_VerifyOptions_Hasher(t *testing.T) {
+	t.Parallel()
+
+	keccak256 := func(encoding []byte) []byte {
+		hasher := sha3.NewLegacyKeccak256()
+		_, err := hasher.Write(encoding)
+		require.NoError(t, err)
+		return hasher.Sum(nil)
+	}
+
+	value := generateBytes(t, 40)
+	leaf := sub.Node{StorageValue: value}
+	leafEncoding := encodeNode(t, leaf)
+
+	// The branch references its child leaf by its keccak-256 digest.
+	branch := sub.Node{
+		PartialKey: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			nil, nil, nil, {NodeValue: keccak256(leafEncoding)},
+		}),
+	}
+	branchEncoding := encodeNode(t, branch)
+	encodedProofNodes := [][]byte{branchEncoding, leafEncoding}
+	rootHash := keccak256(branchEncoding)
+	key := []byte{0x13}
+
+	keccakOptions := VerifyOptions{Hasher: sha3.NewLegacyKeccak256}
+
+	err := VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value, keccakOptions)
+	assert.NoError(t, err)
+
+	proofTrie, err := BuildTrieWithOptions(encodedProofNodes, rootHash, keccakOptions)
+	require.NoError(t, err)
+	assert.Equal(t, value, proofTrie.Get(key))
+
+	err = VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value, VerifyOptions{})
+	assert.ErrorIs(t, err, ErrRootNodeNotFound)
+
+	err = VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value,
+		VerifyOptions{Hasher: sha512.New})
+	assert.ErrorIs(t, err, ErrHasherDigestSize)
+
+	_, err = BuildTrieWithOptions(encodedProofNodes, rootHash, VerifyOptions{})
+	assert.ErrorIs(t, err, ErrRootNodeNotFound)
+
+	err = VerifyWithOptions(encodedProofNodes, rootHash, key, value,
+		VerifyOptions{Hasher: sha512.New})
+	assert.ErrorIs(t, err, ErrHasherDigestSize)
+}
+
+func Test_VerifyOptions_ValueHasher(t *testing.T) {
+	t.Parallel()
+
+	keccak256 := func(b []byte) []byte {
+		hasher := sha3.NewLegacyKeccak256()
+		_, err := hasher.Write(b)
+		require.NoError(t, err)
+		return hasher.Sum(nil)
+	}
+
+	// The leaf value is hashed with keccak-256,
+	// whereas the nodes are hashed with blake2b-256.
+	value := generateBytes(t, 40)
+	leaf := sub.Node{
+		PartialKey:   []byte{2, 4},
+		StorageValue: value,
+		MustBeHashed: true,
+		ValueHasher:  sha3.NewLegacyKeccak256,
+	}
+	leafEncoding := encodeNode(t, leaf)
+	assert.Contains(t, string(leafEncoding), string(keccak256(value)))
+
+	branch := sub.Node{
+		PartialKey: []byte{1},
+		Children: padRightChildren([]*sub.Node{
+			nil, nil, nil, &leaf,
+		}),
+	}
+	encodedProofNodes := [][]byte{encodeNode(t, branch), leafEncoding, value}
+	rootHash := blake2bNode(t, branch)
+	key := []byte{0x13, 0x24}
+
+	options := VerifyOptions{ValueHasher: sha3.NewLegacyKeccak256}
+
+	err := VerifyWithOptions(encodedProofNodes, rootHash, key, value, options)
+	assert.NoError(t, err)
+
+	err = VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value, options)
+	assert.NoError(t, err)
+
+	err = VerifyValueHashWithOptions(encodedProofNodes, rootHash, key, keccak256(value), options)
+	assert.NoError(t, err)
+
+	err = checkMinimal(encodedProofNodes, rootHash, options)
+	assert.NoError(t, err)
+
+	err = VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value, VerifyOptions{})
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+
+	err = VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value,
+		VerifyOptions{ValueHasher: sha512.New})
+	assert.ErrorIs(t, err, sub.ErrValueHasherDigestSize)
+}
@@ -0,0 +1,197 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"golang.org/x/crypto/sha3"
+)
+
+// Hasher names supported by Policy.
+const (
+	HasherBlake2b256 = "blake2b-256"
+	HasherKeccak256  = "keccak-256"
+)
+
+var (
+	ErrHasherUnknown         = errors.New("hasher unknown")
+	ErrProofVersionMismatch  = errors.New("proof node does not match trie version")
+	ErrProofNodeNotCanonical = errors.New("proof node encoding is not canonical")
+)
+
+// Policy is a declarative verification policy, typically loaded from
+// a configuration file, combining the trie version, the trie hasher,
+// the resource limits and strictness flags to apply to proofs.
+// The zero value policy applies no limit and no additional check.
+type Policy struct {
+	// Version, if set, is the state trie version the proof nodes must
+	// follow: for V0, no node can have a hashed value, and for V1,
+	// no node can have an unhashed value larger than 32 bytes.
+	Version trie.Version `json:"version,omitempty"`
+	// Hasher is the name of the trie hasher, which is one of
+	// HasherBlake2b256 and HasherKeccak256. It defaults to
+	// HasherBlake2b256 if left empty.
+	Hasher string `json:"hasher,omitempty"`
+	// MaxNodes, MaxDepth and MaxNodeSize are the resource
+	// limits documented in VerifyOptions.
+	MaxNodes    int `json:"maxNodes,omitempty"`
+	MaxDepth    int `json:"maxDepth,omitempty"`
+	MaxNodeSize int `json:"maxNodeSize,omitempty"`
+	// RequireMinimal rejects proofs containing encoded
+	// nodes not referenced from the root node.
+	RequireMinimal bool `json:"requireMinimal,omitempty"`
+	// RequireCanonical rejects proofs containing encoded nodes
+	// which differ from the encoding of their decoded node.
+	RequireCanonical bool `json:"requireCanonical,omitempty"`
+}
+
+var hashers = map[string]func() hash.Hash{
+	HasherKeccak256: sha3.NewLegacyKeccak256,
+}
+
+// Options returns the verify options corresponding to the policy,
+// or an error wrapping ErrHasherUnknown if the hasher is not known.
+func (p Policy) Options() (options VerifyOptions, err error) {
+	options = VerifyOptions{
+		MaxNodes:    p.MaxNodes,
+		MaxDepth:    p.MaxDepth,
+		MaxNodeSize: p.MaxNodeSize,
+	}
+
+	switch p.Hasher {
+	case "", HasherBlake2b256:
+	default:
+		hasher, ok := hashers[p.Hasher]
+		if !ok {
+			return options, fmt.Errorf("%w: %q", ErrHasherUnknown, p.Hasher)
+		}
+		options.Hasher = hasher
+	}
+
+	return options, nil
+}
+
+// VerifyWithPolicy is like VerifyWithOptions but checks the proof
+// against the policy given.
+func VerifyWithPolicy(encodedProofNodes [][]byte, rootHash, key, value []byte,
+	policy Policy) (err error) {
+	options, err := policy.check(encodedProofNodes, rootHash)
+	if err != nil {
+		return err
+	}
+	return VerifyWithOptions(encodedProofNodes, rootHash, key, value, options)
+}
+
+// BuildTrieWithPolicy is like BuildTrieWithOptions but checks the proof
+// against the policy given.
+func BuildTrieWithPolicy(encodedProofNodes [][]byte, rootHash []byte,
+	policy Policy) (t *trie.Trie, err error) {
+	options, err := policy.check(encodedProofNodes, rootHash)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTrieWithOptions(encodedProofNodes, rootHash, options)
+}
+
+// GenerateWithPolicy is like Generate but returns an error if the
+// proof generated would not be accepted under the policy given, so
+// a proof is never sent to a verifier which would reject it.
+func GenerateWithPolicy(rootHash []byte, fullKeys [][]byte, database Database,
+	policy Policy) (encodedProofNodes [][]byte, err error) {
+	encodedProofNodes, err = Generate(rootHash, fullKeys, database)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = BuildTrieWithPolicy(encodedProofNodes, rootHash, policy)
+	if err != nil {
+		return nil, fmt.Errorf("checking generated proof: %w", err)
+	}
+
+	return encodedProofNodes, nil
+}
+
+// check checks the encoded proof nodes against the version and strictness
+// flags of the policy, and returns the verify options of the policy.
+func (p Policy) check(encodedProofNodes [][]byte, rootHash []byte) (
+	options VerifyOptions, err error) {
+	options, err = p.Options()
+	if err != nil {
+		return options, err
+	}
+
+	if p.Version != 0 || p.RequireCanonical {
+		// The buffer holds a digest and, if canonical encodings are
+		// required, the encoding of a node, so it is taken from the
+		// size class able to hold the largest proof node.
+		maxNodeSize := 0
+		for _, encodedProofNode := range encodedProofNodes {
+			if len(encodedProofNode) > maxNodeSize {
+				maxNodeSize = len(encodedProofNode)
+			}
+		}
+		buffer := sub.GetBuffer(maxNodeSize)
+		defer sub.PutBuffer(buffer)
+		for i, encodedProofNode := range encodedProofNodes {
+			err = p.checkNode(encodedProofNode, i, options, buffer)
+			if err != nil {
+				return options, err
+			}
+		}
+	}
+
+	if p.RequireMinimal {
+		err = checkMinimal(encodedProofNodes, rootHash, options)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
+
+func (p Policy) checkNode(encodedProofNode []byte, index int,
+	options VerifyOptions, buffer *bytes.Buffer) (err error) {
+	buffer.Reset()
+	err = options.hashEncoding(encodedProofNode, buffer)
+	if err != nil {
+		return fmt.Errorf("calculating Merkle value of proof node at index %d: %w",
+			index, err)
+	}
+
+	node, err := decodeProofNode(encodedProofNode, index, buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("decoding node: %w", err)
+	}
+
+	const maxUnhashedValueSize = 32
+	switch {
+	case p.Version == trie.V0 && node.IsHashedValue:
+		return fmt.Errorf("%w: proof node at index %d: %s has a hashed value",
+			ErrProofVersionMismatch, index, p.Version)
+	case p.Version == trie.V1 && !node.IsHashedValue &&
+		len(node.StorageValue) > maxUnhashedValueSize:
+		return fmt.Errorf("%w: proof node at index %d: %s has an unhashed value of %d bytes",
+			ErrProofVersionMismatch, index, p.Version, len(node.StorageValue))
+	}
+
+	if !p.RequireCanonical {
+		return nil
+	}
+
+	buffer.Reset()
+	err = node.Encode(buffer)
+	if err != nil {
+		return fmt.Errorf("encoding decoded node: %w", err)
+	}
+
+	if !bytes.Equal(buffer.Bytes(), encodedProofNode) {
+		return fmt.Errorf("%w: proof node at index %d re-encoded as 0x%x",
+			ErrProofNodeNotCanonical, index, buffer.Bytes())
+	}
+	return nil
+}
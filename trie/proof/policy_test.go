@@ -0,0 +1,145 @@
+package proof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Policy_JSON(t *testing.T) {
+	t.Parallel()
+
+	const data = `{"version":"v1","hasher":"keccak-256","maxNodes":10,"requireMinimal":true}`
+
+	var policy Policy
+	err := json.Unmarshal([]byte(data), &policy)
+	require.NoError(t, err)
+	expected := Policy{
+		Version:        trie.V1,
+		Hasher:         HasherKeccak256,
+		MaxNodes:       10,
+		RequireMinimal: true,
+	}
+	assert.Equal(t, expected, policy)
+
+	encoded, err := json.Marshal(policy)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+
+	options, err := policy.Options()
+	require.NoError(t, err)
+	assert.Equal(t, 10, options.MaxNodes)
+	assert.NotNil(t, options.Hasher)
+
+	_, err = Policy{Hasher: "sha1"}.Options()
+	assert.ErrorIs(t, err, ErrHasherUnknown)
+}
+
+func Test_BuildTrieWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 40))
+	rootHash := tr.MustHash().ToBytes()
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+
+	unrelatedNode := encodeNode(t, trie.Node{
+		PartialKey:   []byte{9},
+		StorageValue: generateBytes(t, 41),
+	})
+
+	// A leaf with an odd partial key length has a padding nibble which
+	// must be zero, but is ignored when decoding the node.
+	padded := encodeNode(t, trie.Node{PartialKey: []byte{1}, StorageValue: []byte{1}})
+	padded[1] |= 0xf0
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		policy            Policy
+		errWrapped        error
+	}{
+		"zero policy": {
+			encodedProofNodes: encodedProofNodes,
+		},
+		"all checks passing": {
+			encodedProofNodes: encodedProofNodes,
+			policy: Policy{
+				Version:          trie.V0,
+				Hasher:           HasherBlake2b256,
+				MaxNodes:         len(encodedProofNodes),
+				RequireMinimal:   true,
+				RequireCanonical: true,
+			},
+		},
+		"unknown hasher": {
+			encodedProofNodes: encodedProofNodes,
+			policy:            Policy{Hasher: "md5"},
+			errWrapped:        ErrHasherUnknown,
+		},
+		"unhashed value for version 1": {
+			encodedProofNodes: encodedProofNodes,
+			policy:            Policy{Version: trie.V1},
+			errWrapped:        ErrProofVersionMismatch,
+		},
+		"not minimal": {
+			encodedProofNodes: append(append([][]byte{}, encodedProofNodes...), unrelatedNode),
+			policy:            Policy{RequireMinimal: true},
+			errWrapped:        ErrProofNotMinimal,
+		},
+		"not canonical": {
+			encodedProofNodes: append(append([][]byte{}, encodedProofNodes...), padded),
+			policy:            Policy{RequireCanonical: true},
+			errWrapped:        ErrProofNodeNotCanonical,
+		},
+		"too many nodes": {
+			encodedProofNodes: encodedProofNodes,
+			policy:            Policy{MaxNodes: 1},
+			errWrapped:        ErrTooManyProofNodes,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proofTrie, err := BuildTrieWithPolicy(testCase.encodedProofNodes,
+				rootHash, testCase.policy)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped == nil {
+				assert.Equal(t, generateBytes(t, 40), proofTrie.Get([]byte("cat")))
+			}
+		})
+	}
+}
+
+func Test_GenerateWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 40))
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	keys := [][]byte{[]byte("cat")}
+	encodedProofNodes, err := GenerateWithPolicy(rootHash, keys, database,
+		Policy{Version: trie.V0, RequireMinimal: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, encodedProofNodes)
+
+	_, err = GenerateWithPolicy(rootHash, keys, database, Policy{MaxNodeSize: 32})
+	assert.ErrorIs(t, err, ErrProofNodeTooLarge)
+}
@@ -0,0 +1,254 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var ErrFetchedNodeHashMismatch = errors.New("fetched node does not match its hash digest")
+
+// NodeFetcher fetches the encoding of a trie node, or of a state
+// version 1 value, from its hash digest, typically from a remote peer
+// or RPC endpoint. It must be safe for concurrent use, and should stop
+// and return an error as soon as the context given is canceled.
+type NodeFetcher interface {
+	FetchNode(ctx context.Context, digest []byte) (encoding []byte, err error)
+}
+
+// NodeFetcherFunc is a function implementing the NodeFetcher interface.
+type NodeFetcherFunc func(ctx context.Context, digest []byte) (encoding []byte, err error)
+
+// FetchNode calls the function itself.
+func (f NodeFetcherFunc) FetchNode(ctx context.Context, digest []byte) (
+	encoding []byte, err error) {
+	return f(ctx, digest)
+}
+
+// PrefetchOptions contains options to prefetch proof nodes.
+type PrefetchOptions struct {
+	// Workers is the maximum number of concurrent fetches.
+	// If it is not strictly positive, the number of CPUs is used.
+	Workers int
+	// VerifyOptions contains the resource limits enforced on the
+	// nodes fetched, where MaxNodes limits the number of nodes fetched,
+	// and the hashers used to check the nodes fetched.
+	VerifyOptions VerifyOptions
+}
+
+// Prefetch fetches the nodes on the paths of the (Little Endian) keys
+// given in the trie with the root hash given, using the node fetcher
+// given, and returns them as encoded proof nodes in canonical order,
+// which prove the values or the absence of the keys. The paths are
+// walked level by level, fetching the nodes of a level concurrently,
+// and each node shared by several paths is fetched only once. Each
+// node fetched is checked to hash to its expected hash digest, so
+// the node fetcher does not need to be trusted.
+func Prefetch(ctx context.Context, rootHash []byte, keys [][]byte,
+	fetcher NodeFetcher, options PrefetchOptions) (
+	encodedProofNodes [][]byte, err error) {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	cursors := make(map[string][]prefetchCursor, 1)
+	for _, key := range keys {
+		cursors[string(rootHash)] = append(cursors[string(rootHash)],
+			prefetchCursor{keyNibbles: sub.KeyLEToNibbles(key)})
+	}
+
+	// fetched maps the hash digests fetched to their encodings,
+	// so nodes shared at different depths are fetched only once.
+	fetched := make(map[string][]byte)
+	for depth := 0; len(cursors) > 0; depth++ {
+		err = options.VerifyOptions.checkDepth(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		digests := make([][]byte, 0, len(cursors))
+		for digest := range cursors {
+			_, ok := fetched[digest]
+			if !ok {
+				digests = append(digests, []byte(digest))
+			}
+		}
+
+		err = options.VerifyOptions.checkNodesCount(len(fetched) + len(digests))
+		if err != nil {
+			return nil, err
+		}
+
+		encodings, err := fetchConcurrently(ctx, digests, fetcher, workers)
+		if err != nil {
+			return nil, err
+		}
+		for i, digest := range digests {
+			fetched[string(digest)] = encodings[i]
+			encodedProofNodes = append(encodedProofNodes, encodings[i])
+		}
+
+		nextCursors := make(map[string][]prefetchCursor)
+		for digest, digestCursors := range cursors {
+			for _, cursor := range digestCursors {
+				nextDigest, nextCursor, err := cursor.advance(fetched[digest],
+					[]byte(digest), options.VerifyOptions)
+				if err != nil {
+					return nil, err
+				}
+				if nextDigest != nil {
+					nextCursors[string(nextDigest)] = append(nextCursors[string(nextDigest)], nextCursor)
+				}
+			}
+		}
+		cursors = nextCursors
+	}
+
+	if len(encodedProofNodes) == 0 {
+		return nil, nil
+	}
+
+	return Canonical(encodedProofNodes, rootHash)
+}
+
+// prefetchCursor is the position of the walk of a key path, which
+// waits for the node or value with a given hash digest to be fetched.
+type prefetchCursor struct {
+	// keyNibbles are the key nibbles left to walk from the node fetched.
+	keyNibbles []byte
+	// value is true if the hash digest is the one of a hashed value.
+	value bool
+}
+
+// advance checks the encoding given hashes to the digest given, and
+// walks the key path from its decoded node down to the next hash
+// referenced node or hashed value, returning its hash digest. It
+// returns a nil digest if the end of the key path is reached.
+func (c prefetchCursor) advance(encoding, digest []byte, options VerifyOptions) (
+	nextDigest []byte, next prefetchCursor, err error) {
+	err = options.checkNodeSize(len(encoding))
+	if err != nil {
+		return nil, next, fmt.Errorf("fetched node for hash digest 0x%x: %w", digest, err)
+	}
+
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+	if c.value {
+		err = options.hashValue(encoding, buffer)
+	} else {
+		err = options.hashEncoding(encoding, buffer)
+	}
+	if err != nil {
+		return nil, next, fmt.Errorf("calculating hash digest: %w", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), digest) {
+		return nil, next, fmt.Errorf("%w: fetched hash digest 0x%x instead of 0x%x",
+			ErrFetchedNodeHashMismatch, buffer.Bytes(), digest)
+	}
+
+	if c.value {
+		return nil, next, nil
+	}
+
+	const unknownIndex = -1
+	node, err := decodeProofNode(encoding, unknownIndex, digest)
+	if err != nil {
+		return nil, next, fmt.Errorf("decoding fetched node: %w", err)
+	}
+
+	keyNibbles := c.keyNibbles
+	for {
+		if !bytes.HasPrefix(keyNibbles, node.PartialKey) {
+			return nil, next, nil
+		}
+		keyNibbles = keyNibbles[len(node.PartialKey):]
+
+		if len(keyNibbles) == 0 {
+			if node.IsHashedValue {
+				return node.StorageValue, prefetchCursor{value: true}, nil
+			}
+			return nil, next, nil
+		} else if node.Kind() == sub.Leaf {
+			return nil, next, nil
+		}
+
+		child := node.Children[keyNibbles[0]]
+		keyNibbles = keyNibbles[1:]
+		if child == nil {
+			return nil, next, nil
+		}
+
+		if len(child.NodeValue) < sub.INLINE_LEN {
+			// child is inlined and already decoded
+			node = child
+			continue
+		}
+
+		return child.NodeValue, prefetchCursor{keyNibbles: keyNibbles}, nil
+	}
+}
+
+// fetchConcurrently fetches the encodings of the hash digests given
+// using up to the number of workers given, and returns them in the
+// order of the digests. It returns the first error encountered, and
+// cancels the other fetches in progress.
+func fetchConcurrently(ctx context.Context, digests [][]byte,
+	fetcher NodeFetcher, workers int) (encodings [][]byte, err error) {
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if workers > len(digests) {
+		workers = len(digests)
+	}
+
+	var errOnce sync.Once
+	var fetchErr error
+	encodings = make([][]byte, len(digests))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				// each worker writes to a distinct index so
+				// no synchronisation is needed on the slice.
+				encoding, err := fetcher.FetchNode(fetchCtx, digests[index])
+				if err != nil {
+					errOnce.Do(func() {
+						fetchErr = fmt.Errorf("fetching node for hash digest 0x%x: %w",
+							digests[index], err)
+						cancel()
+					})
+					continue
+				}
+				encodings[index] = encoding
+			}
+		}()
+	}
+
+	for index := range digests {
+		select {
+		case indexes <- index:
+		case <-fetchCtx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	return encodings, nil
+}
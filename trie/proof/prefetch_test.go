@@ -0,0 +1,140 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test error")
+
+// mapNodeFetcher fetches nodes from a map of hash digest to
+// encoding, and counts the number of fetches for each digest.
+type mapNodeFetcher struct {
+	digestToEncoding map[string][]byte
+	mutex            sync.Mutex
+	fetches          map[string]int
+}
+
+func newMapNodeFetcher(t *testing.T, encodedProofNodes [][]byte) *mapNodeFetcher {
+	t.Helper()
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	require.NoError(t, err)
+	return &mapNodeFetcher{
+		digestToEncoding: digestToEncoding,
+		fetches:          make(map[string]int),
+	}
+}
+
+func (f *mapNodeFetcher) FetchNode(_ context.Context, digest []byte) (
+	encoding []byte, err error) {
+	f.mutex.Lock()
+	f.fetches[string(digest)]++
+	f.mutex.Unlock()
+
+	encoding, ok := f.digestToEncoding[string(digest)]
+	if !ok {
+		return nil, fmt.Errorf("%w: 0x%x", errTest, digest)
+	}
+	return encoding, nil
+}
+
+func Test_Prefetch(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	var allKeys [][]byte
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("key:%02d", i))
+		tr.Put(key, generateBytes(t, uint(30+i)))
+		allKeys = append(allKeys, key)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	allNodes, err := GenerateFromTrie(tr, allKeys)
+	require.NoError(t, err)
+
+	fetcher := newMapNodeFetcher(t, allNodes)
+	keys := [][]byte{[]byte("key:03"), []byte("key:42"), []byte("key:43"), []byte("absent")}
+	encodedProofNodes, err := Prefetch(context.Background(), rootHash, keys,
+		fetcher, PrefetchOptions{Workers: 2})
+	require.NoError(t, err)
+
+	expected, err := GenerateFromTrie(tr, keys[:3])
+	require.NoError(t, err)
+	assert.Equal(t, expected, encodedProofNodes)
+
+	for digest, fetches := range fetcher.fetches {
+		assert.Equalf(t, 1, fetches, "fetches for digest 0x%x", digest)
+	}
+
+	for _, key := range keys[:3] {
+		err = Verify(encodedProofNodes, rootHash, key, tr.Get(key))
+		assert.NoError(t, err)
+	}
+}
+
+func Test_Prefetch_errors(t *testing.T) {
+	t.Parallel()
+
+	leaf := sub.Node{
+		PartialKey:   []byte{2, 4},
+		StorageValue: generateBytes(t, 40),
+	}
+	branch := sub.Node{
+		PartialKey: []byte{1},
+		Children:   padRightChildren([]*sub.Node{nil, nil, nil, &leaf}),
+	}
+	rootHash := blake2bNode(t, branch)
+	key := []byte{0x13, 0x24}
+
+	testCases := map[string]struct {
+		fetcher    NodeFetcher
+		options    PrefetchOptions
+		errWrapped error
+		errMessage string
+	}{
+		"node missing": {
+			fetcher:    newMapNodeFetcher(t, [][]byte{encodeNode(t, branch)}),
+			errWrapped: errTest,
+			errMessage: fmt.Sprintf("fetching node for hash digest 0x%x: test error: 0x%x",
+				blake2bNode(t, leaf), blake2bNode(t, leaf)),
+		},
+		"node hash mismatch": {
+			fetcher: NodeFetcherFunc(func(_ context.Context, digest []byte) ([]byte, error) {
+				return encodeNode(t, leaf), nil
+			}),
+			errWrapped: ErrFetchedNodeHashMismatch,
+			errMessage: fmt.Sprintf("fetched node does not match its hash digest: "+
+				"fetched hash digest 0x%x instead of 0x%x", blake2bNode(t, leaf), rootHash),
+		},
+		"too many nodes": {
+			fetcher: newMapNodeFetcher(t, [][]byte{
+				encodeNode(t, branch), encodeNode(t, leaf)}),
+			options:    PrefetchOptions{VerifyOptions: VerifyOptions{MaxNodes: 1}},
+			errWrapped: ErrTooManyProofNodes,
+			errMessage: "too many proof nodes: 2 exceeds the maximum of 1",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encodedProofNodes, err := Prefetch(context.Background(), rootHash,
+				[][]byte{key}, testCase.fetcher, testCase.options)
+
+			assert.Nil(t, encodedProofNodes)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.EqualError(t, err, testCase.errMessage)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var (
+	ErrPrefixProofIncomplete = errors.New("prefix proof incomplete")
+	ErrPrefixNibbleInvalid   = errors.New("prefix nibble invalid")
+)
+
+// GeneratePrefix generates the encoded proof nodes for the trie
+// corresponding to the root hash given, committing to every key
+// having the (Little Endian) prefix given. The proof contains the
+// nodes on the path to the prefix and all the nodes below it, so
+// the verifier can enumerate all the key-value pairs under the prefix
+// and check none is missing. If no key has the prefix, the proof
+// contains the nodes proving their absence.
+// The database given is used to load the trie using the root hash given.
+func GeneratePrefix(rootHash, prefix []byte, database Database) (
+	encodedProofNodes [][]byte, err error) {
+	return GeneratePrefixNibbles(rootHash, sub.KeyLEToNibbles(prefix), database)
+}
+
+// GeneratePrefixNibbles is like GeneratePrefix but takes the prefix
+// as nibbles, each being a byte in the range [0, 15]. This allows to
+// prove prefixes which are not whole bytes, such as the half byte
+// prefixes given to the on-chain ClearPrefix by some pallets.
+func GeneratePrefixNibbles(rootHash, prefixNibbles []byte, database Database) (
+	encodedProofNodes [][]byte, err error) {
+	err = checkNibbles(prefixNibbles)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := trie.NewEmptyTrie()
+	err = tr.Load(database, util.BytesToHash(rootHash))
+	if err != nil {
+		return nil, fmt.Errorf("loading trie: %w", err)
+	}
+
+	rootNode := tr.RootNode()
+	if rootNode == nil {
+		return nil, nil
+	}
+
+	const isRoot = true
+	return appendPrefixNodes(nil, rootNode, nil, prefixNibbles, isRoot)
+}
+
+// appendPrefixNodes appends the encodings of the node given and of
+// its descendants which may contain keys with the prefix given.
+// The node encoding is only appended if withEncoding is true, which is
+// the case for the root node and for hash referenced nodes, since other
+// nodes are inlined in their parent node encoding. The value preimage of
+// a node with a key having the prefix and a hashed value follows the node.
+func appendPrefixNodes(encodedProofNodes [][]byte, node *sub.Node,
+	keyNibbles, prefixNibbles []byte, withEncoding bool) (
+	newEncodedProofNodes [][]byte, err error) {
+	if withEncoding {
+		// Note we do not use sync.Pool buffers since we would have
+		// to copy it so it persists in encodedProofNodes.
+		encodingBuffer := bytes.NewBuffer(nil)
+		err = node.Encode(encodingBuffer)
+		if err != nil {
+			return nil, fmt.Errorf("encode node: %w", err)
+		}
+		encodedProofNodes = append(encodedProofNodes, encodingBuffer.Bytes())
+	}
+
+	fullKey := concatNibbles(keyNibbles, node.PartialKey)
+	if bytes.HasPrefix(fullKey, prefixNibbles) {
+		encodedProofNodes, err = appendValuePreimage(encodedProofNodes, node)
+		if err != nil {
+			return nil, fmt.Errorf("at key 0x%x: %w", sub.NibblesToKeyLE(fullKey), err)
+		}
+	}
+
+	if node.Kind() == sub.Leaf || !prefixOverlaps(fullKey, prefixNibbles) {
+		return encodedProofNodes, nil
+	}
+
+	// The Merkle values of the children are computed to encode the node,
+	// so they are cached and tell which children are hash referenced.
+	children, err := node.ChildrenMerkleValues()
+	if err != nil {
+		return nil, fmt.Errorf("at key 0x%x: %w", sub.NibblesToKeyLE(fullKey), err)
+	}
+
+	for _, child := range children {
+		childKey := concatNibbles(fullKey, []byte{child.Index})
+		if !prefixOverlaps(childKey, prefixNibbles) {
+			continue
+		}
+		hashReferenced := len(child.MerkleValue) >= sub.INLINE_LEN
+		encodedProofNodes, err = appendPrefixNodes(encodedProofNodes,
+			child.Child, childKey, prefixNibbles, hashReferenced)
+		if err != nil {
+			return nil, err // note: do not wrap since this is recursive
+		}
+	}
+
+	return encodedProofNodes, nil
+}
+
+// VerifyPrefix verifies the encoded proof nodes given commit to all
+// the keys having the (Little Endian) prefix given in the trie with
+// the root hash given, and returns all these key-value pairs as a map
+// from Little Endian key to value. It returns an error wrapping
+// ErrPrefixProofIncomplete if a node which may contain a key with the
+// prefix is missing from the proof. The order of proofs is ignored.
+func VerifyPrefix(encodedProofNodes [][]byte, rootHash, prefix []byte) (
+	entries map[string][]byte, err error) {
+	return VerifyPrefixNibbles(encodedProofNodes, rootHash, sub.KeyLEToNibbles(prefix))
+}
+
+// VerifyPrefixNibbles is like VerifyPrefix but takes the prefix as
+// nibbles, each being a byte in the range [0, 15], to verify proofs
+// generated by GeneratePrefixNibbles for prefixes which are not whole
+// bytes. For an odd number of nibbles, the last prefix nibble is the
+// high nibble of the last key byte, so for example the prefix nibbles
+// [0x1, 0x2, 0x3] match the keys starting with 0x12 followed by a byte
+// in the range [0x30, 0x3f].
+func VerifyPrefixNibbles(encodedProofNodes [][]byte, rootHash, prefixNibbles []byte) (
+	entries map[string][]byte, err error) {
+	err = checkNibbles(prefixNibbles)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encodedProofNodes) == 0 {
+		return nil, fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	rootEncoding, ok := digestToEncoding[string(rootHash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: for root hash 0x%x",
+			ErrRootNodeNotFound, rootHash)
+	}
+
+	root, err := decodeProofNode(rootEncoding,
+		nodeIndex(encodedProofNodes, rootEncoding), rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding root node: %w", err)
+	}
+
+	verifier := prefixVerifier{
+		encodedProofNodes: encodedProofNodes,
+		digestToEncoding:  digestToEncoding,
+		prefixNibbles:     prefixNibbles,
+		entries:           make(map[string][]byte),
+	}
+	err = verifier.collect(root, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifier.entries, nil
+}
+
+type prefixVerifier struct {
+	encodedProofNodes [][]byte
+	digestToEncoding  map[string][]byte
+	prefixNibbles     []byte
+	entries           map[string][]byte
+}
+
+// collect collects the entries with the prefix from the node given
+// and its descendants, resolving hash referenced children from the proof.
+func (v *prefixVerifier) collect(node *sub.Node, keyNibbles []byte) (err error) {
+	fullKey := concatNibbles(keyNibbles, node.PartialKey)
+	if !prefixOverlaps(fullKey, v.prefixNibbles) {
+		// The node and its descendants cannot contain a key with
+		// the prefix, which proves their absence.
+		return nil
+	}
+
+	hasValue := node.Kind() == sub.Leaf || node.StorageValue != nil
+	if hasValue && bytes.HasPrefix(fullKey, v.prefixNibbles) {
+		value := node.StorageValue
+		if node.IsHashedValue {
+			preimage, ok := v.digestToEncoding[string(value)]
+			if !ok {
+				return fmt.Errorf("%w: missing value for hash digest 0x%x at key 0x%x",
+					ErrPrefixProofIncomplete, value, sub.NibblesToKeyLE(fullKey))
+			}
+			value = preimage
+		}
+		v.entries[string(sub.NibblesToKeyLE(fullKey))] = value
+	}
+
+	if node.Kind() == sub.Leaf {
+		return nil
+	}
+
+	for i, child := range node.Children {
+		if child == nil {
+			continue
+		}
+
+		childKey := concatNibbles(fullKey, []byte{byte(i)})
+		if !prefixOverlaps(childKey, v.prefixNibbles) {
+			continue
+		}
+
+		merkleValue := child.NodeValue
+		if len(merkleValue) >= sub.INLINE_LEN {
+			encoding, ok := v.digestToEncoding[string(merkleValue)]
+			if !ok {
+				return fmt.Errorf("%w: missing node for hash digest 0x%x at key 0x%x",
+					ErrPrefixProofIncomplete, merkleValue, sub.NibblesToKeyLE(childKey))
+			}
+
+			child, err = decodeProofNode(encoding,
+				nodeIndex(v.encodedProofNodes, encoding), merkleValue)
+			if err != nil {
+				return fmt.Errorf("decoding child node: %w", err)
+			}
+		}
+
+		err = v.collect(child, childKey)
+		if err != nil {
+			return err // note: do not wrap since this is recursive
+		}
+	}
+
+	return nil
+}
+
+// checkNibbles returns an error wrapping ErrPrefixNibbleInvalid
+// if one of the nibbles given is greater than 15.
+func checkNibbles(nibbles []byte) error {
+	for i, nibble := range nibbles {
+		if nibble > 0xf {
+			return fmt.Errorf("%w: 0x%x at index %d", ErrPrefixNibbleInvalid, nibble, i)
+		}
+	}
+	return nil
+}
+
+// prefixOverlaps returns true if keys starting with the key nibbles
+// given can have the prefix nibbles given.
+func prefixOverlaps(keyNibbles, prefixNibbles []byte) bool {
+	return bytes.HasPrefix(keyNibbles, prefixNibbles) ||
+		bytes.HasPrefix(prefixNibbles, keyNibbles)
+}
+
+func concatNibbles(a, b []byte) (concatenated []byte) {
+	concatenated = make([]byte, 0, len(a)+len(b))
+	concatenated = append(concatenated, a...)
+	return append(concatenated, b...)
+}
@@ -0,0 +1,218 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GeneratePrefix_VerifyPrefix(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"balance:alice": generateBytes(t, 40),
+		"balance:bob":   generateBytes(t, 40),
+		"balance:carol": []byte{1},
+		"nonce:alice":   generateBytes(t, 40),
+		"nonce:bob":     []byte{2},
+	}
+
+	tr := trie.NewEmptyTrie()
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		prefix  []byte
+		entries map[string][]byte
+	}{
+		"all_keys": {
+			entries: keyValues,
+		},
+		"prefix_with_many_keys": {
+			prefix: []byte("balance:"),
+			entries: map[string][]byte{
+				"balance:alice": keyValues["balance:alice"],
+				"balance:bob":   keyValues["balance:bob"],
+				"balance:carol": keyValues["balance:carol"],
+			},
+		},
+		"prefix_equal_to_key": {
+			prefix: []byte("nonce:bob"),
+			entries: map[string][]byte{
+				"nonce:bob": keyValues["nonce:bob"],
+			},
+		},
+		"prefix_without_key": {
+			prefix:  []byte("balance:dave"),
+			entries: map[string][]byte{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encodedProofNodes, err := GeneratePrefix(rootHash, testCase.prefix, database)
+			require.NoError(t, err)
+
+			entries, err := VerifyPrefix(encodedProofNodes, rootHash, testCase.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.entries, entries)
+		})
+	}
+
+	t.Run("incomplete_proof", func(t *testing.T) {
+		t.Parallel()
+
+		prefix := []byte("balance:")
+		encodedProofNodes, err := GeneratePrefix(rootHash, prefix, database)
+		require.NoError(t, err)
+
+		// A single key proof does not commit to the other keys.
+		singleKeyProof, err := Generate(rootHash, [][]byte{[]byte("balance:alice")}, database)
+		require.NoError(t, err)
+		require.Less(t, len(singleKeyProof), len(encodedProofNodes))
+
+		_, err = VerifyPrefix(singleKeyProof, rootHash, prefix)
+		assert.ErrorIs(t, err, ErrPrefixProofIncomplete)
+	})
+
+	t.Run("root_not_found", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := VerifyPrefix([][]byte{{1}}, rootHash, nil)
+		assert.ErrorIs(t, err, ErrRootNodeNotFound)
+	})
+}
+
+func Test_GeneratePrefix_VerifyPrefix_V1(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"balance:alice": generateBytes(t, 40),
+		"balance:bob":   []byte{1},
+		"nonce:alice":   generateBytes(t, 100),
+	}
+
+	tr := trie.NewEmptyTrie()
+	tr.SetVersion(trie.V1)
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	prefix := []byte("balance:")
+	encodedProofNodes, err := GeneratePrefix(rootHash, prefix, database)
+	require.NoError(t, err)
+
+	entries, err := VerifyPrefix(encodedProofNodes, rootHash, prefix)
+	require.NoError(t, err)
+	expectedEntries := map[string][]byte{
+		"balance:alice": keyValues["balance:alice"],
+		"balance:bob":   keyValues["balance:bob"],
+	}
+	assert.Equal(t, expectedEntries, entries)
+}
+
+func Test_GeneratePrefixNibbles_VerifyPrefixNibbles(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"\x12":         generateBytes(t, 40),
+		"\x12\x30":     {1},
+		"\x12\x34\xaa": generateBytes(t, 41),
+		"\x12\x34\xbb": {2},
+		"\x12\x3f":     generateBytes(t, 42),
+		"\x12\x40":     {3},
+		"\x13\x30":     generateBytes(t, 43),
+	}
+
+	tr := trie.NewEmptyTrie()
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		prefixNibbles []byte
+		entries       map[string][]byte
+		errWrapped    error
+		errMessage    string
+	}{
+		"odd_prefix": {
+			prefixNibbles: []byte{0x1, 0x2, 0x3},
+			entries: map[string][]byte{
+				"\x12\x30":     keyValues["\x12\x30"],
+				"\x12\x34\xaa": keyValues["\x12\x34\xaa"],
+				"\x12\x34\xbb": keyValues["\x12\x34\xbb"],
+				"\x12\x3f":     keyValues["\x12\x3f"],
+			},
+		},
+		"odd_prefix_within_partial_key": {
+			prefixNibbles: []byte{0x1, 0x2, 0x3, 0x4, 0xa},
+			entries: map[string][]byte{
+				"\x12\x34\xaa": keyValues["\x12\x34\xaa"],
+			},
+		},
+		"odd_prefix_without_key": {
+			prefixNibbles: []byte{0x1, 0x2, 0x5},
+			entries:       map[string][]byte{},
+		},
+		"single_nibble": {
+			prefixNibbles: []byte{0x1},
+			entries:       keyValues,
+		},
+		"invalid_nibble": {
+			prefixNibbles: []byte{0x1, 0x12},
+			errWrapped:    ErrPrefixNibbleInvalid,
+			errMessage:    "prefix nibble invalid: 0x12 at index 1",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encodedProofNodes, err := GeneratePrefixNibbles(rootHash,
+				testCase.prefixNibbles, database)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+
+			entries, err := VerifyPrefixNibbles(encodedProofNodes, rootHash,
+				testCase.prefixNibbles)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.entries, entries)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/octopus-network/trie-go/substrate/storagekey"
+	"github.com/octopus-network/trie-go/trie"
+)
+
+// Chain profile names supported by LookupProfile.
+const (
+	ProfilePolkadot  = "polkadot"
+	ProfileKusama    = "kusama"
+	ProfileParachain = "parachain"
+)
+
+var ErrProfileUnknown = errors.New("chain profile unknown")
+
+// Profile is a chain profile preset, combining the verification policy
+// suited to the proofs of a chain with the trie key prefixes of its
+// well known storage items.
+type Profile struct {
+	// Name is the name of the profile.
+	Name string
+	// Policy is the verification policy for proofs of the chain.
+	Policy Policy
+	// Prefixes maps the names of well known storage items, such as
+	// "System.Account" or ":code", to their trie key or key prefix.
+	Prefixes map[string][]byte
+}
+
+// maxCodeNodeSize is the maximum proof node size of the profiles, set
+// to accommodate a proof of the runtime code, which is proven as the
+// preimage of a hashed value.
+const maxCodeNodeSize = 8 << 20
+
+// relayChainPolicy is the policy of relay chains, which may have
+// proofs spanning many storage items such as parachain heads.
+var relayChainPolicy = Policy{
+	Version:     trie.V1,
+	Hasher:      HasherBlake2b256,
+	MaxNodes:    4096,
+	MaxDepth:    64,
+	MaxNodeSize: maxCodeNodeSize,
+}
+
+// wellKnownKeys are the trie keys well known to all chains.
+var wellKnownKeys = map[string][]byte{
+	":code":                   []byte(":code"),
+	":heappages":              []byte(":heappages"),
+	":child_storage:default:": trie.ChildStorageKeyPrefix,
+}
+
+var profiles = map[string]struct {
+	policy Policy
+	// storageItems are the well known storage
+	// items, each written as "Pallet.Item".
+	storageItems []string
+}{
+	ProfilePolkadot: {
+		policy: relayChainPolicy,
+		storageItems: []string{"System.Account", "System.Number", "System.Events",
+			"Session.Validators", "Babe.Authorities", "Grandpa.Authorities",
+			"Paras.Heads"},
+	},
+	ProfileKusama: {
+		policy: relayChainPolicy,
+		storageItems: []string{"System.Account", "System.Number", "System.Events",
+			"Session.Validators", "Babe.Authorities", "Grandpa.Authorities",
+			"Paras.Heads"},
+	},
+	ProfileParachain: {
+		policy: Policy{
+			Version:     trie.V1,
+			Hasher:      HasherBlake2b256,
+			MaxNodes:    1024,
+			MaxDepth:    64,
+			MaxNodeSize: maxCodeNodeSize,
+		},
+		storageItems: []string{"System.Account", "System.Number", "System.Events",
+			"Aura.Authorities", "ParachainSystem.ValidationData"},
+	},
+}
+
+// ProfileNames returns the names of the chain profiles in ascending order.
+func ProfileNames() (names []string) {
+	names = make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupProfile returns the chain profile with the name given, which is
+// case insensitive, or an error wrapping ErrProfileUnknown if there is
+// no such profile. The profile returned can be modified freely.
+func LookupProfile(name string) (profile Profile, err error) {
+	name = strings.ToLower(name)
+	definition, ok := profiles[name]
+	if !ok {
+		return profile, fmt.Errorf("%w: %q must be one of %s",
+			ErrProfileUnknown, name, strings.Join(ProfileNames(), ", "))
+	}
+
+	profile = Profile{
+		Name:     name,
+		Policy:   definition.policy,
+		Prefixes: make(map[string][]byte, len(wellKnownKeys)+len(definition.storageItems)),
+	}
+
+	for itemName, key := range wellKnownKeys {
+		profile.Prefixes[itemName] = append([]byte{}, key...)
+	}
+
+	for _, storageItem := range definition.storageItems {
+		pallet, item, _ := strings.Cut(storageItem, ".")
+		prefix, err := storagekey.Value(pallet, item)
+		if err != nil {
+			return profile, fmt.Errorf("computing prefix of %s: %w", storageItem, err)
+		}
+		profile.Prefixes[storageItem] = prefix
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,98 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ProfileNames(t *testing.T) {
+	t.Parallel()
+
+	names := ProfileNames()
+
+	expected := []string{ProfileKusama, ProfileParachain, ProfilePolkadot}
+	assert.Equal(t, expected, names)
+}
+
+func Test_LookupProfile(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		name       string
+		profile    Profile
+		errWrapped error
+		errMessage string
+	}{
+		"unknown profile": {
+			name:       "ethereum",
+			errWrapped: ErrProfileUnknown,
+			errMessage: `chain profile unknown: "ethereum" must be ` +
+				"one of kusama, parachain, polkadot",
+		},
+		"case insensitive name": {
+			name: "Polkadot",
+			profile: Profile{
+				Name:   ProfilePolkadot,
+				Policy: relayChainPolicy,
+			},
+		},
+		"parachain": {
+			name: ProfileParachain,
+			profile: Profile{
+				Name: ProfileParachain,
+				Policy: Policy{
+					Version:     trie.V1,
+					Hasher:      HasherBlake2b256,
+					MaxNodes:    1024,
+					MaxDepth:    64,
+					MaxNodeSize: maxCodeNodeSize,
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			profile, err := LookupProfile(testCase.name)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.profile.Name, profile.Name)
+			assert.Equal(t, testCase.profile.Policy, profile.Policy)
+		})
+	}
+}
+
+func Test_LookupProfile_prefixes(t *testing.T) {
+	t.Parallel()
+
+	profile, err := LookupProfile(ProfilePolkadot)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte(":code"), profile.Prefixes[":code"])
+	assert.Equal(t, trie.ChildStorageKeyPrefix, profile.Prefixes[":child_storage:default:"])
+	systemAccount := util.MustHexToBytes(
+		"0x26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9")
+	assert.Equal(t, systemAccount, profile.Prefixes["System.Account"])
+
+	// Modifying the profile returned does not affect later lookups.
+	profile.Prefixes[":code"][0] = 'x'
+	profile, err = LookupProfile(ProfilePolkadot)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(":code"), profile.Prefixes[":code"])
+
+	options, err := profile.Policy.Options()
+	require.NoError(t, err)
+	assert.Nil(t, options.Hasher)
+	assert.Equal(t, 4096, options.MaxNodes)
+}
@@ -0,0 +1,107 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var ErrFetchedNodeMissing = errors.New("fetched proof nodes missing requested node")
+
+// Fetcher fetches the encoded proof nodes with the hash digests given,
+// typically from a remote peer. It may return the encoded proof nodes
+// in any order, as well as additional encoded proof nodes, which are
+// then used before fetching again. It should stop and return an error
+// as soon as the context given is canceled.
+type Fetcher func(ctx context.Context, digests [][]byte) (encodedProofNodes [][]byte, err error)
+
+// VerifyProgressive verifies the key and value given belong to the trie
+// like VerifyStreaming, but starting from the encoded proof nodes given,
+// which can be empty, and requesting each node missing from the key path
+// with the fetcher given as it is discovered. This allows to complete a
+// proof on demand over the network, instead of requiring all its nodes
+// upfront. It returns the encoded proof nodes given and fetched, which
+// form a proof of the key and can be cached for later verifications.
+// The resource limits of the options given apply to the nodes fetched,
+// so a malicious peer cannot make the verification run forever.
+func VerifyProgressive(ctx context.Context, encodedProofNodes [][]byte,
+	rootHash, key, value []byte, fetcher Fetcher, options VerifyOptions) (
+	proofNodes [][]byte, err error) {
+	err = options.checkNodesCount(len(encodedProofNodes))
+	if err != nil {
+		return nil, err
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, options)
+	if err != nil {
+		return nil, err
+	}
+	proofNodes = append(proofNodes, encodedProofNodes...)
+
+	depth := -1
+	nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+		depth++
+		err = options.checkDepth(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		encoding, ok := digestToEncoding[string(merkleValue)]
+		if ok {
+			return encoding, nil
+		}
+
+		err = ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		fetched, err := fetcher(ctx, [][]byte{merkleValue})
+		if err != nil {
+			return nil, fmt.Errorf("fetching proof node for hash digest 0x%x: %w",
+				merkleValue, err)
+		}
+
+		err = options.checkNodesCount(len(proofNodes) + len(fetched))
+		if err != nil {
+			return nil, err
+		}
+
+		fetchedDigestToEncoding, err := mapDigestToEncoding(fetched, options)
+		if err != nil {
+			return nil, fmt.Errorf("fetched proof nodes: %w", err)
+		}
+		for digest, encoding := range fetchedDigestToEncoding {
+			digestToEncoding[digest] = encoding
+		}
+		proofNodes = append(proofNodes, fetched...)
+
+		encoding, ok = digestToEncoding[string(merkleValue)]
+		if !ok {
+			return nil, fmt.Errorf("%w: for hash digest 0x%x",
+				ErrFetchedNodeMissing, merkleValue)
+		}
+		return encoding, nil
+	}
+
+	proofValue, _, err := walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if proofValue == nil {
+		return nil, fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	// compare the value only if the caller pass a non empty value
+	if len(value) > 0 && !bytes.Equal(value, proofValue) {
+		return nil, fmt.Errorf("%w: expected value %s but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofValue))
+	}
+
+	return proofNodes, nil
+}
@@ -0,0 +1,121 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyProgressive(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("catapult"), generateBytes(t, 41))
+	tr.Put([]byte("dog"), generateBytes(t, 42))
+	rootHash := tr.MustHash().ToBytes()
+	key := []byte("catapult")
+
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{key})
+	require.NoError(t, err)
+	require.Greater(t, len(encodedProofNodes), 1)
+	digestToEncoding := make(map[string][]byte, len(encodedProofNodes))
+	for _, encodedProofNode := range encodedProofNodes {
+		digestToEncoding[string(blake2b(t, encodedProofNode))] = encodedProofNode
+	}
+
+	errFetchTest := errors.New("test error")
+	fetchFromPeer := func(ctx context.Context, digests [][]byte) (
+		encodedProofNodes [][]byte, err error) {
+		for _, digest := range digests {
+			encodedProofNodes = append(encodedProofNodes, digestToEncoding[string(digest)])
+		}
+		return encodedProofNodes, nil
+	}
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := map[string]struct {
+		ctx               context.Context
+		encodedProofNodes [][]byte
+		value             []byte
+		fetcher           Fetcher
+		options           VerifyOptions
+		fetchedCount      int
+		errWrapped        error
+	}{
+		"all nodes fetched": {
+			ctx:          context.Background(),
+			value:        generateBytes(t, 41),
+			fetcher:      fetchFromPeer,
+			fetchedCount: len(encodedProofNodes),
+		},
+		"no node fetched": {
+			ctx:               context.Background(),
+			encodedProofNodes: encodedProofNodes,
+			value:             generateBytes(t, 41),
+			fetcher: func(ctx context.Context, digests [][]byte) ([][]byte, error) {
+				return nil, errFetchTest
+			},
+		},
+		"fetcher error": {
+			ctx: context.Background(),
+			fetcher: func(ctx context.Context, digests [][]byte) ([][]byte, error) {
+				return nil, errFetchTest
+			},
+			errWrapped: errFetchTest,
+		},
+		"requested node not fetched": {
+			ctx: context.Background(),
+			fetcher: func(ctx context.Context, digests [][]byte) ([][]byte, error) {
+				return [][]byte{generateBytes(t, 10)}, nil
+			},
+			errWrapped: ErrFetchedNodeMissing,
+		},
+		"value mismatch": {
+			ctx:        context.Background(),
+			value:      []byte{1},
+			fetcher:    fetchFromPeer,
+			errWrapped: ErrValueMismatchProofTrie,
+		},
+		"too many nodes fetched": {
+			ctx:        context.Background(),
+			fetcher:    fetchFromPeer,
+			options:    VerifyOptions{MaxNodes: 1},
+			errWrapped: ErrTooManyProofNodes,
+		},
+		"context canceled": {
+			ctx:        canceledCtx,
+			fetcher:    fetchFromPeer,
+			errWrapped: context.Canceled,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetchedCount int
+			fetcher := func(ctx context.Context, digests [][]byte) ([][]byte, error) {
+				assert.Len(t, digests, 1)
+				fetched, err := testCase.fetcher(ctx, digests)
+				fetchedCount += len(fetched)
+				return fetched, err
+			}
+
+			proofNodes, err := VerifyProgressive(testCase.ctx, testCase.encodedProofNodes,
+				rootHash, key, testCase.value, fetcher, testCase.options)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped == nil {
+				assert.Equal(t, testCase.fetchedCount, fetchedCount)
+				assert.ElementsMatch(t, encodedProofNodes, proofNodes)
+			}
+		})
+	}
+}
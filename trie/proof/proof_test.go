@@ -48,3 +48,45 @@ func Test_Generate_Verify(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func Test_Generate_Verify_V1(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": []byte{1},
+		"catapora":  generateBytes(t, 100),
+		"dog":       generateBytes(t, 33),
+	}
+
+	tr := trie.NewEmptyTrie()
+	tr.SetVersion(trie.V1)
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+
+	rootHash, err := tr.Hash()
+	require.NoError(t, err)
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	for key, value := range keyValues {
+		fullKeys := [][]byte{[]byte(key)}
+		proof, err := Generate(rootHash.ToBytes(), fullKeys, database)
+		require.NoError(t, err)
+
+		err = Verify(proof, rootHash.ToBytes(), []byte(key), value)
+		require.NoError(t, err)
+
+		proof, err = GenerateFromTrie(tr, fullKeys)
+		require.NoError(t, err)
+
+		err = Verify(proof, rootHash.ToBytes(), []byte(key), value)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,57 @@
+package proof
+
+import (
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// Prune returns the encoded proof nodes, out of the encoded proof nodes
+// given, required to prove the presence or absence of each of the
+// (Little Endian) full keys given in the trie with the root hash given.
+// Nodes unrelated to these keys are discarded, and the nodes returned
+// are deduplicated and ordered as first needed walking down each key.
+// It returns an error wrapping ErrKeyNotFoundInProofTrie if the proof
+// given lacks a node on the path of one of the keys.
+func Prune(encodedProofNodes [][]byte, rootHash []byte, fullKeys [][]byte) (
+	prunedProofNodes [][]byte, err error) {
+	if len(encodedProofNodes) == 0 {
+		return nil, fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]struct{})
+	for _, fullKey := range fullKeys {
+		nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+			encoding, ok := digestToEncoding[string(merkleValue)]
+			if !ok {
+				if len(kept) == 0 {
+					return nil, fmt.Errorf("%w: for root hash 0x%x",
+						ErrRootNodeNotFound, rootHash)
+				}
+				return nil, fmt.Errorf("%w: %s in proof trie for root hash 0x%x: "+
+					"node for hash digest 0x%x not in proof",
+					ErrKeyNotFoundInProofTrie, bytesToString(fullKey), rootHash, merkleValue)
+			}
+
+			_, seen := kept[string(merkleValue)]
+			if !seen {
+				kept[string(merkleValue)] = struct{}{}
+				prunedProofNodes = append(prunedProofNodes, encoding)
+			}
+			return encoding, nil
+		}
+
+		_, _, err = walkKeyPath(rootHash, sub.KeyLEToNibbles(fullKey), nextEncoding, VerifyOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("walking to key 0x%x: %w", fullKey, err)
+		}
+	}
+
+	return prunedProofNodes, nil
+}
@@ -0,0 +1,94 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Prune(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("catapulta"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 40))
+	tr.Put([]byte("doge"), []byte{1})
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	allKeys := [][]byte{[]byte("cat"), []byte("catapulta"), []byte("dog"), []byte("doge")}
+	encodedProofNodes, err := Generate(rootHash, allKeys, database)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		fullKeys [][]byte
+		// provedKeys are the keys whose generated proof
+		// contains the same nodes as the pruned proof.
+		provedKeys [][]byte
+	}{
+		"no_key": {},
+		"single_key": {
+			fullKeys:   [][]byte{[]byte("catapulta")},
+			provedKeys: [][]byte{[]byte("catapulta")},
+		},
+		"keys_sharing_nodes": {
+			fullKeys:   [][]byte{[]byte("dog"), []byte("cat"), []byte("doge")},
+			provedKeys: [][]byte{[]byte("dog"), []byte("cat"), []byte("doge")},
+		},
+		"absent_key": {
+			// The path to "cow" diverges in the "cat" branch.
+			fullKeys:   [][]byte{[]byte("cow")},
+			provedKeys: [][]byte{[]byte("cat")},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			pruned, err := Prune(encodedProofNodes, rootHash, testCase.fullKeys)
+			require.NoError(t, err)
+
+			expected, err := GenerateFromTrie(tr, testCase.provedKeys)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, expected, pruned)
+
+			for _, fullKey := range testCase.fullKeys {
+				value := tr.Get(fullKey)
+				if value == nil {
+					continue
+				}
+				err = VerifyStreaming(pruned, rootHash, fullKey, value)
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("missing_node", func(t *testing.T) {
+		t.Parallel()
+
+		catProof, err := Generate(rootHash, [][]byte{[]byte("cat")}, database)
+		require.NoError(t, err)
+
+		_, err = Prune(catProof, rootHash, [][]byte{[]byte("dog")})
+		assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+	})
+
+	t.Run("root_not_found", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Prune([][]byte{{1}}, rootHash, [][]byte{[]byte("dog")})
+		assert.ErrorIs(t, err, ErrRootNodeNotFound)
+	})
+}
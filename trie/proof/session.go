@@ -0,0 +1,156 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+)
+
+// ReadSession records the trie nodes visited by a sequence of reads
+// of a trie, and exports a single proof for the results of all these
+// reads. Unlike Generate, which proves the values of existing keys,
+// the proof also proves absent keys, next keys and prefix scans, so a
+// verifier can replay the same sequence of reads on the trie built
+// from the proof with BuildTrie, and obtain the same results.
+// The trie must not be modified during the session, and a session
+// is not safe for concurrent use.
+type ReadSession struct {
+	trie *trie.Trie
+	// encodedProofNodes are the encodings of the nodes
+	// visited, deduplicated using encodingsSeen.
+	encodedProofNodes [][]byte
+	encodingsSeen     map[string]struct{}
+}
+
+// NewReadSession returns a read session recording the reads of the trie given.
+func NewReadSession(t *trie.Trie) *ReadSession {
+	return &ReadSession{
+		trie:          t,
+		encodingsSeen: make(map[string]struct{}),
+	}
+}
+
+// Get returns the value at the (Little Endian) key given, as
+// trie Get does, and records the nodes on the key path, which
+// prove the value or the absence of the key.
+func (s *ReadSession) Get(key []byte) (value []byte, err error) {
+	err = s.recordPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.trie.TryGet(key)
+}
+
+// NextKey returns the next key after the (Little Endian) key given,
+// as trie NextKey does, and records the nodes on the paths of both
+// keys, which prove no key is in between.
+func (s *ReadSession) NextKey(key []byte) (nextKey []byte, err error) {
+	err = s.recordPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nextKey = s.trie.NextKey(key)
+	if nextKey == nil {
+		return nil, nil
+	}
+
+	err = s.recordPath(nextKey)
+	if err != nil {
+		return nil, err
+	}
+	return nextKey, nil
+}
+
+// KeysWithPrefix returns the keys having the (Little Endian) prefix
+// given, as trie GetKeysWithPrefix does, and records the nodes on the
+// path to the prefix and all the nodes below it.
+func (s *ReadSession) KeysWithPrefix(prefix []byte) (keys [][]byte, err error) {
+	root := s.trie.RootNode()
+	if root != nil {
+		prefixNibbles := sub.KeyLEToNibbles(prefix)
+		const isRoot = true
+		encodedProofNodes, err := appendPrefixNodes(nil, root, nil, prefixNibbles, isRoot)
+		if err != nil {
+			return nil, fmt.Errorf("recording nodes for prefix 0x%x: %w", prefix, err)
+		}
+		s.record(encodedProofNodes)
+	}
+
+	return s.trie.GetKeysWithPrefix(prefix), nil
+}
+
+// Proof returns the encoded proof nodes proving the results of all
+// the reads of the session so far, in canonical order. It returns
+// no encoded proof node if the trie is empty.
+func (s *ReadSession) Proof() (encodedProofNodes [][]byte, err error) {
+	if len(s.encodedProofNodes) == 0 {
+		return nil, nil
+	}
+
+	rootHash, err := s.trie.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("hashing trie: %w", err)
+	}
+
+	return Canonical(s.encodedProofNodes, rootHash.ToBytes())
+}
+
+func (s *ReadSession) recordPath(key []byte) (err error) {
+	encodedProofNodes, err := appendPathNodes(nil, s.trie.RootNode(), sub.KeyLEToNibbles(key))
+	if err != nil {
+		return fmt.Errorf("recording nodes for key 0x%x: %w", key, err)
+	}
+	s.record(encodedProofNodes)
+	return nil
+}
+
+func (s *ReadSession) record(encodedProofNodes [][]byte) {
+	for _, encodedProofNode := range encodedProofNodes {
+		_, seen := s.encodingsSeen[string(encodedProofNode)]
+		if seen {
+			continue
+		}
+		s.encodingsSeen[string(encodedProofNode)] = struct{}{}
+		s.encodedProofNodes = append(s.encodedProofNodes, encodedProofNode)
+	}
+}
+
+// appendPathNodes appends the encodings of the nodes on the path of the
+// key nibbles given from the root node given, down to the node with the
+// key or to the node proving the key is absent from the trie.
+// Non root node encodings smaller than 32 bytes are not appended since
+// they are inlined in their parent node encoding.
+func appendPathNodes(encodedProofNodes [][]byte, root *sub.Node, keyNibbles []byte) (
+	newEncodedProofNodes [][]byte, err error) {
+	isRoot := true
+	for node := root; node != nil; {
+		// Note we do not use sync.Pool buffers since we would have
+		// to copy it so it persists in encodedProofNodes.
+		encodingBuffer := bytes.NewBuffer(nil)
+		err = node.Encode(encodingBuffer)
+		if err != nil {
+			return nil, fmt.Errorf("encode node: %w", err)
+		}
+
+		if isRoot || encodingBuffer.Len() >= 32 {
+			encodedProofNodes = append(encodedProofNodes, encodingBuffer.Bytes())
+		}
+		isRoot = false
+
+		if node.Kind() == sub.Leaf || !bytes.HasPrefix(keyNibbles, node.PartialKey) {
+			break
+		}
+
+		keyNibbles = keyNibbles[len(node.PartialKey):]
+		if len(keyNibbles) == 0 {
+			break
+		}
+		node = node.Children[keyNibbles[0]]
+		keyNibbles = keyNibbles[1:]
+	}
+
+	return encodedProofNodes, nil
+}
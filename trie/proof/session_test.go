@@ -0,0 +1,109 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadSession(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("account:%03d", i))
+		tr.Put(key, generateBytes(t, uint(30+i%20)))
+		tr.Put([]byte(fmt.Sprintf("nonce:%03d", i)), []byte{byte(i)})
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	// reads is a sequence of reads done through a read session,
+	// and then replayed on the trie built from the session proof.
+	type reads struct {
+		get    func(key []byte) ([]byte, error)
+		next   func(key []byte) ([]byte, error)
+		prefix func(prefix []byte) ([][]byte, error)
+	}
+	readSequence := func(t *testing.T, r reads) (results []interface{}) {
+		t.Helper()
+		for _, key := range []string{"account:010", "account:0105", "zebra"} {
+			value, err := r.get([]byte(key))
+			require.NoError(t, err)
+			results = append(results, value)
+		}
+		for _, key := range []string{"account:050", "account:0505", "account:099", "nonce:099", ""} {
+			nextKey, err := r.next([]byte(key))
+			require.NoError(t, err)
+			results = append(results, nextKey)
+		}
+		keys, err := r.prefix([]byte("nonce:02"))
+		require.NoError(t, err)
+		return append(results, keys)
+	}
+
+	session := NewReadSession(tr)
+	sessionResults := readSequence(t, reads{
+		get:    session.Get,
+		next:   session.NextKey,
+		prefix: session.KeysWithPrefix,
+	})
+
+	trieResults := readSequence(t, reads{
+		get:    func(key []byte) ([]byte, error) { return tr.Get(key), nil },
+		next:   func(key []byte) ([]byte, error) { return tr.NextKey(key), nil },
+		prefix: func(prefix []byte) ([][]byte, error) { return tr.GetKeysWithPrefix(prefix), nil },
+	})
+	require.Equal(t, trieResults, sessionResults)
+	assert.Len(t, sessionResults[len(sessionResults)-1], 10)
+
+	encodedProofNodes, err := session.Proof()
+	require.NoError(t, err)
+
+	canonical, err := Canonical(encodedProofNodes, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, canonical, encodedProofNodes)
+
+	entries, err := tr.EntriesWithOptions(trie.EntriesOptions{})
+	require.NoError(t, err)
+	allKeys := make([][]byte, 0, len(entries))
+	for key := range entries {
+		allKeys = append(allKeys, []byte(key))
+	}
+	fullProof, err := GenerateFromTrie(tr, allKeys)
+	require.NoError(t, err)
+	assert.Less(t, len(encodedProofNodes), len(fullProof))
+
+	proofTrie, err := BuildTrie(encodedProofNodes, rootHash)
+	require.NoError(t, err)
+	proofResults := readSequence(t, reads{
+		get:    func(key []byte) ([]byte, error) { return proofTrie.Get(key), nil },
+		next:   func(key []byte) ([]byte, error) { return proofTrie.NextKey(key), nil },
+		prefix: func(prefix []byte) ([][]byte, error) { return proofTrie.GetKeysWithPrefix(prefix), nil },
+	})
+	assert.Equal(t, sessionResults, proofResults)
+}
+
+func Test_ReadSession_emptyTrie(t *testing.T) {
+	t.Parallel()
+
+	session := NewReadSession(trie.NewEmptyTrie())
+
+	value, err := session.Get([]byte{1})
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	nextKey, err := session.NextKey([]byte{1})
+	require.NoError(t, err)
+	assert.Nil(t, nextKey)
+
+	keys, err := session.KeysWithPrefix([]byte{1})
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	encodedProofNodes, err := session.Proof()
+	require.NoError(t, err)
+	assert.Empty(t, encodedProofNodes)
+}
@@ -0,0 +1,285 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// ErrProofHashMismatch is returned when a proof node or value preimage
+// found while walking a key path does not hash to its expected digest.
+var ErrProofHashMismatch = errors.New("proof item does not match its hash digest")
+
+// VerifyStreaming verifies a given key and value belongs to the trie
+// like Verify, but without building the proof trie. Instead, it walks
+// from the root hash down the key nibble path, decoding only the nodes
+// on the path, which keeps memory usage low for large proofs.
+// The order of proofs is ignored, and a nil error is returned on success.
+func VerifyStreaming(encodedProofNodes [][]byte, rootHash, key, value []byte) (err error) {
+	return VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value, VerifyOptions{})
+}
+
+// VerifyStreamingWithOptions is like VerifyStreaming but enforces the
+// resource limits given, where the depth is the number of hash referenced
+// nodes walked below the root node.
+func VerifyStreamingWithOptions(encodedProofNodes [][]byte, rootHash, key, value []byte,
+	options VerifyOptions) (err error) {
+	proofValue, err := readStreaming(encodedProofNodes, rootHash, key, options)
+	if err != nil {
+		return err
+	}
+
+	// compare the value only if the caller pass a non empty value
+	if len(value) > 0 && !bytes.Equal(value, proofValue) {
+		return fmt.Errorf("%w: expected value %s but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofValue))
+	}
+
+	return nil
+}
+
+// readStreaming returns the value proved for the key given, walking the
+// key nibble path from the root hash given and decoding only the nodes
+// on the path. It returns an error wrapping ErrKeyNotFoundInProofTrie
+// if the key is not in the proof.
+func readStreaming(encodedProofNodes [][]byte, rootHash, key []byte,
+	options VerifyOptions) (value []byte, err error) {
+	value, err = lookupStreaming(encodedProofNodes, rootHash, key, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	return value, nil
+}
+
+// lookupStreaming is like readStreaming but returns a nil value and
+// no error if the proof shows the key is absent from the trie.
+func lookupStreaming(encodedProofNodes [][]byte, rootHash, key []byte,
+	options VerifyOptions) (value []byte, err error) {
+	nextEncoding, err := newProofEncodings(encodedProofNodes, rootHash, key, options)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _, err = walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, options)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// newProofEncodings checks the encoded proof nodes given against the
+// options, and returns a function returning the encoding of a proof node
+// from its Merkle value, for the walk of the key path from the root hash.
+func newProofEncodings(encodedProofNodes [][]byte, rootHash, key []byte,
+	options VerifyOptions) (
+	nextEncoding func(merkleValue []byte) (encoding []byte, err error), err error) {
+	if len(encodedProofNodes) == 0 {
+		return nil, fmt.Errorf("%w: for Merkle root hash 0x%x",
+			ErrEmptyProof, rootHash)
+	}
+
+	err = options.checkNodesCount(len(encodedProofNodes))
+	if err != nil {
+		return nil, err
+	}
+
+	digestToEncoding, err := mapDigestToEncoding(encodedProofNodes, options)
+	if err != nil {
+		return nil, err
+	}
+
+	nextEncoding = func(merkleValue []byte) (encoding []byte, err error) {
+		encoding, ok := digestToEncoding[string(merkleValue)]
+		if !ok {
+			if bytes.Equal(merkleValue, rootHash) {
+				return nil, fmt.Errorf("%w: for root hash 0x%x",
+					ErrRootNodeNotFound, rootHash)
+			}
+			return nil, fmt.Errorf("%w: %s in proof trie for root hash 0x%x: "+
+				"node for hash digest 0x%x not in proof",
+				ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash, merkleValue)
+		}
+		return encoding, nil
+	}
+	return nextEncoding, nil
+}
+
+// mapDigestToEncoding returns a map from the hash digest of each
+// encoded proof node given to its encoding, without decoding them.
+// The size of each encoded proof node is checked against the options.
+func mapDigestToEncoding(encodedProofNodes [][]byte, options VerifyOptions) (
+	digestToEncoding map[string][]byte, err error) {
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+
+	digestToEncoding = make(map[string][]byte, len(encodedProofNodes))
+	for i, encodedProofNode := range encodedProofNodes {
+		err = options.checkNodeSize(len(encodedProofNode))
+		if err != nil {
+			return nil, fmt.Errorf("proof node at index %d: %w", i, err)
+		}
+
+		err = options.mapValueDigest(digestToEncoding, encodedProofNode, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("proof node at index %d: %w", i, err)
+		}
+
+		buffer.Reset()
+		err = options.hashEncoding(encodedProofNode, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("calculating Merkle value: %w", err)
+		}
+		digestToEncoding[buffer.String()] = encodedProofNode
+	}
+	return digestToEncoding, nil
+}
+
+// walkKeyPath walks from the root node down the key nibbles given,
+// decoding only the nodes on the key path. The nextEncoding function
+// is called to obtain the encoding of each hash referenced node,
+// starting with the root node, and each encoding is checked to hash,
+// using the hasher of the options given, to the expected Merkle value.
+// It returns the value found, or nil if the key is not in the trie,
+// and the child indexes taken at each branch.
+// If the node found has a hashed value (state version 1), nextEncoding
+// is called once more with the value hash to obtain the value preimage,
+// which is checked to hash to the value hash using the value hasher of
+// the options given. This call does not count as a depth level.
+// A node or value preimage not matching its hash digest results in an
+// error wrapping ErrProofHashMismatch.
+func walkKeyPath(rootHash, keyNibbles []byte,
+	nextEncoding func(merkleValue []byte) (encoding []byte, err error),
+	options VerifyOptions) (
+	value, path []byte, err error) {
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+	loadEncoding := checkEncodings(nextEncoding, options, buffer)
+
+	node, path, err := walkToKeyNode(rootHash, keyNibbles, loadEncoding)
+	if err != nil {
+		return nil, nil, err
+	} else if node == nil {
+		return nil, path, nil
+	}
+
+	if node.IsHashedValue {
+		value, err = nextEncoding(node.StorageValue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading hashed value: %w", err)
+		}
+
+		buffer.Reset()
+		err = options.hashValue(value, buffer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("hashing value: %w", err)
+		}
+		if !bytes.Equal(buffer.Bytes(), node.StorageValue) {
+			return nil, nil, fmt.Errorf("%w: value hash digest 0x%x does not match expected 0x%x",
+				ErrProofHashMismatch, buffer.Bytes(), node.StorageValue)
+		}
+		return value, path, nil
+	}
+	return node.StorageValue, path, nil
+}
+
+// checkEncodings returns a function calling nextEncoding and checking
+// the encoding returned hashes, using the hasher of the options given,
+// to the Merkle value given. The function is called for each node of
+// the key path, starting with the root node at depth 0, and enforces
+// the depth limit of the options given. The buffer given is used for
+// hashing.
+func checkEncodings(nextEncoding func(merkleValue []byte) (encoding []byte, err error),
+	options VerifyOptions, buffer *bytes.Buffer) (
+	loadEncoding func(merkleValue []byte) (encoding []byte, err error)) {
+	depth := -1
+	return func(merkleValue []byte) (encoding []byte, err error) {
+		depth++
+		err = options.checkDepth(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		encoding, err = nextEncoding(merkleValue)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Reset()
+		err = options.hashEncoding(encoding, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("calculating Merkle value: %w", err)
+		}
+		if !bytes.Equal(buffer.Bytes(), merkleValue) {
+			return nil, fmt.Errorf("%w: node hash digest 0x%x does not match expected 0x%x",
+				ErrProofHashMismatch, buffer.Bytes(), merkleValue)
+		}
+		return encoding, nil
+	}
+}
+
+// walkToKeyNode walks from the root node down the key nibbles given,
+// loading each hash referenced node encoding with the loadEncoding
+// function given. It returns the node with the key given, or nil if
+// the key is not in the trie, and the child indexes taken at each branch.
+func walkToKeyNode(rootHash, keyNibbles []byte,
+	loadEncoding func(merkleValue []byte) (encoding []byte, err error)) (
+	node *sub.Node, path []byte, err error) {
+	loadNode := func(merkleValue []byte) (node *sub.Node, err error) {
+		encoding, err := loadEncoding(merkleValue)
+		if err != nil {
+			return nil, err
+		}
+
+		const unknownIndex = -1
+		node, err = decodeProofNode(encoding, unknownIndex, merkleValue)
+		if err != nil {
+			return nil, fmt.Errorf("decoding node: %w", err)
+		}
+		return node, nil
+	}
+
+	node, err = loadNode(rootHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		if !bytes.HasPrefix(keyNibbles, node.PartialKey) {
+			return nil, path, nil
+		}
+		keyNibbles = keyNibbles[len(node.PartialKey):]
+
+		if len(keyNibbles) == 0 {
+			return node, path, nil
+		} else if node.Kind() == sub.Leaf {
+			return nil, path, nil
+		}
+
+		childIndex := keyNibbles[0]
+		keyNibbles = keyNibbles[1:]
+		path = append(path, childIndex)
+		child := node.Children[childIndex]
+		if child == nil {
+			return nil, path, nil
+		}
+
+		if len(child.NodeValue) < sub.INLINE_LEN {
+			// child is inlined and already decoded
+			node = child
+			continue
+		}
+
+		node, err = loadNode(child.NodeValue)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package proof
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyStreamingWithOptions(t *testing.T) {
+	t.Parallel()
+
+	keyValues := map[string][]byte{
+		"cat":       generateBytes(t, 40),
+		"catapulta": generateBytes(t, 40),
+		"dog":       generateBytes(t, 33),
+		"doguinho":  []byte("doguinho"),
+	}
+
+	tr := trie.NewEmptyTrie()
+	for key, value := range keyValues {
+		tr.Put([]byte(key), value)
+	}
+	rootHash := tr.MustHash().ToBytes()
+
+	database, err := chaindb.NewBadgerDB(&chaindb.Config{
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	err = tr.WriteDirty(database)
+	require.NoError(t, err)
+
+	encodedProofNodes, err := Generate(rootHash,
+		[][]byte{[]byte("catapulta"), []byte("dog")}, database)
+	require.NoError(t, err)
+	catProofNodes, err := Generate(rootHash, [][]byte{[]byte("cat")}, database)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		key               []byte
+		value             []byte
+		options           VerifyOptions
+		errWrapped        error
+	}{
+		"empty_proof": {
+			rootHash:   rootHash,
+			key:        []byte("cat"),
+			errWrapped: ErrEmptyProof,
+		},
+		"root_not_found": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          []byte{1, 2, 3},
+			key:               []byte("cat"),
+			errWrapped:        ErrRootNodeNotFound,
+		},
+		"key_found_without_value_check": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("catapulta"),
+		},
+		"key_found_with_matching_value": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			value:             keyValues["dog"],
+		},
+		"key_found_with_mismatching_value": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			value:             []byte("cat"),
+			errWrapped:        ErrValueMismatchProofTrie,
+		},
+		"key_node_not_in_proof": {
+			encodedProofNodes: catProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			errWrapped:        ErrKeyNotFoundInProofTrie,
+		},
+		"key_not_in_trie": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("horse"),
+			errWrapped:        ErrKeyNotFoundInProofTrie,
+		},
+		"too_many_nodes": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			options:           VerifyOptions{MaxNodes: 1},
+			errWrapped:        ErrTooManyProofNodes,
+		},
+		"node_too_large": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			options:           VerifyOptions{MaxNodeSize: 1},
+			errWrapped:        ErrProofNodeTooLarge,
+		},
+		"too_deep": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("catapulta"),
+			options:           VerifyOptions{MaxDepth: 1},
+			errWrapped:        ErrProofTrieTooDeep,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyStreamingWithOptions(testCase.encodedProofNodes,
+				testCase.rootHash, testCase.key, testCase.value, testCase.options)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+}
+
+func Test_VerifyStreamingWithOptions_hashedValue(t *testing.T) {
+	t.Parallel()
+
+	value := generateBytes(t, 40)
+	leafWithHashedValue := &sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: value,
+		MustBeHashed: true,
+	}
+	root := sub.Node{
+		PartialKey: []byte{},
+		Children: padRightChildren([]*sub.Node{
+			nil, leafWithHashedValue,
+		}),
+	}
+	assertLongEncoding(t, *leafWithHashedValue)
+
+	rootHash := blake2bNode(t, root)
+	encodedProofNodes := [][]byte{
+		encodeNode(t, root),
+		encodeNode(t, *leafWithHashedValue),
+		value,
+	}
+	key := []byte{0x12}
+
+	// The value preimage does not count as a depth level.
+	err := VerifyStreamingWithOptions(encodedProofNodes, rootHash, key, value,
+		VerifyOptions{MaxDepth: 1})
+	require.NoError(t, err)
+
+	// A value preimage not matching its hash digest is rejected.
+	encodings := map[string][]byte{
+		string(rootHash): encodedProofNodes[0],
+		string(blake2bNode(t, *leafWithHashedValue)): encodedProofNodes[1],
+	}
+	nextEncoding := func(merkleValue []byte) (encoding []byte, err error) {
+		encoding, ok := encodings[string(merkleValue)]
+		if !ok {
+			return append([]byte{0}, value...), nil
+		}
+		return encoding, nil
+	}
+	_, _, err = walkKeyPath(rootHash, sub.KeyLEToNibbles(key), nextEncoding, VerifyOptions{})
+	assert.ErrorIs(t, err, ErrProofHashMismatch)
+}
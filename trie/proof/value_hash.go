@@ -0,0 +1,63 @@
+package proof
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// VerifyValueHash verifies the value at the (Little Endian) key given
+// hashes to the value hash given, without the caller needing the value
+// itself, which is useful for large values. The value hash is the
+// blake2b-256 digest of the value. For nodes storing a hashed value
+// (state version 1), the stored hash is compared directly, so the proof
+// does not need to contain the value preimage.
+// It returns an error wrapping ErrKeyNotFoundInProofTrie if the key is
+// not in the proof, and an error wrapping ErrValueMismatchProofTrie if
+// the value does not hash to the value hash given.
+func VerifyValueHash(encodedProofNodes [][]byte, rootHash, key, valueHash []byte) (err error) {
+	return VerifyValueHashWithOptions(encodedProofNodes, rootHash, key, valueHash, VerifyOptions{})
+}
+
+// VerifyValueHashWithOptions is like VerifyValueHash but enforces the
+// resource limits given, and uses the value hasher of the options given,
+// if any, to hash the value instead of blake2b-256.
+func VerifyValueHashWithOptions(encodedProofNodes [][]byte, rootHash, key, valueHash []byte,
+	options VerifyOptions) (err error) {
+	nextEncoding, err := newProofEncodings(encodedProofNodes, rootHash, key, options)
+	if err != nil {
+		return err
+	}
+
+	buffer := sub.DigestBuffers.Get()
+	defer sub.DigestBuffers.Put(buffer)
+	loadEncoding := checkEncodings(nextEncoding, options, buffer)
+
+	node, _, err := walkToKeyNode(rootHash, sub.KeyLEToNibbles(key), loadEncoding)
+	if err != nil {
+		return err
+	} else if node == nil || (node.StorageValue == nil && !node.IsHashedValue) {
+		return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	var proofValueHash []byte
+	if node.IsHashedValue {
+		proofValueHash = node.StorageValue
+	} else {
+		buffer.Reset()
+		err = options.hashValue(node.StorageValue, buffer)
+		if err != nil {
+			return fmt.Errorf("hashing value: %w", err)
+		}
+		proofValueHash = buffer.Bytes()
+	}
+
+	if !bytes.Equal(proofValueHash, valueHash) {
+		return fmt.Errorf("%w: expected value hash 0x%x but got value hash 0x%x from proof trie",
+			ErrValueMismatchProofTrie, valueHash, proofValueHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package proof
+
+import (
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyValueHash(t *testing.T) {
+	t.Parallel()
+
+	largeValue := generateBytes(t, 40)
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), largeValue)
+	tr.Put([]byte("catapulta"), []byte{1})
+	tr.Put([]byte("dog"), largeValue)
+	rootHash := tr.MustHash().ToBytes()
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("cat")})
+	require.NoError(t, err)
+
+	// The leaf with a hashed value (state version 1) is
+	// proved without including the value preimage.
+	hashedValueLeaf := &sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: largeValue,
+		MustBeHashed: true,
+	}
+	hashedValueRoot := sub.Node{
+		PartialKey: []byte{},
+		Children: padRightChildren([]*sub.Node{
+			nil, hashedValueLeaf,
+		}),
+	}
+	hashedValueProof := [][]byte{
+		encodeNode(t, hashedValueRoot),
+		encodeNode(t, *hashedValueLeaf),
+	}
+
+	largeValueHash := util.MustBlake2bHash(largeValue).ToBytes()
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		key               []byte
+		valueHash         []byte
+		errWrapped        error
+	}{
+		"value hash matching": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("cat"),
+			valueHash:         largeValueHash,
+		},
+		"value hash mismatching": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("cat"),
+			valueHash:         make([]byte, 32),
+			errWrapped:        ErrValueMismatchProofTrie,
+		},
+		"key not in trie": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("cab"),
+			valueHash:         largeValueHash,
+			errWrapped:        ErrKeyNotFoundInProofTrie,
+		},
+		"key node not in proof": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          rootHash,
+			key:               []byte("dog"),
+			valueHash:         largeValueHash,
+			errWrapped:        ErrKeyNotFoundInProofTrie,
+		},
+		"root not found": {
+			encodedProofNodes: encodedProofNodes,
+			rootHash:          make([]byte, 32),
+			key:               []byte("cat"),
+			valueHash:         largeValueHash,
+			errWrapped:        ErrRootNodeNotFound,
+		},
+		"hashed value without preimage": {
+			encodedProofNodes: hashedValueProof,
+			rootHash:          blake2bNode(t, hashedValueRoot),
+			key:               []byte{0x12},
+			valueHash:         largeValueHash,
+		},
+		"hashed value mismatching": {
+			encodedProofNodes: hashedValueProof,
+			rootHash:          blake2bNode(t, hashedValueRoot),
+			key:               []byte{0x12},
+			valueHash:         make([]byte, 32),
+			errWrapped:        ErrValueMismatchProofTrie,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyValueHash(testCase.encodedProofNodes, testCase.rootHash,
+				testCase.key, testCase.valueHash)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package proof
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	sub "github.com/octopus-network/trie-go/substrate"
 	"github.com/octopus-network/trie-go/trie"
@@ -19,24 +21,82 @@ var (
 // a proof trie based on the encoded proof nodes given. The order of proofs is ignored.
 // A nil error is returned on success.
 func Verify(encodedProofNodes [][]byte, rootHash, key, value []byte) (err error) {
-	proofTrie, err := BuildTrie(encodedProofNodes, rootHash)
+	return VerifyWithOptions(encodedProofNodes, rootHash, key, value, VerifyOptions{})
+}
+
+// VerifyWithOptions is like Verify but enforces the resource limits
+// given when building the proof trie.
+func VerifyWithOptions(encodedProofNodes [][]byte, rootHash, key, value []byte,
+	options VerifyOptions) (err error) {
+	return VerifyContext(context.Background(), encodedProofNodes, rootHash, key, value, options)
+}
+
+// VerifyContext is like VerifyWithOptions but stops building the proof
+// trie and returns an error wrapping the context error as soon as the
+// context given is canceled or its deadline is exceeded.
+func VerifyContext(ctx context.Context, encodedProofNodes [][]byte,
+	rootHash, key, value []byte, options VerifyOptions) (err error) {
+	done := options.observeVerification(encodedProofNodes)
+	defer func() { done(err) }()
+
+	proofTrie, err := buildTrie(ctx, encodedProofNodes, rootHash, options, nil, nil)
 	if err != nil {
-		// return fmt.Errorf("building trie from proof encoded nodes: %w", err)
-		return nil
-	}
-	if proofTrie != nil {
-		proofTrieValue := proofTrie.Get(key)
-		if proofTrieValue == nil {
-			// return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
-			// 	ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
-			return nil
-		}
-		// compare the value only if the caller pass a non empty value
-		if len(value) > 0 && !bytes.Equal(value, proofTrieValue) {
-			// return fmt.Errorf("%w: expected value %s but got value %s from proof trie",
-			// 	ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofTrieValue))
-			return nil
-		}
+		return fmt.Errorf("building trie from proof encoded nodes: %w", err)
+	}
+
+	proofTrieValue, err := proofTrie.TryGet(key)
+	if err != nil {
+		return fmt.Errorf("getting key %s from proof trie for root hash 0x%x: %w",
+			bytesToString(key), rootHash, err)
+	} else if proofTrieValue == nil {
+		return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	}
+
+	// compare the value only if the caller pass a non empty value
+	if len(value) > 0 && !bytes.Equal(value, proofTrieValue) {
+		return fmt.Errorf("%w: expected value %s but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(value), bytesToString(proofTrieValue))
+	}
+
+	return nil
+}
+
+// VerifyEmptyValue verifies the key given belongs to the trie with an
+// empty value, which Verify cannot check since it only compares non
+// empty values. Such keys are distinct from deleted keys, and are used
+// by pallets storing unit values. An error wrapping
+// ErrKeyNotFoundInProofTrie is returned if the key is not in the proof
+// trie, and an error wrapping ErrValueMismatchProofTrie is returned if
+// its value is not empty.
+func VerifyEmptyValue(encodedProofNodes [][]byte, rootHash, key []byte) (err error) {
+	return VerifyEmptyValueWithOptions(encodedProofNodes, rootHash, key, VerifyOptions{})
+}
+
+// VerifyEmptyValueWithOptions is like VerifyEmptyValue but enforces
+// the resource limits given when building the proof trie.
+func VerifyEmptyValueWithOptions(encodedProofNodes [][]byte, rootHash, key []byte,
+	options VerifyOptions) (err error) {
+	done := options.observeVerification(encodedProofNodes)
+	defer func() { done(err) }()
+
+	proofTrie, err := buildTrie(context.Background(), encodedProofNodes, rootHash,
+		options, nil, nil)
+	if err != nil {
+		return fmt.Errorf("building trie from proof encoded nodes: %w", err)
+	}
+
+	proofTrieValue, err := proofTrie.TryGet(key)
+	switch {
+	case err != nil:
+		return fmt.Errorf("getting key %s from proof trie for root hash 0x%x: %w",
+			bytesToString(key), rootHash, err)
+	case proofTrieValue == nil:
+		return fmt.Errorf("%w: %s in proof trie for root hash 0x%x",
+			ErrKeyNotFoundInProofTrie, bytesToString(key), rootHash)
+	case len(proofTrieValue) > 0:
+		return fmt.Errorf("%w: expected empty value but got value %s from proof trie",
+			ErrValueMismatchProofTrie, bytesToString(proofTrieValue))
 	}
 
 	return nil
@@ -49,18 +109,67 @@ var (
 
 // BuildTrie sets a partial trie based on the proof slice of encoded nodes.
 func BuildTrie(encodedProofNodes [][]byte, rootHash []byte) (t *trie.Trie, err error) {
+	return BuildTrieWithOptions(encodedProofNodes, rootHash, VerifyOptions{})
+}
+
+// BuildTrieWithOptions is like BuildTrie but enforces the resource
+// limits given on the encoded proof nodes and on the trie built.
+func BuildTrieWithOptions(encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions) (t *trie.Trie, err error) {
+	return BuildTrieContext(context.Background(), encodedProofNodes, rootHash, options)
+}
+
+// BuildTrieContext is like BuildTrieWithOptions but stops and returns
+// an error wrapping the context error as soon as the context given is
+// canceled or its deadline is exceeded.
+func BuildTrieContext(ctx context.Context, encodedProofNodes [][]byte,
+	rootHash []byte, options VerifyOptions) (t *trie.Trie, err error) {
+	done := options.observeVerification(encodedProofNodes)
+	defer func() { done(err) }()
+
+	return buildTrie(ctx, encodedProofNodes, rootHash, options, nil, nil)
+}
+
+// BuildPartialTrie is like BuildTrieWithOptions but also returns the
+// hash digests of the children referenced in the proof trie whose encoded
+// node is not in the proof, in depth first order and without duplicates,
+// so the missing nodes can be requested from a peer. These children are
+// not part of the partial trie returned.
+func BuildPartialTrie(encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions) (t *trie.Trie, missing [][]byte, err error) {
+	t, err = buildTrie(context.Background(), encodedProofNodes, rootHash,
+		options, nil, &missing)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, missing, nil
+}
+
+// buildTrie builds the proof trie and, if the referenced map given
+// is not nil, records in it the hash digest of every encoded proof
+// node referenced from the root node, excluding the root node itself.
+// If the missing slice pointer given is not nil, the hash digests of
+// the children not found in the proof are appended to it.
+func buildTrie(ctx context.Context, encodedProofNodes [][]byte, rootHash []byte,
+	options VerifyOptions, referenced map[string]struct{}, missing *[][]byte) (
+	t *trie.Trie, err error) {
 	if len(encodedProofNodes) == 0 {
 		return nil, fmt.Errorf("%w: for Merkle root hash 0x%x",
 			ErrEmptyProof, rootHash)
 
 	}
 
+	err = options.checkNodesCount(len(encodedProofNodes))
+	if err != nil {
+		return nil, err
+	}
+
 	digestToEncoding := make(map[string][]byte, len(encodedProofNodes))
 
 	// note we can use a buffer from the pool since
 	// the calculated root hash digest is not used after
 	// the function completes.
-	buffer := sub.DigestBuffers.Get().(*bytes.Buffer)
+	buffer := sub.DigestBuffers.Get()
 	defer sub.DigestBuffers.Put(buffer)
 
 	// This loop does two things:
@@ -69,7 +178,22 @@ func BuildTrie(encodedProofNodes [][]byte, rootHash []byte) (t *trie.Trie, err e
 	//    their encoding. They are only decoded later if the root or one of its
 	//    descendant nodes reference their hash digest.
 	var root *sub.Node
-	for _, encodedProofNode := range encodedProofNodes {
+	for i, encodedProofNode := range encodedProofNodes {
+		err = ctx.Err()
+		if err != nil {
+			return nil, fmt.Errorf("hashing proof node at index %d: %w", i, err)
+		}
+
+		err = options.checkNodeSize(len(encodedProofNode))
+		if err != nil {
+			return nil, fmt.Errorf("proof node at index %d: %w", i, err)
+		}
+
+		err = options.mapValueDigest(digestToEncoding, encodedProofNode, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("proof node at index %d: %w", i, err)
+		}
+
 		// Note all encoded proof nodes are one of the following:
 		// - trie root node
 		// - child trie root node
@@ -78,10 +202,9 @@ func BuildTrie(encodedProofNodes [][]byte, rootHash []byte) (t *trie.Trie, err e
 		// so we use MerkleValueRoot to force hashing the node in case
 		// it is a root node smaller or equal to 32 bytes.
 		buffer.Reset()
-		err = sub.MerkleValueRoot(encodedProofNode, buffer)
+		err = options.hashEncoding(encodedProofNode, buffer)
 		if err != nil {
-			// return nil, fmt.Errorf("calculating Merkle value: %w", err)
-			return nil, nil
+			return nil, fmt.Errorf("calculating Merkle value: %w", err)
 		}
 		digest := buffer.Bytes()
 
@@ -92,10 +215,9 @@ func BuildTrie(encodedProofNodes [][]byte, rootHash []byte) (t *trie.Trie, err e
 			// Note: no need to add the root node to the map of hash to encoding
 		}
 
-		root, err = sub.Decode(bytes.NewReader(encodedProofNode))
+		root, err = decodeProofNode(encodedProofNode, i, digest)
 		if err != nil {
-			// return nil, fmt.Errorf("decoding root node: %w", err)
-			return nil, nil
+			return nil, fmt.Errorf("decoding root node: %w", err)
 		}
 		// The built proof trie is not used with a database, but just in case
 		// it becomes used with a database in the future, we set the dirty flag
@@ -109,38 +231,111 @@ func BuildTrie(encodedProofNodes [][]byte, rootHash []byte) (t *trie.Trie, err e
 			hashDigestHex := util.BytesToHex([]byte(hashDigestString))
 			proofHashDigests = append(proofHashDigests, hashDigestHex)
 		}
-		// return nil, fmt.Errorf("%w: for root hash 0x%x in proof hash digests %s",
-		// 	ErrRootNodeNotFound, rootHash, strings.Join(proofHashDigests, ", "))
-		return nil, nil
-
+		return nil, fmt.Errorf("%w: for root hash 0x%x in proof hash digests %s",
+			ErrRootNodeNotFound, rootHash, strings.Join(proofHashDigests, ", "))
 	}
 
-	err = LoadProof(digestToEncoding, root)
+	const rootDepth = 0
+	loader := newProofLoader(ctx, digestToEncoding, options)
+	loader.referenced = referenced
+	loader.encodedProofNodes = encodedProofNodes
+	loader.missing = missing
+	err = loader.load(root, rootDepth)
 	if err != nil {
-		// return nil, fmt.Errorf("loading proof: %w", err)
-		return nil, nil
+		return nil, fmt.Errorf("loading proof: %w", err)
 	}
 
 	return trie.NewTrie(root), nil
 }
 
-// LoadProof is a recursive function that will create all the trie paths based
-// on the map from node hash digest to node encoding, starting from the node `n`.
+// LoadProof creates all the trie paths based on the map from
+// node hash digest to node encoding, starting from the node `n`.
 func LoadProof(digestToEncoding map[string][]byte, n *sub.Node) (err error) {
-	if n.Kind() != sub.Branch {
-		return nil
+	return LoadProofWithOptions(digestToEncoding, n, VerifyOptions{})
+}
+
+// LoadProofWithOptions is like LoadProof but enforces the resource
+// limits given, where the node `n` is considered to be the root node.
+func LoadProofWithOptions(digestToEncoding map[string][]byte, n *sub.Node,
+	options VerifyOptions) (err error) {
+	return LoadProofContext(context.Background(), digestToEncoding, n, options)
+}
+
+// LoadProofContext is like LoadProofWithOptions but stops and returns
+// an error wrapping the context error as soon as the context given is
+// canceled or its deadline is exceeded.
+func LoadProofContext(ctx context.Context, digestToEncoding map[string][]byte,
+	n *sub.Node, options VerifyOptions) (err error) {
+	const rootDepth = 0
+	return newProofLoader(ctx, digestToEncoding, options).load(n, rootDepth)
+}
+
+// proofLoader holds the state shared across the loading of a proof trie.
+type proofLoader struct {
+	ctx              context.Context
+	digestToEncoding map[string][]byte
+	options          VerifyOptions
+	nodesDecoded     int
+	// referenced is the set of hash digests of decoded nodes,
+	// and is only populated if it is not nil.
+	referenced map[string]struct{}
+	// encodedProofNodes, if not nil, are the encoded proof nodes
+	// used to report the index of a node failing to decode.
+	encodedProofNodes [][]byte
+	// missing, if not nil, is appended the hash digests of
+	// the children not found in the proof, without duplicates.
+	missing     *[][]byte
+	missingSeen map[string]struct{}
+}
+
+func newProofLoader(ctx context.Context, digestToEncoding map[string][]byte,
+	options VerifyOptions) *proofLoader {
+	const rootNodesDecoded = 1
+	return &proofLoader{
+		ctx:              ctx,
+		digestToEncoding: digestToEncoding,
+		options:          options,
+		nodesDecoded:     rootNodesDecoded,
 	}
+}
+
+// loadFrame is a branch being loaded, together with its depth
+// and the index of its next child to load.
+type loadFrame struct {
+	branch     *sub.Node
+	depth      int
+	childIndex int
+}
+
+// load loads the trie paths from the node `n` iteratively using
+// an explicit stack of frames, instead of recursing for each branch
+// child, so a deep crafted proof cannot exhaust the goroutine stack.
+// Nodes are visited in the same depth first order as a recursive
+// implementation would.
+func (l *proofLoader) load(n *sub.Node, depth int) (err error) {
+	l.resolveHashedValue(n)
+	stack := []loadFrame{{branch: n, depth: depth}}
+	for len(stack) > 0 {
+		frame := &stack[len(stack)-1]
+		branch := frame.branch
+		if branch.Kind() != sub.Branch || frame.childIndex >= len(branch.Children) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
 
-	branch := n
-	for i, child := range branch.Children {
+		i := frame.childIndex
+		frame.childIndex++
+		child := branch.Children[i]
 		if child == nil {
 			continue
 		}
 
 		merkleValue := child.NodeValue
-		encoding, ok := digestToEncoding[string(merkleValue)]
+		encoding, ok := l.digestToEncoding[string(merkleValue)]
 		if !ok {
-			inlinedChild := len(child.StorageValue) > 0 || child.HasChild()
+			// Note an inlined leaf can have an empty non-nil storage value,
+			// whereas a hash referenced child has a nil storage value.
+			inlinedChild := child.StorageValue != nil || child.HasChild()
 			if inlinedChild {
 				// The built proof trie is not used with a database, but just in case
 				// it becomes used with a database in the future, we set the dirty flag
@@ -149,6 +344,7 @@ func LoadProof(digestToEncoding map[string][]byte, n *sub.Node) (err error) {
 			} else {
 				// hash not found and the child is not inlined,
 				// so clear the child from the branch.
+				l.recordMissing(merkleValue)
 				branch.Descendants -= 1 + child.Descendants
 				branch.Children[i] = nil
 				if !branch.HasChild() {
@@ -159,30 +355,95 @@ func LoadProof(digestToEncoding map[string][]byte, n *sub.Node) (err error) {
 			continue
 		}
 
-		child, err := sub.Decode(bytes.NewReader(encoding))
+		err = l.ctx.Err()
+		if err != nil {
+			return fmt.Errorf("decoding child node for hash digest 0x%x: %w",
+				merkleValue, err)
+		}
+
+		if l.referenced != nil {
+			l.referenced[string(merkleValue)] = struct{}{}
+		}
+
+		childDepth := frame.depth + 1
+		err = l.options.checkDepth(childDepth)
 		if err != nil {
-			// return fmt.Errorf("decoding child node for hash digest 0x%x: %w",
-			// 	merkleValue, err)
-			return nil
+			return err
+		}
+
+		l.nodesDecoded++
+		err = l.options.checkNodesCount(l.nodesDecoded)
+		if err != nil {
+			return err
+		}
+
+		child, err := decodeProofNode(encoding,
+			nodeIndex(l.encodedProofNodes, encoding), merkleValue)
+		if err != nil {
+			return fmt.Errorf("decoding child node: %w", err)
 		}
 
 		// The built proof trie is not used with a database, but just in case
 		// it becomes used with a database in the future, we set the dirty flag
 		// to true.
 		child.Dirty = true
+		l.resolveHashedValue(child)
 
 		branch.Children[i] = child
 		branch.Descendants += child.Descendants
-		err = LoadProof(digestToEncoding, child)
-		if err != nil {
-			// return err // do not wrap error since this is recursive
-			return nil
-		}
+		// Note frame must not be used after this append.
+		stack = append(stack, loadFrame{branch: child, depth: childDepth})
 	}
 
 	return nil
 }
 
+// recordMissing records the hash digest given of a child not
+// found in the proof, if missing children are recorded.
+func (l *proofLoader) recordMissing(merkleValue []byte) {
+	if l.missing == nil {
+		return
+	}
+
+	if l.missingSeen == nil {
+		l.missingSeen = make(map[string]struct{})
+	}
+	_, seen := l.missingSeen[string(merkleValue)]
+	if seen {
+		return
+	}
+	l.missingSeen[string(merkleValue)] = struct{}{}
+	*l.missing = append(*l.missing, append([]byte{}, merkleValue...))
+}
+
+// resolveHashedValue replaces the hashed storage value of the node
+// given, as found in state version 1 nodes, with its preimage if the
+// preimage is one of the proof items. The node is then marked to have
+// its value hashed with the value hasher of the options when encoded,
+// so its Merkle value is unchanged.
+// The hashed value is left as is if its preimage is not in the proof,
+// since the proof may not be about this node value.
+func (l *proofLoader) resolveHashedValue(node *sub.Node) {
+	if !node.IsHashedValue {
+		return
+	}
+
+	hashedValue := node.StorageValue
+	preimage, ok := l.digestToEncoding[string(hashedValue)]
+	if !ok {
+		return
+	}
+
+	if l.referenced != nil {
+		l.referenced[string(hashedValue)] = struct{}{}
+	}
+
+	node.StorageValue = preimage
+	node.IsHashedValue = false
+	node.MustBeHashed = true
+	node.ValueHasher = l.options.valueHasher()
+}
+
 func bytesToString(b []byte) (s string) {
 	switch {
 	case b == nil:
@@ -2,6 +2,7 @@ package proof
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"testing"
@@ -157,7 +158,9 @@ func Test_buildTrie(t *testing.T) {
 			},
 			rootHash:   blake2b(t, getBadNodeEncoding()),
 			errWrapped: sub.ErrVariantUnknown,
-			errMessage: "decoding root node: decoding header: " +
+			errMessage: "decoding root node: proof node at index 0 with hash digest " +
+				"0xcfa21f0ec11a3658d77701b7b1f52fbcb783fe3df662977b6e860252b6c37e1e: " +
+				"at byte offset 1: decoding header: " +
 				"decoding header byte: node variant is unknown: " +
 				"for header byte 00000001",
 		},
@@ -264,9 +267,9 @@ func Test_buildTrie(t *testing.T) {
 				scaleEncode(t, blake2b(t, getBadNodeEncoding())), // child hash
 			})),
 			errWrapped: sub.ErrVariantUnknown,
-			errMessage: "loading proof: decoding child node for hash digest " +
+			errMessage: "loading proof: decoding child node: proof node at index 0 with hash digest " +
 				"0xcfa21f0ec11a3658d77701b7b1f52fbcb783fe3df662977b6e860252b6c37e1e: " +
-				"decoding header: decoding header byte: " +
+				"at byte offset 1: decoding header: decoding header byte: " +
 				"node variant is unknown: for header byte 00000001",
 		},
 		"root not found": {
@@ -790,8 +793,8 @@ func Test_loadProof(t *testing.T) {
 				}),
 			},
 			errWrapped: sub.ErrVariantUnknown,
-			errMessage: "decoding child node for hash digest 0x02: " +
-				"decoding header: decoding header byte: node variant is unknown: " +
+			errMessage: "decoding child node: proof node with hash digest 0x02: " +
+				"at byte offset 1: decoding header: decoding header byte: node variant is unknown: " +
 				"for header byte 00000001",
 		},
 		"grand child": {
@@ -881,9 +884,9 @@ func Test_loadProof(t *testing.T) {
 				}),
 			},
 			errWrapped: sub.ErrVariantUnknown,
-			errMessage: "decoding child node for hash digest " +
+			errMessage: "decoding child node: proof node with hash digest " +
 				"0x6888b9403129c11350c6054b46875292c0ffedcfd581e66b79bdf350b775ebf2: " +
-				"decoding header: decoding header byte: node variant is unknown: " +
+				"at byte offset 1: decoding header: decoding header byte: node variant is unknown: " +
 				"for header byte 00000001",
 		},
 	}
@@ -944,3 +947,281 @@ func Test_bytesToString(t *testing.T) {
 		})
 	}
 }
+
+func Test_LoadProof_deepProof(t *testing.T) {
+	t.Parallel()
+
+	const depth = 10000
+	value := generateBytes(t, 40)
+
+	// Build a chain of branches from the deepest leaf to the
+	// root, each branch referencing its child by hash.
+	deepest := &sub.Node{PartialKey: []byte{1}, StorageValue: value}
+	digestToEncoding := map[string][]byte{
+		string(blake2bNode(t, *deepest)): encodeNode(t, *deepest),
+	}
+	node := deepest
+	for i := 0; i < depth; i++ {
+		node = &sub.Node{
+			PartialKey:   []byte{},
+			StorageValue: value,
+			Children:     padRightChildren([]*sub.Node{node}),
+		}
+		digestToEncoding[string(blake2bNode(t, *node))] = encodeNode(t, *node)
+	}
+
+	root, err := sub.Decode(bytes.NewReader(encodeNode(t, *node)))
+	require.NoError(t, err)
+
+	err = LoadProof(digestToEncoding, root)
+	require.NoError(t, err)
+
+	loadedDepth := 0
+	for node := root; node.Kind() == sub.Branch; node = node.Children[0] {
+		loadedDepth++
+	}
+	assert.Equal(t, depth, loadedDepth)
+
+	root, err = sub.Decode(bytes.NewReader(encodeNode(t, *node)))
+	require.NoError(t, err)
+	err = LoadProofWithOptions(digestToEncoding, root, VerifyOptions{MaxDepth: depth - 1})
+	assert.ErrorIs(t, err, ErrProofTrieTooDeep)
+}
+
+func Test_hashedValues(t *testing.T) {
+	t.Parallel()
+
+	value := generateBytes(t, 40)
+	leafWithHashedValue := &sub.Node{
+		PartialKey:   []byte{2},
+		StorageValue: value,
+		MustBeHashed: true,
+	}
+	inlinedLeaf := &sub.Node{
+		PartialKey:   []byte{4},
+		StorageValue: []byte{5},
+	}
+	root := sub.Node{
+		PartialKey: []byte{},
+		Children: padRightChildren([]*sub.Node{
+			nil, leafWithHashedValue, nil, inlinedLeaf,
+		}),
+	}
+	assertLongEncoding(t, *leafWithHashedValue)
+
+	rootHash := blake2bNode(t, root)
+	encodedProofNodes := [][]byte{
+		encodeNode(t, root),
+		encodeNode(t, *leafWithHashedValue),
+		value, // hashed value preimage
+	}
+	key := []byte{0x12}
+
+	proofTrie, err := BuildTrie(encodedProofNodes, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, value, proofTrie.Get(key))
+	assert.Equal(t, []byte{5}, proofTrie.Get([]byte{0x34}))
+	assert.Equal(t, rootHash, proofTrie.MustHash().ToBytes())
+
+	err = VerifyStreaming(encodedProofNodes, rootHash, key, value)
+	require.NoError(t, err)
+	err = VerifyStreaming(encodedProofNodes, rootHash, key, []byte{1})
+	assert.ErrorIs(t, err, ErrValueMismatchProofTrie)
+
+	embeddedProof, err := NewEmbeddedProof(encodedProofNodes, rootHash, key)
+	require.NoError(t, err)
+	err = VerifyEmbedded(embeddedProof, rootHash, key, value)
+	require.NoError(t, err)
+
+	entries, err := VerifyPrefix(encodedProofNodes, rootHash, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"\x12": value,
+		"\x34": {5},
+	}, entries)
+
+	unreferenced, err := UnreferencedNodes(encodedProofNodes, rootHash)
+	require.NoError(t, err)
+	assert.Empty(t, unreferenced)
+
+	// Without the value preimage, the hashed value cannot be resolved.
+	withoutPreimage := encodedProofNodes[:2]
+	err = VerifyStreaming(withoutPreimage, rootHash, key, value)
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+	_, err = VerifyPrefix(withoutPreimage, rootHash, nil)
+	assert.ErrorIs(t, err, ErrPrefixProofIncomplete)
+	err = Verify(withoutPreimage, rootHash, key, nil)
+	assert.ErrorIs(t, err, trie.ErrValueNotLoaded)
+	err = VerifyEmptyValue(withoutPreimage, rootHash, key)
+	assert.ErrorIs(t, err, trie.ErrValueNotLoaded)
+}
+
+func Test_VerifyContext(t *testing.T) {
+	t.Parallel()
+
+	leaf := sub.Node{
+		PartialKey:   []byte{1},
+		StorageValue: generateBytes(t, 40),
+	}
+	root := sub.Node{
+		PartialKey: []byte{},
+		Children:   padRightChildren([]*sub.Node{&leaf}),
+	}
+	encodedProofNodes := [][]byte{encodeNode(t, root), encodeNode(t, leaf)}
+	rootHash := blake2bNode(t, root)
+	key := []byte{0x01}
+
+	err := VerifyContext(context.Background(), encodedProofNodes,
+		rootHash, key, leaf.StorageValue, VerifyOptions{})
+	require.NoError(t, err)
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = VerifyContext(canceledCtx, encodedProofNodes,
+		rootHash, key, leaf.StorageValue, VerifyOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.EqualError(t, err, "building trie from proof encoded nodes: "+
+		"hashing proof node at index 0: context canceled")
+
+	rootNode, err := sub.Decode(bytes.NewReader(encodeNode(t, root)))
+	require.NoError(t, err)
+	digestToEncoding := map[string][]byte{
+		string(blake2bNode(t, leaf)): encodeNode(t, leaf),
+	}
+	err = LoadProofContext(canceledCtx, digestToEncoding, rootNode, VerifyOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_BuildPartialTrie(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), generateBytes(t, 40))
+	tr.Put([]byte("dog"), generateBytes(t, 41))
+	tr.Put([]byte("doge"), generateBytes(t, 42))
+	rootHash := tr.MustHash().ToBytes()
+
+	fullProof, err := GenerateFromTrie(tr, [][]byte{
+		[]byte("cat"), []byte("dog"), []byte("doge")})
+	require.NoError(t, err)
+	dogProof, err := GenerateFromTrie(tr, [][]byte{[]byte("dog")})
+	require.NoError(t, err)
+
+	var dogProofMissing [][]byte
+	for _, encoding := range fullProof {
+		if nodeIndex(dogProof, encoding) == -1 {
+			dogProofMissing = append(dogProofMissing, blake2b(t, encoding))
+		}
+	}
+	require.Len(t, dogProofMissing, 2)
+
+	leaf := generateBytes(t, 43)
+	leafHash := blake2b(t, leaf)
+	branchSameChildren := concatBytes([][]byte{
+		{0b1000_0000 | 0b0000_0001}, // branch with key size 1
+		{1},                         // key
+		{0b0000_0011, 0b0000_0000},  // children bitmap
+		scaleEncode(t, leafHash),    // child hash
+		scaleEncode(t, leafHash),    // child hash
+	})
+
+	testCases := map[string]struct {
+		encodedProofNodes [][]byte
+		rootHash          []byte
+		missing           [][]byte
+		errWrapped        error
+	}{
+		"complete proof": {
+			encodedProofNodes: fullProof,
+			rootHash:          rootHash,
+		},
+		"incomplete proof": {
+			encodedProofNodes: dogProof,
+			rootHash:          rootHash,
+			missing:           dogProofMissing,
+		},
+		"missing child referenced twice": {
+			encodedProofNodes: [][]byte{branchSameChildren},
+			rootHash:          blake2b(t, branchSameChildren),
+			missing:           [][]byte{leafHash},
+		},
+		"root node not found": {
+			encodedProofNodes: dogProof,
+			rootHash:          leafHash,
+			errWrapped:        ErrRootNodeNotFound,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proofTrie, missing, err := BuildPartialTrie(testCase.encodedProofNodes,
+				testCase.rootHash, VerifyOptions{})
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				return
+			}
+			assert.ElementsMatch(t, testCase.missing, missing)
+			if len(missing) == 0 {
+				assert.Equal(t, testCase.rootHash, proofTrie.MustHash().ToBytes())
+			}
+		})
+	}
+}
+
+func Test_VerifyEmptyValue(t *testing.T) {
+	t.Parallel()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("a"), []byte{})
+	tr.Put([]byte("b"), []byte{1})
+	tr.Put([]byte("branch"), []byte{})
+	tr.Put([]byte("branches"), []byte{2})
+	rootHash := tr.MustHash().ToBytes()
+
+	encodedProofNodes, err := GenerateFromTrie(tr, [][]byte{
+		[]byte("a"), []byte("b"), []byte("branch")})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		key        []byte
+		errWrapped error
+	}{
+		"inlined leaf with empty value": {
+			key: []byte("a"),
+		},
+		"branch with empty value": {
+			key: []byte("branch"),
+		},
+		"non empty value": {
+			key:        []byte("b"),
+			errWrapped: ErrValueMismatchProofTrie,
+		},
+		"key not found": {
+			key:        []byte("c"),
+			errWrapped: ErrKeyNotFoundInProofTrie,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyEmptyValue(encodedProofNodes, rootHash, testCase.key)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+
+	// Deleting the key makes it absent from new proofs.
+	tr.Delete([]byte("a"))
+	deletedProofNodes, err := GenerateFromTrie(tr, [][]byte{[]byte("b")})
+	require.NoError(t, err)
+	err = VerifyEmptyValue(deletedProofNodes, tr.MustHash().ToBytes(), []byte("a"))
+	assert.ErrorIs(t, err, ErrKeyNotFoundInProofTrie)
+}
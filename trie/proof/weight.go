@@ -0,0 +1,68 @@
+package proof
+
+// WeightModel contains the coefficients of a proof verification weight
+// model, which should match the benchmarking model of the chain
+// verifying the proof, such as the reference time in picoseconds of a
+// Substrate runtime.
+type WeightModel struct {
+	// Base is the fixed weight of a proof verification.
+	Base uint64
+	// PerByte is the weight of hashing one byte of an encoded proof node.
+	PerByte uint64
+	// PerNode is the weight of hashing and decoding one encoded proof node.
+	PerNode uint64
+}
+
+// DefaultWeightModel is the weight model used by EstimateWeight,
+// with rough reference time coefficients in picoseconds for blake2b-256
+// hashing and node decoding. Chains should use coefficients derived from
+// their own benchmarks with EstimateWeightWithModel instead.
+var DefaultWeightModel = WeightModel{
+	Base:    5_000_000,
+	PerByte: 2_000,
+	PerNode: 1_000_000,
+}
+
+// EstimateWeight returns the weight of verifying the proof given
+// using the DefaultWeightModel.
+func EstimateWeight(proof [][]byte) (weight uint64) {
+	return EstimateWeightWithModel(proof, DefaultWeightModel)
+}
+
+// EstimateWeightWithModel returns the weight of verifying the proof
+// given with the weight model given. The weight only depends on the
+// number of encoded proof nodes and their total size, and not on their
+// content, so it is deterministic and can be computed by a relayer before
+// submitting the proof. Each encoded proof node is counted as decoded,
+// which is an upper bound since hashed value preimages are not decoded.
+// The weight saturates at the maximum uint64 value instead of overflowing.
+func EstimateWeightWithModel(proof [][]byte, model WeightModel) (weight uint64) {
+	var bytesHashed uint64
+	for _, encodedProofNode := range proof {
+		bytesHashed += uint64(len(encodedProofNode))
+	}
+
+	weight = model.Base
+	weight = saturatingAdd(weight, saturatingMul(bytesHashed, model.PerByte))
+	weight = saturatingAdd(weight, saturatingMul(uint64(len(proof)), model.PerNode))
+	return weight
+}
+
+func saturatingAdd(a, b uint64) (sum uint64) {
+	sum = a + b
+	if sum < a {
+		return ^uint64(0)
+	}
+	return sum
+}
+
+func saturatingMul(a, b uint64) (product uint64) {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	product = a * b
+	if product/b != a {
+		return ^uint64(0)
+	}
+	return product
+}
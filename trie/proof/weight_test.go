@@ -0,0 +1,63 @@
+package proof
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EstimateWeight(t *testing.T) {
+	t.Parallel()
+
+	proof := [][]byte{generateBytes(t, 10), generateBytes(t, 20)}
+
+	weight := EstimateWeight(proof)
+
+	const expected = 5_000_000 + 30*2_000 + 2*1_000_000
+	assert.Equal(t, uint64(expected), weight)
+}
+
+func Test_EstimateWeightWithModel(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		proof  [][]byte
+		model  WeightModel
+		weight uint64
+	}{
+		"empty proof": {
+			model:  WeightModel{Base: 7, PerByte: 2, PerNode: 3},
+			weight: 7,
+		},
+		"zero model": {
+			proof: [][]byte{{1, 2}},
+		},
+		"proof": {
+			proof:  [][]byte{{1, 2}, {3}, {}},
+			model:  WeightModel{Base: 7, PerByte: 2, PerNode: 3},
+			weight: 7 + 3*2 + 3*3,
+		},
+		"saturating multiplication": {
+			proof:  [][]byte{{1, 2}},
+			model:  WeightModel{PerByte: math.MaxUint64},
+			weight: math.MaxUint64,
+		},
+		"saturating addition": {
+			proof:  [][]byte{{1}},
+			model:  WeightModel{Base: math.MaxUint64, PerNode: 1},
+			weight: math.MaxUint64,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			weight := EstimateWeightWithModel(testCase.proof, testCase.model)
+
+			assert.Equal(t, testCase.weight, weight)
+		})
+	}
+}
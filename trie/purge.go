@@ -0,0 +1,144 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// PurgeRemoved deletes from the database given the nodes of the trie
+// with the previous root hash given, which were written to the database
+// and are no longer part of the trie since they were modified or removed.
+// It must be called before WriteDirty, since the dirty nodes are used to
+// find the nodes still part of the trie, so unchanged subtrees of the
+// previous trie are not walked. It returns the number of nodes deleted.
+// Note nodes are content addressed, so nodes still used by other tries
+// written to the same database, such as the tries of previous blocks
+// still needed, or by identical subtrees elsewhere in the trie, are
+// deleted as well. Value preimages of state version 1 nodes are not
+// deleted since they may be shared by other nodes, and nor are the
+// nodes of child tries.
+func (t *Trie) PurgeRemoved(db KVStore, previousRoot util.Hash) (
+	purged int, err error) {
+	if previousRoot == EmptyHash {
+		return 0, nil
+	}
+
+	kept := make(map[string]struct{})
+	if t.root != nil {
+		rootMerkleValue, err := t.root.CalculateRootMerkleValue()
+		if err != nil {
+			return 0, fmt.Errorf("calculating root Merkle value: %w", err)
+		}
+		kept[string(rootMerkleValue)] = struct{}{}
+
+		err = addDirtyMerkleValues(t.root, kept)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	batch := &storeBatch{store: db}
+	purged, err = purgeNode(db, batch, previousRoot.ToBytes(), kept)
+	if err != nil {
+		batch.Reset()
+		return 0, err
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return 0, fmt.Errorf("flushing batch: %w", err)
+	}
+	return purged, nil
+}
+
+// addDirtyMerkleValues adds the Merkle values of the dirty descendants
+// of the dirty node given, and of their children, to the set given.
+// An unchanged node of the previous trie still in the trie is either
+// the child of a dirty node, or a descendant of such node.
+func addDirtyMerkleValues(node *Node, merkleValues map[string]struct{}) (err error) {
+	if !node.Dirty || node.Kind() != sub.Branch {
+		return nil
+	}
+
+	for _, child := range node.Children {
+		if child == nil {
+			continue
+		}
+
+		merkleValue, err := child.CalculateMerkleValue()
+		if err != nil {
+			return fmt.Errorf("calculating Merkle value: %w", err)
+		}
+		merkleValues[string(merkleValue)] = struct{}{}
+
+		err = addDirtyMerkleValues(child, merkleValues)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeNode deletes the node with the Merkle value given and its
+// descendants from the database, unless their Merkle value is in the
+// kept set given, and returns the number of nodes deleted.
+func purgeNode(db KVStore, batch NodeWriter, merkleValue []byte,
+	kept map[string]struct{}) (purged int, err error) {
+	_, ok := kept[string(merkleValue)]
+	if ok {
+		return 0, nil
+	}
+
+	has, err := db.Has(merkleValue)
+	if err != nil {
+		return 0, fmt.Errorf("checking node with Merkle value 0x%x: %w", merkleValue, err)
+	} else if !has {
+		// the node was already deleted or never written.
+		return 0, nil
+	}
+
+	encoding, err := db.Get(merkleValue)
+	if err != nil {
+		return 0, fmt.Errorf("getting node with Merkle value 0x%x: %w", merkleValue, err)
+	}
+
+	node, err := sub.Decode(bytes.NewReader(encoding))
+	if err != nil {
+		return 0, fmt.Errorf("decoding node with Merkle value 0x%x: %w", merkleValue, err)
+	}
+
+	err = batch.Del(merkleValue)
+	if err != nil {
+		return 0, fmt.Errorf("deleting node with Merkle value 0x%x: %w", merkleValue, err)
+	}
+	purged = 1
+
+	for _, child := range node.Children {
+		if child == nil {
+			continue
+		}
+
+		childMerkleValue := child.NodeValue
+		if len(childMerkleValue) < sub.INLINE_LEN {
+			// Inlined children are decoded from their parent encoding,
+			// but they are also written to the database by WriteDirty,
+			// keyed by their encoding.
+			childMerkleValue, err = child.CalculateMerkleValue()
+			if err != nil {
+				return 0, fmt.Errorf("calculating Merkle value of inlined child: %w", err)
+			}
+		}
+
+		childPurged, err := purgeNode(db, batch, childMerkleValue, kept)
+		if err != nil {
+			// Note: do not wrap error since this is called recursively.
+			return 0, err
+		}
+		purged += childPurged
+	}
+	return purged, nil
+}
@@ -0,0 +1,79 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+// NodeRecord is the record of a node visited by a lookup.
+type NodeRecord struct {
+	// MerkleValue is the Merkle value of the node, which is the root
+	// hash for the root node, and the encoding itself for other nodes
+	// with an encoding smaller than 32 bytes.
+	MerkleValue []byte
+	// Encoding is the encoding of the node.
+	Encoding []byte
+}
+
+// Recorder records the nodes visited by lookups done with
+// GetWithRecorder, in the order they are visited. Nodes visited
+// by several lookups are recorded once each time they are visited.
+// A recorder is not safe for concurrent use.
+type Recorder struct {
+	nodes []NodeRecord
+}
+
+// NewRecorder returns a new empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Nodes returns the records of the nodes visited.
+func (r *Recorder) Nodes() (nodes []NodeRecord) {
+	return r.nodes
+}
+
+// Reset removes all the node records.
+func (r *Recorder) Reset() {
+	r.nodes = nil
+}
+
+// GetWithRecorder returns the value at the (Little Endian) key given,
+// like Get, and records each node visited by the lookup in the recorder
+// given, from the root node down to the node of the key, or down to the
+// last node visited if the key is not in the trie. The nodes recorded
+// for a key form the nodes of a read proof for this key, and the nodes
+// recorded for an absent key prove its absence.
+func (t *Trie) GetWithRecorder(keyLE []byte, recorder *Recorder) (value []byte, err error) {
+	key := sub.KeyLEToNibbles(keyLE)
+	node := t.root
+	isRoot := true
+	for node != nil {
+		var record NodeRecord
+		if isRoot {
+			record.Encoding, record.MerkleValue, err = node.EncodeAndHashRoot()
+		} else {
+			record.Encoding, record.MerkleValue, err = node.EncodeAndHash()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding node at key 0x%x: %w", keyLE, err)
+		}
+		recorder.nodes = append(recorder.nodes, record)
+		isRoot = false
+
+		if bytes.Equal(node.PartialKey, key) {
+			return node.StorageValue, nil
+		}
+
+		if node.Kind() == sub.Leaf || !bytes.HasPrefix(key, node.PartialKey) {
+			return nil, nil
+		}
+
+		childIndex := key[len(node.PartialKey)]
+		key = key[len(node.PartialKey)+1:]
+		node = node.Children[childIndex]
+	}
+	return nil, nil
+}
@@ -0,0 +1,52 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_GetWithRecorder(t *testing.T) {
+	t.Parallel()
+
+	const size = 300
+	trie, keyValues := makeSeededTrie(t, size)
+	rootHash := trie.MustHash()
+
+	absentKeys := []string{"absent key", "", "\x00"}
+	keys := make([]string, 0, len(keyValues)+len(absentKeys))
+	for key := range keyValues {
+		keys = append(keys, key)
+	}
+	keys = append(keys, absentKeys...)
+
+	for _, key := range keys {
+		recorder := NewRecorder()
+
+		value, err := trie.GetWithRecorder([]byte(key), recorder)
+
+		require.NoError(t, err)
+		assert.Equal(t, trie.Get([]byte(key)), value)
+
+		nodes := recorder.Nodes()
+		require.NotEmpty(t, nodes)
+		assert.Equal(t, rootHash[:], nodes[0].MerkleValue)
+		for i := 1; i < len(nodes); i++ {
+			// Each node is referenced by its Merkle value in its parent encoding.
+			assert.True(t, bytes.Contains(nodes[i-1].Encoding, nodes[i].MerkleValue))
+		}
+	}
+
+	recorder := NewRecorder()
+	value, err := NewEmptyTrie().GetWithRecorder([]byte{1}, recorder)
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	assert.Empty(t, recorder.Nodes())
+
+	_, err = trie.GetWithRecorder([]byte{1}, recorder)
+	require.NoError(t, err)
+	recorder.Reset()
+	assert.Empty(t, recorder.Nodes())
+}
@@ -0,0 +1,75 @@
+package trie
+
+import sub "github.com/octopus-network/trie-go/substrate"
+
+// pooledNode returns a node from the node pool set to the node given.
+func pooledNode(node Node) *Node {
+	pooled := sub.Nodes.Get()
+	*pooled = node
+	return pooled
+}
+
+// Discard discards the trie, which is typically a snapshot fork no
+// longer needed, and returns the nodes created exclusively in its
+// generation to the node pool, so they are reused by later tries
+// instead of being garbage collected. Nodes of older generations are
+// shared with the trie the snapshot was taken from and are left intact.
+// It returns the number of nodes recycled, including the nodes of its
+// child tries. The trie is empty after the call.
+// Discard must not be called if a snapshot was taken from the trie and
+// is still in use, since that snapshot shares the nodes of the trie,
+// nor if values or nodes obtained from the trie are still referenced
+// as node fields.
+func (t *Trie) Discard() (recycled int) {
+	recycled = recycleNodes(t.root, t.generation)
+	for _, childTrie := range t.childTries {
+		recycled += childTrie.Discard()
+	}
+
+	t.root = nil
+	t.childTries = make(map[string]*Trie)
+	t.deletedMerkleValues = make(map[string]struct{})
+	return recycled
+}
+
+// Clear removes all the entries of the trie and its child tries,
+// keeping the trie generation and reusing its allocations: the nodes
+// created in the current generation are put back in the node pool to be
+// taken by the next insertions, and the child tries and deleted Merkle
+// values maps are emptied without being allocated again. This is meant
+// for scratch tries rebuilt repeatedly, such as tries computing roots
+// of block extrinsics. The deleted Merkle values are not tracked, so
+// ClearPrefix with an empty prefix should be used instead for a trie
+// whose nodes are written to a database.
+// Like Discard, Clear must not be called if a snapshot was taken
+// from the trie in its current generation and is still in use.
+func (t *Trie) Clear() {
+	recycleNodes(t.root, t.generation)
+	t.root = nil
+
+	for keyToChild, childTrie := range t.childTries {
+		childTrie.Clear()
+		delete(t.childTries, keyToChild)
+	}
+
+	for merkleValue := range t.deletedMerkleValues {
+		delete(t.deletedMerkleValues, merkleValue)
+	}
+}
+
+// recycleNodes puts the node given and its descendants of the
+// generation given back in the node pool, and returns the number
+// of nodes recycled. Since nodes are copied on write, a node of an
+// older generation only has descendants of older generations, so
+// its subtree is not walked.
+func recycleNodes(node *Node, generation uint64) (recycled int) {
+	if node == nil || node.Generation != generation {
+		return 0
+	}
+
+	for _, child := range node.Children {
+		recycled += recycleNodes(child, generation)
+	}
+	sub.Nodes.Put(node)
+	return recycled + 1
+}
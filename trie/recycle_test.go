@@ -0,0 +1,94 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_Discard(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	trie.Put([]byte{0x01}, []byte{1})
+	trie.Put([]byte{0x12}, []byte{2})
+	trie.Put([]byte{0x13}, []byte{3})
+	child := NewEmptyTrie()
+	child.Put([]byte{0x21}, []byte{4})
+	err := trie.SetChild([]byte{9}, child)
+	require.NoError(t, err)
+	rootHash := trie.MustHash()
+	entries := trie.Entries()
+
+	snapshot := trie.Snapshot()
+	assert.Equal(t, 0, snapshot.Discard())
+
+	snapshot = trie.Snapshot()
+	// Copies the root branch and the branch at nibble 1,
+	// and creates a new leaf at nibble 4.
+	snapshot.Put([]byte{0x14}, []byte{5})
+	snapshotChild, err := snapshot.GetChild([]byte{9})
+	require.NoError(t, err)
+	// Creates a branch parent of the new leaf and of
+	// a copy of the child root leaf with a shorter key.
+	snapshotChild.Put([]byte{0x22}, []byte{6})
+
+	recycled := snapshot.Discard()
+
+	assert.Equal(t, 6, recycled)
+	assert.Nil(t, snapshot.RootNode())
+	assert.Equal(t, rootHash, trie.MustHash())
+	assert.Equal(t, entries, trie.Entries())
+}
+
+func Test_Trie_Clear(t *testing.T) {
+	t.Parallel()
+
+	trie, _ := makeSeededTrie(t, 100)
+	err := trie.PutIntoChild([]byte{9}, []byte{1}, []byte{2})
+	require.NoError(t, err)
+	trie.deletedMerkleValues["a"] = struct{}{}
+	childTries := trie.childTries
+	deletedMerkleValues := trie.deletedMerkleValues
+
+	trie.Clear()
+
+	assert.Nil(t, trie.root)
+	assert.Empty(t, trie.childTries)
+	assert.Empty(t, trie.deletedMerkleValues)
+	// The maps are reused.
+	trie.childTries["a"] = nil
+	assert.Len(t, childTries, 1)
+	trie.deletedMerkleValues["b"] = struct{}{}
+	assert.Len(t, deletedMerkleValues, 1)
+	delete(trie.childTries, "a")
+	delete(trie.deletedMerkleValues, "b")
+
+	// The trie can be filled again.
+	expected := NewEmptyTrie()
+	expected.Put([]byte{1}, []byte{2})
+	trie.Put([]byte{1}, []byte{2})
+	assert.Equal(t, expected.MustHash(), trie.MustHash())
+	assert.Equal(t, expected.Entries(), trie.Entries())
+}
+
+func Test_recycleNodes(t *testing.T) {
+	t.Parallel()
+
+	const generation = 2
+	older := &Node{PartialKey: []byte{1}, StorageValue: []byte{1}, Generation: 1}
+	leaf := &Node{PartialKey: []byte{2}, StorageValue: []byte{2}, Generation: generation}
+	branch := &Node{
+		PartialKey: []byte{3},
+		Generation: generation,
+		Children:   padRightChildren([]*Node{older, leaf}),
+	}
+
+	recycled := recycleNodes(branch, generation)
+
+	assert.Equal(t, 2, recycled)
+	assert.Equal(t, Node{}, *branch)
+	assert.Equal(t, Node{}, *leaf)
+	assert.Equal(t, []byte{1}, older.StorageValue)
+}
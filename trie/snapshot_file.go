@@ -0,0 +1,346 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// The snapshot file starts with a header made of the magic bytes, the
+// format version, the state trie version, the trie root hash and the
+// number of nodes. It is followed by the node index, sorted by node
+// hash, where each entry is the node hash, the offset and the length
+// of the node encoding in the file. The node encodings follow the
+// index. The value preimages of state version 1 nodes are stored
+// as nodes indexed by the value hash.
+const (
+	snapshotFileMagic      = "TRIESNAP"
+	snapshotFileVersion    = 2
+	snapshotFileHeaderSize = len(snapshotFileMagic) + 1 + 1 + 32 + 4
+	snapshotIndexEntrySize = 32 + 8 + 4
+)
+
+var (
+	ErrSnapshotFileFormat = errors.New("snapshot file format invalid")
+	ErrSnapshotNodeAbsent = errors.New("node not found in snapshot file")
+)
+
+// WriteSnapshotFile writes the encodings of all the nodes of the trie
+// and of its child tries, and their state version 1 value preimages,
+// to a read-only snapshot file at the path given.
+// The file is written to a temporary file first and then renamed, so
+// processes opening the path never see a partially written file.
+// The snapshot file can be opened with OpenSnapshotFile.
+func (t *Trie) WriteSnapshotFile(path string) (err error) {
+	digestToEncoding := make(map[string][]byte)
+	rootHash, err := t.collectSnapshotNodes(digestToEncoding)
+	if err != nil {
+		return err
+	}
+
+	digests := make([]string, 0, len(digestToEncoding))
+	for digest := range digestToEncoding {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteString(snapshotFileMagic)
+	buffer.WriteByte(snapshotFileVersion)
+	buffer.WriteByte(byte(t.Version()))
+	buffer.Write(rootHash[:])
+	uint32Bytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(uint32Bytes, uint32(len(digests)))
+	buffer.Write(uint32Bytes)
+
+	offset := uint64(snapshotFileHeaderSize + len(digests)*snapshotIndexEntrySize)
+	uint64Bytes := make([]byte, 8)
+	for _, digest := range digests {
+		encodingLength := len(digestToEncoding[digest])
+		buffer.WriteString(digest)
+		binary.LittleEndian.PutUint64(uint64Bytes, offset)
+		buffer.Write(uint64Bytes)
+		binary.LittleEndian.PutUint32(uint32Bytes, uint32(encodingLength))
+		buffer.Write(uint32Bytes)
+		offset += uint64(encodingLength)
+	}
+
+	for _, digest := range digests {
+		buffer.Write(digestToEncoding[digest])
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	_, err = file.Write(buffer.Bytes())
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return fmt.Errorf("renaming temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// collectSnapshotNodes adds the encodings of the root node and of the
+// hash referenced nodes of the trie and of its child tries, and their
+// value preimages, to the digestToEncoding map, and returns the root
+// hash of the trie.
+func (t *Trie) collectSnapshotNodes(digestToEncoding map[string][]byte) (
+	rootHash util.Hash, err error) {
+	if t.root == nil {
+		return EmptyHash, nil
+	}
+
+	encoding, merkleValue, err := t.root.EncodeAndHashRoot()
+	if err != nil {
+		return rootHash, fmt.Errorf("encoding root node: %w", err)
+	}
+	copy(rootHash[:], merkleValue)
+	digestToEncoding[string(merkleValue)] = encoding
+
+	err = collectSnapshotValue(t.root, digestToEncoding)
+	if err != nil {
+		return rootHash, err
+	}
+
+	err = collectSnapshotDescendants(t.root, digestToEncoding)
+	if err != nil {
+		return rootHash, err
+	}
+
+	for keyToChild, childTrie := range t.childTries {
+		_, err = childTrie.collectSnapshotNodes(digestToEncoding)
+		if err != nil {
+			return rootHash, fmt.Errorf("collecting nodes of child trie at key 0x%x: %w",
+				keyToChild, err)
+		}
+	}
+
+	return rootHash, nil
+}
+
+// collectSnapshotDescendants adds the encodings of the hash referenced
+// descendants of the parent node given, and their value preimages, to
+// the digestToEncoding map. Inlined nodes are part of their parent node
+// encoding and are skipped, but not their value preimages.
+func collectSnapshotDescendants(parent *Node, digestToEncoding map[string][]byte) (err error) {
+	if parent.Kind() != sub.Branch {
+		return nil
+	}
+
+	for _, child := range parent.Children {
+		if child == nil {
+			continue
+		}
+
+		encoding, merkleValue, err := child.EncodeAndHash()
+		if err != nil {
+			return fmt.Errorf("encoding node with partial key 0x%x: %w",
+				child.PartialKey, err)
+		}
+
+		if len(merkleValue) == 32 {
+			digestToEncoding[string(merkleValue)] = encoding
+		}
+
+		err = collectSnapshotValue(child, digestToEncoding)
+		if err != nil {
+			return err
+		}
+
+		err = collectSnapshotDescendants(child, digestToEncoding)
+		if err != nil {
+			// Note: do not wrap error since it's returned recursively.
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectSnapshotValue adds the storage value preimage of the node given
+// to the digestToEncoding map if the node encoding only contains its hash.
+// It returns an error wrapping ErrValueNotLoaded if the node only holds
+// the hash of its storage value.
+func collectSnapshotValue(node *Node, digestToEncoding map[string][]byte) (err error) {
+	if node.IsHashedValue {
+		return fmt.Errorf("%w: for value hash 0x%x", ErrValueNotLoaded, node.StorageValue)
+	}
+
+	valueHash, err := nodeValueHash(node)
+	if err != nil {
+		return fmt.Errorf("hashing value of node with partial key 0x%x: %w",
+			node.PartialKey, err)
+	} else if valueHash != nil {
+		digestToEncoding[string(valueHash)] = node.StorageValue
+	}
+	return nil
+}
+
+// SnapshotFile is a read-only trie snapshot file opened with
+// OpenSnapshotFile. The file is memory mapped where the platform
+// supports it, so processes opening the same snapshot file share its
+// memory pages. Node encodings are decoded on demand, and values
+// returned do not reference the file memory.
+// It is safe for concurrent use, but must not be used once closed.
+type SnapshotFile struct {
+	data     []byte
+	unmap    func() error
+	version  Version
+	rootHash util.Hash
+	count    int
+}
+
+// OpenSnapshotFile opens the snapshot file written by WriteSnapshotFile
+// at the path given. The caller must call Close once done with it.
+// It returns an error wrapping ErrSnapshotFileFormat if the file is
+// not a valid snapshot file.
+func OpenSnapshotFile(path string) (snapshot *SnapshotFile, err error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("mapping file: %w", err)
+	}
+
+	snapshot, err = newSnapshotFile(data)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+	snapshot.unmap = unmap
+
+	return snapshot, nil
+}
+
+func newSnapshotFile(data []byte) (snapshot *SnapshotFile, err error) {
+	if len(data) < snapshotFileHeaderSize {
+		return nil, fmt.Errorf("%w: file size %d is smaller than header size %d",
+			ErrSnapshotFileFormat, len(data), snapshotFileHeaderSize)
+	}
+
+	if string(data[:len(snapshotFileMagic)]) != snapshotFileMagic {
+		return nil, fmt.Errorf("%w: magic bytes 0x%x",
+			ErrSnapshotFileFormat, data[:len(snapshotFileMagic)])
+	}
+
+	header := data[len(snapshotFileMagic):]
+	if header[0] != snapshotFileVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported",
+			ErrSnapshotFileFormat, header[0])
+	}
+	header = header[1:]
+
+	version := Version(header[0])
+	if version != V0 && version != V1 {
+		return nil, fmt.Errorf("%w: state version %d is not supported",
+			ErrSnapshotFileFormat, version)
+	}
+	header = header[1:]
+
+	snapshot = &SnapshotFile{data: data, version: version}
+	copy(snapshot.rootHash[:], header[:32])
+	snapshot.count = int(binary.LittleEndian.Uint32(header[32:]))
+
+	indexEnd := uint64(snapshotFileHeaderSize) +
+		uint64(snapshot.count)*snapshotIndexEntrySize
+	if indexEnd > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: index of %d nodes exceeds file size %d",
+			ErrSnapshotFileFormat, snapshot.count, len(data))
+	}
+
+	for i := 0; i < snapshot.count; i++ {
+		entry := snapshot.indexEntry(i)
+		offset := binary.LittleEndian.Uint64(entry[32:])
+		length := uint64(binary.LittleEndian.Uint32(entry[40:]))
+		if offset < indexEnd || offset > uint64(len(data)) ||
+			length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("%w: node %d encoding at offset %d "+
+				"and of length %d is out of bounds",
+				ErrSnapshotFileFormat, i, offset, length)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// RootHash returns the root hash of the trie in the snapshot file.
+func (s *SnapshotFile) RootHash() util.Hash {
+	return s.rootHash
+}
+
+// Get returns the value at the (Little Endian) key given in the trie
+// of the snapshot file, or nil if the key is not in the trie.
+func (s *SnapshotFile) Get(keyLE []byte) (value []byte, err error) {
+	return GetFromDB(snapshotNodes{s}, s.rootHash, keyLE)
+}
+
+// Trie loads and returns the entire trie from the snapshot file,
+// with the state version of the trie written to the snapshot file.
+func (s *SnapshotFile) Trie() (t *Trie, err error) {
+	t = NewEmptyTrie()
+	t.SetVersion(s.version)
+	err = t.Load(snapshotNodes{s}, s.rootHash)
+	if err != nil {
+		return nil, fmt.Errorf("loading trie: %w", err)
+	}
+	return t, nil
+}
+
+// Close releases the memory mapping of the snapshot file.
+func (s *SnapshotFile) Close() (err error) {
+	return s.unmap()
+}
+
+func (s *SnapshotFile) indexEntry(i int) (entry []byte) {
+	start := snapshotFileHeaderSize + i*snapshotIndexEntrySize
+	return s.data[start : start+snapshotIndexEntrySize]
+}
+
+// encoding returns the encoding of the node with the hash given,
+// binary searching the sorted index of the snapshot file.
+func (s *SnapshotFile) encoding(hash []byte) (encoding []byte, err error) {
+	i := sort.Search(s.count, func(i int) bool {
+		return bytes.Compare(s.indexEntry(i)[:32], hash) >= 0
+	})
+	if i == s.count || !bytes.Equal(s.indexEntry(i)[:32], hash) {
+		return nil, fmt.Errorf("%w: for hash 0x%x", ErrSnapshotNodeAbsent, hash)
+	}
+
+	entry := s.indexEntry(i)
+	offset := binary.LittleEndian.Uint64(entry[32:])
+	length := uint64(binary.LittleEndian.Uint32(entry[40:]))
+	return s.data[offset : offset+length], nil
+}
+
+// snapshotNodes implements the Database interface
+// to read node encodings from a snapshot file.
+type snapshotNodes struct {
+	snapshot *SnapshotFile
+}
+
+func (s snapshotNodes) Get(key []byte) (value []byte, err error) {
+	return s.snapshot.encoding(key)
+}
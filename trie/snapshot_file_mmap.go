@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package trie
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile memory maps the file at the path given as read-only and
+// shared, and returns the mapped data and a function to unmap it.
+func mapFile(path string) (data []byte, unmap func() error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting file information: %w", err)
+	}
+
+	if stat.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(stat.Size()),
+		syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("memory mapping file: %w", err)
+	}
+
+	unmap = func() error {
+		return syscall.Munmap(data)
+	}
+	return data, unmap, nil
+}
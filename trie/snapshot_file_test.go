@@ -0,0 +1,185 @@
+package trie
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_WriteSnapshotFile(t *testing.T) {
+	t.Parallel()
+
+	generator := newGenerator()
+	keyValues := generateKeyValues(t, generator, 200)
+	trie := NewEmptyTrie()
+	for key, value := range keyValues {
+		trie.Put([]byte(key), value)
+	}
+	childTrie := NewEmptyTrie()
+	childTrie.Put([]byte("key"), []byte("value"))
+	err := trie.SetChild([]byte("child"), childTrie)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	err = trie.WriteSnapshotFile(path)
+	require.NoError(t, err)
+
+	snapshot, err := OpenSnapshotFile(path)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, snapshot.Close())
+	}()
+
+	assert.Equal(t, trie.MustHash(), snapshot.RootHash())
+
+	for key, value := range keyValues {
+		snapshotValue, err := snapshot.Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, value, snapshotValue)
+	}
+
+	value, err := snapshot.Get([]byte("absent"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	loaded, err := snapshot.Trie()
+	require.NoError(t, err)
+	assert.Equal(t, trie.MustHash(), loaded.MustHash())
+	childValue, err := loaded.GetFromChild([]byte("child"), []byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), childValue)
+}
+
+func Test_Trie_WriteSnapshotFile_V1(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	trie.SetVersion(V1)
+	largeValue := make([]byte, 40)
+	largeValue[0] = 1
+	trie.Put([]byte("large"), largeValue)
+	trie.Put([]byte("small"), []byte{1})
+	err := trie.PutIntoChild([]byte("child"), []byte("key"), largeValue[:33])
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	err = trie.WriteSnapshotFile(path)
+	require.NoError(t, err)
+
+	snapshot, err := OpenSnapshotFile(path)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, snapshot.Close())
+	}()
+
+	value, err := snapshot.Get([]byte("large"))
+	require.NoError(t, err)
+	assert.Equal(t, largeValue, value)
+
+	loaded, err := snapshot.Trie()
+	require.NoError(t, err)
+	assert.Equal(t, V1, loaded.Version())
+	assert.Equal(t, trie.MustHash(), loaded.MustHash())
+	assert.Equal(t, largeValue, loaded.Get([]byte("large")))
+	childValue, err := loaded.GetFromChild([]byte("child"), []byte("key"))
+	require.NoError(t, err)
+	assert.Equal(t, largeValue[:33], childValue)
+
+	// Without its value preimage, a trie cannot be written to a snapshot file.
+	hashedValueTrie := NewTrie(&Node{
+		PartialKey:    []byte{1},
+		StorageValue:  make([]byte, 32),
+		IsHashedValue: true,
+	})
+	err = hashedValueTrie.WriteSnapshotFile(path)
+	assert.ErrorIs(t, err, ErrValueNotLoaded)
+}
+
+func Test_Trie_WriteSnapshotFile_empty(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	err := NewEmptyTrie().WriteSnapshotFile(path)
+	require.NoError(t, err)
+
+	snapshot, err := OpenSnapshotFile(path)
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	assert.Equal(t, EmptyHash, snapshot.RootHash())
+	value, err := snapshot.Get([]byte{1})
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func Test_OpenSnapshotFile(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	trie.Put([]byte{1}, make([]byte, 40))
+	trie.Put([]byte{2}, make([]byte, 40))
+	validPath := filepath.Join(t.TempDir(), "state.snapshot")
+	err := trie.WriteSnapshotFile(validPath)
+	require.NoError(t, err)
+	valid, err := os.ReadFile(validPath)
+	require.NoError(t, err)
+
+	withByte := func(index int, value byte) []byte {
+		data := append([]byte{}, valid...)
+		data[index] = value
+		return data
+	}
+
+	testCases := map[string]struct {
+		data       []byte
+		errWrapped error
+	}{
+		"empty file": {
+			data:       []byte{},
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"bad magic": {
+			data:       withByte(0, 'X'),
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"unsupported version": {
+			data:       withByte(len(snapshotFileMagic), 3),
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"unsupported state version": {
+			data:       withByte(len(snapshotFileMagic)+1, 3),
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"index exceeds file": {
+			data:       withByte(snapshotFileHeaderSize-1, 0xff),
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"truncated encodings": {
+			data:       valid[:len(valid)-1],
+			errWrapped: ErrSnapshotFileFormat,
+		},
+		"valid": {
+			data: valid,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "state.snapshot")
+			err := os.WriteFile(path, testCase.data, 0600)
+			require.NoError(t, err)
+
+			snapshot, err := OpenSnapshotFile(path)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if err == nil {
+				assert.NoError(t, snapshot.Close())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package trie
+
+import sub "github.com/octopus-network/trie-go/substrate"
+
+// Stats contains statistics on the shape of a trie.
+type Stats struct {
+	// Leaves is the number of leaf nodes.
+	Leaves int
+	// Branches is the number of branch nodes, with or without value.
+	Branches int
+	// BranchesWithValue is the number of branch nodes with a value.
+	BranchesWithValue int
+	// MaxDepth is the maximum depth of the nodes with a value, where
+	// the depth of a node is its number of ancestor nodes, so the
+	// root node has a depth of 0.
+	MaxDepth int
+	// AverageDepth is the average depth of the nodes with a value,
+	// and is the average number of nodes, minus one, in the proof
+	// of a key of the trie.
+	AverageDepth float64
+}
+
+// Stats returns statistics on the shape of the trie,
+// excluding its child tries.
+func (t *Trie) Stats() (stats Stats) {
+	var values, totalDepth int
+	addNodeStats(t.root, 0, &stats, &values, &totalDepth)
+	if values > 0 {
+		stats.AverageDepth = float64(totalDepth) / float64(values)
+	}
+	return stats
+}
+
+func addNodeStats(node *Node, depth int, stats *Stats, values, totalDepth *int) {
+	if node == nil {
+		return
+	}
+
+	if node.Kind() == sub.Leaf {
+		stats.Leaves++
+	} else {
+		stats.Branches++
+		if node.StorageValue != nil {
+			stats.BranchesWithValue++
+		}
+	}
+
+	if node.Kind() == sub.Leaf || node.StorageValue != nil {
+		*values++
+		*totalDepth += depth
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+	}
+
+	for _, child := range node.Children {
+		addNodeStats(child, depth+1, stats, values, totalDepth)
+	}
+}
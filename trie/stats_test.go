@@ -0,0 +1,48 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Trie_Stats(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		keys  []string
+		stats Stats
+	}{
+		"empty trie": {},
+		"single leaf": {
+			keys:  []string{"a"},
+			stats: Stats{Leaves: 1},
+		},
+		"branches with and without value": {
+			// The root branch without value has the leaf 0x10 and the
+			// branch 0x11 with value, having the leaf 0x1122 as child.
+			keys: []string{"\x10", "\x11", "\x11\x22"},
+			stats: Stats{
+				Leaves:            2,
+				Branches:          2,
+				BranchesWithValue: 1,
+				MaxDepth:          2,
+				AverageDepth:      4.0 / 3.0,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trie := NewEmptyTrie()
+			for _, key := range testCase.keys {
+				trie.Put([]byte(key), []byte{1})
+			}
+
+			assert.Equal(t, testCase.stats, trie.Stats())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package trie
+
+import "fmt"
+
+// NodeGetter gets the node encodings and state version 1 value
+// preimages stored in a key value store by their hash digest.
+// If it also implements Has, it is used to tell a value preimage
+// absent from the store from a store failure.
+type NodeGetter interface {
+	Get(key []byte) (value []byte, err error)
+}
+
+// Database is the former name of NodeGetter.
+type Database = NodeGetter
+
+// NodeWriter writes the node encodings and state version 1 value
+// preimages to a key value store by their hash digest, and deletes
+// them. Writes may be buffered until Flush is called.
+type NodeWriter interface {
+	Put(key, value []byte) (err error)
+	Del(key []byte) (err error)
+	Flush() (err error)
+}
+
+// KVStore is a key value store the trie nodes are persisted to,
+// such as a chaindb database.
+type KVStore interface {
+	NodeGetter
+	NodeWriter
+	Has(key []byte) (has bool, err error)
+}
+
+// KeyValueIterator iterates over the key value pairs of a store.
+type KeyValueIterator interface {
+	// ForEach calls fn with each key value pair of the store, and
+	// stops at the first error returned by fn. The key and value
+	// may be reused by the store after fn returns.
+	ForEach(fn func(key, value []byte) (err error)) (err error)
+}
+
+// GCStore is a key value store whose unreachable
+// nodes can be garbage collected, see Trie.GC.
+type GCStore interface {
+	KVStore
+	KeyValueIterator
+}
+
+// keyChecker checks if a key is present in a database.
+type keyChecker interface {
+	Has(key []byte) (has bool, err error)
+}
+
+// hasKey returns true if the key given is in the database given,
+// or if the database cannot check the presence of keys.
+func hasKey(db NodeGetter, key []byte) (has bool, err error) {
+	checker, ok := db.(keyChecker)
+	if !ok {
+		return true, nil
+	}
+	return checker.Has(key)
+}
+
+// storeBatch buffers the writes made to it in memory, so nothing is
+// written to the store if writing the dirty nodes fails, and writes
+// them to the store when flushed.
+type storeBatch struct {
+	memoryBatch
+	store NodeWriter
+}
+
+func (b *storeBatch) Flush() (err error) {
+	defer b.Reset()
+
+	for _, keyValue := range b.keyValues {
+		if keyValue.Value == nil {
+			err = b.store.Del(keyValue.Key)
+			if err != nil {
+				return fmt.Errorf("deleting key 0x%x: %w", keyValue.Key, err)
+			}
+			continue
+		}
+
+		err = b.store.Put(keyValue.Key, keyValue.Value)
+		if err != nil {
+			return fmt.Errorf("putting key 0x%x: %w", keyValue.Key, err)
+		}
+	}
+
+	return b.store.Flush()
+}
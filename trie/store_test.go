@@ -0,0 +1,155 @@
+package trie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMapStoreKeyNotFound = errors.New("key not found")
+
+// mapStore is a KVStore backed by a map, to check the
+// trie can be persisted to stores other than chaindb.
+type mapStore struct {
+	keyValues map[string][]byte
+	flushes   int
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{keyValues: make(map[string][]byte)}
+}
+
+func (s *mapStore) Get(key []byte) (value []byte, err error) {
+	value, ok := s.keyValues[string(key)]
+	if !ok {
+		return nil, errMapStoreKeyNotFound
+	}
+	return value, nil
+}
+
+func (s *mapStore) Has(key []byte) (has bool, err error) {
+	_, has = s.keyValues[string(key)]
+	return has, nil
+}
+
+func (s *mapStore) Put(key, value []byte) (err error) {
+	s.keyValues[string(key)] = value
+	return nil
+}
+
+func (s *mapStore) Del(key []byte) (err error) {
+	delete(s.keyValues, string(key))
+	return nil
+}
+
+func (s *mapStore) Flush() (err error) {
+	s.flushes++
+	return nil
+}
+
+func (s *mapStore) ForEach(fn func(key, value []byte) (err error)) (err error) {
+	for key, value := range s.keyValues {
+		err = fn([]byte(key), value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_KVStore_mapStore(t *testing.T) {
+	t.Parallel()
+
+	store := newMapStore()
+	trie := NewEmptyTrie()
+	trie.SetVersion(V1)
+	largeValue := make([]byte, 40)
+	largeValue[0] = 1
+	trie.Put([]byte("large"), largeValue)
+	trie.Put([]byte("small"), []byte{1})
+
+	err := trie.WriteDirty(store)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.flushes)
+	rootHash := trie.MustHash()
+
+	loaded := NewEmptyTrie()
+	err = loaded.Load(store, rootHash)
+	require.NoError(t, err)
+	assert.Equal(t, largeValue, loaded.Get([]byte("large")))
+	assert.Equal(t, []byte{1}, loaded.Get([]byte("small")))
+
+	lazy, err := NewLazyTrie(store, rootHash)
+	require.NoError(t, err)
+	value, err := lazy.Get([]byte("large"))
+	require.NoError(t, err)
+	assert.Equal(t, largeValue, value)
+
+	trie.Delete([]byte("small"))
+	purged, err := trie.PurgeRemoved(store, rootHash)
+	require.NoError(t, err)
+	assert.Positive(t, purged)
+	err = trie.WriteDirty(store)
+	require.NoError(t, err)
+
+	loaded = NewEmptyTrie()
+	err = loaded.Load(store, trie.MustHash())
+	require.NoError(t, err)
+	assert.Equal(t, largeValue, loaded.Get([]byte("large")))
+	assert.Nil(t, loaded.Get([]byte("small")))
+}
+
+func Test_loadValue_missingPreimage(t *testing.T) {
+	t.Parallel()
+
+	valueHash := make([]byte, 32)
+
+	testCases := map[string]struct {
+		db         NodeGetter
+		errWrapped error
+		errMessage string
+	}{
+		"store checking keys": {
+			db: newMapStore(),
+		},
+		"store not checking keys": {
+			db:         getOnlyStore{newMapStore()},
+			errWrapped: errMapStoreKeyNotFound,
+			errMessage: "getting value preimage for hash " +
+				"0x0000000000000000000000000000000000000000000000000000000000000000: " +
+				"key not found",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			node := &Node{
+				PartialKey:    []byte{1},
+				StorageValue:  valueHash,
+				IsHashedValue: true,
+			}
+
+			err := loadValue(testCase.db, node)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.True(t, node.IsHashedValue)
+		})
+	}
+}
+
+// getOnlyStore hides all the methods of the store it wraps except Get.
+type getOnlyStore struct {
+	store *mapStore
+}
+
+func (s getOnlyStore) Get(key []byte) (value []byte, err error) {
+	return s.store.Get(key)
+}
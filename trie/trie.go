@@ -15,7 +15,14 @@ var EmptyHash = util.MustBlake2bHash([]byte{0})
 type Trie struct {
 	generation uint64
 	root       *Node
-	childTries map[util.Hash]*Trie
+	// childTries are the child tries keyed by their child storage key,
+	// without the ChildStorageKeyPrefix prefix.
+	childTries map[string]*Trie
+	// version is the state trie version used to encode values
+	// inserted in the trie, and is V0 if left to its zero value.
+	version Version
+	// metrics, if not nil, receives observations of the trie operations.
+	metrics Metrics
 	// deletedMerkleValues are the node Merkle values that were deleted
 	// from this trie since the last snapshot. These are used by the online
 	// pruner to detect with database keys (trie node Merkle values) can
@@ -32,7 +39,7 @@ func NewEmptyTrie() *Trie {
 func NewTrie(root *Node) *Trie {
 	return &Trie{
 		root:                root,
-		childTries:          make(map[util.Hash]*Trie),
+		childTries:          make(map[string]*Trie),
 		generation:          0, // Initially zero but increases after every snapshot.
 		deletedMerkleValues: make(map[string]struct{}),
 	}
@@ -41,28 +48,77 @@ func NewTrie(root *Node) *Trie {
 // Snapshot creates a copy of the trie.
 // Note it does not deep copy the trie, but will
 // copy on write as modifications are done on this new trie.
+// The generation of the trie itself is also incremented, so
+// its modifications are copied on write as well, and the
+// snapshot is an immutable view of the trie at this point,
+// for example to read values or generate proofs while new
+// blocks are applied to the trie.
 // It does a snapshot of all child tries as well, and resets
 // the set of deleted hashes.
+// Note Snapshot must not be called concurrently with
+// modifications of the trie.
 func (t *Trie) Snapshot() (newTrie *Trie) {
-	childTries := make(map[util.Hash]*Trie, len(t.childTries))
+	t.generation++
+
+	childTries := make(map[string]*Trie, len(t.childTries))
 	rootCopySettings := sub.DefaultCopySettings
 	rootCopySettings.CopyCached = true
-	for rootHash, childTrie := range t.childTries {
-		childTries[rootHash] = &Trie{
-			generation:          childTrie.generation + 1,
-			root:                childTrie.root.Copy(rootCopySettings),
+	for keyToChild, childTrie := range t.childTries {
+		childTrie.generation++
+		var root *Node
+		if childTrie.root != nil {
+			root = childTrie.root.Copy(rootCopySettings)
+		}
+		childTries[keyToChild] = &Trie{
+			generation:          childTrie.generation,
+			root:                root,
+			version:             childTrie.version,
+			metrics:             childTrie.metrics,
 			deletedMerkleValues: make(map[string]struct{}),
 		}
 	}
 
 	return &Trie{
-		generation:          t.generation + 1,
+		generation:          t.generation,
 		root:                t.root,
 		childTries:          childTries,
+		version:             t.version,
+		metrics:             t.metrics,
 		deletedMerkleValues: make(map[string]struct{}),
 	}
 }
 
+// Version returns the state trie version of the trie.
+func (t *Trie) Version() (version Version) {
+	if t.version == 0 {
+		return V0
+	}
+	return t.version
+}
+
+// SetVersion sets the state trie version used to encode the values
+// inserted in the trie from now on, and in its child tries. With V1,
+// values larger than 32 bytes are encoded as their hash in the trie
+// nodes, and their preimage is written separately to the database,
+// keyed by this hash. Values already in the trie are left unchanged,
+// so the version should be set on an empty trie, or on a trie loaded
+// from a database where it was written with the same version.
+func (t *Trie) SetVersion(version Version) {
+	t.version = version
+	for _, childTrie := range t.childTries {
+		childTrie.SetVersion(version)
+	}
+}
+
+// setStorageValue sets the storage value of the node given, and
+// sets whether it must be hashed in the node encoding according
+// to the trie version.
+func (t *Trie) setStorageValue(node *Node, value []byte) {
+	node.StorageValue = value
+	node.IsHashedValue = false
+	node.MustBeHashed = t.version == V1 && len(value) > MaxInlineValueSize
+}
+
 // handleTrackedDeltas sets the pending deleted Merkle values in
 // the trie deleted merkle values set if and only if success is true.
 func (t *Trie) handleTrackedDeltas(success bool, pendingDeletedMerkleValues map[string]struct{}) {
@@ -123,18 +179,24 @@ func updateGeneration(currentNode *Node, trieGeneration uint64,
 	return newNode
 }
 
-// DeepCopy deep copies the trie and returns
-// the copy. Note this method is meant to be used
-// in tests and should not be used in production
-// since it's rather inefficient compared to the copy
-// on write mechanism achieved through snapshots.
+// DeepCopy copies the trie in constant time and returns the copy,
+// such as for speculative execution. The copy shares all the nodes
+// of the trie, and a node is only duplicated when it is modified in
+// the trie or in its copy, since both move to a newer generation
+// than the generation of their shared nodes. Its child tries are
+// copied the same way, and the deleted Merkle values tracked are
+// copied as well.
 func (t *Trie) DeepCopy() (trieCopy *Trie) {
 	if t == nil {
 		return nil
 	}
 
+	t.generation++
 	trieCopy = &Trie{
 		generation: t.generation,
+		root:       t.root,
+		version:    t.version,
+		metrics:    t.metrics,
 	}
 
 	if t.deletedMerkleValues != nil {
@@ -145,22 +207,21 @@ func (t *Trie) DeepCopy() (trieCopy *Trie) {
 	}
 
 	if t.childTries != nil {
-		trieCopy.childTries = make(map[util.Hash]*Trie, len(t.childTries))
-		for hash, trie := range t.childTries {
-			trieCopy.childTries[hash] = trie.DeepCopy()
+		trieCopy.childTries = make(map[string]*Trie, len(t.childTries))
+		for keyToChild, trie := range t.childTries {
+			trieCopy.childTries[keyToChild] = trie.DeepCopy()
 		}
 	}
 
-	if t.root != nil {
-		copySettings := sub.DeepCopySettings
-		trieCopy.root = t.root.Copy(copySettings)
-	}
-
 	return trieCopy
 }
 
-// RootNode returns a copy of the root node of the trie.
+// RootNode returns a copy of the root node of the trie,
+// or nil if the trie is empty.
 func (t *Trie) RootNode() *Node {
+	if t.root == nil {
+		return nil
+	}
 	copySettings := sub.DefaultCopySettings
 	copySettings.CopyCached = true
 	return t.root.Copy(copySettings)
@@ -179,6 +240,8 @@ func (t *Trie) MustHash() util.Hash {
 
 // Hash returns the hashed root of the trie.
 func (t *Trie) Hash() (rootHash util.Hash, err error) {
+	defer t.observe(OperationHash)()
+
 	if t.root == nil {
 		return EmptyHash, nil
 	}
@@ -206,6 +269,16 @@ func (t *Trie) String() string {
 	return t.root.String()
 }
 
+// StringWithOptions returns the trie stringified through pre-order
+// traversal using the options given, to render large tries partially.
+func (t *Trie) StringWithOptions(options StringOptions) string {
+	if t.root == nil {
+		return "empty"
+	}
+
+	return t.root.StringNodeWithOptions(options).String()
+}
+
 func entries(parent *Node, prefix []byte, kv map[string][]byte) map[string][]byte {
 	if parent == nil {
 		return kv
@@ -234,7 +307,9 @@ func entries(parent *Node, prefix []byte, kv map[string][]byte) map[string][]byt
 	return kv
 }
 
-// NextKey returns the next key in the trie in lexicographic order.
+// NextKey returns the next key in the trie in lexicographic order,
+// strictly after the (Little Endian) key given, which does not need to
+// be in the trie, like the Substrate storage_next_key host function.
 // It returns nil if no next key is found.
 func (t *Trie) NextKey(keyLE []byte) (nextKeyLE []byte) {
 	prefix := []byte(nil)
@@ -261,35 +336,46 @@ func findNextKey(parent *Node, prefix, searchKey []byte) (nextKey []byte) {
 }
 
 func findNextKeyLeaf(leaf *Node, prefix, searchKey []byte) (nextKey []byte) {
-	parentLeafKey := leaf.PartialKey
-	fullKey := concatenateSlices(prefix, parentLeafKey)
-
-	if keyIsLexicographicallyBigger(searchKey, fullKey) {
+	fullKey := concatenateSlices(prefix, leaf.PartialKey)
+	if bytes.Compare(fullKey, searchKey) <= 0 {
 		return nil
 	}
-
 	return fullKey
 }
 
 func findNextKeyBranch(parentBranch *Node, prefix, searchKey []byte) (nextKey []byte) {
 	fullKey := concatenateSlices(prefix, parentBranch.PartialKey)
 
-	if bytes.Equal(searchKey, fullKey) {
-		const startChildIndex = 0
-		return findNextKeyChild(parentBranch.Children, startChildIndex, fullKey, searchKey)
+	commonLength := len(fullKey)
+	if len(searchKey) < commonLength {
+		commonLength = len(searchKey)
 	}
 
-	if keyIsLexicographicallyBigger(searchKey, fullKey) {
-		if len(searchKey) < len(fullKey) {
-			return nil
-		} else if len(searchKey) > len(fullKey) {
+	switch bytes.Compare(searchKey[:commonLength], fullKey[:commonLength]) {
+	case 1:
+		// The search key diverges from the branch full key with a bigger
+		// nibble, so all the keys of the branch are smaller than it.
+		return nil
+	case 0:
+		if len(searchKey) > len(fullKey) {
+			// The search key is in the subtrie of the branch child at the
+			// nibble following the branch full key, and all the keys of
+			// the next children are bigger than it.
 			startChildIndex := searchKey[len(fullKey)]
 			return findNextKeyChild(parentBranch.Children,
 				startChildIndex, fullKey, searchKey)
+		} else if len(searchKey) == len(fullKey) {
+			// The branch key is the search key and all the keys
+			// of the branch children are bigger than it.
+			const startChildIndex = 0
+			return findNextKeyChild(parentBranch.Children,
+				startChildIndex, fullKey, searchKey)
 		}
 	}
 
-	// search key is smaller than full key
+	// All the keys of the branch are bigger than the search key,
+	// because it is a prefix of the branch full key or it diverges
+	// from it with a smaller nibble.
 	if parentBranch.StorageValue != nil {
 		return fullKey
 	}
@@ -298,13 +384,6 @@ func findNextKeyBranch(parentBranch *Node, prefix, searchKey []byte) (nextKey []
 		fullKey, searchKey)
 }
 
-func keyIsLexicographicallyBigger(key, key2 []byte) (bigger bool) {
-	if len(key) < len(key2) {
-		return bytes.Compare(key, key2[:len(key)]) == 1
-	}
-	return bytes.Compare(key[:len(key2)], key2) != -1
-}
-
 // findNextKeyChild searches for a next key in the children
 // given and returns a next key or nil if no next key is found.
 func findNextKeyChild(children []*Node, startIndex byte,
@@ -328,6 +407,8 @@ func findNextKeyChild(children []*Node, startIndex byte,
 // Put inserts a value into the trie at the
 // key specified in little Endian format.
 func (t *Trie) Put(keyLE, value []byte) {
+	defer t.observe(OperationPut)()
+
 	pendingDeletedMerkleValues := make(map[string]struct{})
 	defer func() {
 		const success = true
@@ -354,12 +435,13 @@ func (t *Trie) insert(parent *Node, key, value []byte,
 	if parent == nil {
 		mutated = true
 		nodesCreated = 1
-		return &Node{
-			PartialKey:   key,
-			StorageValue: value,
-			Generation:   t.generation,
-			Dirty:        true,
-		}, mutated, nodesCreated
+		newParent = pooledNode(Node{
+			PartialKey: key,
+			Generation: t.generation,
+			Dirty:      true,
+		})
+		t.setStorageValue(newParent, value)
+		return newParent, mutated, nodesCreated
 	}
 
 	// TODO ensure all values have dirty set to true
@@ -383,7 +465,7 @@ func (t *Trie) insertInLeaf(parentLeaf *Node, key, value []byte,
 		copySettings := sub.DefaultCopySettings
 		copySettings.CopyStorageValue = false
 		parentLeaf = t.prepLeafForMutation(parentLeaf, copySettings, deletedMerkleValues)
-		parentLeaf.StorageValue = value
+		t.setStorageValue(parentLeaf, value)
 		mutated = true
 		return parentLeaf, mutated, nodesCreated
 	}
@@ -392,17 +474,17 @@ func (t *Trie) insertInLeaf(parentLeaf *Node, key, value []byte,
 
 	// Convert the current leaf parent into a branch parent
 	mutated = true
-	newBranchParent := &Node{
+	newBranchParent := pooledNode(Node{
 		PartialKey: key[:commonPrefixLength],
 		Generation: t.generation,
 		Children:   make([]*sub.Node, sub.ChildrenCapacity),
 		Dirty:      true,
-	}
+	})
 	parentLeafKey := parentLeaf.PartialKey
 
 	if len(key) == commonPrefixLength {
 		// key is included in parent leaf key
-		newBranchParent.StorageValue = value
+		t.setStorageValue(newBranchParent, value)
 
 		if len(key) < len(parentLeafKey) {
 			// Move the current leaf parent as a child to the new branch.
@@ -424,6 +506,9 @@ func (t *Trie) insertInLeaf(parentLeaf *Node, key, value []byte,
 	if len(parentLeaf.PartialKey) == commonPrefixLength {
 		// the key of the parent leaf is at this new branch
 		newBranchParent.StorageValue = parentLeaf.StorageValue
+		newBranchParent.IsHashedValue = parentLeaf.IsHashedValue
+		newBranchParent.MustBeHashed = parentLeaf.MustBeHashed
+		newBranchParent.ValueHasher = parentLeaf.ValueHasher
 	} else {
 		// make the leaf a child of the new branch
 		copySettings := sub.DefaultCopySettings
@@ -438,12 +523,13 @@ func (t *Trie) insertInLeaf(parentLeaf *Node, key, value []byte,
 		nodesCreated++
 	}
 	childIndex := key[commonPrefixLength]
-	newBranchParent.Children[childIndex] = &Node{
-		PartialKey:   key[commonPrefixLength+1:],
-		StorageValue: value,
-		Generation:   t.generation,
-		Dirty:        true,
-	}
+	newLeaf := pooledNode(Node{
+		PartialKey: key[commonPrefixLength+1:],
+		Generation: t.generation,
+		Dirty:      true,
+	})
+	t.setStorageValue(newLeaf, value)
+	newBranchParent.Children[childIndex] = newLeaf
 	newBranchParent.Descendants++
 	nodesCreated++
 
@@ -461,7 +547,7 @@ func (t *Trie) insertInBranch(parentBranch *Node, key, value []byte,
 			return parentBranch, mutated, 0
 		}
 		parentBranch = t.prepBranchForMutation(parentBranch, copySettings, deletedMerkleValues)
-		parentBranch.StorageValue = value
+		t.setStorageValue(parentBranch, value)
 		mutated = true
 		return parentBranch, mutated, 0
 	}
@@ -474,12 +560,12 @@ func (t *Trie) insertInBranch(parentBranch *Node, key, value []byte,
 		child := parentBranch.Children[childIndex]
 
 		if child == nil {
-			child = &Node{
-				PartialKey:   remainingKey,
-				StorageValue: value,
-				Generation:   t.generation,
-				Dirty:        true,
-			}
+			child = pooledNode(Node{
+				PartialKey: remainingKey,
+				Generation: t.generation,
+				Dirty:      true,
+			})
+			t.setStorageValue(child, value)
 			nodesCreated = 1
 			parentBranch = t.prepBranchForMutation(parentBranch, copySettings, deletedMerkleValues)
 			parentBranch.Children[childIndex] = child
@@ -504,12 +590,12 @@ func (t *Trie) insertInBranch(parentBranch *Node, key, value []byte,
 	mutated = true
 	nodesCreated = 1
 	commonPrefixLength := lenCommonPrefix(key, parentBranch.PartialKey)
-	newParentBranch := &Node{
+	newParentBranch := pooledNode(Node{
 		PartialKey: key[:commonPrefixLength],
 		Generation: t.generation,
 		Children:   make([]*sub.Node, sub.ChildrenCapacity),
 		Dirty:      true,
-	}
+	})
 
 	oldParentIndex := parentBranch.PartialKey[commonPrefixLength]
 	remainingOldParentKey := parentBranch.PartialKey[commonPrefixLength+1:]
@@ -521,7 +607,7 @@ func (t *Trie) insertInBranch(parentBranch *Node, key, value []byte,
 	newParentBranch.Descendants += 1 + parentBranch.Descendants
 
 	if len(key) <= commonPrefixLength {
-		newParentBranch.StorageValue = value
+		t.setStorageValue(newParentBranch, value)
 	} else {
 		childIndex := key[commonPrefixLength]
 		remainingKey := key[commonPrefixLength+1:]
@@ -570,7 +656,6 @@ func (t *Trie) GetKeysWithPrefix(prefixLE []byte) (keysLE [][]byte) {
 	var prefixNibbles []byte
 	if len(prefixLE) > 0 {
 		prefixNibbles = sub.KeyLEToNibbles(prefixLE)
-		prefixNibbles = bytes.TrimSuffix(prefixNibbles, []byte{0})
 	}
 
 	prefix := []byte{}
@@ -610,9 +695,9 @@ func getKeysWithPrefixFromBranch(parent *Node, prefix, key []byte,
 		return addAllKeys(parent, prefix, keysLE)
 	}
 
-	noPossiblePrefixedKeys :=
-		len(parent.PartialKey) > len(key) &&
-			!bytes.HasPrefix(parent.PartialKey, key)
+	// The key is not a prefix of the branch partial key, so it must
+	// extend the branch partial key for a child to have prefixed keys.
+	noPossiblePrefixedKeys := !bytes.HasPrefix(key, parent.PartialKey)
 	if noPossiblePrefixedKeys {
 		return keysLE
 	}
@@ -669,6 +754,8 @@ func makeChildPrefix(branchPrefix, branchKey []byte,
 // which matches its key with the key given.
 // Note the key argument is given in little Endian format.
 func (t *Trie) Get(keyLE []byte) (value []byte) {
+	defer t.observe(OperationGet)()
+
 	keyNibbles := sub.KeyLEToNibbles(keyLE)
 	return retrieve(t.root, keyNibbles)
 }
@@ -692,17 +779,18 @@ func retrieveFromLeaf(leaf *Node, key []byte) (value []byte) {
 }
 
 func retrieveFromBranch(branch *Node, key []byte) (value []byte) {
-	if len(key) == 0 || bytes.Equal(branch.PartialKey, key) {
+	if bytes.Equal(branch.PartialKey, key) {
 		return branch.StorageValue
 	}
 
-	if len(branch.PartialKey) > len(key) && bytes.HasPrefix(branch.PartialKey, key) {
+	if !bytes.HasPrefix(key, branch.PartialKey) {
+		// The key is shorter than the branch partial key
+		// or diverges from it, so it is not in the trie.
 		return nil
 	}
 
-	commonPrefixLength := lenCommonPrefix(branch.PartialKey, key)
-	childIndex := key[commonPrefixLength]
-	childKey := key[commonPrefixLength+1:]
+	childIndex := key[len(branch.PartialKey)]
+	childKey := key[len(branch.PartialKey)+1:]
 	child := branch.Children[childIndex]
 	return retrieve(child, childKey)
 }
@@ -710,7 +798,9 @@ func retrieveFromBranch(branch *Node, key []byte) (value []byte) {
 // ClearPrefixLimit deletes the keys having the prefix given in little
 // Endian format for up to `limit` keys. It returns the number of deleted
 // keys and a boolean indicating if all keys with the prefix were deleted
-// within the limit.
+// within the limit, as the Substrate ext_storage_clear_prefix_version_2
+// host function does. A zero limit deletes no key and reports not all
+// keys were deleted.
 func (t *Trie) ClearPrefixLimit(prefixLE []byte, limit uint32) (deleted uint32, allDeleted bool) {
 	pendingDeletedMerkleValues := make(map[string]struct{})
 	defer func() {
@@ -723,7 +813,6 @@ func (t *Trie) ClearPrefixLimit(prefixLE []byte, limit uint32) (deleted uint32,
 	}
 
 	prefix := sub.KeyLEToNibbles(prefixLE)
-	prefix = bytes.TrimSuffix(prefix, []byte{0})
 
 	t.root, deleted, _, allDeleted = t.clearPrefixLimitAtNode(
 		t.root, prefix, limit, pendingDeletedMerkleValues)
@@ -921,7 +1010,6 @@ func (t *Trie) ClearPrefix(prefixLE []byte) {
 	}
 
 	prefix := sub.KeyLEToNibbles(prefixLE)
-	prefix = bytes.TrimSuffix(prefix, []byte{0})
 
 	t.root, _ = t.clearPrefixAtNode(t.root, prefix, pendingDeletedMerkleValues)
 }
@@ -1001,6 +1089,8 @@ func (t *Trie) clearPrefixAtNode(parent *Node, prefix []byte,
 // matching the key given in little Endian format.
 // If no node is found at this key, nothing is deleted.
 func (t *Trie) Delete(keyLE []byte) {
+	defer t.observe(OperationDelete)()
+
 	pendingDeletedMerkleValues := make(map[string]struct{})
 	defer func() {
 		const success = true
@@ -1046,7 +1136,7 @@ func (t *Trie) deleteBranch(branch *Node, key []byte,
 		branch = t.prepBranchForMutation(branch, copySettings, deletedMerkleValues)
 		// we need to set to nil if the branch has the same generation
 		// as the current trie.
-		branch.StorageValue = nil
+		t.setStorageValue(branch, nil)
 		deleted = true
 		var branchChildMerged bool
 		newParent, branchChildMerged = handleDeletion(branch, key)
@@ -1110,12 +1200,15 @@ func handleDeletion(branch *Node, key []byte) (newNode *Node, branchChildMerged
 	case childrenCount == 0 && branch.StorageValue != nil:
 		const branchChildMerged = false
 		commonPrefixLength := lenCommonPrefix(branch.PartialKey, key)
-		return &Node{
-			PartialKey:   key[:commonPrefixLength],
-			StorageValue: branch.StorageValue,
-			Dirty:        true,
-			Generation:   branch.Generation,
-		}, branchChildMerged
+		return pooledNode(Node{
+			PartialKey:    key[:commonPrefixLength],
+			StorageValue:  branch.StorageValue,
+			IsHashedValue: branch.IsHashedValue,
+			MustBeHashed:  branch.MustBeHashed,
+			ValueHasher:   branch.ValueHasher,
+			Dirty:         true,
+			Generation:    branch.Generation,
+		}), branchChildMerged
 	case childrenCount == 1 && branch.StorageValue == nil:
 		const branchChildMerged = true
 		childIndex := firstChildIndex
@@ -1123,25 +1216,31 @@ func handleDeletion(branch *Node, key []byte) (newNode *Node, branchChildMerged
 
 		if child.Kind() == sub.Leaf {
 			newLeafKey := concatenateSlices(branch.PartialKey, intToByteSlice(childIndex), child.PartialKey)
-			return &Node{
-				PartialKey:   newLeafKey,
-				StorageValue: child.StorageValue,
-				Dirty:        true,
-				Generation:   branch.Generation,
-			}, branchChildMerged
+			return pooledNode(Node{
+				PartialKey:    newLeafKey,
+				StorageValue:  child.StorageValue,
+				IsHashedValue: child.IsHashedValue,
+				MustBeHashed:  child.MustBeHashed,
+				ValueHasher:   child.ValueHasher,
+				Dirty:         true,
+				Generation:    branch.Generation,
+			}), branchChildMerged
 		}
 
 		childBranch := child
 		newBranchKey := concatenateSlices(branch.PartialKey, intToByteSlice(childIndex), childBranch.PartialKey)
-		newBranch := &Node{
-			PartialKey:   newBranchKey,
-			StorageValue: childBranch.StorageValue,
-			Generation:   branch.Generation,
-			Children:     make([]*sub.Node, sub.ChildrenCapacity),
-			Dirty:        true,
+		newBranch := pooledNode(Node{
+			PartialKey:    newBranchKey,
+			StorageValue:  childBranch.StorageValue,
+			IsHashedValue: childBranch.IsHashedValue,
+			MustBeHashed:  childBranch.MustBeHashed,
+			ValueHasher:   childBranch.ValueHasher,
+			Generation:    branch.Generation,
+			Children:      make([]*sub.Node, sub.ChildrenCapacity),
+			Dirty:         true,
 			// this is the descendants of the original branch minus one
 			Descendants: childBranch.Descendants,
-		}
+		})
 
 		// Adopt the grand-children
 		for i, grandChild := range childBranch.Children {
@@ -26,7 +26,7 @@ func Test_EmptyHash(t *testing.T) {
 
 func Test_NewEmptyTrie(t *testing.T) {
 	expectedTrie := &Trie{
-		childTries:          make(map[util.Hash]*Trie),
+		childTries:          make(map[string]*Trie),
 		deletedMerkleValues: map[string]struct{}{},
 	}
 	trie := NewEmptyTrie()
@@ -43,7 +43,7 @@ func Test_NewTrie(t *testing.T) {
 			PartialKey:   []byte{0},
 			StorageValue: []byte{17},
 		},
-		childTries:          make(map[util.Hash]*Trie),
+		childTries:          make(map[string]*Trie),
 		deletedMerkleValues: map[string]struct{}{},
 	}
 	trie := NewTrie(root)
@@ -56,15 +56,15 @@ func Test_Trie_Snapshot(t *testing.T) {
 	trie := &Trie{
 		generation: 8,
 		root:       &Node{PartialKey: []byte{8}, StorageValue: []byte{1}},
-		childTries: map[util.Hash]*Trie{
-			{1}: {
+		childTries: map[string]*Trie{
+			"a": {
 				generation: 1,
 				root:       &Node{PartialKey: []byte{1}, StorageValue: []byte{1}},
 				deletedMerkleValues: map[string]struct{}{
 					"a": {},
 				},
 			},
-			{2}: {
+			"b": {
 				generation: 2,
 				root:       &Node{PartialKey: []byte{2}, StorageValue: []byte{1}},
 				deletedMerkleValues: map[string]struct{}{
@@ -81,13 +81,13 @@ func Test_Trie_Snapshot(t *testing.T) {
 	expectedTrie := &Trie{
 		generation: 9,
 		root:       &Node{PartialKey: []byte{8}, StorageValue: []byte{1}},
-		childTries: map[util.Hash]*Trie{
-			{1}: {
+		childTries: map[string]*Trie{
+			"a": {
 				generation:          2,
 				root:                &Node{PartialKey: []byte{1}, StorageValue: []byte{1}},
 				deletedMerkleValues: map[string]struct{}{},
 			},
-			{2}: {
+			"b": {
 				generation:          3,
 				root:                &Node{PartialKey: []byte{2}, StorageValue: []byte{1}},
 				deletedMerkleValues: map[string]struct{}{},
@@ -101,6 +101,122 @@ func Test_Trie_Snapshot(t *testing.T) {
 	assert.Equal(t, expectedTrie.childTries, newTrie.childTries)
 }
 
+func Test_Trie_Snapshot_liveWrites(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+	expectedHash := trie.MustHash()
+
+	snapshot := trie.Snapshot()
+	for key := range keyValues {
+		trie.Put([]byte(key), []byte("modified"))
+		break
+	}
+	trie.Put([]byte("new key"), []byte("new value"))
+	trie.ClearPrefix([]byte{})
+
+	assert.Equal(t, keyValues, snapshot.Entries())
+	assert.Equal(t, expectedHash, snapshot.MustHash())
+}
+
+func Test_Trie_DeepCopy_speculativeWrites(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	trie, keyValues := makeSeededTrie(t, size)
+	expectedHash := trie.MustHash()
+
+	var collectNodes func(node *Node, nodes map[*Node]struct{})
+	collectNodes = func(node *Node, nodes map[*Node]struct{}) {
+		if node == nil {
+			return
+		}
+		nodes[node] = struct{}{}
+		for _, child := range node.Children {
+			collectNodes(child, nodes)
+		}
+	}
+	originalNodes := make(map[*Node]struct{})
+	collectNodes(trie.root, originalNodes)
+
+	trieCopy := trie.DeepCopy()
+	assert.Same(t, trie.root, trieCopy.root)
+
+	var key string
+	for key = range keyValues {
+		break
+	}
+	trieCopy.Put([]byte(key), []byte("modified"))
+
+	assert.Equal(t, keyValues, trie.Entries())
+	assert.Equal(t, expectedHash, trie.MustHash())
+
+	// Only the nodes on the modified key path are duplicated.
+	copyNodes := make(map[*Node]struct{})
+	collectNodes(trieCopy.root, copyNodes)
+	duplicated := 0
+	for node := range copyNodes {
+		if _, shared := originalNodes[node]; !shared {
+			duplicated++
+		}
+	}
+	keyNibbles := len(sub.KeyLEToNibbles([]byte(key)))
+	assert.Greater(t, duplicated, 0)
+	assert.LessOrEqual(t, duplicated, keyNibbles)
+	assert.Less(t, duplicated, len(copyNodes)/2)
+
+	// Writes to the original trie leave the copy unchanged.
+	copyHash := trieCopy.MustHash()
+	trie.Put([]byte(key), []byte("original"))
+	trie.Delete([]byte(key))
+	assert.Equal(t, []byte("modified"), trieCopy.Get([]byte(key)))
+	assert.Equal(t, copyHash, trieCopy.MustHash())
+}
+
+func Test_Trie_Hash_incremental(t *testing.T) {
+	t.Parallel()
+
+	const size = 500
+	trie, keyValues := makeSeededTrie(t, size)
+	_, err := trie.Hash()
+	require.NoError(t, err)
+
+	var countUncached func(node *Node) int
+	countUncached = func(node *Node) (count int) {
+		if node == nil {
+			return 0
+		}
+		if node.NodeValue == nil {
+			count++
+		}
+		for _, child := range node.Children {
+			count += countUncached(child)
+		}
+		return count
+	}
+	assert.Zero(t, countUncached(trie.root))
+
+	var key string
+	for key = range keyValues {
+		break
+	}
+	trie.Put([]byte(key), []byte("modified"))
+	keyValues[key] = []byte("modified")
+
+	// Only the nodes on the modified key path are hashed again, and
+	// the cached Merkle values of other dirty nodes are reused.
+	uncached := countUncached(trie.root)
+	assert.Greater(t, uncached, 0)
+	assert.LessOrEqual(t, uncached, len(key)*2+1)
+
+	expected := NewEmptyTrie()
+	for key, value := range keyValues {
+		expected.Put([]byte(key), value)
+	}
+	assert.Equal(t, expected.MustHash(), trie.MustHash())
+}
+
 func Test_Trie_updateGeneration(t *testing.T) {
 	t.Parallel()
 
@@ -197,20 +313,20 @@ func assertPointersNotEqual(t *testing.T, a, b interface{}) {
 }
 
 // testTrieForDeepCopy verifies each pointer of the copied trie
-// are different from the new copy trie.
+// are different from the new copy trie, except for the root node
+// which is shared.
 func testTrieForDeepCopy(t *testing.T, original, copy *Trie) {
 	assertPointersNotEqual(t, original, copy)
 	if original == nil {
 		return
 	}
-	assertPointersNotEqual(t, original.generation, copy.generation)
 	assertPointersNotEqual(t, original.deletedMerkleValues, copy.deletedMerkleValues)
 	assertPointersNotEqual(t, original.childTries, copy.childTries)
 	for hashKey, childTrie := range copy.childTries {
 		originalChildTrie := original.childTries[hashKey]
 		testTrieForDeepCopy(t, originalChildTrie, childTrie)
 	}
-	assertPointersNotEqual(t, original.root, copy.root)
+	assert.Same(t, original.root, copy.root)
 }
 
 func Test_Trie_DeepCopy(t *testing.T) {
@@ -223,14 +339,14 @@ func Test_Trie_DeepCopy(t *testing.T) {
 		"nil": {},
 		"empty trie": {
 			trieOriginal: &Trie{},
-			trieCopy:     &Trie{},
+			trieCopy:     &Trie{generation: 1},
 		},
 		"filled trie": {
 			trieOriginal: &Trie{
 				generation: 1,
 				root:       &Node{PartialKey: []byte{1, 2}, StorageValue: []byte{1}},
-				childTries: map[util.Hash]*Trie{
-					{1, 2, 3}: {
+				childTries: map[string]*Trie{
+					"a": {
 						generation: 2,
 						root:       &Node{PartialKey: []byte{1}, StorageValue: []byte{1}},
 						deletedMerkleValues: map[string]struct{}{
@@ -245,11 +361,11 @@ func Test_Trie_DeepCopy(t *testing.T) {
 				},
 			},
 			trieCopy: &Trie{
-				generation: 1,
+				generation: 2,
 				root:       &Node{PartialKey: []byte{1, 2}, StorageValue: []byte{1}},
-				childTries: map[util.Hash]*Trie{
-					{1, 2, 3}: {
-						generation: 2,
+				childTries: map[string]*Trie{
+					"a": {
+						generation: 3,
 						root:       &Node{PartialKey: []byte{1}, StorageValue: []byte{1}},
 						deletedMerkleValues: map[string]struct{}{
 							"a": {},
@@ -272,7 +388,10 @@ func Test_Trie_DeepCopy(t *testing.T) {
 
 			trieCopy := testCase.trieOriginal.DeepCopy()
 
-			assert.Equal(t, trieCopy, testCase.trieCopy)
+			assert.Equal(t, testCase.trieCopy, trieCopy)
+			if testCase.trieOriginal != nil {
+				assert.Equal(t, trieCopy.generation, testCase.trieOriginal.generation)
+			}
 
 			testTrieForDeepCopy(t, testCase.trieOriginal, trieCopy)
 		})
@@ -296,6 +415,9 @@ func Test_Trie_RootNode(t *testing.T) {
 	root := trie.RootNode()
 
 	assert.Equal(t, expectedRoot, root)
+
+	emptyTrie := NewEmptyTrie()
+	assert.Nil(t, emptyTrie.RootNode())
 }
 
 func Test_Trie_MustHash(t *testing.T) {
@@ -540,7 +662,7 @@ func Test_Trie_Entries(t *testing.T) {
 
 		trie := Trie{
 			root:       nil,
-			childTries: make(map[util.Hash]*Trie),
+			childTries: make(map[string]*Trie),
 		}
 
 		kv := map[string][]byte{
@@ -591,6 +713,88 @@ func Test_Trie_NextKey(t *testing.T) {
 			key:     []byte{0x10}, // 10 => [1, 0] in nibbles
 			nextKey: []byte{2},
 		},
+		"key smaller than root branch full key": {
+			trie: Trie{
+				root: &Node{
+					PartialKey:   []byte{2, 2},
+					StorageValue: []byte{1},
+					Descendants:  1,
+					Children: padRightChildren([]*Node{
+						{
+							PartialKey:   []byte{0},
+							StorageValue: []byte{1},
+						},
+					}),
+				},
+			},
+			key:     []byte{0x21},
+			nextKey: []byte{0x22},
+		},
+		"key equal to root branch full key": {
+			trie: Trie{
+				root: &Node{
+					PartialKey:   []byte{2, 2},
+					StorageValue: []byte{1},
+					Descendants:  1,
+					Children: padRightChildren([]*Node{
+						{
+							PartialKey:   []byte{0},
+							StorageValue: []byte{1},
+						},
+					}),
+				},
+			},
+			key:     []byte{0x22},
+			nextKey: []byte{0x22, 0x00},
+		},
+		"key diverging from root branch full key with a bigger first nibble": {
+			trie: Trie{
+				root: &Node{
+					PartialKey:   []byte{2, 2},
+					StorageValue: []byte{1},
+					Descendants:  1,
+					Children: padRightChildren([]*Node{
+						{
+							PartialKey:   []byte{0},
+							StorageValue: []byte{1},
+						},
+					}),
+				},
+			},
+			key: []byte{0x33},
+		},
+		"key diverging from root branch full key with a bigger last nibble": {
+			trie: Trie{
+				root: &Node{
+					PartialKey:   []byte{2, 2},
+					StorageValue: []byte{1},
+					Descendants:  1,
+					Children: padRightChildren([]*Node{
+						{
+							PartialKey:   []byte{0},
+							StorageValue: []byte{1},
+						},
+					}),
+				},
+			},
+			key: []byte{0x23},
+		},
+		"key bigger than all root branch keys": {
+			trie: Trie{
+				root: &Node{
+					PartialKey:   []byte{2, 2},
+					StorageValue: []byte{1},
+					Descendants:  1,
+					Children: padRightChildren([]*Node{
+						{
+							PartialKey:   []byte{0},
+							StorageValue: []byte{1},
+						},
+					}),
+				},
+			},
+			key: []byte{0x22, 0x00},
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -882,7 +1086,7 @@ func Test_nextKey(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			originalTrie := testCase.trie.DeepCopy()
+			originalTrie := fullCopy(&testCase.trie)
 
 			nextKey := findNextKey(testCase.trie.root, nil, testCase.key)
 
@@ -892,6 +1096,27 @@ func Test_nextKey(t *testing.T) {
 	}
 }
 
+func Test_Trie_Put_emptyValue(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	trie.Put([]byte{1}, []byte{2})
+	withoutKeyHash := trie.MustHash()
+
+	trie.Put([]byte{1, 2}, []byte{})
+
+	value := trie.Get([]byte{1, 2})
+	assert.NotNil(t, value)
+	assert.Empty(t, value)
+	withEmptyValueHash := trie.MustHash()
+	assert.NotEqual(t, withoutKeyHash, withEmptyValueHash)
+
+	trie.Delete([]byte{1, 2})
+
+	assert.Nil(t, trie.Get([]byte{1, 2}))
+	assert.Equal(t, withoutKeyHash, trie.MustHash())
+}
+
 func Test_Trie_Put(t *testing.T) {
 	t.Parallel()
 
@@ -1162,7 +1387,7 @@ func Test_Trie_insert(t *testing.T) {
 			t.Parallel()
 
 			trie := testCase.trie
-			expectedTrie := *trie.DeepCopy()
+			expectedTrie := *fullCopy(&trie)
 
 			newNode, mutated, nodesCreated := trie.insert(
 				testCase.parent, testCase.key, testCase.value,
@@ -1481,14 +1706,14 @@ func Test_LoadFromMap(t *testing.T) {
 	}{
 		"nil data": {
 			expectedTrie: Trie{
-				childTries:          map[util.Hash]*Trie{},
+				childTries:          map[string]*Trie{},
 				deletedMerkleValues: map[string]struct{}{},
 			},
 		},
 		"empty data": {
 			data: map[string]string{},
 			expectedTrie: Trie{
-				childTries:          map[util.Hash]*Trie{},
+				childTries:          map[string]*Trie{},
 				deletedMerkleValues: map[string]struct{}{},
 			},
 		},
@@ -1521,7 +1746,7 @@ func Test_LoadFromMap(t *testing.T) {
 					},
 					Dirty: true,
 				},
-				childTries:          map[util.Hash]*Trie{},
+				childTries:          map[string]*Trie{},
 				deletedMerkleValues: map[string]struct{}{},
 			},
 		},
@@ -1551,7 +1776,7 @@ func Test_LoadFromMap(t *testing.T) {
 						},
 					}),
 				},
-				childTries:          map[util.Hash]*Trie{},
+				childTries:          map[string]*Trie{},
 				deletedMerkleValues: map[string]struct{}{},
 			},
 		},
@@ -1949,7 +2174,18 @@ func Test_retrieve(t *testing.T) {
 					{PartialKey: []byte{1}, StorageValue: []byte{1}},
 				}),
 			},
-			value: []byte{2},
+		},
+		"branch key diverging from search key": {
+			parent: &Node{
+				PartialKey:   []byte{1, 2, 3},
+				StorageValue: []byte{2},
+				Descendants:  1,
+				Children: padRightChildren([]*Node{
+					nil, nil, nil, nil,
+					{PartialKey: []byte{0, 0}, StorageValue: []byte{1}},
+				}),
+			},
+			key: []byte{1, 4, 0, 0},
 		},
 		"branch key mismatch with shorter search key": {
 			parent: &Node{
@@ -2058,6 +2294,36 @@ func Test_Trie_ClearPrefixLimit(t *testing.T) {
 	}
 }
 
+func Test_Trie_prefixTrailingZeroNibble(t *testing.T) {
+	t.Parallel()
+
+	newTrie := func() *Trie {
+		trie := NewEmptyTrie()
+		trie.Put([]byte{0x10}, []byte{1})
+		trie.Put([]byte{0x11}, []byte{2})
+		trie.Put([]byte{0x12}, []byte{3})
+		return trie
+	}
+	prefix := []byte{0x10}
+
+	keys := newTrie().GetKeysWithPrefix(prefix)
+	assert.Equal(t, [][]byte{{0x10}}, keys)
+
+	trie := newTrie()
+	deleted, allDeleted := trie.ClearPrefixLimit(prefix, 10)
+	assert.Equal(t, uint32(1), deleted)
+	assert.True(t, allDeleted)
+	assert.Nil(t, trie.Get([]byte{0x10}))
+	assert.Equal(t, []byte{2}, trie.Get([]byte{0x11}))
+	assert.Equal(t, []byte{3}, trie.Get([]byte{0x12}))
+
+	trie = newTrie()
+	trie.ClearPrefix(prefix)
+	assert.Nil(t, trie.Get([]byte{0x10}))
+	assert.Equal(t, []byte{2}, trie.Get([]byte{0x11}))
+	assert.Equal(t, []byte{3}, trie.Get([]byte{0x12}))
+}
+
 func Test_Trie_clearPrefixLimitAtNode(t *testing.T) {
 	t.Parallel()
 
@@ -2585,7 +2851,7 @@ func Test_Trie_clearPrefixLimitAtNode(t *testing.T) {
 			t.Parallel()
 
 			trie := testCase.trie
-			expectedTrie := *trie.DeepCopy()
+			expectedTrie := *fullCopy(&trie)
 
 			newParent, valuesDeleted, nodesRemoved, allDeleted :=
 				trie.clearPrefixLimitAtNode(testCase.parent, testCase.prefix,
@@ -2759,7 +3025,7 @@ func Test_Trie_deleteNodesLimit(t *testing.T) {
 			t.Parallel()
 
 			trie := testCase.trie
-			expectedTrie := *trie.DeepCopy()
+			expectedTrie := *fullCopy(&trie)
 
 			newNode, valuesDeleted, nodesRemoved :=
 				trie.deleteNodesLimit(testCase.parent,
@@ -3133,7 +3399,7 @@ func Test_Trie_clearPrefixAtNode(t *testing.T) {
 			t.Parallel()
 
 			trie := testCase.trie
-			expectedTrie := *trie.DeepCopy()
+			expectedTrie := *fullCopy(&trie)
 
 			newParent, nodesRemoved := trie.clearPrefixAtNode(
 				testCase.parent, testCase.prefix, testCase.deletedMerkleValues)
@@ -3535,7 +3801,7 @@ func Test_Trie_deleteAtNode(t *testing.T) {
 				expectedKey = make([]byte, len(testCase.key))
 				copy(expectedKey, testCase.key)
 			}
-			expectedTrie := *testCase.trie.DeepCopy()
+			expectedTrie := *fullCopy(&testCase.trie)
 
 			newParent, updated, nodesRemoved := testCase.trie.deleteAtNode(
 				testCase.parent, testCase.key, testCase.deletedMerkleValues)
@@ -3631,6 +3897,30 @@ func Test_Trie_String(t *testing.T) {
 	}
 }
 
+func Test_Trie_StringWithOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "empty", NewEmptyTrie().StringWithOptions(StringOptions{MaxNodes: 1}))
+
+	trie := NewEmptyTrie()
+	trie.Put([]byte{0x12}, []byte{1})
+	trie.Put([]byte{0x13}, []byte{2})
+
+	s := trie.StringWithOptions(StringOptions{MaxNodes: 1, FullKeys: true})
+
+	expected := `Branch
+├── Generation: 0
+├── Dirty: true
+├── Key: 0x01
+├── Full key: 0x1
+├── Storage value: nil
+├── Descendants: 2
+├── Merkle value: nil
+├── Child 2 (not shown)
+└── Child 3 (not shown)`
+	assert.Equal(t, expected, s)
+}
+
 func Test_handleDeletion(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,143 @@
+// Package triedb persists tries to a database together with a manifest
+// recording the last committed root, so a restarted process can reopen
+// its last state without any external bookkeeping.
+package triedb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/octopus-network/trie-go/scale"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// ManifestKey is the database key under which the manifest of the
+// last committed trie is stored. It cannot collide with trie node keys,
+// which are either 32 bytes Merkle values or the encodings of nodes
+// shorter than 32 bytes, since no node encoding is equal to it.
+var ManifestKey = []byte(":trie_manifest")
+
+var ErrManifestNotFound = errors.New("trie manifest not found")
+
+// Reader reads the trie nodes and the manifest stored in a database.
+type Reader interface {
+	trie.NodeGetter
+	Has(key []byte) (has bool, err error)
+}
+
+// Manifest describes the last trie committed to a database.
+type Manifest struct {
+	RootHash  util.Hash
+	Version   trie.Version
+	NodeCount uint32
+	// Timestamp is the Unix time in seconds at which the trie was committed.
+	Timestamp uint64
+}
+
+// encodedManifest is the SCALE encoded form of a manifest.
+type encodedManifest struct {
+	RootHash  [32]byte
+	Version   uint8
+	NodeCount uint32
+	Timestamp uint64
+}
+
+// Commit writes the dirty nodes of the trie given to the database,
+// and then persists its manifest entry, which is returned.
+// The manifest records the state version of the trie.
+// The manifest is written after the nodes, so an interrupted commit
+// leaves the previous manifest pointing to a complete trie.
+func Commit(db trie.KVStore, t *trie.Trie) (
+	manifest Manifest, err error) {
+	return commit(db, t, time.Now)
+}
+
+func commit(db trie.KVStore, t *trie.Trie,
+	now func() time.Time) (manifest Manifest, err error) {
+	rootHash, err := t.Hash()
+	if err != nil {
+		return manifest, fmt.Errorf("hashing trie: %w", err)
+	}
+
+	err = t.WriteDirty(db)
+	if err != nil {
+		return manifest, fmt.Errorf("writing dirty nodes: %w", err)
+	}
+
+	manifest = Manifest{
+		RootHash:  rootHash,
+		Version:   t.Version(),
+		Timestamp: uint64(now().Unix()),
+	}
+	if rootHash != trie.EmptyHash {
+		manifest.NodeCount = 1 + t.RootNode().Descendants
+	}
+
+	encoded, err := scale.Marshal(encodedManifest{
+		RootHash:  manifest.RootHash,
+		Version:   uint8(manifest.Version),
+		NodeCount: manifest.NodeCount,
+		Timestamp: manifest.Timestamp,
+	})
+	if err != nil {
+		return manifest, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	err = db.Put(ManifestKey, encoded)
+	if err != nil {
+		return manifest, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// LatestRoot returns the manifest of the last trie committed to the
+// database with Commit. It returns an error wrapping ErrManifestNotFound
+// if no trie was ever committed to the database.
+func LatestRoot(db Reader) (manifest Manifest, err error) {
+	has, err := db.Has(ManifestKey)
+	if err != nil {
+		return manifest, fmt.Errorf("checking manifest in database: %w", err)
+	} else if !has {
+		return manifest, ErrManifestNotFound
+	}
+
+	encoded, err := db.Get(ManifestKey)
+	if err != nil {
+		return manifest, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	var decoded encodedManifest
+	err = scale.Unmarshal(encoded, &decoded)
+	if err != nil {
+		return manifest, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	return Manifest{
+		RootHash:  decoded.RootHash,
+		Version:   trie.Version(decoded.Version),
+		NodeCount: decoded.NodeCount,
+		Timestamp: decoded.Timestamp,
+	}, nil
+}
+
+// Open loads the last trie committed to the database with Commit,
+// with the state version recorded in its manifest, and returns it
+// together with its manifest.
+func Open(db Reader) (t *trie.Trie, manifest Manifest, err error) {
+	manifest, err = LatestRoot(db)
+	if err != nil {
+		return nil, manifest, err
+	}
+
+	t = trie.NewEmptyTrie()
+	t.SetVersion(manifest.Version)
+	err = t.Load(db, manifest.RootHash)
+	if err != nil {
+		return nil, manifest, fmt.Errorf("loading trie: %w", err)
+	}
+
+	return t, manifest, nil
+}
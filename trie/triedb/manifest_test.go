@@ -0,0 +1,81 @@
+package triedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Commit_LatestRoot_Open(t *testing.T) {
+	t.Parallel()
+
+	database := newMapStore()
+
+	_, err := LatestRoot(database)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+	_, _, err = Open(database)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+
+	now := func() time.Time { return time.Unix(1000, 0) }
+
+	tr := trie.NewEmptyTrie()
+	manifest, err := commit(database, tr, now)
+	require.NoError(t, err)
+	assert.Equal(t, Manifest{
+		RootHash:  trie.EmptyHash,
+		Version:   trie.V0,
+		Timestamp: 1000,
+	}, manifest)
+
+	tr.Put([]byte("cat"), []byte("meow"))
+	tr.Put([]byte("catapulta"), []byte("whoosh"))
+	tr.Put([]byte("dog"), []byte("woof"))
+	manifest, err = commit(database, tr, now)
+	require.NoError(t, err)
+	expectedManifest := Manifest{
+		RootHash:  tr.MustHash(),
+		Version:   trie.V0,
+		NodeCount: 4,
+		Timestamp: 1000,
+	}
+	assert.Equal(t, expectedManifest, manifest)
+
+	latest, err := LatestRoot(database)
+	require.NoError(t, err)
+	assert.Equal(t, expectedManifest, latest)
+
+	reopened, reopenedManifest, err := Open(database)
+	require.NoError(t, err)
+	assert.Equal(t, expectedManifest, reopenedManifest)
+	assert.Equal(t, tr.MustHash(), reopened.MustHash())
+	assert.Equal(t, []byte("whoosh"), reopened.Get([]byte("catapulta")))
+	assert.Equal(t, trie.V0, reopened.Version())
+}
+
+func Test_Commit_Open_V1(t *testing.T) {
+	t.Parallel()
+
+	database := newMapStore()
+
+	tr := trie.NewEmptyTrie()
+	tr.SetVersion(trie.V1)
+	largeValue := make([]byte, 40)
+	tr.Put([]byte("large"), largeValue)
+	manifest, err := Commit(database, tr)
+	require.NoError(t, err)
+	assert.Equal(t, trie.V1, manifest.Version)
+
+	reopened, reopenedManifest, err := Open(database)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, reopenedManifest)
+	assert.Equal(t, trie.V1, reopened.Version())
+	assert.Equal(t, largeValue, reopened.Get([]byte("large")))
+
+	// Values put in the reopened trie are hashed as in state version 1.
+	tr.Put([]byte("other"), largeValue)
+	reopened.Put([]byte("other"), largeValue)
+	assert.Equal(t, tr.MustHash(), reopened.MustHash())
+}
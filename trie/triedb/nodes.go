@@ -0,0 +1,51 @@
+package triedb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+)
+
+var ErrNodeRecordMalformed = errors.New("node record malformed")
+
+// IterateNodes walks the trie node records stored in the database with
+// the key prefix given, such as a table prefix, in the iteration order
+// of the database. For each record, it decodes the node and calls fn with the Merkle value
+// of the node, which is its database key without the prefix.
+// Node records are the records with a 32 bytes key, or with a key equal
+// to their value for nodes with an encoding shorter than 32 bytes, so
+// other records such as the manifest are skipped. It returns an error
+// wrapping ErrNodeRecordMalformed if a node record cannot be decoded,
+// and stops at the first error returned by fn.
+func IterateNodes(db trie.KeyValueIterator, prefix []byte,
+	fn func(hash []byte, node *sub.Node) error) (err error) {
+	return db.ForEach(func(key, value []byte) (err error) {
+		if !bytes.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		hash := key[len(prefix):]
+		const hashSize = 32
+		isNodeRecord := len(hash) == hashSize ||
+			(len(hash) < hashSize && bytes.Equal(hash, value))
+		if !isNodeRecord {
+			return nil
+		}
+		// The key may be reused by the database once this returns.
+		hash = append([]byte{}, hash...)
+
+		node, err := sub.Decode(bytes.NewReader(value))
+		if err != nil {
+			return fmt.Errorf("%w: for node 0x%x: %s", ErrNodeRecordMalformed, hash, err)
+		}
+
+		err = fn(hash, node)
+		if err != nil {
+			return fmt.Errorf("for node 0x%x: %w", hash, err)
+		}
+		return nil
+	})
+}
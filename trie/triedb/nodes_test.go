@@ -0,0 +1,90 @@
+package triedb
+
+import (
+	"errors"
+	"testing"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IterateNodes(t *testing.T) {
+	t.Parallel()
+
+	database := newMapStore()
+
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("cat"), []byte("meow"))
+	tr.Put([]byte("catapulta"), []byte("whoosh"))
+	tr.Put([]byte("dog"), []byte("woof"))
+	tr.Put([]byte("horse"), make([]byte, 40))
+	_, err := Commit(database, tr)
+	require.NoError(t, err)
+
+	expected := make(map[string]struct{})
+	trie.PopulateNodeHashes(tr.RootNode(), expected)
+	rootHash := tr.MustHash()
+	expected[string(rootHash[:])] = struct{}{}
+
+	// Nodes of another trie stored with a table prefix must be skipped.
+	otherTrie := trie.NewEmptyTrie()
+	otherTrie.Put([]byte("other"), make([]byte, 50))
+	otherDatabase := newMapStore()
+	err = otherTrie.WriteDirty(otherDatabase)
+	require.NoError(t, err)
+	for key, value := range otherDatabase.keyValues {
+		err = database.Put([]byte("other"+key), value)
+		require.NoError(t, err)
+	}
+
+	nodes := make(map[string]struct{})
+	err = IterateNodes(database, nil, func(hash []byte, node *sub.Node) error {
+		var merkleValue []byte
+		if string(hash) == string(rootHash[:]) {
+			_, merkleValue, err = node.EncodeAndHashRoot()
+		} else {
+			_, merkleValue, err = node.EncodeAndHash()
+		}
+		require.NoError(t, err)
+		assert.Equal(t, hash, merkleValue)
+		nodes[string(hash)] = struct{}{}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Subset(t, keys(nodes), keys(expected))
+	// Nodes with an encoding shorter than 32 bytes are
+	// also stored, with their encoding as key.
+	const inlinedNodes = 3
+	assert.Len(t, nodes, len(expected)+inlinedNodes)
+
+	var otherNodes int
+	err = IterateNodes(database, []byte("other"), func(hash []byte, node *sub.Node) error {
+		assert.Equal(t, []byte("other"), sub.NibblesToKeyLE(node.PartialKey))
+		otherNodes++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, otherNodes)
+
+	errTest := errors.New("test error")
+	err = IterateNodes(database, []byte("other"), func(hash []byte, node *sub.Node) error {
+		return errTest
+	})
+	assert.ErrorIs(t, err, errTest)
+
+	err = database.Put(make([]byte, 32), []byte{0xff})
+	require.NoError(t, err)
+	err = IterateNodes(database, nil, func(hash []byte, node *sub.Node) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNodeRecordMalformed)
+}
+
+func keys(set map[string]struct{}) (keys []string) {
+	for key := range set {
+		keys = append(keys, key)
+	}
+	return keys
+}
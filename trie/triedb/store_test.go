@@ -0,0 +1,50 @@
+package triedb
+
+import "errors"
+
+var errMapStoreKeyNotFound = errors.New("key not found")
+
+// mapStore is a key value store backed by a map, implementing
+// the trie.KVStore and trie.KeyValueIterator interfaces.
+type mapStore struct {
+	keyValues map[string][]byte
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{keyValues: make(map[string][]byte)}
+}
+
+func (s *mapStore) Get(key []byte) (value []byte, err error) {
+	value, ok := s.keyValues[string(key)]
+	if !ok {
+		return nil, errMapStoreKeyNotFound
+	}
+	return value, nil
+}
+
+func (s *mapStore) Has(key []byte) (has bool, err error) {
+	_, has = s.keyValues[string(key)]
+	return has, nil
+}
+
+func (s *mapStore) Put(key, value []byte) (err error) {
+	s.keyValues[string(key)] = value
+	return nil
+}
+
+func (s *mapStore) Del(key []byte) (err error) {
+	delete(s.keyValues, string(key))
+	return nil
+}
+
+func (s *mapStore) Flush() (err error) { return nil }
+
+func (s *mapStore) ForEach(fn func(key, value []byte) (err error)) (err error) {
+	for key, value := range s.keyValues {
+		err = fn([]byte(key), value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
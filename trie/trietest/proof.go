@@ -0,0 +1,61 @@
+// Package trietest contains helpers to test code using tries and
+// proofs, such as downstream proof verifiers.
+package trietest
+
+import (
+	"math/rand"
+)
+
+// ShuffleProof returns a copy of the encoded proof nodes given,
+// shuffled deterministically using the seed given. Since proofs are
+// order independent, verifiers should accept any shuffled proof.
+// The encoded proof nodes themselves are not copied.
+func ShuffleProof(encodedProofNodes [][]byte, seed int64) (shuffled [][]byte) {
+	shuffled = copyProof(encodedProofNodes)
+	generator := rand.New(rand.NewSource(seed)) //nolint:gosec
+	generator.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// PruneProof returns a copy of the encoded proof nodes given, with
+// count nodes removed, chosen deterministically using the seed given.
+// The order of the remaining nodes is preserved. If count is larger
+// than the number of nodes, all nodes are removed, and if it is
+// negative, no node is removed.
+// It is useful to check verifiers reject incomplete proofs.
+func PruneProof(encodedProofNodes [][]byte, seed int64, count int) (pruned [][]byte) {
+	if count < 0 {
+		count = 0
+	}
+
+	if count >= len(encodedProofNodes) {
+		return [][]byte{}
+	}
+
+	generator := rand.New(rand.NewSource(seed)) //nolint:gosec
+	removed := make(map[int]struct{}, count)
+	for _, index := range generator.Perm(len(encodedProofNodes))[:count] {
+		removed[index] = struct{}{}
+	}
+
+	pruned = make([][]byte, 0, len(encodedProofNodes)-count)
+	for i, encodedProofNode := range encodedProofNodes {
+		_, remove := removed[i]
+		if remove {
+			continue
+		}
+		pruned = append(pruned, encodedProofNode)
+	}
+	return pruned
+}
+
+func copyProof(encodedProofNodes [][]byte) (copied [][]byte) {
+	if encodedProofNodes == nil {
+		return nil
+	}
+	copied = make([][]byte, len(encodedProofNodes))
+	copy(copied, encodedProofNodes)
+	return copied
+}
@@ -0,0 +1,83 @@
+package trietest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeProof(size int) (encodedProofNodes [][]byte) {
+	encodedProofNodes = make([][]byte, size)
+	for i := range encodedProofNodes {
+		encodedProofNodes[i] = []byte{byte(i)}
+	}
+	return encodedProofNodes
+}
+
+func Test_ShuffleProof(t *testing.T) {
+	t.Parallel()
+
+	proof := makeProof(20)
+	original := makeProof(20)
+
+	shuffled := ShuffleProof(proof, 1)
+
+	assert.Equal(t, original, proof, "input must not be modified")
+	assert.ElementsMatch(t, proof, shuffled)
+	assert.NotEqual(t, proof, shuffled)
+	assert.Equal(t, shuffled, ShuffleProof(proof, 1))
+	assert.NotEqual(t, shuffled, ShuffleProof(proof, 2))
+
+	assert.Nil(t, ShuffleProof(nil, 1))
+}
+
+func Test_PruneProof(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		size   int
+		count  int
+		length int
+	}{
+		"prune_none": {
+			size:   5,
+			length: 5,
+		},
+		"negative_count": {
+			size:   5,
+			count:  -1,
+			length: 5,
+		},
+		"prune_some": {
+			size:   10,
+			count:  3,
+			length: 7,
+		},
+		"prune_all": {
+			size:  3,
+			count: 3,
+		},
+		"prune_more_than_all": {
+			size:  3,
+			count: 10,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			proof := makeProof(testCase.size)
+
+			pruned := PruneProof(proof, 42, testCase.count)
+
+			assert.Len(t, pruned, testCase.length)
+			assert.Equal(t, pruned, PruneProof(proof, 42, testCase.count))
+			assert.Subset(t, proof, pruned)
+			for i := 1; i < len(pruned); i++ {
+				assert.Less(t, pruned[i-1][0], pruned[i][0], "order must be preserved")
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package trietest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/util"
+)
+
+var (
+	ErrReplayRootMismatch     = errors.New("replayed root hash mismatch")
+	ErrReplayOperationUnknown = errors.New("replayed operation unknown")
+)
+
+// Operation kinds recorded in a fixture.
+const (
+	OperationPut              = "put"
+	OperationDelete           = "delete"
+	OperationClearPrefix      = "clear_prefix"
+	OperationClearPrefixLimit = "clear_prefix_limit"
+)
+
+// Operation is a trie operation recorded in a fixture, together
+// with the trie root hash after the operation. Key is the prefix
+// for the clear prefix operations, Value is only set for put
+// operations and Limit is only set for clear prefix limit operations.
+// Keys and values are 0x prefixed hexadecimal strings, so fixtures
+// can be read and edited by hand. An operation without kind only
+// records the root hash of the trie when the recording started.
+type Operation struct {
+	Kind  string    `json:"op,omitempty"`
+	Key   string    `json:"key,omitempty"`
+	Value string    `json:"value,omitempty"`
+	Limit uint32    `json:"limit,omitempty"`
+	Root  util.Hash `json:"root"`
+}
+
+// Recorder applies operations to a trie and records each of them,
+// with the trie root hash after it, to write them as a fixture which
+// can be replayed with Replay, for example to reproduce a state root
+// divergence reported by a downstream chain.
+type Recorder struct {
+	trie       *trie.Trie
+	operations []Operation
+}
+
+// NewRecorder returns a recorder applying operations to the trie
+// given, and records its current root hash as the initial root hash.
+func NewRecorder(t *trie.Trie) (recorder *Recorder, err error) {
+	root, err := t.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("hashing trie: %w", err)
+	}
+	return &Recorder{
+		trie:       t,
+		operations: []Operation{{Root: root}},
+	}, nil
+}
+
+// Put puts the value at the (Little Endian) key given and records it.
+func (r *Recorder) Put(key, value []byte) (err error) {
+	r.trie.Put(key, value)
+	return r.record(Operation{
+		Kind:  OperationPut,
+		Key:   util.BytesToHex(key),
+		Value: util.BytesToHex(value),
+	})
+}
+
+// Delete deletes the (Little Endian) key given and records it.
+func (r *Recorder) Delete(key []byte) (err error) {
+	r.trie.Delete(key)
+	return r.record(Operation{
+		Kind: OperationDelete,
+		Key:  util.BytesToHex(key),
+	})
+}
+
+// ClearPrefix deletes the keys with the (Little Endian)
+// prefix given and records it.
+func (r *Recorder) ClearPrefix(prefix []byte) (err error) {
+	r.trie.ClearPrefix(prefix)
+	return r.record(Operation{
+		Kind: OperationClearPrefix,
+		Key:  util.BytesToHex(prefix),
+	})
+}
+
+// ClearPrefixLimit deletes up to limit keys with the (Little Endian)
+// prefix given, as trie ClearPrefixLimit does, and records it.
+func (r *Recorder) ClearPrefixLimit(prefix []byte, limit uint32) (
+	deleted uint32, allDeleted bool, err error) {
+	deleted, allDeleted = r.trie.ClearPrefixLimit(prefix, limit)
+	err = r.record(Operation{
+		Kind:  OperationClearPrefixLimit,
+		Key:   util.BytesToHex(prefix),
+		Limit: limit,
+	})
+	return deleted, allDeleted, err
+}
+
+// Operations returns the operations recorded so far, starting
+// with the operation recording the initial root hash.
+func (r *Recorder) Operations() (operations []Operation) {
+	operations = make([]Operation, len(r.operations))
+	copy(operations, r.operations)
+	return operations
+}
+
+// Write writes the operations recorded to the writer given,
+// as one JSON encoded operation per line.
+func (r *Recorder) Write(writer io.Writer) (err error) {
+	encoder := json.NewEncoder(writer)
+	for i, operation := range r.operations {
+		err = encoder.Encode(operation)
+		if err != nil {
+			return fmt.Errorf("encoding operation %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the operations recorded to the file at the path given.
+func (r *Recorder) WriteFile(path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = r.Write(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (r *Recorder) record(operation Operation) (err error) {
+	operation.Root, err = r.trie.Hash()
+	if err != nil {
+		return fmt.Errorf("hashing trie after %s: %w", operation.Kind, err)
+	}
+	r.operations = append(r.operations, operation)
+	return nil
+}
+
+// Replay reads the operations written by a recorder from the reader
+// given, and applies them to the trie given, which should be in the
+// same state as the recorded trie when the recording started, usually
+// empty. It checks the trie root hash before the first operation and
+// after each operation, and returns an error wrapping
+// ErrReplayRootMismatch for the first root hash differing from the
+// recorded one, so the first diverging operation is identified.
+func Replay(t *trie.Trie, reader io.Reader) (err error) {
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+	for index := 0; ; index++ {
+		var operation Operation
+		err = decoder.Decode(&operation)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decoding operation %d: %w", index, err)
+		}
+
+		err = apply(t, operation)
+		if err != nil {
+			return fmt.Errorf("applying operation %d: %w", index, err)
+		}
+
+		root, err := t.Hash()
+		if err != nil {
+			return fmt.Errorf("hashing trie after operation %d: %w", index, err)
+		}
+
+		if root != operation.Root {
+			description := "initial state"
+			if operation.Kind != "" {
+				description = operation.Kind + " at key " + operation.Key
+			}
+			return fmt.Errorf("%w: after operation %d (%s): root hash is %s instead of %s",
+				ErrReplayRootMismatch, index, description, root, operation.Root)
+		}
+	}
+}
+
+// ReplayFile replays the operations of the fixture file at the path
+// given, as written by Recorder WriteFile, on a new empty trie.
+func ReplayFile(path string) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return Replay(trie.NewEmptyTrie(), file)
+}
+
+func apply(t *trie.Trie, operation Operation) (err error) {
+	if operation.Kind == "" {
+		return nil
+	}
+
+	key, err := util.HexToBytes(operation.Key)
+	if err != nil {
+		return fmt.Errorf("decoding key: %w", err)
+	}
+
+	switch operation.Kind {
+	case OperationPut:
+		value, err := util.HexToBytes(operation.Value)
+		if err != nil {
+			return fmt.Errorf("decoding value: %w", err)
+		}
+		t.Put(key, value)
+	case OperationDelete:
+		t.Delete(key)
+	case OperationClearPrefix:
+		t.ClearPrefix(key)
+	case OperationClearPrefixLimit:
+		t.ClearPrefixLimit(key, operation.Limit)
+	default:
+		return fmt.Errorf("%w: %q", ErrReplayOperationUnknown, operation.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package trietest
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordOperations(t *testing.T) (recorder *Recorder) {
+	t.Helper()
+
+	recorder, err := NewRecorder(trie.NewEmptyTrie())
+	require.NoError(t, err)
+
+	require.NoError(t, recorder.Put([]byte("alice"), []byte{1}))
+	require.NoError(t, recorder.Put([]byte("alicia"), bytes.Repeat([]byte{2}, 40)))
+	require.NoError(t, recorder.Put([]byte("bob"), []byte{}))
+	require.NoError(t, recorder.Put([]byte("carol"), []byte{3}))
+	require.NoError(t, recorder.Delete([]byte("bob")))
+	deleted, allDeleted, err := recorder.ClearPrefixLimit([]byte("ali"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), deleted)
+	assert.False(t, allDeleted)
+	require.NoError(t, recorder.ClearPrefix([]byte("ali")))
+	return recorder
+}
+
+func Test_Recorder_Replay(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordOperations(t)
+
+	operations := recorder.Operations()
+	require.Len(t, operations, 8)
+	assert.Equal(t, trie.EmptyHash, operations[0].Root)
+	assert.Equal(t, Operation{
+		Kind:  OperationPut,
+		Key:   "0x626f62",
+		Value: "0x",
+		Root:  operations[3].Root,
+	}, operations[3])
+
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	err := recorder.WriteFile(path)
+	require.NoError(t, err)
+
+	err = ReplayFile(path)
+	assert.NoError(t, err)
+}
+
+func Test_Replay_errors(t *testing.T) {
+	t.Parallel()
+
+	buffer := bytes.NewBuffer(nil)
+	err := recordOperations(t).Write(buffer)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(buffer.String(), "\n")
+
+	testCases := map[string]struct {
+		trie       *trie.Trie
+		fixture    string
+		errWrapped error
+		errMessage string
+	}{
+		"initial state mismatch": {
+			trie: func() *trie.Trie {
+				tr := trie.NewEmptyTrie()
+				tr.Put([]byte{1}, []byte{2})
+				return tr
+			}(),
+			fixture:    lines[0],
+			errWrapped: ErrReplayRootMismatch,
+			errMessage: "replayed root hash mismatch: after operation 0 (initial state): ",
+		},
+		"diverging operation": {
+			fixture: lines[0] + lines[1] +
+				strings.Replace(lines[2], `"key":"0x616c69636961"`, `"key":"0x616c69636962"`, 1),
+			errWrapped: ErrReplayRootMismatch,
+			errMessage: "replayed root hash mismatch: after operation 2 (put at key 0x616c69636962): ",
+		},
+		"unknown operation": {
+			fixture:    `{"op":"rename","key":"0x01","root":"0x00"}`,
+			errWrapped: ErrReplayOperationUnknown,
+			errMessage: `applying operation 0: replayed operation unknown: "rename"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := testCase.trie
+			if tr == nil {
+				tr = trie.NewEmptyTrie()
+			}
+
+			err := Replay(tr, strings.NewReader(testCase.fixture))
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.True(t, strings.HasPrefix(err.Error(), testCase.errMessage), err.Error())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	sub "github.com/octopus-network/trie-go/substrate"
+)
+
+var (
+	// ErrNodeNotLoaded is returned when a lookup reaches a node only
+	// known by its Merkle value, such as a node absent from a proof
+	// or a node not yet loaded from the database.
+	ErrNodeNotLoaded = errors.New("node not loaded")
+	// ErrValueNotLoaded is returned when a lookup reaches a state
+	// version 1 node whose hashed storage value preimage is not loaded.
+	ErrValueNotLoaded = errors.New("value not loaded")
+)
+
+// TryGet returns the value at the (Little Endian) key given, like Get,
+// but can tell an absent key from a key which cannot be looked up.
+// It returns a nil value and a nil error if the key is not in the trie.
+// It returns an error wrapping ErrNodeNotLoaded if the lookup reaches a
+// node which is not loaded, in which case the key may or may not be in
+// the trie, and an error wrapping ErrValueNotLoaded if the node of the
+// key only holds the hash of its value.
+func (t *Trie) TryGet(keyLE []byte) (value []byte, err error) {
+	defer t.observe(OperationGet)()
+
+	key := sub.KeyLEToNibbles(keyLE)
+	node := t.root
+	for node != nil {
+		if isUnloaded(node) {
+			return nil, fmt.Errorf("%w: Merkle value 0x%x on the path of key 0x%x",
+				ErrNodeNotLoaded, node.NodeValue, keyLE)
+		}
+
+		if bytes.Equal(node.PartialKey, key) {
+			if node.IsHashedValue {
+				return nil, fmt.Errorf("%w: value hash 0x%x at key 0x%x",
+					ErrValueNotLoaded, node.StorageValue, keyLE)
+			}
+			return node.StorageValue, nil
+		}
+
+		if node.Kind() == sub.Leaf || !bytes.HasPrefix(key, node.PartialKey) {
+			return nil, nil
+		}
+
+		childIndex := key[len(node.PartialKey)]
+		key = key[len(node.PartialKey)+1:]
+		node = node.Children[childIndex]
+	}
+	return nil, nil
+}
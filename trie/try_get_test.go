@@ -0,0 +1,101 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Trie_TryGet(t *testing.T) {
+	t.Parallel()
+
+	unloadedMerkleValue := make([]byte, 32)
+	unloadedMerkleValue[0] = 1
+	valueHash := make([]byte, 32)
+	valueHash[0] = 2
+
+	testCases := map[string]struct {
+		trie       *Trie
+		keyLE      []byte
+		value      []byte
+		errWrapped error
+		errMessage string
+	}{
+		"empty trie": {
+			trie:  NewEmptyTrie(),
+			keyLE: []byte{0x12},
+		},
+		"key found": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{1},
+				Children: padRightChildren([]*Node{
+					nil, nil,
+					{PartialKey: []byte{3, 0}, StorageValue: []byte{2}},
+				}),
+			}),
+			keyLE: []byte{0x12, 0x30},
+			value: []byte{2},
+		},
+		"empty value": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1, 2},
+				StorageValue: []byte{},
+			}),
+			keyLE: []byte{0x12},
+			value: []byte{},
+		},
+		"key not found": {
+			trie: NewTrie(&Node{
+				PartialKey:   []byte{1},
+				StorageValue: []byte{1},
+				Children: padRightChildren([]*Node{
+					nil, nil,
+					{PartialKey: []byte{3}, StorageValue: []byte{2}},
+				}),
+			}),
+			keyLE: []byte{0x13},
+		},
+		"node not loaded": {
+			trie: NewTrie(&Node{
+				PartialKey: []byte{1},
+				Children: padRightChildren([]*Node{
+					nil, nil,
+					{NodeValue: unloadedMerkleValue},
+				}),
+			}),
+			keyLE:      []byte{0x12, 0x30},
+			errWrapped: ErrNodeNotLoaded,
+			errMessage: "node not loaded: Merkle value " +
+				"0x0100000000000000000000000000000000000000000000000000000000000000 " +
+				"on the path of key 0x1230",
+		},
+		"value not loaded": {
+			trie: NewTrie(&Node{
+				PartialKey:    []byte{1, 2},
+				StorageValue:  valueHash,
+				IsHashedValue: true,
+			}),
+			keyLE:      []byte{0x12},
+			errWrapped: ErrValueNotLoaded,
+			errMessage: "value not loaded: value hash " +
+				"0x0200000000000000000000000000000000000000000000000000000000000000 " +
+				"at key 0x12",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := testCase.trie.TryGet(testCase.keyLE)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.value, value)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrValueNotFound = errors.New("value not found")
+	ErrValueSize     = errors.New("value size mismatch")
+)
+
+// GetReader returns a reader over the value in the trie at the
+// Little Endian key given, together with the value size in bytes.
+// It returns an error wrapping ErrValueNotFound if there is no value
+// at this key, and an error wrapping ErrNodeNotLoaded or
+// ErrValueNotLoaded if the value cannot be looked up, as for TryGet.
+// Note the trie has no detached value store yet, so the value is
+// still held in memory and the reader only streams it to the caller.
+func (t *Trie) GetReader(keyLE []byte) (reader io.ReadCloser, size int64, err error) {
+	value, err := t.TryGet(keyLE)
+	if err != nil {
+		return nil, 0, err
+	} else if value == nil {
+		return nil, 0, fmt.Errorf("%w: at key 0x%x", ErrValueNotFound, keyLE)
+	}
+	return io.NopCloser(bytes.NewReader(value)), int64(len(value)), nil
+}
+
+// PutReader inserts the value read from the reader given into the
+// trie at the Little Endian key given. Exactly size bytes are read,
+// and an error wrapping ErrValueSize is returned if the reader ends
+// before, in which case the trie is left unchanged. Memory is only
+// allocated as bytes are read, so a size larger than the data of the
+// reader does not allocate it upfront.
+// Note the trie has no detached value store yet, so the value read
+// is fully held in memory in the trie node.
+func (t *Trie) PutReader(keyLE []byte, reader io.Reader, size int64) (err error) {
+	if size < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrValueSize, size)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	n, err := buffer.ReadFrom(io.LimitReader(reader, size))
+	if err != nil {
+		return fmt.Errorf("reading value: %w", err)
+	} else if n != size {
+		return fmt.Errorf("%w: read %d bytes instead of %d", ErrValueSize, n, size)
+	}
+
+	t.Put(keyLE, buffer.Bytes())
+	return nil
+}
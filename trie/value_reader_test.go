@@ -0,0 +1,58 @@
+package trie
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trie_GetReader_PutReader(t *testing.T) {
+	t.Parallel()
+
+	trie := NewEmptyTrie()
+	code := bytes.Repeat([]byte{1, 2, 3}, 1000)
+
+	err := trie.PutReader([]byte(":code"), bytes.NewReader(code), int64(len(code)))
+	require.NoError(t, err)
+
+	reader, size, err := trie.GetReader([]byte(":code"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(code)), size)
+	value, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, code, value)
+	err = reader.Close()
+	require.NoError(t, err)
+
+	_, _, err = trie.GetReader([]byte("missing"))
+	assert.ErrorIs(t, err, ErrValueNotFound)
+	assert.EqualError(t, err, "value not found: at key 0x6d697373696e67")
+
+	err = trie.PutReader([]byte("short"), bytes.NewReader([]byte{1}), 2)
+	assert.ErrorIs(t, err, ErrValueSize)
+	assert.EqualError(t, err, "value size mismatch: read 1 bytes instead of 2")
+	assert.Nil(t, trie.Get([]byte("short")))
+
+	// The size given is not allocated upfront.
+	const hugeSize = 1 << 50
+	err = trie.PutReader([]byte("huge"), bytes.NewReader([]byte{1}), hugeSize)
+	assert.ErrorIs(t, err, ErrValueSize)
+	assert.EqualError(t, err, "value size mismatch: read 1 bytes instead of 1125899906842624")
+	assert.Nil(t, trie.Get([]byte("huge")))
+
+	// Only size bytes are read from the reader.
+	err = trie.PutReader([]byte("long"), bytes.NewReader([]byte{1, 2, 3}), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, trie.Get([]byte("long")))
+
+	hashedValueTrie := NewTrie(&Node{
+		PartialKey:    []byte{0, 1},
+		StorageValue:  make([]byte, 32),
+		IsHashedValue: true,
+	})
+	_, _, err = hashedValueTrie.GetReader([]byte{0x01})
+	assert.ErrorIs(t, err, ErrValueNotLoaded)
+}
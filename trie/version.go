@@ -17,26 +17,58 @@ const (
 	// inserted into the trie directly.
 	// TODO set to iota once CI passes
 	V0 Version = 1
+	// V1 is the state trie version 1 where the values of the keys
+	// larger than 32 bytes are hashed and only their hash is inserted
+	// into the trie nodes.
+	V1 Version = 2
 )
 
+// MaxInlineValueSize is the maximum size in bytes of a value encoded
+// as is in a trie node with the state trie version V1. Larger values
+// are encoded as their hash in the node.
+const MaxInlineValueSize = 32
+
 func (v Version) String() string {
 	switch v {
 	case V0:
 		return "v0"
+	case V1:
+		return "v1"
 	default:
 		panic(fmt.Sprintf("unknown version %d", v))
 	}
 }
 
-var ErrParseVersion = errors.New("parsing version failed")
+var (
+	ErrParseVersion   = errors.New("parsing version failed")
+	ErrVersionUnknown = errors.New("version unknown")
+)
 
 // ParseVersion parses a state trie version string.
 func ParseVersion(s string) (version Version, err error) {
 	switch {
 	case strings.EqualFold(s, V0.String()):
 		return V0, nil
+	case strings.EqualFold(s, V1.String()):
+		return V1, nil
 	default:
-		return version, fmt.Errorf("%w: %q must be %s",
-			ErrParseVersion, s, V0)
+		return version, fmt.Errorf("%w: %q must be one of %s, %s",
+			ErrParseVersion, s, V0, V1)
 	}
 }
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v Version) MarshalText() (text []byte, err error) {
+	switch v {
+	case V0, V1:
+		return []byte(v.String()), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrVersionUnknown, v)
+	}
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *Version) UnmarshalText(text []byte) (err error) {
+	*v, err = ParseVersion(string(text))
+	return err
+}
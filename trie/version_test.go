@@ -3,9 +3,12 @@
 package trie
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/octopus-network/trie-go/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Version_String(t *testing.T) {
@@ -20,6 +23,10 @@ func Test_Version_String(t *testing.T) {
 			version:       V0,
 			versionString: "v0",
 		},
+		"v1": {
+			version:       V1,
+			versionString: "v1",
+		},
 		"invalid": {
 			version:      Version(99),
 			panicMessage: "unknown version 99",
@@ -61,10 +68,14 @@ func Test_ParseVersion(t *testing.T) {
 			s:       "V0",
 			version: V0,
 		},
+		"v1": {
+			s:       "v1",
+			version: V1,
+		},
 		"invalid": {
 			s:          "xyz",
 			errWrapped: ErrParseVersion,
-			errMessage: "parsing version failed: \"xyz\" must be v0",
+			errMessage: "parsing version failed: \"xyz\" must be one of v0, v1",
 		},
 	}
 
@@ -83,3 +94,105 @@ func Test_ParseVersion(t *testing.T) {
 		})
 	}
 }
+
+func Test_Version_MarshalText(t *testing.T) {
+	t.Parallel()
+
+	text, err := V1.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), text)
+
+	var version Version
+	err = version.UnmarshalText(text)
+	assert.NoError(t, err)
+	assert.Equal(t, V1, version)
+
+	_, err = Version(99).MarshalText()
+	assert.ErrorIs(t, err, ErrVersionUnknown)
+
+	err = version.UnmarshalText([]byte("v9"))
+	assert.ErrorIs(t, err, ErrParseVersion)
+}
+
+func Test_Trie_SetVersion(t *testing.T) {
+	t.Parallel()
+
+	largeValue := bytes.Repeat([]byte{1}, MaxInlineValueSize+1)
+	smallValue := bytes.Repeat([]byte{2}, MaxInlineValueSize)
+
+	rootHash := func(t *testing.T, node *Node) util.Hash {
+		t.Helper()
+		merkleValue, err := node.CalculateRootMerkleValue()
+		require.NoError(t, err)
+		return util.BytesToHash(merkleValue)
+	}
+
+	v0Trie := NewEmptyTrie()
+	assert.Equal(t, V0, v0Trie.Version())
+	v0Trie.Put([]byte{0x12}, largeValue)
+	assert.Equal(t, rootHash(t, &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: largeValue,
+	}), v0Trie.MustHash())
+
+	v1Trie := NewEmptyTrie()
+	v1Trie.SetVersion(V1)
+	assert.Equal(t, V1, v1Trie.Version())
+	v1Trie.Put([]byte{0x12}, largeValue)
+	assert.Equal(t, rootHash(t, &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: largeValue,
+		MustBeHashed: true,
+	}), v1Trie.MustHash())
+	assert.Equal(t, largeValue, v1Trie.Get([]byte{0x12}))
+
+	// Values up to 32 bytes are not hashed.
+	v1Trie.Put([]byte{0x12}, smallValue)
+	assert.Equal(t, rootHash(t, &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: smallValue,
+	}), v1Trie.MustHash())
+
+	// Hashed values are kept hashed when nodes are restructured.
+	v1Trie.Put([]byte{0x12}, largeValue)
+	v1Trie.Put([]byte{0x12, 0x34}, smallValue)
+	v1Trie.Delete([]byte{0x12, 0x34})
+	assert.Equal(t, rootHash(t, &Node{
+		PartialKey:   []byte{1, 2},
+		StorageValue: largeValue,
+		MustBeHashed: true,
+	}), v1Trie.MustHash())
+}
+
+func Test_Trie_SetVersion_WriteDirty_Load(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	keyValues := generateKeyValues(t, newGenerator(), size)
+	trie := NewEmptyTrie()
+	trie.SetVersion(V1)
+	for key, value := range keyValues {
+		trie.Put([]byte(key), value)
+	}
+	trie.Put([]byte("large"), bytes.Repeat([]byte{1}, 100))
+	keyValues["large"] = bytes.Repeat([]byte{1}, 100)
+
+	db := newTestDB(t)
+	err := trie.WriteDirty(db)
+	require.NoError(t, err)
+
+	// The value preimage is stored keyed by its hash.
+	valueHash := util.MustBlake2bHash(keyValues["large"])
+	preimage, err := db.Get(valueHash.ToBytes())
+	require.NoError(t, err)
+	assert.Equal(t, keyValues["large"], preimage)
+
+	rootHash := trie.MustHash()
+	loaded := NewEmptyTrie()
+	loaded.SetVersion(V1)
+	err = loaded.Load(db, rootHash)
+	require.NoError(t, err)
+
+	assert.Equal(t, keyValues, loaded.Entries())
+	assert.Equal(t, rootHash, loaded.MustHash())
+}
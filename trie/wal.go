@@ -0,0 +1,360 @@
+package trie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// The write-ahead log file starts with the magic bytes and the format
+// version. It is followed by the records, each made of the record
+// type, the key length, the value length, the key and the value.
+// It ends with a commit record made of the record type, the number
+// of records and the CRC-32 checksum of all the bytes preceding the
+// commit record. A log without a valid commit record is incomplete.
+const (
+	walFileMagic          = "TRIEWAL"
+	walFileVersion        = 1
+	walFileHeaderSize     = len(walFileMagic) + 1
+	walRecordHeaderSize   = 1 + 4 + 4
+	walCommitRecordSize   = 1 + 8 + 4
+	walRecordPut          = 1
+	walRecordDelete       = 2
+	walRecordCommit       = 3
+	walFilePermissions    = 0o600
+	walChecksumPolynomial = crc32.Castagnoli
+)
+
+var (
+	ErrWALFormat  = errors.New("write-ahead log format invalid")
+	ErrWALPending = errors.New("write-ahead log pending recovery")
+)
+
+// WAL is a write-ahead log file making the writes of dirty nodes to a
+// store crash consistent, when set in the write options. The node
+// encodings are first written and synced to the log file, then written
+// to the store, and the log file is removed once the store is flushed.
+// If the process crashes in between, Recover must be called on restart
+// before writing to the store again: it replays a complete log to the
+// store, or discards an incomplete log, in which case nothing was
+// written to the store. The store thus never holds a partially
+// persisted state root, even if the nodes are written in several
+// batches. A WAL is not safe for concurrent use.
+type WAL struct {
+	path string
+}
+
+// NewWAL returns a write-ahead log using the file at the path given.
+func NewWAL(path string) *WAL {
+	return &WAL{path: path}
+}
+
+// Recover replays the log file to the store given, flushing the store
+// every batchSize bytes of values if it is not zero, and removes the
+// log file. It returns true if the log was complete and replayed, and
+// false if there was no log file or if the log was incomplete, in which
+// case it is discarded. It returns an error wrapping ErrWALFormat if
+// the file is not a write-ahead log file.
+func (w *WAL) Recover(store NodeWriter, batchSize int) (replayed bool, err error) {
+	file, err := os.Open(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("opening write-ahead log file: %w", err)
+	}
+
+	offsets, err := readWALOffsets(file)
+	if err != nil {
+		_ = file.Close()
+		return false, err
+	}
+
+	if offsets != nil {
+		err = replayWAL(file, offsets, store, batchSize)
+		if err != nil {
+			_ = file.Close()
+			return false, err
+		}
+	}
+
+	err = file.Close()
+	if err != nil {
+		return false, fmt.Errorf("closing write-ahead log file: %w", err)
+	}
+
+	err = os.Remove(w.path)
+	if err != nil {
+		return false, fmt.Errorf("removing write-ahead log file: %w", err)
+	}
+
+	return offsets != nil, nil
+}
+
+// writeDirtyWithWAL writes all dirty nodes to the log file of the
+// write options given, syncs it, and then replays it to the store.
+func (t *Trie) writeDirtyWithWAL(db KVStore, options WriteOptions,
+	existing keyChecker) (err error) {
+	log, err := options.WAL.create()
+	if err != nil {
+		return err
+	}
+
+	err = t.writeDirtyNode(log, t.root, existing)
+	if err != nil {
+		log.abort(options.WAL.path)
+		return err
+	}
+
+	err = log.commit()
+	if err != nil {
+		log.abort(options.WAL.path)
+		return err
+	}
+
+	// Note the log file is kept if replaying it fails, so it is
+	// replayed again on recovery.
+	_, err = options.WAL.Recover(t.observedBatch(db), options.BatchSize)
+	if err != nil {
+		return fmt.Errorf("replaying write-ahead log: %w", err)
+	}
+	return nil
+}
+
+// create creates the log file and returns a writer of log records to
+// it. It returns an error wrapping ErrWALPending if the log file exists.
+func (w *WAL) create() (writer *walWriter, err error) {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, walFilePermissions)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", ErrWALPending, w.path)
+	} else if err != nil {
+		return nil, fmt.Errorf("creating write-ahead log file: %w", err)
+	}
+
+	checksum := crc32.New(crc32.MakeTable(walChecksumPolynomial))
+	writer = &walWriter{
+		file:     file,
+		buffer:   bufio.NewWriter(io.MultiWriter(file, checksum)),
+		checksum: checksum,
+	}
+
+	_, err = writer.buffer.WriteString(walFileMagic)
+	if err == nil {
+		err = writer.buffer.WriteByte(walFileVersion)
+	}
+	if err != nil {
+		writer.abort(w.path)
+		return nil, fmt.Errorf("writing write-ahead log header: %w", err)
+	}
+
+	return writer, nil
+}
+
+// walWriter writes the records put to it to the log file.
+type walWriter struct {
+	file     *os.File
+	buffer   *bufio.Writer
+	checksum hash.Hash32
+	records  uint64
+}
+
+func (w *walWriter) Put(key, value []byte) (err error) {
+	return w.writeRecord(walRecordPut, key, value)
+}
+
+func (w *walWriter) Del(key []byte) (err error) {
+	return w.writeRecord(walRecordDelete, key, nil)
+}
+
+// Flush does nothing since the records are only
+// synced to the log file once it is committed.
+func (w *walWriter) Flush() (err error) { return nil }
+
+func (w *walWriter) writeRecord(recordType byte, key, value []byte) (err error) {
+	header := make([]byte, walRecordHeaderSize)
+	header[0] = recordType
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[5:], uint32(len(value)))
+
+	for _, data := range [][]byte{header, key, value} {
+		_, err = w.buffer.Write(data)
+		if err != nil {
+			return fmt.Errorf("writing write-ahead log record: %w", err)
+		}
+	}
+	w.records++
+	return nil
+}
+
+// commit writes the commit record, syncs and closes the log file.
+func (w *walWriter) commit() (err error) {
+	err = w.buffer.Flush()
+	if err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("writing write-ahead log records: %w", err)
+	}
+
+	commitRecord := make([]byte, walCommitRecordSize)
+	commitRecord[0] = walRecordCommit
+	binary.LittleEndian.PutUint64(commitRecord[1:], w.records)
+	binary.LittleEndian.PutUint32(commitRecord[9:], w.checksum.Sum32())
+	_, err = w.file.Write(commitRecord)
+	if err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("writing write-ahead log commit record: %w", err)
+	}
+
+	err = w.file.Sync()
+	if err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("syncing write-ahead log file: %w", err)
+	}
+
+	err = w.file.Close()
+	if err != nil {
+		return fmt.Errorf("closing write-ahead log file: %w", err)
+	}
+	return nil
+}
+
+// abort closes and removes the log file at the path given, since
+// nothing was written to the store.
+func (w *walWriter) abort(path string) {
+	_ = w.file.Close()
+	_ = os.Remove(path)
+}
+
+// readWALOffsets reads the log file given and returns the offsets of
+// its records, or nil if the log is incomplete.
+func readWALOffsets(file *os.File) (offsets []int64, err error) {
+	checksum := crc32.New(crc32.MakeTable(walChecksumPolynomial))
+	reader := io.TeeReader(bufio.NewReader(file), checksum)
+
+	header := make([]byte, walFileHeaderSize)
+	_, err = io.ReadFull(reader, header)
+	if err != nil {
+		return nil, readWALError(err)
+	}
+
+	if string(header[:len(walFileMagic)]) != walFileMagic {
+		return nil, fmt.Errorf("%w: magic bytes 0x%x",
+			ErrWALFormat, header[:len(walFileMagic)])
+	} else if header[len(walFileMagic)] != walFileVersion {
+		return nil, fmt.Errorf("%w: version %d is not supported",
+			ErrWALFormat, header[len(walFileMagic)])
+	}
+
+	offsets = []int64{}
+	offset := int64(walFileHeaderSize)
+	recordHeader := make([]byte, walRecordHeaderSize)
+	for {
+		// The checksum covers the bytes preceding the commit
+		// record, so it is taken before reading the record type.
+		sum := checksum.Sum32()
+
+		_, err = io.ReadFull(reader, recordHeader[:1])
+		if err != nil {
+			return nil, readWALError(err)
+		}
+
+		switch recordHeader[0] {
+		case walRecordPut, walRecordDelete:
+		case walRecordCommit:
+			return readWALCommit(reader, offsets, sum)
+		default:
+			// torn write of the record
+			return nil, nil
+		}
+
+		_, err = io.ReadFull(reader, recordHeader[1:])
+		if err != nil {
+			return nil, readWALError(err)
+		}
+		dataLength := int64(binary.LittleEndian.Uint32(recordHeader[1:])) +
+			int64(binary.LittleEndian.Uint32(recordHeader[5:]))
+		_, err = io.CopyN(io.Discard, reader, dataLength)
+		if err != nil {
+			return nil, readWALError(err)
+		}
+
+		offsets = append(offsets, offset)
+		offset += walRecordHeaderSize + dataLength
+	}
+}
+
+// readWALCommit reads the rest of the commit record from the reader
+// given, and returns the offsets given if the commit record matches
+// them and the checksum given, or nil if it does not.
+func readWALCommit(reader io.Reader, offsets []int64, checksum uint32) (
+	committedOffsets []int64, err error) {
+	commitRecord := make([]byte, walCommitRecordSize)
+	_, err = io.ReadFull(reader, commitRecord[1:])
+	if err != nil {
+		return nil, readWALError(err)
+	}
+
+	records := binary.LittleEndian.Uint64(commitRecord[1:])
+	if records != uint64(len(offsets)) ||
+		binary.LittleEndian.Uint32(commitRecord[9:]) != checksum {
+		return nil, nil
+	}
+	return offsets, nil
+}
+
+// readWALError returns a nil error if the error given is caused by
+// the end of the log file, since the log is then incomplete, and
+// returns the error given wrapped otherwise.
+func readWALError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+	return fmt.Errorf("reading write-ahead log file: %w", err)
+}
+
+// replayWAL writes the records at the offsets given of the log file
+// given to the store given, in reverse order, such that the root node,
+// written first to the log, is written last to the store.
+func replayWAL(file *os.File, offsets []int64, store NodeWriter, batchSize int) (err error) {
+	batch := &storeBatch{store: store}
+	var writer NodeWriter = batch
+	if batchSize > 0 {
+		writer = &flushingBatch{storeBatch: batch, maxSize: batchSize}
+	}
+
+	recordHeader := make([]byte, walRecordHeaderSize)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		_, err = file.ReadAt(recordHeader, offsets[i])
+		if err != nil {
+			return fmt.Errorf("reading write-ahead log record: %w", err)
+		}
+
+		keyLength := binary.LittleEndian.Uint32(recordHeader[1:])
+		valueLength := binary.LittleEndian.Uint32(recordHeader[5:])
+		data := make([]byte, int(keyLength)+int(valueLength))
+		_, err = file.ReadAt(data, offsets[i]+walRecordHeaderSize)
+		if err != nil {
+			return fmt.Errorf("reading write-ahead log record: %w", err)
+		}
+		key, value := data[:keyLength], data[keyLength:]
+
+		if recordHeader[0] == walRecordDelete {
+			err = writer.Del(key)
+		} else {
+			err = writer.Put(key, value)
+		}
+		if err != nil {
+			batch.Reset()
+			return fmt.Errorf("replaying write-ahead log record for key 0x%x: %w", key, err)
+		}
+	}
+
+	err = batch.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package trie
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCommittedWAL writes the dirty nodes of the trie given to a
+// committed log file at the path given, without replaying it, as if
+// the process crashed before writing the nodes to the store.
+func writeCommittedWAL(t *testing.T, trie *Trie, path string) {
+	t.Helper()
+
+	log, err := NewWAL(path).create()
+	require.NoError(t, err)
+	err = trie.writeDirtyNode(log, trie.root, nil)
+	require.NoError(t, err)
+	err = log.commit()
+	require.NoError(t, err)
+}
+
+func Test_Trie_WriteDirtyWithOptions_WAL(t *testing.T) {
+	t.Parallel()
+
+	trie, keyValues := makeSeededTrie(t, 200)
+	rootHash := trie.MustHash()
+	path := filepath.Join(t.TempDir(), "trie.wal")
+	store := newMapStore()
+
+	err := trie.WriteDirtyWithOptions(store, WriteOptions{
+		BatchSize: 1000,
+		WAL:       NewWAL(path),
+	})
+	require.NoError(t, err)
+
+	assert.Greater(t, store.flushes, 1)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	trieFromStore := NewEmptyTrie()
+	err = trieFromStore.Load(store, rootHash)
+	require.NoError(t, err)
+	for keyString, expectedValue := range keyValues {
+		assert.Equal(t, expectedValue, trieFromStore.Get([]byte(keyString)))
+	}
+}
+
+func Test_Trie_WriteDirtyWithOptions_WALPending(t *testing.T) {
+	t.Parallel()
+
+	trie, _ := makeSeededTrie(t, 10)
+	path := filepath.Join(t.TempDir(), "trie.wal")
+	writeCommittedWAL(t, trie, path)
+	trie.root.SetDirty()
+
+	err := trie.WriteDirtyWithOptions(newMapStore(), WriteOptions{WAL: NewWAL(path)})
+
+	assert.ErrorIs(t, err, ErrWALPending)
+	assert.EqualError(t, err, "write-ahead log pending recovery: "+path)
+}
+
+func Test_WAL_Recover(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		// corrupt modifies the committed log file data.
+		corrupt    func(data []byte) []byte
+		noFile     bool
+		replayed   bool
+		errWrapped error
+		errMessage string
+	}{
+		"no log file": {
+			noFile: true,
+		},
+		"committed log": {
+			corrupt:  func(data []byte) []byte { return data },
+			replayed: true,
+		},
+		"missing commit record": {
+			corrupt: func(data []byte) []byte {
+				return data[:len(data)-walCommitRecordSize]
+			},
+		},
+		"truncated commit record": {
+			corrupt: func(data []byte) []byte { return data[:len(data)-1] },
+		},
+		"truncated header": {
+			corrupt: func(data []byte) []byte { return data[:3] },
+		},
+		"corrupted record": {
+			corrupt: func(data []byte) []byte {
+				data[walFileHeaderSize+walRecordHeaderSize]++
+				return data
+			},
+		},
+		"invalid record type": {
+			corrupt: func(data []byte) []byte {
+				data[walFileHeaderSize] = 0
+				return data
+			},
+		},
+		"not a log file": {
+			corrupt: func(data []byte) []byte {
+				copy(data, "NOTAWAL")
+				return data
+			},
+			errWrapped: ErrWALFormat,
+			errMessage: "write-ahead log format invalid: magic bytes 0x4e4f544157414c",
+		},
+		"unsupported version": {
+			corrupt: func(data []byte) []byte {
+				data[len(walFileMagic)] = 2
+				return data
+			},
+			errWrapped: ErrWALFormat,
+			errMessage: "write-ahead log format invalid: version 2 is not supported",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trie, keyValues := makeSeededTrie(t, 50)
+			rootHash := trie.MustHash()
+			path := filepath.Join(t.TempDir(), "trie.wal")
+			if !testCase.noFile {
+				writeCommittedWAL(t, trie, path)
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+				err = os.WriteFile(path, testCase.corrupt(data), walFilePermissions)
+				require.NoError(t, err)
+			}
+			store := newMapStore()
+
+			replayed, err := NewWAL(path).Recover(store, 0)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.replayed, replayed)
+
+			_, err = os.Stat(path)
+			assert.ErrorIs(t, err, os.ErrNotExist)
+
+			if !testCase.replayed {
+				assert.Empty(t, store.keyValues)
+				return
+			}
+
+			trieFromStore := NewEmptyTrie()
+			err = trieFromStore.Load(store, rootHash)
+			require.NoError(t, err)
+			for keyString, expectedValue := range keyValues {
+				assert.Equal(t, expectedValue, trieFromStore.Get([]byte(keyString)))
+			}
+		})
+	}
+}
+
+// orderRecordingStore records the keys put in order.
+type orderRecordingStore struct {
+	*mapStore
+	keys [][]byte
+}
+
+func (s *orderRecordingStore) Put(key, value []byte) (err error) {
+	s.keys = append(s.keys, key)
+	return s.mapStore.Put(key, value)
+}
+
+func Test_WAL_Recover_rootWrittenLast(t *testing.T) {
+	t.Parallel()
+
+	trie, _ := makeSeededTrie(t, 50)
+	rootHash := trie.MustHash()
+	path := filepath.Join(t.TempDir(), "trie.wal")
+	writeCommittedWAL(t, trie, path)
+	store := &orderRecordingStore{mapStore: newMapStore()}
+
+	replayed, err := NewWAL(path).Recover(store, 0)
+
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	require.NotEmpty(t, store.keys)
+	assert.Equal(t, rootHash[:], store.keys[len(store.keys)-1])
+}
@@ -0,0 +1,180 @@
+// Package trieapi is a stable facade over the trie and proof packages.
+// It exposes interfaces which do not leak the substrate node type or
+// other internals, so downstream projects are insulated from internal
+// refactors of the trie and proof packages.
+package trieapi
+
+import (
+	"fmt"
+
+	"github.com/octopus-network/trie-go/trie"
+	"github.com/octopus-network/trie-go/trie/proof"
+	"github.com/octopus-network/trie-go/util"
+)
+
+// Errors returned by the facade, which can be checked with errors.Is.
+var (
+	ErrKeyNotFound   = proof.ErrKeyNotFound
+	ErrKeyNotInProof = proof.ErrKeyNotFoundInProofTrie
+	ErrValueMismatch = proof.ErrValueMismatchProofTrie
+	ErrRootNotFound  = proof.ErrRootNodeNotFound
+	ErrProofTooLarge = proof.ErrTooManyProofNodes
+)
+
+// Hash is a 32 bytes trie root hash.
+type Hash [32]byte
+
+// String returns the 0x prefixed hexadecimal representation of the hash.
+func (h Hash) String() string {
+	return fmt.Sprintf("0x%x", h[:])
+}
+
+// Trie is a Base-16 Modified Merkle trie with (Little Endian) byte keys.
+// A Trie is not safe for concurrent use.
+type Trie interface {
+	Prover
+	// Get returns the value at the key given, or nil if the key is absent.
+	Get(key []byte) (value []byte)
+	// Put puts the value given at the key given.
+	Put(key, value []byte)
+	// Delete deletes the key given from the trie.
+	Delete(key []byte)
+	// NextKey returns the next key after the key given in
+	// lexicographic order, or nil if there is no next key.
+	NextKey(key []byte) (nextKey []byte)
+	// Root returns the root hash of the trie.
+	Root() (root Hash, err error)
+	// Commit writes the trie nodes modified since the trie was
+	// created, loaded or last committed to the database given.
+	Commit(db DB) (err error)
+}
+
+// Prover generates proofs for the values of keys of a trie.
+type Prover interface {
+	// Prove returns the encoded proof nodes proving the values of the
+	// keys given. It returns an error wrapping ErrKeyNotFound if one of
+	// the keys is absent from the trie.
+	Prove(keys [][]byte) (proof [][]byte, err error)
+}
+
+// Verifier verifies proofs generated by a Prover.
+type Verifier interface {
+	// Verify verifies the key and value given belong to the trie with
+	// the root hash given using the proof given. The value is only
+	// compared if it is not empty. It returns an error wrapping
+	// ErrRootNotFound, ErrKeyNotInProof or ErrValueMismatch on failure,
+	// or ErrProofTooLarge if the proof exceeds the verifier limits.
+	Verify(proof [][]byte, root Hash, key, value []byte) (err error)
+}
+
+// DB is a key value database storing the trie nodes
+// by their hash digest, such as a chaindb database.
+type DB interface {
+	Get(key []byte) (value []byte, err error)
+	Put(key, value []byte) (err error)
+}
+
+// Limits contains the resource limits enforced by a verifier on
+// proofs, which usually come from untrusted peers. A zero value
+// field means there is no limit.
+type Limits struct {
+	// MaxNodes is the maximum number of proof nodes.
+	MaxNodes int
+	// MaxDepth is the maximum depth of the proof trie.
+	MaxDepth int
+	// MaxNodeSize is the maximum size in bytes of a proof node.
+	MaxNodeSize int
+}
+
+// New returns a new empty trie.
+func New() Trie {
+	return &trieFacade{trie: trie.NewEmptyTrie()}
+}
+
+// Load loads the trie with the root hash given from the database given.
+func Load(db DB, root Hash) (t Trie, err error) {
+	loaded := trie.NewEmptyTrie()
+	err = loaded.Load(db, util.Hash(root))
+	if err != nil {
+		return nil, fmt.Errorf("loading trie: %w", err)
+	}
+	return &trieFacade{trie: loaded}, nil
+}
+
+// NewVerifier returns a verifier enforcing the limits given.
+func NewVerifier(limits Limits) Verifier {
+	return verifier{options: proof.VerifyOptions{
+		MaxNodes:    limits.MaxNodes,
+		MaxDepth:    limits.MaxDepth,
+		MaxNodeSize: limits.MaxNodeSize,
+	}}
+}
+
+type trieFacade struct {
+	trie *trie.Trie
+}
+
+func (f *trieFacade) Get(key []byte) (value []byte) {
+	return f.trie.Get(key)
+}
+
+func (f *trieFacade) Put(key, value []byte) {
+	f.trie.Put(key, value)
+}
+
+func (f *trieFacade) Delete(key []byte) {
+	f.trie.Delete(key)
+}
+
+func (f *trieFacade) NextKey(key []byte) (nextKey []byte) {
+	return f.trie.NextKey(key)
+}
+
+func (f *trieFacade) Root() (root Hash, err error) {
+	hash, err := f.trie.Hash()
+	if err != nil {
+		return root, err
+	}
+	return Hash(hash), nil
+}
+
+func (f *trieFacade) Commit(db DB) (err error) {
+	return f.trie.WriteDirtyToBatch(&dbBatch{db: db})
+}
+
+func (f *trieFacade) Prove(keys [][]byte) (encodedProofNodes [][]byte, err error) {
+	return proof.GenerateFromTrie(f.trie, keys)
+}
+
+type verifier struct {
+	options proof.VerifyOptions
+}
+
+func (v verifier) Verify(encodedProofNodes [][]byte, root Hash, key, value []byte) (err error) {
+	return proof.VerifyWithOptions(encodedProofNodes, root[:], key, value, v.options)
+}
+
+// dbBatch writes directly to the database it wraps, to write
+// trie nodes to a database without batches. Deletions are ignored
+// since writing dirty nodes only puts node encodings.
+type dbBatch struct {
+	db   DB
+	size int
+}
+
+func (b *dbBatch) Put(key, value []byte) (err error) {
+	err = b.db.Put(key, value)
+	if err != nil {
+		return err
+	}
+	b.size += len(value)
+	return nil
+}
+
+func (b *dbBatch) Del([]byte) (err error) { return nil }
+
+func (b *dbBatch) Flush() (err error) { return nil }
+
+func (b *dbBatch) ValueSize() int { return b.size }
+
+func (b *dbBatch) Reset() { b.size = 0 }
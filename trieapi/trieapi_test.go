@@ -0,0 +1,79 @@
+package trieapi
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapDB struct {
+	mutex sync.Mutex
+	data  map[string][]byte
+}
+
+func (db *mapDB) Get(key []byte) (value []byte, err error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	value, ok := db.data[string(key)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (db *mapDB) Put(key, value []byte) (err error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.data[string(key)] = value
+	return nil
+}
+
+func Test_Trie_Prove_Verify_Commit_Load(t *testing.T) {
+	t.Parallel()
+
+	tr := New()
+	tr.Put([]byte("alice"), []byte("balance of alice with more than thirty two bytes"))
+	tr.Put([]byte("bob"), []byte{2})
+	tr.Put([]byte("carol"), []byte{3})
+	tr.Delete([]byte("carol"))
+
+	assert.Equal(t, []byte{2}, tr.Get([]byte("bob")))
+	assert.Nil(t, tr.Get([]byte("carol")))
+	assert.Equal(t, []byte("bob"), tr.NextKey([]byte("alice")))
+
+	root, err := tr.Root()
+	require.NoError(t, err)
+
+	proof, err := tr.Prove([][]byte{[]byte("bob")})
+	require.NoError(t, err)
+	_, err = tr.Prove([][]byte{[]byte("carol")})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	verifier := NewVerifier(Limits{MaxNodes: 10})
+	err = verifier.Verify(proof, root, []byte("bob"), []byte{2})
+	assert.NoError(t, err)
+	err = verifier.Verify(proof, root, []byte("bob"), []byte{3})
+	assert.ErrorIs(t, err, ErrValueMismatch)
+	err = verifier.Verify(proof, Hash{1}, []byte("bob"), []byte{2})
+	assert.ErrorIs(t, err, ErrRootNotFound)
+	err = NewVerifier(Limits{MaxNodes: 1}).Verify(append(proof, proof...), root,
+		[]byte("bob"), []byte{2})
+	assert.ErrorIs(t, err, ErrProofTooLarge)
+
+	db := &mapDB{data: make(map[string][]byte)}
+	err = tr.Commit(db)
+	require.NoError(t, err)
+	assert.NotEmpty(t, db.data)
+
+	loaded, err := Load(db, root)
+	require.NoError(t, err)
+	loadedRoot, err := loaded.Root()
+	require.NoError(t, err)
+	assert.Equal(t, root, loadedRoot)
+	assert.Equal(t, tr.Get([]byte("alice")), loaded.Get([]byte("alice")))
+
+	_, err = Load(db, Hash{1})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}